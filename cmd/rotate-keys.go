@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/guardlight/server/internal/analysismanager"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/encryption"
+	"github.com/guardlight/server/internal/infrastructure/database"
+	"github.com/guardlight/server/internal/webhook"
+	"go.uber.org/zap"
+)
+
+// RotateKeys re-encrypts the stored content and webhook secrets with the
+// current master key. The previous keys have to stay configured until it
+// finished.
+func RotateKeys() {
+	if err := encryption.Setup(); err != nil {
+		zap.S().Fatalw("Invalid encryption config", "error", err)
+	}
+
+	db := database.InitDatabase(config.Get().GetDbDsn())
+
+	n, err := analysismanager.ReencryptRawData(db, max(config.Get().Encryption.RotateBatchSize, 1))
+	if err != nil {
+		zap.S().Fatalw("Could not re-encrypt raw data", "rotated", n, "error", err)
+	}
+
+	ws, err := webhook.ReencryptSecrets(db)
+	if err != nil {
+		zap.S().Fatalw("Could not re-encrypt webhook secrets", "error", err)
+	}
+
+	zap.S().Infow("Rotated encryption keys", "key_id", encryption.CurrentKeyId(), "rotated", n, "webhooks", ws)
+}
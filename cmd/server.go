@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/guardlight/server/internal/adapterruntime"
 	"github.com/guardlight/server/internal/analysismanager"
 	"github.com/guardlight/server/internal/auth"
 	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/encryption"
 	"github.com/guardlight/server/internal/essential/logging"
 	"github.com/guardlight/server/internal/essential/testcontainers"
 	"github.com/guardlight/server/internal/health"
@@ -23,6 +26,9 @@ import (
 	"github.com/guardlight/server/internal/scheduler"
 	"github.com/guardlight/server/internal/ssemanager"
 	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/internal/webhook"
+	"github.com/guardlight/server/pkg/contentstore"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/guardlight/server/servers/natsmessaging"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
@@ -54,56 +60,104 @@ func Server() {
 	}
 	// Messaging
 	var ncon *nats.Conn
+	nd := adapterruntime.NatsDetails{
+		User:     config.Get().Nats.User,
+		Password: config.Get().Nats.Password,
+	}
 	if config.Get().Nats.Server == "" {
 		GlNatsServer()
 		ncon = messaging.InitNatsInProcess(natsmessaging.GetServer())
+		nd.Url = natsmessaging.GetNatsUrl()
 	} else {
 		ncon = messaging.InitNats()
+		nd.Url = fmt.Sprintf("nats://%s:%d", config.Get().Nats.Server, config.Get().Nats.Port)
 	}
 
 	GLAdapters(ncon)
 
 	// Database
+	if err := encryption.Setup(); err != nil {
+		zap.S().Fatalw("Invalid encryption config", "error", err)
+	}
 	db := database.InitDatabase(dsn)
 
 	// Repositories
 	jmr := jobmanager.NewJobManagerRepository(db)
 	amr := analysismanager.NewAnalysisManagerRepository(db)
 	tsr := theme.NewThemeRepository(db)
+	whr := webhook.NewWebhookRepository(db)
 
 	// Controller Groups
 	mainRouter := http.NewRouter(logging.GetLogger())
 	baseGroup := mainRouter.Group("")
 
 	// Services
+	// Orchestration and maintenance only run on the leader, every replica
+	// keeps serving requests and handling results.
+	le := database.NewElector(dsn, config.Get().Orchestrator.Leader.LockId, time.Duration(config.Get().Orchestrator.Leader.CheckIntervalSeconds)*time.Second)
 	sch, err := scheduler.NewScheduler(loc)
-
-	nc := natsclient.NewNatsClient(ncon)
-	jm := jobmanager.NewJobMananger(jmr, sch.Gos)
 	if err != nil {
 		zap.S().Errorw("Could not create scheduler", "error", err)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	}
+	lsch, err := scheduler.NewLeaderScheduler(loc, le)
+	if err != nil {
+		zap.S().Errorw("Could not create leader scheduler", "error", err)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	}
+
+	nc := natsclient.NewNatsClient(ncon)
+	jm := jobmanager.NewJobMananger(jmr, lsch.Gos)
+	if err := jm.ListenForHeartbeats(ncon); err != nil {
+		zap.S().Errorw("Could not listen for heartbeats", "error", err)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	}
 	ssem := ssemanager.NewSseMananger()
-	_, err = orchestrator.NewOrchestrator(jm, sch.Gos, nc)
+	csctx, cscancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cscancel()
+	cs, err := contentstore.NewStore(csctx, ncon)
+	if err != nil {
+		zap.S().Fatalw("Could not open content store", "error", err)
+	}
+	rt, err := adapterruntime.NewProcessRuntime(nd, sch.Gos, jm)
+	if err != nil {
+		zap.S().Errorw("Could not create adapter runtime", "error", err)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	}
+	o, err := orchestrator.NewOrchestrator(jm, lsch.Gos, nc, rt)
 	if err != nil {
 		zap.S().Errorw("Could not create orhestrator", "error", err)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	}
+	var jl *database.Listener
+	if config.Get().Orchestrator.ListenForJobs && o != nil {
+		jl = database.NewListener(dsn, jobmanager.JobsChannel)
+		o.ListenForJobs(jl.Notifications(), le)
+	}
+	jas := jobmanager.NewJobAdminService(jmr, nc)
 	ts := theme.NewThemeService(tsr)
-	ars := analysismanager.NewAnalysisResultService(amr, amr, ts)
-	am := analysismanager.NewAnalysisManangerRequester(jm, amr, ssem, ts)
-
-	_ = analysismanager.NewAnalysisManagerAllocator(ncon, amr, jm, ssem)
-
-	_ = analysismanager.NewRawDataManager(sch.Gos, db)
+	whs := webhook.NewWebhookService(whr, lsch.Gos)
+	ars := analysismanager.NewAnalysisResultService(amr, amr, ts, jm, jm, nc, cs)
+	ama := analysismanager.NewAnalysisManagerAllocator(ncon, amr, jm, ssem, whs, workqueue.WatchCancellations(ncon))
+	jmr.SetDeadLetterHandler(ama)
+	am := analysismanager.NewAnalysisManangerRequester(jm, amr, ssem, ts, ama, cs, whs)
+	amrr := analysismanager.NewAnalysisManagerRerunner(amr, ama, ts, ssem, jm, nc)
+	amb := analysismanager.NewAnalysisManagerBatcher(amr, am, ts)
+
+	_ = analysismanager.NewRawDataManager(lsch.Gos, db)
+	rm := analysismanager.NewRetentionManager(lsch.Gos, amr, cs, jm, nc)
+	sts := analysismanager.NewStatsService(lsch.Gos, amr, ts)
 
 	// Controllers
 	health.NewHealthController(baseGroup)
-	analysismanager.NewAnalysisRequestController(baseGroup, am, ars)
+	analysismanager.NewAnalysisRequestController(baseGroup, am, ars, amrr, amb)
 	parser.NewParserController(baseGroup)
 	theme.NewThemeController(baseGroup, ts)
 	auth.NewAuthenticationController(baseGroup)
+	jobmanager.NewJobController(baseGroup, jas)
+	webhook.NewWebhookController(baseGroup, whs)
+	analysismanager.NewRetentionController(baseGroup, rm)
+	analysismanager.NewStatsController(baseGroup, sts)
 
 	ssemanager.NewSseController(baseGroup, ssem)
 
@@ -127,7 +181,15 @@ func Server() {
 	defer cancel()
 
 	http.LetDie(ctx)
+	lsch.Gos.Shutdown()
 	sch.Gos.Shutdown()
+	le.Close()
+	if jl != nil {
+		jl.Close()
+	}
+	if rt != nil {
+		rt.Stop()
+	}
 
 	// catching ctx.Done(). timeout of 5 seconds.
 	<-ctx.Done()
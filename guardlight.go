@@ -41,5 +41,9 @@ func getEnv(key, fallback string) string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		cmd.RotateKeys()
+		return
+	}
 	cmd.Server()
 }
@@ -0,0 +1,89 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package adapterruntime
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockinprogressCounter is an autogenerated mock type for the inprogressCounter type
+type MockinprogressCounter struct {
+	mock.Mock
+}
+
+type MockinprogressCounter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockinprogressCounter) EXPECT() *MockinprogressCounter_Expecter {
+	return &MockinprogressCounter_Expecter{mock: &_m.Mock}
+}
+
+// GetInprogressCounts provides a mock function with no fields
+func (_m *MockinprogressCounter) GetInprogressCounts() (map[string]int, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInprogressCounts")
+	}
+
+	var r0 map[string]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (map[string]int, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() map[string]int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockinprogressCounter_GetInprogressCounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInprogressCounts'
+type MockinprogressCounter_GetInprogressCounts_Call struct {
+	*mock.Call
+}
+
+// GetInprogressCounts is a helper method to define mock.On call
+func (_e *MockinprogressCounter_Expecter) GetInprogressCounts() *MockinprogressCounter_GetInprogressCounts_Call {
+	return &MockinprogressCounter_GetInprogressCounts_Call{Call: _e.mock.On("GetInprogressCounts")}
+}
+
+func (_c *MockinprogressCounter_GetInprogressCounts_Call) Run(run func()) *MockinprogressCounter_GetInprogressCounts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockinprogressCounter_GetInprogressCounts_Call) Return(_a0 map[string]int, _a1 error) *MockinprogressCounter_GetInprogressCounts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockinprogressCounter_GetInprogressCounts_Call) RunAndReturn(run func() (map[string]int, error)) *MockinprogressCounter_GetInprogressCounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockinprogressCounter creates a new instance of MockinprogressCounter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockinprogressCounter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockinprogressCounter {
+	mock := &MockinprogressCounter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
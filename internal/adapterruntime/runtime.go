@@ -0,0 +1,217 @@
+package adapterruntime
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoCommand = errors.New("no command configured for local adapter")
+	ErrStarting  = errors.New("local adapter is starting")
+)
+
+// Environment variables a local adapter process gets to connect to NATS and
+// to know which subject to consume.
+const (
+	EnvNatsUrl      = "GUARDLIGHT_NATS_URL"
+	EnvNatsUser     = "GUARDLIGHT_NATS_USER"
+	EnvNatsPassword = "GUARDLIGHT_NATS_PASSWORD"
+	EnvSubject      = "GUARDLIGHT_ADAPTER_SUBJECT"
+	EnvWorkerId     = "GUARDLIGHT_WORKER_ID"
+)
+
+// ReadyLine must be written on its own line to stdout by a local adapter once
+// it consumes its subject.
+const ReadyLine = "READY"
+
+// Spec describes an adapter that runs next to the server.
+type Spec struct {
+	GroupKey    string
+	Command     string
+	Args        []string
+	Concurrency int
+}
+
+type NatsDetails struct {
+	Url      string
+	User     string
+	Password string
+}
+
+type taskCreater interface {
+	NewJob(jobDefinition gocron.JobDefinition, task gocron.Task, options ...gocron.JobOption) (gocron.Job, error)
+}
+
+type inprogressCounter interface {
+	GetInprogressCounts() (map[string]int, error)
+}
+
+// ProcessRuntime runs adapters as local subprocesses, one pool of workers per
+// group key with as many processes as the adapter's concurrency.
+type ProcessRuntime struct {
+	sync.Mutex
+	nd    NatsDetails
+	ic    inprogressCounter
+	pools map[string]*pool
+}
+
+func NewProcessRuntime(nd NatsDetails, tc taskCreater, ic inprogressCounter) (*ProcessRuntime, error) {
+	pr := &ProcessRuntime{
+		nd:    nd,
+		ic:    ic,
+		pools: make(map[string]*pool),
+	}
+
+	_, err := tc.NewJob(
+		gocron.DurationJob(30*time.Second),
+		gocron.NewTask(pr.stopIdlePools),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// Ensure starts the workers of an adapter when they are not running yet. It
+// does not wait for them, ErrStarting is returned until all of them are ready
+// and ready is called once they are.
+func (pr *ProcessRuntime) Ensure(s Spec, ready func()) error {
+	if s.Command == "" {
+		return ErrNoCommand
+	}
+
+	pr.Lock()
+	p, ok := pr.pools[s.GroupKey]
+	if !ok {
+		p = newPool(s, pr.nd)
+		pr.pools[s.GroupKey] = p
+	}
+	pr.Unlock()
+
+	return p.ensure(ready)
+}
+
+// Stop stops all workers of all adapters.
+func (pr *ProcessRuntime) Stop() {
+	pr.Lock()
+	defer pr.Unlock()
+
+	for gk, p := range pr.pools {
+		p.stop()
+		delete(pr.pools, gk)
+	}
+}
+
+// stopIdlePools stops the adapters that had no job in progress for the idle
+// timeout. Nothing is stopped when the jobs in progress cannot be counted.
+func (pr *ProcessRuntime) stopIdlePools() {
+	idle := time.Duration(config.Get().Orchestrator.Runtime.IdleTimeoutSeconds) * time.Second
+
+	counts, err := pr.ic.GetInprogressCounts()
+	if err != nil {
+		zap.S().Errorw("Could not count inprogress jobs of local adapters", "error", err)
+		return
+	}
+
+	pr.Lock()
+	defer pr.Unlock()
+
+	for gk, p := range pr.pools {
+		if counts[gk] > 0 {
+			p.use()
+			continue
+		}
+		if p.idleFor() > idle {
+			zap.S().Infow("Stopping idle local adapter", "group_key", gk)
+			p.stop()
+			delete(pr.pools, gk)
+		}
+	}
+}
+
+type pool struct {
+	sync.Mutex
+	spec     Spec
+	nd       NatsDetails
+	workers  []*worker
+	lastUsed time.Time
+	// starting is set while a start is waited on
+	starting bool
+}
+
+func newPool(s Spec, nd NatsDetails) *pool {
+	return &pool{
+		spec: s,
+		nd:   nd,
+	}
+}
+
+func (p *pool) ensure(ready func()) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.lastUsed = time.Now()
+	if p.starting {
+		return ErrStarting
+	}
+	for len(p.workers) < max(p.spec.Concurrency, 1) {
+		p.workers = append(p.workers, startWorker(p.spec, p.nd, len(p.workers)))
+	}
+	if lo.EveryBy(p.workers, func(w *worker) bool { return w.isReady() }) {
+		return nil
+	}
+
+	p.starting = true
+	go p.waitReady(p.workers, ready)
+	return ErrStarting
+}
+
+func (p *pool) waitReady(ws []*worker, ready func()) {
+	timeout := time.Duration(config.Get().Orchestrator.Runtime.ReadyTimeoutSeconds) * time.Second
+	var err error
+	for _, w := range ws {
+		if err = w.waitReady(timeout); err != nil {
+			break
+		}
+	}
+
+	p.Lock()
+	p.starting = false
+	p.Unlock()
+
+	if err != nil {
+		zap.S().Errorw("Could not start local adapter", "group_key", p.spec.GroupKey, "error", err)
+		return
+	}
+	ready()
+}
+
+func (p *pool) use() {
+	p.Lock()
+	defer p.Unlock()
+	p.lastUsed = time.Now()
+}
+
+func (p *pool) idleFor() time.Duration {
+	p.Lock()
+	defer p.Unlock()
+	return time.Since(p.lastUsed)
+}
+
+func (p *pool) stop() {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, w := range p.workers {
+		w.stop()
+	}
+	p.workers = nil
+}
@@ -0,0 +1,139 @@
+package adapterruntime
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestHelperProcess is not a real test, it acts as a local adapter when the
+// test binary is started by the runtime.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv(EnvSubject) == "" {
+		return
+	}
+
+	fmt.Println(ReadyLine)
+	if os.Args[len(os.Args)-1] == "crash" {
+		time.Sleep(100 * time.Millisecond)
+		os.Exit(1)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM)
+	<-quit
+	os.Exit(0)
+}
+
+func newTestRuntime(t *testing.T) (*ProcessRuntime, *MockinprogressCounter) {
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+	logging.SetupLogging("test")
+
+	mockTc := NewMocktaskCreater(t)
+	mockTc.EXPECT().NewJob(mock.AnythingOfType("durationJobDefinition"), mock.AnythingOfType("Task"), mock.AnythingOfType("JobOption")).Return(nil, nil)
+
+	mockIc := NewMockinprogressCounter(t)
+
+	pr, err := NewProcessRuntime(NatsDetails{Url: "nats://127.0.0.1:4222"}, mockTc, mockIc)
+	assert.NoError(t, err)
+	t.Cleanup(pr.Stop)
+	return pr, mockIc
+}
+
+// ensureReady ensures the workers of the spec and waits until they are ready.
+func ensureReady(t *testing.T, pr *ProcessRuntime, s Spec) {
+	ready := make(chan struct{})
+	err := pr.Ensure(s, func() { close(ready) })
+	assert.ErrorIs(t, err, ErrStarting)
+
+	select {
+	case <-ready:
+	case <-time.After(10 * time.Second):
+		t.Fatal("local adapter did not get ready")
+	}
+}
+
+func helperSpec(mode string, concurrency int) Spec {
+	return Spec{
+		GroupKey:    "parser.local",
+		Command:     os.Args[0],
+		Args:        []string{"-test.run=TestHelperProcess", "--", mode},
+		Concurrency: concurrency,
+	}
+}
+
+func TestEnsureStartsConcurrencyWorkers(t *testing.T) {
+	pr, _ := newTestRuntime(t)
+
+	ensureReady(t, pr, helperSpec("serve", 2))
+	assert.Len(t, pr.pools["parser.local"].workers, 2)
+
+	// Ensuring again does not start more workers
+	err := pr.Ensure(helperSpec("serve", 2), func() { t.Fatal("ready workers are not waited on") })
+	assert.NoError(t, err)
+	assert.Len(t, pr.pools["parser.local"].workers, 2)
+}
+
+func TestEnsureWithoutCommand(t *testing.T) {
+	pr, _ := newTestRuntime(t)
+
+	err := pr.Ensure(Spec{GroupKey: "parser.local", Concurrency: 1}, func() {})
+	assert.ErrorIs(t, err, ErrNoCommand)
+	assert.Empty(t, pr.pools)
+}
+
+func TestCrashedWorkerIsRestarted(t *testing.T) {
+	pr, _ := newTestRuntime(t)
+
+	ensureReady(t, pr, helperSpec("crash", 1))
+
+	w := pr.pools["parser.local"].workers[0]
+	w.mu.Lock()
+	pid := w.cmd.Process.Pid
+	w.mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.cmd.Process.Pid != pid
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestStopIdlePools(t *testing.T) {
+	pr, mockIc := newTestRuntime(t)
+
+	ensureReady(t, pr, helperSpec("serve", 1))
+	w := pr.pools["parser.local"].workers[0]
+
+	mockIc.EXPECT().GetInprogressCounts().Return(map[string]int{"parser.other": 1}, nil)
+	pr.pools["parser.local"].lastUsed = time.Now().Add(-time.Hour)
+	pr.stopIdlePools()
+
+	assert.Empty(t, pr.pools)
+	select {
+	case <-w.done:
+	default:
+		t.Fatal("idle worker was not stopped")
+	}
+}
+
+func TestStopIdlePoolsKeepsPoolsWithJobs(t *testing.T) {
+	pr, mockIc := newTestRuntime(t)
+
+	ensureReady(t, pr, helperSpec("serve", 1))
+
+	mockIc.EXPECT().GetInprogressCounts().Return(map[string]int{"parser.local": 1}, nil)
+	pr.pools["parser.local"].lastUsed = time.Now().Add(-time.Hour)
+	pr.stopIdlePools()
+
+	assert.Len(t, pr.pools, 1)
+	assert.Less(t, pr.pools["parser.local"].idleFor(), time.Minute)
+}
@@ -0,0 +1,167 @@
+package adapterruntime
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/guardlight/server/internal/essential/config"
+	"go.uber.org/zap"
+)
+
+const stopGracePeriod = 5 * time.Second
+
+// worker supervises a single adapter process and restarts it when it exits
+// without being stopped.
+type worker struct {
+	mu    sync.Mutex
+	spec  Spec
+	id    int
+	env   []string
+	cmd   *exec.Cmd
+	ready chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+func startWorker(s Spec, nd NatsDetails, id int) *worker {
+	w := &worker{
+		spec: s,
+		id:   id,
+		env: []string{
+			EnvNatsUrl + "=" + nd.Url,
+			EnvNatsUser + "=" + nd.User,
+			EnvNatsPassword + "=" + nd.Password,
+			EnvSubject + "=" + s.GroupKey,
+			EnvWorkerId + "=" + strconv.Itoa(id),
+		},
+		ready: make(chan struct{}),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	go w.supervise()
+
+	return w
+}
+
+func (w *worker) supervise() {
+	defer close(w.done)
+
+	restartDelay := time.Duration(config.Get().Orchestrator.Runtime.RestartDelaySeconds) * time.Second
+	for {
+		err := w.run()
+
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+
+		zap.S().Warnw("Local adapter exited, restarting", "group_key", w.spec.GroupKey, "worker_id", w.id, "error", err)
+		select {
+		case <-w.quit:
+			return
+		case <-time.After(restartDelay):
+		}
+
+		w.mu.Lock()
+		w.ready = make(chan struct{})
+		w.mu.Unlock()
+	}
+}
+
+func (w *worker) run() error {
+	cmd := exec.Command(w.spec.Command, w.spec.Args...)
+	cmd.Env = append(os.Environ(), w.env...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	select {
+	case <-w.quit:
+		w.mu.Unlock()
+		return nil
+	default:
+	}
+	if err := cmd.Start(); err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	w.cmd = cmd
+	ready := w.ready
+	w.mu.Unlock()
+
+	zap.S().Infow("Local adapter started", "group_key", w.spec.GroupKey, "worker_id", w.id, "pid", cmd.Process.Pid)
+
+	isReady := false
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !isReady && line == ReadyLine {
+			isReady = true
+			close(ready)
+			zap.S().Infow("Local adapter ready", "group_key", w.spec.GroupKey, "worker_id", w.id)
+			continue
+		}
+		zap.S().Debugw("Local adapter output", "group_key", w.spec.GroupKey, "worker_id", w.id, "line", line)
+	}
+
+	return cmd.Wait()
+}
+
+func (w *worker) isReady() bool {
+	w.mu.Lock()
+	ready := w.ready
+	w.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *worker) waitReady(timeout time.Duration) error {
+	w.mu.Lock()
+	ready := w.ready
+	w.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("local adapter %s worker %d not ready after %s", w.spec.GroupKey, w.id, timeout)
+	}
+}
+
+func (w *worker) stop() {
+	w.mu.Lock()
+	close(w.quit)
+	cmd := w.cmd
+	w.mu.Unlock()
+
+	if cmd == nil {
+		<-w.done
+		return
+	}
+
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-w.done:
+	case <-time.After(stopGracePeriod):
+		zap.S().Warnw("Local adapter did not stop, killing it", "group_key", w.spec.GroupKey, "worker_id", w.id)
+		cmd.Process.Kill()
+		<-w.done
+	}
+}
@@ -1,21 +1,53 @@
 package analysismanager
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/theme"
 	"github.com/guardlight/server/pkg/analysisresult"
+	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/guardlight/server/pkg/controlcontract"
 	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMissingReason = errors.New("a reason is required")
+	ErrNotOverridden = errors.New("score is not overridden")
+	ErrInvalidScore  = errors.New("score must be between -1 and 1 and not 0")
+	// The processed text is encrypted at rest
+	ErrSearchUnavailable = errors.New("search is not available")
 )
 
 type analysisGetter interface {
-	getAnalysesByUserId(id uuid.UUID, pag Pagination, catType, catCat, query, sc string) (AnalysisResultPaginated, error)
+	getAnalysesByUserId(id uuid.UUID, pag Pagination, catType, catCat, query string, verdict Verdict, sort string) (AnalysisResultPaginated, error)
 	getAnalysesByAnalysisIdAndUserId(id, arid uuid.UUID) (AnalysisRequest, error)
+	searchAnalysesByUserId(uid uuid.UUID, query string, pag Pagination) (AnalysisSearchPaginated, error)
+	getScoreOverridesByAnalysisRequestId(uid, arid uuid.UUID) ([]ScoreOverride, error)
 }
 
 type analysisUpdater interface {
-	updateScore(id uuid.UUID, score float32) error
-	deleteAnalysisRequestById(arid, uid uuid.UUID) error
+	overrideScore(uid, aid uuid.UUID, score float32, reason string) error
+	revertScoreOverride(uid, aid uuid.UUID, reason string) error
+	deleteAnalysisRequestById(arid, uid uuid.UUID, cancelJobs func(tx *gorm.DB) error) (string, error)
+}
+
+type jobCanceller interface {
+	jobmanager.JobCanceller
+}
+
+type jobHistoryGetter interface {
+	GetJobTimeline(arid uuid.UUID) ([]jobmanager.JobTimeline, error)
+	GetAverageJobDuration(groupKey string) (time.Duration, error)
+}
+
+type cancelBroadcaster interface {
+	Broadcast(topic string, payload interface{}) error
 }
 
 type themeService interface {
@@ -26,18 +58,28 @@ type AnalysisResultService struct {
 	ag analysisGetter
 	au analysisUpdater
 	ts themeService
+	jc jobCanceller
+	jh jobHistoryGetter
+	cb cancelBroadcaster
+	cs contentRemover
 }
 
-func NewAnalysisResultService(ag analysisGetter, au analysisUpdater, ts themeService) *AnalysisResultService {
+func NewAnalysisResultService(ag analysisGetter, au analysisUpdater, ts themeService, jc jobCanceller, jh jobHistoryGetter, cb cancelBroadcaster, cs contentRemover) *AnalysisResultService {
 	return &AnalysisResultService{
 		ag: ag,
 		au: au,
 		ts: ts,
+		jc: jc,
+		jh: jh,
+		cb: cb,
+		cs: cs,
 	}
 }
 
-func (ars *AnalysisResultService) GetAnalysesByUserId(id uuid.UUID, limit, page int, catType, catCat, query, sc string) (analysisresult.AnalysisPaginated, error) {
-	as, err := ars.ag.getAnalysesByUserId(id, Pagination{Limit: limit, Page: page}, catType, catCat, query, sc)
+// GetAnalysesByUserId lists the analyses of the user. Sorting by "verdict" puts
+// the most severe verdicts first, they are newest first otherwise.
+func (ars *AnalysisResultService) GetAnalysesByUserId(id uuid.UUID, limit, page int, catType, catCat, query string, verdict Verdict, sort string) (analysisresult.AnalysisPaginated, error) {
+	as, err := ars.ag.getAnalysesByUserId(id, Pagination{Limit: limit, Page: page}, catType, catCat, query, verdict, sort)
 	if err != nil {
 		return analysisresult.AnalysisPaginated{}, err
 	}
@@ -69,6 +111,41 @@ func (ars *AnalysisResultService) GetAnalysesByUserId(id uuid.UUID, limit, page
 
 }
 
+// SearchAnalyses searches the processed text of the analyses of the user.
+// Texts that were exported or purged are no longer in the database and never
+// match.
+func (ars *AnalysisResultService) SearchAnalyses(uid uuid.UUID, query string, limit, page int) (analysisresult.AnalysisSearchPaginated, error) {
+	sp, err := ars.ag.searchAnalysesByUserId(uid, query, Pagination{Limit: limit, Page: page})
+	if err != nil {
+		return analysisresult.AnalysisSearchPaginated{}, err
+	}
+
+	res := analysisresult.AnalysisSearchPaginated{
+		Limit:      sp.Limit,
+		Page:       sp.Page,
+		TotalPages: sp.TotalPages,
+		Results:    []analysisresult.SearchResult{},
+	}
+	if len(sp.Hits) == 0 {
+		return res, nil
+	}
+
+	ts, err := ars.ts.GetAllThemesByUserId(uid)
+	if err != nil {
+		return analysisresult.AnalysisSearchPaginated{}, err
+	}
+
+	res.Results = lo.Map(sp.Hits, func(h AnalysisSearchHit, _ int) analysisresult.SearchResult {
+		return analysisresult.SearchResult{
+			Analysis: mapToAnalysisResult(h.Request, ts),
+			Snippet:  h.Snippet,
+			Rank:     h.Rank,
+		}
+	})
+
+	return res, nil
+}
+
 func (ars *AnalysisResultService) GetAnalysesByAnalysisIdAndUserId(uid, arid uuid.UUID) (analysisresult.Analysis, error) {
 	ar, err := ars.ag.getAnalysesByAnalysisIdAndUserId(uid, arid)
 	if err != nil {
@@ -108,8 +185,14 @@ func mapToAnalysisResult(ar AnalysisRequest, ts []theme.ThemeDto) analysisresult
 		Category:      ar.Category,
 		RequestOrigin: ar.RequestOrigin,
 		ContentType:   ar.ContentType,
+		Verdict:       string(ar.Verdict),
 		Themes:        themes,
 		CreatedAt:     ar.CreatedAt,
+		Text: analysisresult.Text{
+			State:    string(ar.RawData.textState()),
+			PurgedAt: ar.RawData.PurgedAt,
+			PurgedBy: ar.RawData.PurgedBy,
+		},
 	}
 
 	return a
@@ -140,6 +223,16 @@ func mapToAnalyzerToResult(a Analysis) analysisresult.Analyzer {
 		Status:  string(a.Status),
 		Score:   a.Score,
 		Content: a.Content,
+		Findings: lo.Map(a.Findings, func(f analyzercontract.Finding, _ int) analysisresult.Finding {
+			return analysisresult.Finding{
+				Term:       f.Term,
+				Sentence:   f.Sentence,
+				ChunkIndex: f.ChunkIndex,
+				Start:      f.Start,
+				End:        f.End,
+				Confidence: f.Confidence,
+			}
+		}),
 		Inputs: lo.Map(a.Inputs, func(i AnalysisInput, _ int) analysisresult.AnalyzerInput {
 			iName := i.Key
 			if in, ok := lo.Find(ac.Inputs, func(inp config.AnalyzerInput) bool { return inp.Key == i.Key }); ok {
@@ -150,13 +243,134 @@ func mapToAnalyzerToResult(a Analysis) analysisresult.Analyzer {
 		Jobs: lo.Map(a.Jobs, func(j SingleJobProgress, _ int) analysisresult.AnalyzerJobProgress {
 			return analysisresult.AnalyzerJobProgress{Status: string(j.Status)}
 		}),
+		ReporterScore: a.ReporterScore,
+		Overridden:    a.Overridden,
+	}
+}
+
+// OverrideScore sets the score of an analysis of the user by hand. The reason
+// is kept with the override. Scores go from -1 to 1 like the ones of the
+// reporters, 0 would make the analysis pending again.
+func (ars *AnalysisResultService) OverrideScore(uid, aid uuid.UUID, score float32, reason string) error {
+	if !(score >= -1 && score <= 1) || score == 0 {
+		return ErrInvalidScore
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrMissingReason
+	}
+	return ars.au.overrideScore(uid, aid, score, reason)
+}
+
+// RevertScoreOverride puts the score of the reporter back on the analysis.
+func (ars *AnalysisResultService) RevertScoreOverride(uid, aid uuid.UUID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrMissingReason
 	}
+	return ars.au.revertScoreOverride(uid, aid, reason)
 }
 
-func (ars *AnalysisResultService) UpdateScore(id uuid.UUID, score float32) error {
-	return ars.au.updateScore(id, score)
+// GetScoreOverrides returns the score changes of the analysis request, newest
+// first.
+func (ars *AnalysisResultService) GetScoreOverrides(uid, arid uuid.UUID) ([]analysisresult.ScoreOverride, error) {
+	sos, err := ars.ag.getScoreOverridesByAnalysisRequestId(uid, arid)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(sos, func(so ScoreOverride, _ int) analysisresult.ScoreOverride {
+		return analysisresult.ScoreOverride{
+			Id:            so.Id,
+			AnalysisId:    so.AnalysisId,
+			UserId:        so.UserId,
+			ReporterScore: so.ReporterScore,
+			PreviousScore: so.PreviousScore,
+			Score:         so.Score,
+			Reason:        so.Reason,
+			Reverted:      so.Reverted,
+			CreatedAt:     so.CreatedAt,
+		}
+	}), nil
 }
 
+// DeleteAnalysisRequestById cancels the jobs of the analysis request and
+// deletes it, both or neither. Adapters still working on one of the jobs are
+// told to stop and the stored content is removed.
 func (ars *AnalysisResultService) DeleteAnalysisRequestById(arid, uid uuid.UUID) error {
-	return ars.au.deleteAnalysisRequestById(arid, uid)
+	var jids []uuid.UUID
+	ref, err := ars.au.deleteAnalysisRequestById(arid, uid, func(tx *gorm.DB) error {
+		var err error
+		jids, err = ars.jc.CancelJobsByAnalysisRequestId(tx, arid)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	deleteStoredContent(ars.cs, ref)
+	if len(jids) == 0 {
+		return nil
+	}
+
+	return ars.cb.Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{
+		JobIds: jids,
+		Reason: "analysis request deleted",
+	})
+}
+
+// GetProgress returns how far the analyses of a request of the user are, the
+// same progress the events carry for clients that reconnect.
+func (ars *AnalysisResultService) GetProgress(uid, arid uuid.UUID) (analysisresult.AnalysisProgress, error) {
+	ar, err := ars.ag.getAnalysesByAnalysisIdAndUserId(uid, arid)
+	if err != nil {
+		return analysisresult.AnalysisProgress{}, err
+	}
+
+	return buildProgress(ar.Id, ar.Analysis, estimates(ars.jh, ar.Analysis)), nil
+}
+
+// GetTimeline returns the jobs of an analysis request owned by the user, with
+// how long each of them waited and ran.
+func (ars *AnalysisResultService) GetTimeline(uid, arid uuid.UUID) (analysisresult.Timeline, error) {
+	_, err := ars.ag.getAnalysesByAnalysisIdAndUserId(uid, arid)
+	if err != nil {
+		return analysisresult.Timeline{}, err
+	}
+
+	jts, err := ars.jh.GetJobTimeline(arid)
+	if err != nil {
+		return analysisresult.Timeline{}, err
+	}
+
+	now := time.Now()
+	return analysisresult.Timeline{
+		AnalysisRequestId: arid,
+		Steps: lo.Map(jts, func(jt jobmanager.JobTimeline, _ int) analysisresult.TimelineStep {
+			return mapToTimelineStep(jt, now)
+		}),
+	}, nil
+}
+
+func mapToTimelineStep(jt jobmanager.JobTimeline, now time.Time) analysisresult.TimelineStep {
+	return analysisresult.TimelineStep{
+		JobId:             jt.JobId,
+		Type:              string(jt.Type),
+		GroupKey:          jt.GroupKey,
+		Status:            string(jt.Status),
+		StatusDescription: jt.StatusDescription,
+		RetryCount:        jt.RetryCount,
+		QueuedAt:          jt.QueuedAt,
+		StartedAt:         lo.If(jt.StartedAt.IsZero(), (*time.Time)(nil)).Else(&jt.StartedAt),
+		EndedAt:           lo.If(jt.Ended(), &jt.EndedAt).Else(nil),
+		WaitMs:            jt.WaitDuration().Milliseconds(),
+		RunMs:             jt.RunDuration(now).Milliseconds(),
+		Events: lo.Map(jt.Events, func(je jobmanager.JobEvent, _ int) analysisresult.TimelineEvent {
+			return analysisresult.TimelineEvent{
+				Status:            string(je.Status),
+				StatusDescription: je.StatusDescription,
+				RetryCount:        je.RetryCount,
+				CreatedAt:         je.CreatedAt,
+			}
+		}),
+	}
 }
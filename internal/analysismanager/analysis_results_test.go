@@ -1,23 +1,33 @@
 package analysismanager
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/theme"
 	"github.com/guardlight/server/pkg/analysisresult"
+	"github.com/guardlight/server/pkg/controlcontract"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 func TestAnalysisGetAllAnalysis(t *testing.T) {
 	mars := NewMockanalysisGetter(t)
 	marsu := NewMockanalysisUpdater(t)
 	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
 	config.SetupConfig("../../testdata/envs/analysisresults.yaml")
 
 	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
-	analyzerResults := NewAnalysisResultService(mars, marsu, mts)
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
 
 	t.Run("success", func(t *testing.T) {
 
@@ -65,10 +75,10 @@ func TestAnalysisGetAllAnalysis(t *testing.T) {
 			},
 		}
 
-		mars.EXPECT().getAnalysesByUserId(userId, Pagination{Limit: 10, Page: 1}, "", "", "", "").Return(AnalysisResultPaginated{Limit: 10, Page: 1, TotalPages: 1, Requests: as}, nil)
+		mars.EXPECT().getAnalysesByUserId(userId, Pagination{Limit: 10, Page: 1}, "", "", "", Verdict(""), "").Return(AnalysisResultPaginated{Limit: 10, Page: 1, TotalPages: 1, Requests: as}, nil)
 		mts.EXPECT().GetAllThemesByUserId(userId).Return(ts, nil)
 
-		res, err := analyzerResults.GetAnalysesByUserId(userId, 10, 1, "", "", "", "")
+		res, err := analyzerResults.GetAnalysesByUserId(userId, 10, 1, "", "", "", "", "")
 		assert.NoError(t, err)
 
 		asRes := []analysisresult.Analysis{
@@ -113,3 +123,294 @@ func TestAnalysisGetAllAnalysis(t *testing.T) {
 	})
 
 }
+
+func TestAnalysisDeleteCancelsJobs(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+	jids := []uuid.UUID{uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")}
+
+	marsu.EXPECT().deleteAnalysisRequestById(arid, userId, mock.Anything).RunAndReturn(func(_, _ uuid.UUID, cancelJobs func(*gorm.DB) error) (string, error) {
+		return arid.String(), cancelJobs(nil)
+	})
+	mjc.EXPECT().CancelJobsByAnalysisRequestId((*gorm.DB)(nil), arid).Return(jids, nil)
+	mcr.EXPECT().Delete(mock.Anything, arid.String()).Return(nil)
+	mcb.EXPECT().Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{JobIds: jids, Reason: "analysis request deleted"}).Return(nil)
+
+	err := analyzerResults.DeleteAnalysisRequestById(arid, userId)
+	assert.NoError(t, err)
+}
+
+func TestAnalysisDeleteNotOwnedKeepsJobs(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+
+	marsu.EXPECT().deleteAnalysisRequestById(arid, userId, mock.Anything).Return("", errors.New("no record found for request id and user id"))
+	mjc.AssertNotCalled(t, "CancelJobsByAnalysisRequestId")
+	mcb.AssertNotCalled(t, "Broadcast")
+
+	err := analyzerResults.DeleteAnalysisRequestById(arid, userId)
+	assert.Error(t, err)
+}
+
+func TestAnalysisDeleteKeptWhenJobsNotCancelled(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+
+	marsu.EXPECT().deleteAnalysisRequestById(arid, userId, mock.Anything).RunAndReturn(func(_, _ uuid.UUID, cancelJobs func(*gorm.DB) error) (string, error) {
+		return "", cancelJobs(nil)
+	})
+	mjc.EXPECT().CancelJobsByAnalysisRequestId((*gorm.DB)(nil), arid).Return(nil, errors.New("connection lost"))
+	mcb.AssertNotCalled(t, "Broadcast")
+
+	err := analyzerResults.DeleteAnalysisRequestById(arid, userId)
+	assert.Error(t, err)
+}
+
+func TestAnalysisTimeline(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+	jid := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
+	queued := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	mars.EXPECT().getAnalysesByAnalysisIdAndUserId(userId, arid).Return(AnalysisRequest{Id: arid}, nil)
+	mjh.EXPECT().GetJobTimeline(arid).Return([]jobmanager.JobTimeline{
+		{
+			JobId:     jid,
+			Type:      jobmanager.Parse,
+			GroupKey:  "parser.freetext",
+			Status:    jobmanager.Finished,
+			QueuedAt:  queued,
+			StartedAt: queued.Add(2 * time.Second),
+			EndedAt:   queued.Add(5 * time.Second),
+			Events: []jobmanager.JobEvent{
+				{JobId: jid, Status: jobmanager.Queued, CreatedAt: queued},
+				{JobId: jid, Status: jobmanager.Inprogress, CreatedAt: queued.Add(2 * time.Second)},
+				{JobId: jid, Status: jobmanager.Finished, CreatedAt: queued.Add(5 * time.Second)},
+			},
+		},
+	}, nil)
+
+	tl, err := analyzerResults.GetTimeline(userId, arid)
+	assert.NoError(t, err)
+	assert.Equal(t, arid, tl.AnalysisRequestId)
+	assert.Len(t, tl.Steps, 1)
+	assert.Equal(t, "parse", tl.Steps[0].Type)
+	assert.Equal(t, int64(2000), tl.Steps[0].WaitMs)
+	assert.Equal(t, int64(3000), tl.Steps[0].RunMs)
+	assert.NotNil(t, tl.Steps[0].EndedAt)
+	assert.Len(t, tl.Steps[0].Events, 3)
+}
+
+func TestAnalysisTimelineNotOwned(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+
+	mars.EXPECT().getAnalysesByAnalysisIdAndUserId(userId, arid).Return(AnalysisRequest{}, gorm.ErrRecordNotFound)
+	mjh.AssertNotCalled(t, "GetJobTimeline")
+
+	_, err := analyzerResults.GetTimeline(userId, arid)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestAnalysisSearch(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+	config.SetupConfig("../../testdata/envs/analysisresults.yaml")
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+	themeId := uuid.MustParse("09a8c66d-d0df-435f-87e2-4f5f17c8c0f1")
+
+	mars.EXPECT().searchAnalysesByUserId(userId, "old gun", Pagination{Limit: 5, Page: 2}).Return(AnalysisSearchPaginated{
+		Limit:      5,
+		Page:       2,
+		TotalPages: 3,
+		Hits: []AnalysisSearchHit{
+			{
+				Request: AnalysisRequest{
+					Id:     arid,
+					UserId: userId,
+					Title:  "Book one",
+					Analysis: []Analysis{
+						{AnalyzerKey: "word_search", ThemeId: themeId, Status: AnalysisFinished},
+					},
+				},
+				Snippet: "The <mark>gun</mark> was <mark>old</mark>",
+				Rank:    0.6,
+			},
+		},
+	}, nil)
+	mts.EXPECT().GetAllThemesByUserId(userId).Return([]theme.ThemeDto{{Id: themeId, Title: "Violence"}}, nil)
+
+	sr, err := analyzerResults.SearchAnalyses(userId, "old gun", 5, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sr.TotalPages)
+	assert.Equal(t, 2, sr.Page)
+	assert.Len(t, sr.Results, 1)
+	assert.Equal(t, arid, sr.Results[0].Analysis.Id)
+	assert.Equal(t, "Violence", sr.Results[0].Analysis.Themes[0].Title)
+	assert.Equal(t, "The <mark>gun</mark> was <mark>old</mark>", sr.Results[0].Snippet)
+	assert.Equal(t, float32(0.6), sr.Results[0].Rank)
+}
+
+func TestAnalysisSearchNoHits(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+
+	mars.EXPECT().searchAnalysesByUserId(userId, "knife", Pagination{}).Return(AnalysisSearchPaginated{Limit: 10, Page: 1}, nil)
+	mts.AssertNotCalled(t, "GetAllThemesByUserId")
+
+	sr, err := analyzerResults.SearchAnalyses(userId, "knife", 0, 0)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, sr.Results)
+	assert.Empty(t, sr.Results)
+}
+
+func TestHighlightSnippetEscapesText(t *testing.T) {
+	headline := "<script>alert(1)</script> the " + searchMarkStart + "knife" + searchMarkStop + " & more"
+
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt; the <mark>knife</mark> &amp; more", highlightSnippet(headline))
+}
+
+func TestAnalysisOverrideScore(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	aid := uuid.MustParse("99d28902-0c4c-40c5-acf0-5d74af24b85b")
+
+	err := analyzerResults.OverrideScore(userId, aid, 1, "  ")
+	assert.ErrorIs(t, err, ErrMissingReason)
+
+	for _, score := range []float32{0, 2, -1.5} {
+		err = analyzerResults.OverrideScore(userId, aid, score, "Used in a cooking context")
+		assert.ErrorIs(t, err, ErrInvalidScore)
+	}
+
+	marsu.EXPECT().overrideScore(userId, aid, float32(1), "Used in a cooking context").Return(nil)
+	err = analyzerResults.OverrideScore(userId, aid, 1, " Used in a cooking context ")
+	assert.NoError(t, err)
+
+	err = analyzerResults.RevertScoreOverride(userId, aid, " ")
+	assert.ErrorIs(t, err, ErrMissingReason)
+
+	marsu.EXPECT().revertScoreOverride(userId, aid, "Not a cooking context").Return(ErrNotOverridden)
+	err = analyzerResults.RevertScoreOverride(userId, aid, "Not a cooking context")
+	assert.ErrorIs(t, err, ErrNotOverridden)
+}
+
+func TestAnalysisScoreOverrides(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+	aid := uuid.MustParse("99d28902-0c4c-40c5-acf0-5d74af24b85b")
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mars.EXPECT().getScoreOverridesByAnalysisRequestId(userId, arid).Return([]ScoreOverride{
+		{AnalysisId: aid, AnalysisRequestId: arid, UserId: userId, ReporterScore: -1, PreviousScore: 1, Score: -1, Reason: "Mistake", Reverted: true, CreatedAt: at.Add(time.Hour)},
+		{AnalysisId: aid, AnalysisRequestId: arid, UserId: userId, ReporterScore: -1, PreviousScore: -1, Score: 1, Reason: "Used in a cooking context", CreatedAt: at},
+	}, nil)
+
+	sos, err := analyzerResults.GetScoreOverrides(userId, arid)
+
+	assert.NoError(t, err)
+	assert.Len(t, sos, 2)
+	assert.True(t, sos[0].Reverted)
+	assert.Equal(t, analysisresult.ScoreOverride{
+		AnalysisId:    aid,
+		UserId:        userId,
+		ReporterScore: -1,
+		PreviousScore: -1,
+		Score:         1,
+		Reason:        "Used in a cooking context",
+		CreatedAt:     at,
+	}, sos[1])
+
+	mars.EXPECT().getScoreOverridesByAnalysisRequestId(userId, aid).Return(nil, gorm.ErrRecordNotFound)
+	_, err = analyzerResults.GetScoreOverrides(userId, aid)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
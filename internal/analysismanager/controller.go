@@ -1,28 +1,38 @@
 package analysismanager
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/essential/glerror"
 	"github.com/guardlight/server/internal/essential/glsecurity"
 	"github.com/guardlight/server/pkg/analysisrequest"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type AnalysisRequestController struct {
 	manager *AnalysisManagerRequester
 	ars     *AnalysisResultService
+	rerun   *AnalysisManagerRerunner
+	batch   *AnalysisManagerBatcher
 }
 
-func NewAnalysisRequestController(group *gin.RouterGroup, manager *AnalysisManagerRequester, ars *AnalysisResultService) *AnalysisRequestController {
+func NewAnalysisRequestController(group *gin.RouterGroup, manager *AnalysisManagerRequester, ars *AnalysisResultService, rerun *AnalysisManagerRerunner, batch *AnalysisManagerBatcher) *AnalysisRequestController {
 	arc := &AnalysisRequestController{
 		manager: manager,
 		ars:     ars,
+		rerun:   rerun,
+		batch:   batch,
 	}
 
 	analysisGroup := group.Group("analysis")
@@ -30,13 +40,24 @@ func NewAnalysisRequestController(group *gin.RouterGroup, manager *AnalysisManag
 	analysisGroupLoom := analysisGroup.Group("dataloom")
 	analysisGroupLoom.Use(glsecurity.UseGuardlightAuthApiKey())
 	analysisGroupLoom.POST("", arc.analysisRequestDataloom)
+	analysisGroupLoom.POST("/batch", arc.analysisBatchDataloom)
+	analysisGroupLoom.GET("/batch/:id", arc.analysisBatch)
 
 	analysisGroup.Use(glsecurity.UseGuardlightAuth())
 	analysisGroup.POST("", arc.analysisRequest)
+	analysisGroup.POST("/upload", arc.analysisUpload)
 	analysisGroup.GET("", arc.analyses)
+	analysisGroup.GET("/search", arc.searchAnalyses)
 	analysisGroup.GET("/:arid", arc.analysisById)
+	analysisGroup.GET("/:arid/timeline", arc.analysisTimeline)
+	analysisGroup.GET("/:arid/progress", arc.analysisProgress)
+	analysisGroup.GET("/:arid/overrides", arc.scoreOverrides)
 	analysisGroup.DELETE("/:arid", arc.deleteAnalysisRequestById)
+	analysisGroup.POST("/:arid/rerun", arc.rerunAnalysis)
+	analysisGroup.POST("/rerun", arc.rerunTheme)
+	analysisGroup.GET("/batch/:id", arc.analysisBatch)
 	analysisGroup.POST("/update/score", arc.updateAnalysisScore)
+	analysisGroup.POST("/update/score/revert", arc.revertAnalysisScore)
 
 	return arc
 }
@@ -78,6 +99,80 @@ func (arc *AnalysisRequestController) analysisRequestDataloom(c *gin.Context) {
 
 }
 
+// analysisBatchDataloom takes either a JSON list of dataloom requests or a zip
+// archive of files with a manifest.
+func (arc *AnalysisRequestController) analysisBatchDataloom(c *gin.Context) {
+	ui := glsecurity.GetUserIdFromContextParsed(c)
+
+	// The archive is kept in memory while its files are streamed out of it
+	limit := config.Get().Data.MaxBatchBytes
+	if c.ContentType() == "application/zip" {
+		limit = config.Get().Data.MaxUploadBytes
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	b, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		zap.S().Errorw("error reading analysis batch", "error", err)
+		if errors.As(err, new(*http.MaxBytesError)) {
+			c.JSON(glerror.PayloadTooLargeError())
+			return
+		}
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	var br analysisrequest.AnalysisBatchResponse
+	if c.ContentType() == "application/zip" {
+		br, err = arc.batch.SubmitDataloomArchive(ui, bytes.NewReader(b), int64(len(b)))
+	} else {
+		c.Set(gin.BodyBytesKey, b)
+		ardb := &analysisrequest.AnalysisRequestDataloomBatch{}
+		if err := glsecurity.ReuseBindAndValidate(c, ardb); err != nil {
+			zap.S().Errorw("error validating analysis batch", "error", err)
+			c.JSON(glerror.BadRequestError())
+			return
+		}
+		br, err = arc.batch.SubmitDataloom(ui, ardb.Items)
+	}
+
+	if err != nil {
+		zap.S().Errorw("error submitting analysis batch", "error", err)
+		switch {
+		case errors.Is(err, ErrBatchTooLarge):
+			c.JSON(glerror.PayloadTooLargeError())
+		case errors.Is(err, ErrEmptyBatch), errors.Is(err, ErrInvalidManifest):
+			c.JSON(glerror.BadRequestError())
+		default:
+			c.JSON(glerror.InternalServerError())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, br)
+}
+
+func (arc *AnalysisRequestController) analysisBatch(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	bid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	bp, err := arc.batch.GetBatch(uid, bid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(glerror.ResourceNotFoundError())
+			return
+		}
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, bp)
+}
+
 func (arc *AnalysisRequestController) analysisRequest(c *gin.Context) {
 	ar := &analysisrequest.AnalysisRequest{}
 	err := glsecurity.ReuseBindAndValidate(c, ar)
@@ -114,6 +209,82 @@ func (arc *AnalysisRequestController) analysisRequest(c *gin.Context) {
 	})
 }
 
+// analysisUpload takes a multipart upload with a "metadata" part followed by a
+// "file" part. The file part is streamed into the content store as it comes in.
+func (arc *AnalysisRequestController) analysisUpload(c *gin.Context) {
+	// Room for the metadata part and the multipart framing
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.Get().Data.MaxUploadBytes+1<<20)
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		zap.S().Errorw("error reading upload", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	var au *analysisrequest.AnalysisUpload
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			zap.S().Errorw("error reading upload part", "error", err)
+			if errors.As(err, new(*http.MaxBytesError)) {
+				c.JSON(glerror.PayloadTooLargeError())
+				return
+			}
+			c.JSON(glerror.BadRequestError())
+			return
+		}
+
+		switch part.FormName() {
+		case "metadata":
+			au = &analysisrequest.AnalysisUpload{}
+			if err := json.NewDecoder(part).Decode(au); err != nil {
+				zap.S().Errorw("error decoding upload metadata", "error", err)
+				c.JSON(glerror.BadRequestError())
+				return
+			}
+			continue
+		case "file":
+		default:
+			continue
+		}
+
+		if au == nil {
+			zap.S().Errorw("upload metadata must come before the file")
+			c.JSON(glerror.BadRequestError())
+			return
+		}
+
+		ar := &analysisrequest.AnalysisRequest{
+			Title:       au.Title,
+			ContentType: au.ContentType,
+			Category:    au.Category,
+			File:        analysisrequest.File{Mimetype: au.Mimetype},
+			Themes:      au.Themes,
+		}
+		ui := glsecurity.GetUserIdFromContextParsed(c)
+
+		aid, err := arc.manager.RequestAnalysisUpload(ar, part, ui, string(RequestOriginUser))
+		if err != nil && err != ErrHashAlreadyExist {
+			zap.S().Errorw("error creating analysis request from upload", "error", err)
+			switch {
+			case errors.Is(err, ErrFileTooLarge), errors.As(err, new(*http.MaxBytesError)):
+				c.JSON(glerror.PayloadTooLargeError())
+			case errors.Is(err, ErrInvalidAnalyzer), errors.Is(err, ErrInvalidParser):
+				c.JSON(glerror.BadRequestError())
+			default:
+				c.JSON(glerror.InternalServerError())
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, analysisrequest.AnalysisRequestResponse{
+			Id: aid,
+		})
+		return
+	}
+}
+
 func (arc *AnalysisRequestController) analyses(c *gin.Context) {
 	uid := glsecurity.GetUserIdFromContextParsed(c)
 
@@ -155,9 +326,12 @@ func (arc *AnalysisRequestController) analyses(c *gin.Context) {
 
 	pgQuery := c.Query("query")
 
-	sc := c.Query("score")
+	verdict := Verdict(c.Query("verdict"))
+	if verdict == "" {
+		verdict = verdictFromScoreFilter(c.Query("score"))
+	}
 
-	ars, err := arc.ars.GetAnalysesByUserId(uid, pgLim, pgNr, pgCatType, pgCatCat, pgQuery, sc)
+	ars, err := arc.ars.GetAnalysesByUserId(uid, pgLim, pgNr, pgCatType, pgCatCat, pgQuery, verdict, c.Query("sort"))
 	if err != nil {
 		zap.S().Errorw("error get analyses", "error", err)
 		c.JSON(glerror.InternalServerError())
@@ -167,6 +341,53 @@ func (arc *AnalysisRequestController) analyses(c *gin.Context) {
 	c.JSON(http.StatusOK, ars)
 }
 
+// verdictFromScoreFilter maps the score filter the console used before there
+// were verdicts.
+func verdictFromScoreFilter(sc string) Verdict {
+	switch sc {
+	case "GOOD":
+		return VerdictApproved
+	case "BAD":
+		return VerdictFlagged
+	case "MIXED":
+		return VerdictNeedsReview
+	default:
+		return ""
+	}
+}
+
+func (arc *AnalysisRequestController) searchAnalyses(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	pgNr, err := strconv.Atoi(c.DefaultQuery("page", "0"))
+	if err != nil {
+		pgNr = 0
+	}
+	pgLim, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		pgLim = 0
+	}
+
+	sr, err := arc.ars.SearchAnalyses(uid, q, max(0, pgLim), max(0, pgNr))
+	if errors.Is(err, ErrSearchUnavailable) {
+		c.JSON(glerror.StateConflictError())
+		return
+	}
+	if err != nil {
+		zap.S().Errorw("error search analyses", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, sr)
+}
+
 func (arc *AnalysisRequestController) analysisById(c *gin.Context) {
 	uid := glsecurity.GetUserIdFromContextParsed(c)
 	arid, err := uuid.Parse(c.Param("arid"))
@@ -186,6 +407,52 @@ func (arc *AnalysisRequestController) analysisById(c *gin.Context) {
 	c.JSON(http.StatusOK, ars)
 }
 
+func (arc *AnalysisRequestController) analysisTimeline(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	arid, err := uuid.Parse(c.Param("arid"))
+	if err != nil {
+		zap.S().Errorw("Analysis Request id is not uuid", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	tl, err := arc.ars.GetTimeline(uid, arid)
+	if err != nil {
+		zap.S().Errorw("error get analysis timeline", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(glerror.ResourceNotFoundError())
+			return
+		}
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, tl)
+}
+
+func (arc *AnalysisRequestController) analysisProgress(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	arid, err := uuid.Parse(c.Param("arid"))
+	if err != nil {
+		zap.S().Errorw("Analysis Request id is not uuid", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	p, err := arc.ars.GetProgress(uid, arid)
+	if err != nil {
+		zap.S().Errorw("error get analysis progress", "error", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(glerror.ResourceNotFoundError())
+			return
+		}
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
 func (arc *AnalysisRequestController) deleteAnalysisRequestById(c *gin.Context) {
 	uid := glsecurity.GetUserIdFromContextParsed(c)
 	arid, err := uuid.Parse(c.Param("arid"))
@@ -207,21 +474,85 @@ func (arc *AnalysisRequestController) deleteAnalysisRequestById(c *gin.Context)
 	})
 }
 
+func (arc *AnalysisRequestController) rerunAnalysis(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	arid, err := uuid.Parse(c.Param("arid"))
+	if err != nil {
+		zap.S().Errorw("Analysis Request id is not uuid", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	rr := &analysisrequest.AnalysisRerun{}
+	err = glsecurity.ReuseBindAndValidate(c, rr)
+	if err != nil || len(rr.ThemeIds) == 0 {
+		zap.S().Errorw("error validating rerun request", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	err = arc.rerun.Rerun(uid, arid, rr.ThemeIds)
+	if err != nil {
+		zap.S().Errorw("error rerunning analysis request", "error", err)
+		c.JSON(rerunError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, analysisrequest.AnalysisRequestResponse{
+		Id: arid,
+	})
+}
+
+func (arc *AnalysisRequestController) rerunTheme(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	rt := &analysisrequest.AnalysisRerunTheme{}
+	err := glsecurity.ReuseBindAndValidate(c, rt)
+	if err != nil || rt.ThemeId == uuid.Nil {
+		zap.S().Errorw("error validating rerun theme request", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	resp, err := arc.rerun.RerunTheme(uid, rt.ThemeId)
+	if err != nil {
+		zap.S().Errorw("error rerunning theme", "error", err)
+		c.JSON(rerunError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func rerunError(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return glerror.ResourceNotFoundError()
+	case errors.Is(err, ErrInvalidTheme), errors.Is(err, ErrInvalidAnalyzer):
+		return glerror.BadRequestError()
+	case errors.Is(err, ErrNotParsed), errors.Is(err, ErrAnalysisInProgress),
+		errors.Is(err, ErrTextExported), errors.Is(err, ErrTextPurged):
+		return glerror.StateConflictError()
+	default:
+		return glerror.InternalServerError()
+	}
+}
+
 func (arc *AnalysisRequestController) updateAnalysisScore(c *gin.Context) {
-	_ = glsecurity.GetUserIdFromContextParsed(c)
+	uid := glsecurity.GetUserIdFromContextParsed(c)
 
 	aus := &analysisrequest.AnalysisUpdateScore{}
 	err := glsecurity.ReuseBindAndValidate(c, aus)
-	if err != nil {
+	if err != nil || aus.Id == uuid.Nil {
 		zap.S().Errorw("error validating analysis request", "error", err)
 		c.JSON(glerror.BadRequestError())
 		return
 	}
 
-	err = arc.ars.UpdateScore(aus.Id, aus.Score)
+	err = arc.ars.OverrideScore(uid, aus.Id, aus.Score, aus.Reason)
 	if err != nil {
-		zap.S().Errorw("error get analyses", "error", err)
-		c.JSON(glerror.InternalServerError())
+		zap.S().Errorw("error override score", "error", err)
+		c.JSON(scoreOverrideError(err))
 		return
 	}
 
@@ -229,3 +560,132 @@ func (arc *AnalysisRequestController) updateAnalysisScore(c *gin.Context) {
 		"status": "ok",
 	})
 }
+
+func (arc *AnalysisRequestController) revertAnalysisScore(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	ars := &analysisrequest.AnalysisRevertScore{}
+	err := glsecurity.ReuseBindAndValidate(c, ars)
+	if err != nil || ars.Id == uuid.Nil {
+		zap.S().Errorw("error validating revert score request", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	err = arc.ars.RevertScoreOverride(uid, ars.Id, ars.Reason)
+	if err != nil {
+		zap.S().Errorw("error revert score", "error", err)
+		c.JSON(scoreOverrideError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+func (arc *AnalysisRequestController) scoreOverrides(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	arid, err := uuid.Parse(c.Param("arid"))
+	if err != nil {
+		zap.S().Errorw("Analysis Request id is not uuid", "error", err)
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	sos, err := arc.ars.GetScoreOverrides(uid, arid)
+	if err != nil {
+		zap.S().Errorw("error get score overrides", "error", err)
+		c.JSON(scoreOverrideError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, sos)
+}
+
+func scoreOverrideError(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return glerror.ResourceNotFoundError()
+	case errors.Is(err, ErrMissingReason), errors.Is(err, ErrInvalidScore):
+		return glerror.BadRequestError()
+	case errors.Is(err, ErrNotOverridden):
+		return glerror.StateConflictError()
+	default:
+		return glerror.InternalServerError()
+	}
+}
+
+type RetentionController struct {
+	rm *RetentionManager
+}
+
+func NewRetentionController(group *gin.RouterGroup, rm *RetentionManager) *RetentionController {
+	rc := &RetentionController{
+		rm: rm,
+	}
+
+	retentionGroup := group.Group("retention")
+	retentionGroup.Use(glsecurity.UseGuardlightAuth(), glsecurity.UseGuardlightRole(glsecurity.Admin))
+	retentionGroup.GET("/report", rc.report)
+
+	return rc
+}
+
+// report is a dry run of the retention rules.
+func (rc *RetentionController) report(c *gin.Context) {
+	r, err := rc.rm.Report()
+	if err != nil {
+		zap.S().Errorw("error building retention report", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, r)
+}
+
+type StatsController struct {
+	sts *StatsService
+}
+
+func NewStatsController(group *gin.RouterGroup, sts *StatsService) *StatsController {
+	sc := &StatsController{
+		sts: sts,
+	}
+
+	statsGroup := group.Group("stats")
+	statsGroup.Use(glsecurity.UseGuardlightAuth())
+	statsGroup.GET("", sc.stats)
+
+	return sc
+}
+
+// stats takes the trend bucket, week or month, the number of buckets and the
+// number of terms per theme.
+func (sc *StatsController) stats(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	periods, err := strconv.Atoi(c.DefaultQuery("periods", "12"))
+	if err != nil || periods < 1 || periods > 120 {
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+	terms, err := strconv.Atoi(c.DefaultQuery("terms", "10"))
+	if err != nil || terms < 1 || terms > 100 {
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	s, err := sc.sts.GetStats(uid, c.DefaultQuery("bucket", StatsBucketWeek), periods, terms)
+	if errors.Is(err, ErrInvalidStatsBucket) {
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+	if err != nil {
+		zap.S().Errorw("error get stats", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, s)
+}
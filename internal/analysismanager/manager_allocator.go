@@ -3,29 +3,35 @@ package analysismanager
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/chunker"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/ssemanager"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analyzercontract"
 	"github.com/guardlight/server/pkg/parsercontract"
 	"github.com/guardlight/server/pkg/reportercontract"
 	"github.com/nats-io/nats.go"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type analysisStore interface {
 	updateProcessedText(ai uuid.UUID, text string) error
 	getAllAnalysisByAnalysisRecordId(id uuid.UUID) ([]Analysis, error)
 	updateAnalysisJobs(ai uuid.UUID, jbs []SingleJobProgress) error
-	updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string) (bool, error)
+	updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string, findings []analyzercontract.Finding) (bool, error)
 	getUserIdByAnalysisId(analysisId uuid.UUID) (uuid.UUID, error)
-	updateScore(analysisId uuid.UUID, score float32) error
+	getAnalysisRequestById(arid uuid.UUID) (AnalysisRequest, error)
+	updateReporterScore(analysisId uuid.UUID, score float32) error
 	getReporterKeyByAnalysisId(aid uuid.UUID) (string, error)
 	getAllAnalysisById(aid uuid.UUID) (Analysis, error)
-	updateAllAnalysesStatusByAnalysisRequestId(arid uuid.UUID, status AnalysisStatus) error
+	failAnalyses(tx *gorm.DB, fw failedWork) error
+	failAnalysis(aid uuid.UUID) error
 }
 
 type subsriber interface {
@@ -34,25 +40,44 @@ type subsriber interface {
 
 type jobber interface {
 	jobmanager.IdCreater
+	jobmanager.InprogressChecker
 	jobmanager.JobUpdater
 	jobmanager.Enqueuer
+	jobmanager.JobDurationGetter
+}
+
+// cancellations knows the jobs that were cancelled on the control subject.
+type cancellations interface {
+	Cancelled(id uuid.UUID) bool
 }
 
 type sseEventSender interface {
 	SendEvent(userId uuid.UUID, e ssemanager.SseEvent)
 }
 
+type webhookNotifier interface {
+	Notify(userId uuid.UUID, e webhook.Event)
+}
+
 type AnalysisManagerAllocator struct {
 	as  analysisStore
 	ju  jobber
 	sse sseEventSender
+	wn  webhookNotifier
+	cs  cancellations
 }
 
-func NewAnalysisManagerAllocator(s subsriber, as analysisStore, ju jobber, sse sseEventSender) *AnalysisManagerAllocator {
+func NewAnalysisManagerAllocator(s subsriber, as analysisStore, ju jobber, sse sseEventSender, wn webhookNotifier, cs cancellations) *AnalysisManagerAllocator {
 	ama := &AnalysisManagerAllocator{
 		as:  as,
 		ju:  ju,
 		sse: sse,
+		wn:  wn,
+		cs:  cs,
+	}
+
+	if err := validateChunking(); err != nil {
+		zap.S().Fatalw("Invalid analyzer config", "error", err)
 	}
 
 	s.Subscribe("parser.result", ama.processParserResult)
@@ -62,6 +87,52 @@ func NewAnalysisManagerAllocator(s subsriber, as analysisStore, ju jobber, sse s
 	return ama
 }
 
+// validateChunking checks that the text of every text analyzer can be
+// chunked, so it does not fail on the first request instead.
+func validateChunking() error {
+	for _, a := range config.Get().Analyzers {
+		if a.Model != "text" {
+			continue
+		}
+		err := chunker.Validate(chunker.Options{
+			Size:     a.ContextWindow,
+			Overlap:  a.ChunkOverlap,
+			Unit:     chunker.Unit(a.ChunkUnit),
+			Encoding: a.Encoding,
+		})
+		if err != nil {
+			return fmt.Errorf("analyzer %s: %w", a.Key, err)
+		}
+	}
+	return nil
+}
+
+// cancelled reports whether a result belongs to a cancelled job. Those results
+// are dropped quietly, their analysis request is gone.
+func (ama *AnalysisManagerAllocator) cancelled(jid uuid.UUID) bool {
+	c := ama.cs.Cancelled(jid)
+	if c {
+		zap.S().Debugw("Dropping result of cancelled job", "job_id", jid)
+	}
+	return c
+}
+
+// outdated reports whether a result belongs to a job that is not in progress
+// anymore. A job that timed out is published again while its first message
+// can still be handled, the copy that answers last finds the job queued again
+// or already finished.
+func (ama *AnalysisManagerAllocator) outdated(jid uuid.UUID) bool {
+	ok, err := ama.ju.IsInprogress(jid)
+	if err != nil {
+		// The job times out and is retried
+		return true
+	}
+	if !ok {
+		zap.S().Infow("Dropping result of job that is not in progress", "job_id", jid)
+	}
+	return !ok
+}
+
 func (ama *AnalysisManagerAllocator) processParserResult(m *nats.Msg) {
 	var pr parsercontract.ParserResponse
 	err := json.Unmarshal(m.Data, &pr)
@@ -72,14 +143,15 @@ func (ama *AnalysisManagerAllocator) processParserResult(m *nats.Msg) {
 		//      Update to error status with description, "Task running to long"
 	}
 
+	if ama.cancelled(pr.JobId) || ama.outdated(pr.JobId) {
+		return
+	}
+
 	if pr.Status == parsercontract.ParseError {
+		// The analyses fail along with the dead lettered job, see FailJobs
 		err = ama.ju.UpdateJobStatus(pr.JobId, jobmanager.Error, pr.Text, 0)
 		if err != nil {
 			zap.S().Errorw("Could not update job status", "error", err)
-		}
-		err = ama.as.updateAllAnalysesStatusByAnalysisRequestId(pr.AnalysisId, AnalysisError)
-		if err != nil {
-			zap.S().Errorw("Could not update analyses status", "error", err)
 			return
 		}
 		return
@@ -109,6 +181,11 @@ func (ama *AnalysisManagerAllocator) processParserResult(m *nats.Msg) {
 }
 
 func (ama *AnalysisManagerAllocator) allocateAnalyzeJobs(ai uuid.UUID, text string) {
+	ar, err := ama.as.getAnalysisRequestById(ai)
+	if err != nil {
+		return
+	}
+
 	al, err := ama.as.getAllAnalysisByAnalysisRecordId(ai)
 	if err != nil {
 		zap.S().Errorw("Could not get analysis from request", "Analysis_request_id", ai)
@@ -116,27 +193,50 @@ func (ama *AnalysisManagerAllocator) allocateAnalyzeJobs(ai uuid.UUID, text stri
 	}
 
 	for _, a := range al {
-		jbs := ama.buildJobsForAnalyzer(a, text)
+		// A rerun only adds jobs for the analyses it replaced
+		if len(a.Jobs) > 0 {
+			continue
+		}
+		jbs, err := ama.buildJobsForAnalyzer(a, text, ar.jobMeta())
+		if err != nil {
+			// Without jobs the analysis would wait forever
+			if err := ama.as.failAnalysis(a.Id); err != nil {
+				zap.S().Errorw("Could not fail analysis", "analysis_id", a.Id, "error", err)
+			}
+			continue
+		}
 		ama.as.updateAnalysisJobs(a.Id, jbs)
 	}
 
 }
 
-func (ama *AnalysisManagerAllocator) buildJobsForAnalyzer(a Analysis, text string) []SingleJobProgress {
+func (ama *AnalysisManagerAllocator) buildJobsForAnalyzer(a Analysis, text string, meta jobmanager.JobMeta) ([]SingleJobProgress, error) {
 	analyzerFromConfig, ok := config.Get().GetAnalyzer(a.AnalyzerKey)
 	if !ok {
 		zap.S().Errorw("Could not get analyzer from config", "analyzer_key", a.AnalyzerKey)
-		return nil
+		return nil, ErrInvalidAnalyzer
 	}
 	jobs := make([]SingleJobProgress, 0)
 
 	if analyzerFromConfig.Model == "text" {
-		chks := lo.ChunkString(text, analyzerFromConfig.ContextWindow)
+		chks, err := chunker.Split(text, chunker.Options{
+			Size:     analyzerFromConfig.ContextWindow,
+			Overlap:  analyzerFromConfig.ChunkOverlap,
+			Unit:     chunker.Unit(analyzerFromConfig.ChunkUnit),
+			Encoding: analyzerFromConfig.Encoding,
+		})
+		if err != nil {
+			zap.S().Errorw("Could not chunk processed text", "analyzer_key", a.AnalyzerKey, "error", err)
+			return nil, err
+		}
 		for _, ch := range chks {
 			jid := ama.ju.CreateId()
 			jobs = append(jobs, SingleJobProgress{
-				JobId:  jid,
-				Status: AnalysisWaiting,
+				JobId:      jid,
+				Status:     AnalysisWaiting,
+				ChunkIndex: ch.Index,
+				Start:      ch.Start,
+				End:        ch.End,
 			})
 			ainputs := lo.Map(a.Inputs, func(inp AnalysisInput, _ int) analyzercontract.AnalysisInput {
 				return analyzercontract.AnalysisInput{
@@ -151,18 +251,20 @@ func (ama *AnalysisManagerAllocator) buildJobsForAnalyzer(a Analysis, text strin
 				AnalyzerData: analyzercontract.AnalyzerRequest{
 					JobId:      jid,
 					AnalysisId: a.Id,
-					Content:    ch,
+					Content:    ch.Text,
+					ChunkIndex: ch.Index,
+					Offset:     ch.Start,
 					Inputs:     ainputs,
 				},
 			}
 			gk := fmt.Sprintf("analyzer.%s", analyzerFromConfig.Key)
-			ama.ju.EnqueueJob(jid, jobmanager.Analyze, gk, ajd)
+			ama.ju.EnqueueJob(jid, jobmanager.Analyze, gk, ajd, meta)
 		}
 	} else {
 		zap.S().Errorw("Model not supported", "model", analyzerFromConfig.Model)
 	}
 
-	return jobs
+	return jobs, nil
 }
 
 func (ama *AnalysisManagerAllocator) processAnalyzerResult(m *nats.Msg) {
@@ -175,7 +277,24 @@ func (ama *AnalysisManagerAllocator) processAnalyzerResult(m *nats.Msg) {
 		//      Update to error status with description, "Task running to long"
 	}
 
-	analysisCompleted, err := ama.as.updateAnalysisJobProgress(ar.AnalysisId, ar.JobId, AnalysisFinished, ar.Results)
+	if ama.cancelled(ar.JobId) || ama.outdated(ar.JobId) {
+		return
+	}
+
+	if ar.Status == analyzercontract.AnalyzerError {
+		// The analysis fails along with the dead lettered job, see FailJobs
+		reason := strings.Join(ar.Results, "; ")
+		if reason == "" {
+			reason = "analyzer failed"
+		}
+		err = ama.ju.UpdateJobStatus(ar.JobId, jobmanager.Error, reason, 0)
+		if err != nil {
+			zap.S().Errorw("Could not update job status", "error", err)
+		}
+		return
+	}
+
+	analysisCompleted, err := ama.as.updateAnalysisJobProgress(ar.AnalysisId, ar.JobId, AnalysisFinished, ar.Results, ar.Findings)
 	if err != nil {
 		zap.S().Errorw("Could not update analysis progress", "error", err)
 		return
@@ -193,15 +312,17 @@ func (ama *AnalysisManagerAllocator) processAnalyzerResult(m *nats.Msg) {
 	if err != nil {
 		return
 	}
-	zap.S().Info(uid)
 
-	ama.sse.SendEvent(uid, ssemanager.SseEvent{
-		Type:   ssemanager.TypeUpdate,
-		Action: ssemanager.ActionAnalysisDone,
-		Data:   ar.AnalysisId.String(),
-	})
+	ama.sendProgress(uid, ar.AnalysisId)
 
 	if analysisCompleted {
+		ama.sse.SendEvent(uid, ssemanager.SseEvent{
+			Type:   ssemanager.TypeUpdate,
+			Action: ssemanager.ActionAnalysisDone,
+			Data:   ar.AnalysisId.String(),
+		})
+		ama.notify(uid, webhook.EventAnalysisFinished, ar.AnalysisId)
+
 		zap.S().Infow("Sending analysis results to reporting", "analysis_id", ar.AnalysisId)
 
 		rkey, err := ama.as.getReporterKeyByAnalysisId(ar.AnalysisId)
@@ -214,6 +335,11 @@ func (ama *AnalysisManagerAllocator) processAnalyzerResult(m *nats.Msg) {
 			return
 		}
 
+		areq, err := ama.as.getAnalysisRequestById(ana.AnalysisRequestId)
+		if err != nil {
+			return
+		}
+
 		reporterFromConfig, ok := config.Get().GetReporter(rkey)
 		if !ok {
 			zap.S().Errorw("Could not get reporter from config", "reporter_key", rkey)
@@ -232,7 +358,7 @@ func (ama *AnalysisManagerAllocator) processAnalyzerResult(m *nats.Msg) {
 			},
 		}
 		gk := fmt.Sprintf("reporter.%s", reporterFromConfig.Key)
-		ama.ju.EnqueueJob(jid, jobmanager.Report, gk, rjd)
+		ama.ju.EnqueueJob(jid, jobmanager.Report, gk, rjd, areq.jobMeta())
 	}
 
 }
@@ -244,13 +370,31 @@ func (ama *AnalysisManagerAllocator) processReporterResult(m *nats.Msg) {
 		zap.S().Errorw("Could not unmarshal reporter response", "error", err)
 	}
 
+	if ama.cancelled(rr.JobId) || ama.outdated(rr.JobId) {
+		return
+	}
+
+	if rr.Status == reportercontract.ReportError {
+		// The score of a failed report means nothing, the analysis fails along
+		// with the dead lettered job instead, see FailJobs
+		reason := rr.Comments
+		if reason == "" {
+			reason = "reporter failed"
+		}
+		err = ama.ju.UpdateJobStatus(rr.JobId, jobmanager.Error, reason, 0)
+		if err != nil {
+			zap.S().Errorw("Could not update job status", "error", err, "jid", rr.JobId)
+		}
+		return
+	}
+
 	err = ama.ju.UpdateJobStatus(rr.JobId, jobmanager.Finished, "", 0)
 	if err != nil {
 		zap.S().Errorw("Could not update job status", "error", err, "jid", rr.JobId)
 		return
 	}
 
-	err = ama.as.updateScore(rr.AnalysisId, rr.Score)
+	err = ama.as.updateReporterScore(rr.AnalysisId, rr.Score)
 	if err != nil {
 		zap.S().Errorw("Could not update analysis score", "error", err)
 		return
@@ -269,4 +413,166 @@ func (ama *AnalysisManagerAllocator) processReporterResult(m *nats.Msg) {
 		Action: ssemanager.ActionReportDone,
 		Data:   rr.AnalysisId.String(),
 	})
+	ama.notify(uid, webhook.EventReportFinished, rr.AnalysisId)
+
+	ama.sendProgress(uid, rr.AnalysisId)
+}
+
+// sendProgress sends the progress of the request the analysis belongs to.
+func (ama *AnalysisManagerAllocator) sendProgress(uid, aid uuid.UUID) {
+	a, err := ama.as.getAllAnalysisById(aid)
+	if err != nil {
+		return
+	}
+
+	as, err := ama.as.getAllAnalysisByAnalysisRecordId(a.AnalysisRequestId)
+	if err != nil {
+		zap.S().Errorw("Could not get analyses for progress", "analysis_request_id", a.AnalysisRequestId, "error", err)
+		return
+	}
+
+	ama.sse.SendEvent(uid, ssemanager.SseEvent{
+		Type:   ssemanager.TypeUpdate,
+		Action: ssemanager.ActionAnalysisProgress,
+		Data:   buildProgress(a.AnalysisRequestId, as, estimates(ama.ju, as)),
+	})
+}
+
+// notify sends an event about the analysis to the webhooks of the user.
+func (ama *AnalysisManagerAllocator) notify(uid uuid.UUID, et webhook.EventType, aid uuid.UUID) {
+	a, err := ama.as.getAllAnalysisById(aid)
+	if err != nil {
+		return
+	}
+
+	areq, err := ama.as.getAnalysisRequestById(a.AnalysisRequestId)
+	if err != nil {
+		return
+	}
+
+	e := requestEvent(et, areq)
+	e.AnalysisId = &a.Id
+	e.Status = string(a.Status)
+	if et == webhook.EventReportFinished {
+		e.Score = &a.Score
+	}
+	ama.wn.Notify(uid, e)
+}
+
+func requestEvent(et webhook.EventType, ar AnalysisRequest) webhook.Event {
+	return webhook.Event{
+		Type:              et,
+		AnalysisRequestId: ar.Id,
+		Title:             ar.Title,
+		Verdict:           string(ar.Verdict),
+	}
+}
+
+// failedWork is what waited on dead lettered jobs. A parse job blocks all
+// analyses of its request, other jobs the analysis they belong to.
+type failedWork struct {
+	requestIds  []uuid.UUID
+	analysisIds []uuid.UUID
+	jobIds      []uuid.UUID
+}
+
+func failedWorkOf(jobs []jobmanager.DeadLetterJob) failedWork {
+	fw := failedWork{}
+	for _, j := range jobs {
+		fw.jobIds = append(fw.jobIds, j.Id)
+		arid, aid, ok := failedBy(j)
+		switch {
+		case !ok:
+		case aid != uuid.Nil:
+			fw.analysisIds = append(fw.analysisIds, aid)
+		default:
+			fw.requestIds = append(fw.requestIds, arid)
+		}
+	}
+	return fw
+}
+
+// failedBy returns the request a dead lettered parse job blocked, or the
+// analysis any other job belonged to.
+func failedBy(j jobmanager.DeadLetterJob) (uuid.UUID, uuid.UUID, bool) {
+	switch j.Type {
+	case jobmanager.Parse:
+		if j.AnalysisRequestId != uuid.Nil {
+			return j.AnalysisRequestId, uuid.Nil, true
+		}
+		var pjd jobmanager.ParserJobData
+		if err := json.Unmarshal(j.Data, &pjd); err != nil {
+			return uuid.Nil, uuid.Nil, false
+		}
+		return pjd.ParserData.AnalysisId, uuid.Nil, true
+	case jobmanager.Analyze:
+		var ajd jobmanager.AnalyzerJobData
+		if err := json.Unmarshal(j.Data, &ajd); err != nil {
+			return uuid.Nil, uuid.Nil, false
+		}
+		return j.AnalysisRequestId, ajd.AnalyzerData.AnalysisId, true
+	case jobmanager.Report:
+		var rjd jobmanager.ReportJobData
+		if err := json.Unmarshal(j.Data, &rjd); err != nil {
+			return uuid.Nil, uuid.Nil, false
+		}
+		return j.AnalysisRequestId, rjd.ReporterData.AnalysisId, true
+	}
+	return uuid.Nil, uuid.Nil, false
+}
+
+// FailJobs sets the analyses that waited on the dead lettered jobs to error,
+// in the transaction that dead letters them.
+func (ama *AnalysisManagerAllocator) FailJobs(tx *gorm.DB, jobs []jobmanager.DeadLetterJob) error {
+	return ama.as.failAnalyses(tx, failedWorkOf(jobs))
+}
+
+// JobsFailed tells the webhooks of the users about the failed work and sends
+// the progress of the requests whose analyses failed.
+func (ama *AnalysisManagerAllocator) JobsFailed(jobs []jobmanager.DeadLetterJob) {
+	var arids []uuid.UUID
+	for _, j := range jobs {
+		arid, aid, ok := failedBy(j)
+		if !ok {
+			continue
+		}
+
+		var a *Analysis
+		if aid != uuid.Nil {
+			fa, err := ama.as.getAllAnalysisById(aid)
+			if err != nil {
+				continue
+			}
+			arid, a = fa.AnalysisRequestId, &fa
+		}
+		arids = append(arids, arid)
+
+		areq, err := ama.as.getAnalysisRequestById(arid)
+		if err != nil {
+			continue
+		}
+		e := requestEvent(webhook.EventAnalysisError, areq)
+		e.Status = string(AnalysisError)
+		e.Error = lo.Ternary(j.StatusDescription != "", j.StatusDescription, j.Reason)
+		if a != nil {
+			e.AnalysisId = &a.Id
+		}
+		ama.wn.Notify(areq.UserId, e)
+	}
+
+	for _, arid := range lo.Uniq(arids) {
+		areq, err := ama.as.getAnalysisRequestById(arid)
+		if err != nil {
+			continue
+		}
+		as, err := ama.as.getAllAnalysisByAnalysisRecordId(arid)
+		if err != nil {
+			continue
+		}
+		ama.sse.SendEvent(areq.UserId, ssemanager.SseEvent{
+			Type:   ssemanager.TypeUpdate,
+			Action: ssemanager.ActionAnalysisProgress,
+			Data:   buildProgress(arid, as, estimates(ama.ju, as)),
+		})
+	}
 }
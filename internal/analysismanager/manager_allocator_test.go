@@ -7,9 +7,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analyzercontract"
 	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/reportercontract"
 	"github.com/nats-io/nats.go"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -21,15 +24,20 @@ func TestAnalysisAllocatorParserResult(t *testing.T) {
 	mockAs := NewMockanalysisStore(t)
 	mockJu := NewMockjobber(t)
 	mockSse := NewMocksseEventSender(t)
+	mockWn := NewMockwebhookNotifier(t)
+	mockCs := NewMockcancellations(t)
 
 	mockS.EXPECT().Subscribe("parser.result", mock.AnythingOfType("nats.MsgHandler")).Return(nil, nil)
 	mockS.EXPECT().Subscribe("analyzer.result", mock.AnythingOfType("nats.MsgHandler")).Return(nil, nil)
 
-	ama := NewAnalysisManagerAllocator(mockS, mockAs, mockJu, mockSse)
+	ama := NewAnalysisManagerAllocator(mockS, mockAs, mockJu, mockSse, mockWn, mockCs)
 
 	arid := uuid.MustParse("674e46b6-a4f5-4b4f-bc16-c29ba80971c0")
 	jobId := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
 
+	mockCs.EXPECT().Cancelled(jobId).Return(false)
+	mockJu.EXPECT().IsInprogress(jobId).Return(true, nil)
+
 	t.Run("parser_result_success", func(t *testing.T) {
 		pr := parsercontract.ParserResponse{
 			JobId:      jobId,
@@ -68,6 +76,8 @@ func TestAnalysisAllocatorParserResult(t *testing.T) {
 			},
 		}
 
+		userId := uuid.MustParse("fc28fb4c-2280-49f5-a3ba-f99ed8f8843c")
+		mockAs.EXPECT().getAnalysisRequestById(arid).Return(AnalysisRequest{Id: arid, UserId: userId, RequestOrigin: string(RequestOriginDataloom)}, nil)
 		mockAs.EXPECT().getAllAnalysisByAnalysisRecordId(arid).Return(ans, nil)
 
 		jid := uuid.MustParse("45826a77-8377-4cce-9388-6f8f2154f998")
@@ -95,7 +105,7 @@ func TestAnalysisAllocatorParserResult(t *testing.T) {
 			},
 		}).Return(nil)
 		mockJu.EXPECT().CreateId().Return(jid)
-		mockJu.EXPECT().EnqueueJob(jid, jobmanager.Analyze, "analyzer.word_search", ar).Return(nil)
+		mockJu.EXPECT().EnqueueJob(jid, jobmanager.Analyze, "analyzer.word_search", ar, jobmanager.JobMeta{UserId: userId, AnalysisRequestId: arid, Priority: jobmanager.PriorityBackfill}).Return(nil)
 
 		ama.processParserResult(n)
 
@@ -129,7 +139,246 @@ func TestAnalysisAllocatorParserResult(t *testing.T) {
 
 }
 
+func TestAnalysisAllocatorNotifiesWebhooks(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/analysismanangerallocator.yaml")
+
+	mockAs := NewMockanalysisStore(t)
+	mockJu := NewMockjobber(t)
+	mockSse := NewMocksseEventSender(t)
+	mockWn := NewMockwebhookNotifier(t)
+	mockCs := NewMockcancellations(t)
+	ama := &AnalysisManagerAllocator{as: mockAs, ju: mockJu, sse: mockSse, wn: mockWn, cs: mockCs}
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("674e46b6-a4f5-4b4f-bc16-c29ba80971c0")
+	aid := uuid.MustParse("8e1305f1-3fae-44e5-8a4f-9f815321ae8c")
+	jobId := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
+	areq := AnalysisRequest{Id: arid, UserId: userId, Title: "Alice", Verdict: VerdictFlagged}
+
+	t.Run("report_finished", func(t *testing.T) {
+		dat, err := json.Marshal(reportercontract.ReporterResponse{JobId: jobId, AnalysisId: aid, Score: -1})
+		assert.NoError(t, err)
+
+		a := Analysis{Id: aid, AnalysisRequestId: arid, Status: AnalysisFinished, Score: -1, Jobs: JobsProgress{{JobId: jobId, Status: AnalysisFinished}}}
+		mockCs.EXPECT().Cancelled(jobId).Return(false).Once()
+		mockJu.EXPECT().IsInprogress(jobId).Return(true, nil).Once()
+		mockJu.EXPECT().UpdateJobStatus(jobId, jobmanager.Finished, "", 0).Return(nil).Once()
+		mockAs.EXPECT().updateReporterScore(aid, float32(-1)).Return(nil).Once()
+		mockAs.EXPECT().getUserIdByAnalysisId(aid).Return(userId, nil).Once()
+		mockAs.EXPECT().getAllAnalysisById(aid).Return(a, nil)
+		mockAs.EXPECT().getAnalysisRequestById(arid).Return(areq, nil).Once()
+		mockAs.EXPECT().getAllAnalysisByAnalysisRecordId(arid).Return([]Analysis{a}, nil).Once()
+		mockSse.EXPECT().SendEvent(userId, mock.Anything)
+		mockWn.EXPECT().Notify(userId, webhook.Event{
+			Type:              webhook.EventReportFinished,
+			AnalysisRequestId: arid,
+			AnalysisId:        &aid,
+			Title:             "Alice",
+			Status:            string(AnalysisFinished),
+			Verdict:           string(VerdictFlagged),
+			Score:             lo.ToPtr(float32(-1)),
+		}).Once()
+
+		ama.processReporterResult(&nats.Msg{Data: dat})
+	})
+
+	t.Run("parser_error", func(t *testing.T) {
+		dat, err := json.Marshal(parsercontract.ParserResponse{JobId: jobId, AnalysisId: arid, Text: "Error parsing", Status: parsercontract.ParseError})
+		assert.NoError(t, err)
+
+		// The job may still be retried, the error is sent once it is dead lettered
+		mockCs.EXPECT().Cancelled(jobId).Return(false).Once()
+		mockJu.EXPECT().IsInprogress(jobId).Return(true, nil).Once()
+		mockJu.EXPECT().UpdateJobStatus(jobId, jobmanager.Error, "Error parsing", 0).Return(nil).Once()
+
+		ama.processParserResult(&nats.Msg{Data: dat})
+	})
+
+	t.Run("jobs_failed", func(t *testing.T) {
+		ajd, err := json.Marshal(jobmanager.AnalyzerJobData{AnalyzerData: analyzercontract.AnalyzerRequest{AnalysisId: aid}})
+		assert.NoError(t, err)
+
+		a := Analysis{Id: aid, AnalysisRequestId: arid, Status: AnalysisError}
+		mockAs.EXPECT().getAllAnalysisById(aid).Return(a, nil)
+		mockAs.EXPECT().getAnalysisRequestById(arid).Return(areq, nil)
+		mockAs.EXPECT().getAllAnalysisByAnalysisRecordId(arid).Return([]Analysis{a}, nil).Once()
+		mockSse.EXPECT().SendEvent(userId, mock.Anything)
+		mockWn.EXPECT().Notify(userId, webhook.Event{
+			Type:              webhook.EventAnalysisError,
+			AnalysisRequestId: arid,
+			Title:             "Alice",
+			Status:            string(AnalysisError),
+			Verdict:           string(VerdictFlagged),
+			Error:             "Error parsing",
+		}).Once()
+		mockWn.EXPECT().Notify(userId, webhook.Event{
+			Type:              webhook.EventAnalysisError,
+			AnalysisRequestId: arid,
+			AnalysisId:        &aid,
+			Title:             "Alice",
+			Status:            string(AnalysisError),
+			Verdict:           string(VerdictFlagged),
+			Error:             "no threshold",
+		}).Once()
+
+		ama.JobsFailed([]jobmanager.DeadLetterJob{
+			{Id: jobId, Type: jobmanager.Parse, AnalysisRequestId: arid, StatusDescription: "Error parsing", Reason: "max retries"},
+			{Id: uuid.New(), Type: jobmanager.Analyze, Data: ajd, StatusDescription: "no threshold", Reason: "max retries"},
+		})
+	})
+}
+
+func TestAnalysisAllocatorErrorResults(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/analysismanangerallocator.yaml")
+
+	mockAs := NewMockanalysisStore(t)
+	mockJu := NewMockjobber(t)
+	mockCs := NewMockcancellations(t)
+	ama := &AnalysisManagerAllocator{as: mockAs, ju: mockJu, cs: mockCs}
+
+	aid := uuid.MustParse("8e1305f1-3fae-44e5-8a4f-9f815321ae8c")
+	jobId := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
+	mockAs.AssertNotCalled(t, "updateAnalysisJobProgress")
+	mockAs.AssertNotCalled(t, "updateReporterScore")
+
+	t.Run("analyzer_error", func(t *testing.T) {
+		dat, err := json.Marshal(analyzercontract.AnalyzerResponse{JobId: jobId, AnalysisId: aid, Results: []string{"no threshold"}, Status: analyzercontract.AnalyzerError})
+		assert.NoError(t, err)
+
+		mockCs.EXPECT().Cancelled(jobId).Return(false).Once()
+		mockJu.EXPECT().IsInprogress(jobId).Return(true, nil).Once()
+		mockJu.EXPECT().UpdateJobStatus(jobId, jobmanager.Error, "no threshold", 0).Return(nil).Once()
+
+		ama.processAnalyzerResult(&nats.Msg{Data: dat})
+	})
+
+	t.Run("reporter_error", func(t *testing.T) {
+		dat, err := json.Marshal(reportercontract.ReporterResponse{JobId: jobId, AnalysisId: aid, Score: 0, Comments: "no contents", Status: reportercontract.ReportError})
+		assert.NoError(t, err)
+
+		mockCs.EXPECT().Cancelled(jobId).Return(false).Once()
+		mockJu.EXPECT().IsInprogress(jobId).Return(true, nil).Once()
+		mockJu.EXPECT().UpdateJobStatus(jobId, jobmanager.Error, "no contents", 0).Return(nil).Once()
+
+		ama.processReporterResult(&nats.Msg{Data: dat})
+	})
+}
+
+func TestAnalysisAllocatorDropsOutdatedResults(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/analysismanangerallocator.yaml")
+
+	mockAs := NewMockanalysisStore(t)
+	mockJu := NewMockjobber(t)
+	mockCs := NewMockcancellations(t)
+	ama := &AnalysisManagerAllocator{as: mockAs, ju: mockJu, cs: mockCs}
+
+	aid := uuid.MustParse("8e1305f1-3fae-44e5-8a4f-9f815321ae8c")
+	jobId := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
+
+	dat, err := json.Marshal(analyzercontract.AnalyzerResponse{JobId: jobId, AnalysisId: aid, Results: []string{"alice"}, Status: analyzercontract.AnalyzerSuccess})
+	assert.NoError(t, err)
+
+	// The result of the first delivery already finished the job
+	mockCs.EXPECT().Cancelled(jobId).Return(false).Once()
+	mockJu.EXPECT().IsInprogress(jobId).Return(false, nil).Once()
+
+	ama.processAnalyzerResult(&nats.Msg{Data: dat})
+
+	mockJu.AssertNotCalled(t, "UpdateJobStatus")
+	mockAs.AssertNotCalled(t, "updateAnalysisJobProgress")
+}
+
 func TestAnalysis(t *testing.T) {
 	// mockAs.EXPECT().updateAnalysisJobProgress(aid, jid, AnalysisFinished, []string{}, 0).Return(nil)
 	// TODO Add processAnalyzerResult
 }
+
+func TestBuildJobsForAnalyzerChunksOnSentences(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/chunking.yaml")
+	mockJu := NewMockjobber(t)
+	ama := &AnalysisManagerAllocator{ju: mockJu}
+
+	a := Analysis{
+		Id:          uuid.MustParse("6a786e6d-e6f9-4ff8-a477-40ba73c6d6d1"),
+		AnalyzerKey: "word_search",
+		Inputs:      Inputs{{Key: "strict_words", Value: "gun"}},
+	}
+
+	mockJu.EXPECT().CreateId().RunAndReturn(uuid.New)
+	var reqs []analyzercontract.AnalyzerRequest
+	mockJu.EXPECT().EnqueueJob(mock.Anything, jobmanager.Analyze, "analyzer.word_search", mock.Anything, mock.Anything).RunAndReturn(func(_ uuid.UUID, _ jobmanager.JobType, _ string, data interface{}, _ jobmanager.JobMeta) error {
+		reqs = append(reqs, data.(jobmanager.AnalyzerJobData).AnalyzerData)
+		return nil
+	})
+
+	jbs, err := ama.buildJobsForAnalyzer(a, "Ünder a gun. It was there. Then gone.", jobmanager.JobMeta{})
+
+	assert.NoError(t, err)
+	assert.Len(t, jbs, 2)
+	assert.Equal(t, "Ünder a gun. It was there. ", reqs[0].Content)
+	assert.Equal(t, 0, reqs[0].Offset)
+	// The second chunk starts with the overlap of the first
+	assert.Equal(t, "It was there. Then gone.", reqs[1].Content)
+	assert.Equal(t, 1, reqs[1].ChunkIndex)
+	assert.Equal(t, 13, reqs[1].Offset)
+	assert.Equal(t, SingleJobProgress{JobId: jbs[1].JobId, Status: AnalysisWaiting, ChunkIndex: 1, Start: 13, End: 37}, jbs[1])
+}
+
+func TestAllocateAnalyzeJobsFailsAnalysisWithoutJobs(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/chunking.yaml")
+	mockAs := NewMockanalysisStore(t)
+	mockJu := NewMockjobber(t)
+	ama := &AnalysisManagerAllocator{as: mockAs, ju: mockJu}
+
+	arid := uuid.MustParse("0d3f4bb8-7a0e-4b43-a0a5-d2b3c0a0e1a1")
+	aid := uuid.MustParse("6a786e6d-e6f9-4ff8-a477-40ba73c6d6d1")
+
+	mockAs.EXPECT().getAnalysisRequestById(arid).Return(AnalysisRequest{Id: arid}, nil)
+	mockAs.EXPECT().getAllAnalysisByAnalysisRecordId(arid).Return([]Analysis{{Id: aid, AnalysisRequestId: arid, AnalyzerKey: "removed"}}, nil)
+	mockAs.EXPECT().failAnalysis(aid).Return(nil)
+	mockAs.AssertNotCalled(t, "updateAnalysisJobs")
+	mockJu.AssertNotCalled(t, "EnqueueJob")
+
+	ama.allocateAnalyzeJobs(arid, "A gun.")
+}
+
+func TestMergeJobResultsOfOverlappingChunks(t *testing.T) {
+	gun := func(sentence string, start int) analyzercontract.Finding {
+		return analyzercontract.Finding{Term: "gun", Sentence: sentence, Start: start, End: start + 3}
+	}
+	con, fs := mergeJobResults(nil, nil,
+		[]string{"A gun.", "A gun."},
+		[]analyzercontract.Finding{gun("A gun.", 2), gun("A gun.", 9)},
+	)
+	// The second chunk overlaps the first one from the second sentence on
+	con, fs = mergeJobResults(con, fs,
+		[]string{"A gun.", "One gun."},
+		[]analyzercontract.Finding{gun("A gun.", 9), gun("One gun.", 18)},
+	)
+
+	assert.Equal(t, Content{"A gun.", "A gun.", "One gun."}, con)
+	assert.Len(t, fs, 3)
+}
+
+func TestFailedWorkOfDeadLetteredJobs(t *testing.T) {
+	arid := uuid.MustParse("0d3f4bb8-7a0e-4b43-a0a5-d2b3c0a0e1a1")
+	aid := uuid.MustParse("6a786e6d-e6f9-4ff8-a477-40ba73c6d6d1")
+	rid := uuid.MustParse("9b0c4f7e-1d8e-4d59-8f53-4f0e6d2a7c11")
+
+	ajd, err := json.Marshal(jobmanager.AnalyzerJobData{AnalyzerData: analyzercontract.AnalyzerRequest{AnalysisId: aid}})
+	assert.NoError(t, err)
+	rjd, err := json.Marshal(jobmanager.ReportJobData{ReporterData: reportercontract.ReporterRequest{AnalysisId: rid}})
+	assert.NoError(t, err)
+
+	jobs := []jobmanager.DeadLetterJob{
+		{Id: uuid.New(), Type: jobmanager.Parse, AnalysisRequestId: arid},
+		{Id: uuid.New(), Type: jobmanager.Analyze, Data: ajd},
+		{Id: uuid.New(), Type: jobmanager.Report, Data: rjd},
+	}
+
+	fw := failedWorkOf(jobs)
+
+	assert.Equal(t, []uuid.UUID{arid}, fw.requestIds)
+	assert.Equal(t, []uuid.UUID{aid, rid}, fw.analysisIds)
+	assert.Equal(t, []uuid.UUID{jobs[0].Id, jobs[1].Id, jobs[2].Id}, fw.jobIds)
+}
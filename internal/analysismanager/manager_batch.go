@@ -0,0 +1,403 @@
+package analysismanager
+
+import (
+	"archive/zip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/analysisrequest"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// batchManifestName is the manifest of a batch archive, it lists the files to
+// analyze.
+const batchManifestName = "manifest.json"
+
+// maxBatchManifestBytes is the largest manifest read from an archive.
+const maxBatchManifestBytes = 1 << 20
+
+var (
+	ErrEmptyBatch      = errors.New("batch has no items")
+	ErrBatchTooLarge   = errors.New("batch has too many items")
+	ErrInvalidManifest = errors.New("batch archive has no valid manifest")
+	ErrBatchOverBudget = errors.New("batch archive takes up too much space uncompressed")
+)
+
+type batchStore interface {
+	createBatch(b *Batch) error
+	getBatchByIdAndUserId(uid, bid uuid.UUID) (Batch, error)
+	getAnalysesByAnalysisRequestIds(arids []uuid.UUID) ([]Analysis, error)
+}
+
+type batchRequester interface {
+	RequestAnalysis(arDto *analysisrequest.AnalysisRequest, ui uuid.UUID, requestOrigin string) (uuid.UUID, error)
+	RequestAnalysisUpload(arDto *analysisrequest.AnalysisRequest, r io.Reader, ui uuid.UUID, requestOrigin string) (uuid.UUID, error)
+}
+
+// AnalysisManagerBatcher requests the analyses of many dataloom items at once.
+// The themes of the user are looked up once and every item is validated
+// before any of them is requested.
+type AnalysisManagerBatcher struct {
+	bs batchStore
+	rq batchRequester
+	ts themeService
+}
+
+func NewAnalysisManagerBatcher(bs batchStore, rq batchRequester, ts themeService) *AnalysisManagerBatcher {
+	return &AnalysisManagerBatcher{
+		bs: bs,
+		rq: rq,
+		ts: ts,
+	}
+}
+
+// batchEntry is an item of a batch, with the reason it is rejected before it
+// is validated, e.g. its file is missing from the archive. The content of
+// archive items is streamed from their file instead of carried along.
+type batchEntry struct {
+	ard    analysisrequest.AnalysisRequestDataloom
+	file   *zip.File
+	reason string
+}
+
+// batchBudget is what the files of a batch archive may still take up once
+// uncompressed.
+type batchBudget struct {
+	left int64
+}
+
+// budgetReader counts what is read against the budget and fails once it is
+// used up, whatever the archive claims the sizes are.
+type budgetReader struct {
+	r  io.Reader
+	bb *batchBudget
+}
+
+func (br budgetReader) Read(p []byte) (int, error) {
+	n, err := br.r.Read(p)
+	br.bb.left -= int64(n)
+	if br.bb.left < 0 {
+		return n, ErrBatchOverBudget
+	}
+	return n, err
+}
+
+func (amb *AnalysisManagerBatcher) SubmitDataloom(uid uuid.UUID, items []analysisrequest.AnalysisRequestDataloom) (analysisrequest.AnalysisBatchResponse, error) {
+	entries := lo.Map(items, func(ard analysisrequest.AnalysisRequestDataloom, _ int) batchEntry {
+		return batchEntry{ard: ard}
+	})
+	return amb.submit(uid, entries)
+}
+
+// SubmitDataloomArchive submits the files of a zip archive, as listed by its
+// manifest.
+func (amb *AnalysisManagerBatcher) SubmitDataloomArchive(uid uuid.UUID, r io.ReaderAt, size int64) (analysisrequest.AnalysisBatchResponse, error) {
+	entries, err := readBatchArchive(r, size)
+	if err != nil {
+		return analysisrequest.AnalysisBatchResponse{}, err
+	}
+	return amb.submit(uid, entries)
+}
+
+func (amb *AnalysisManagerBatcher) submit(uid uuid.UUID, entries []batchEntry) (analysisrequest.AnalysisBatchResponse, error) {
+	if len(entries) == 0 {
+		return analysisrequest.AnalysisBatchResponse{}, ErrEmptyBatch
+	}
+	if len(entries) > config.Get().Data.MaxBatchItems {
+		return analysisrequest.AnalysisBatchResponse{}, ErrBatchTooLarge
+	}
+
+	userThemes, err := amb.ts.GetAllThemesByUserId(uid)
+	if err != nil {
+		return analysisrequest.AnalysisBatchResponse{}, err
+	}
+
+	b := &Batch{
+		UserId: uid,
+		Items:  make([]BatchItem, len(entries)),
+	}
+
+	// Validate everything before the first analysis is requested
+	ars := make([]*analysisrequest.AnalysisRequest, len(entries))
+	for i, e := range entries {
+		b.Items[i] = BatchItem{
+			Position: i,
+			Title:    e.ard.Title,
+			Result:   BatchItemRejected,
+			Reason:   e.reason,
+		}
+		if e.reason != "" {
+			continue
+		}
+
+		ar := &analysisrequest.AnalysisRequest{
+			Title:       e.ard.Title,
+			ContentType: e.ard.ContentType,
+			Category:    e.ard.Category,
+			File:        e.ard.File,
+			Themes:      selectThemes(userThemes, lo.Uniq(e.ard.ThemeIds)),
+		}
+		b.Items[i].Reason = validateBatchItem(ar, len(lo.Uniq(e.ard.ThemeIds)))
+		if b.Items[i].Reason == "" && e.file == nil {
+			b.Items[i].Reason = validateBatchContent(ar)
+		}
+		if b.Items[i].Reason == "" {
+			ars[i] = ar
+		}
+	}
+
+	bb := &batchBudget{left: config.Get().Data.MaxBatchBytes}
+	for i, ar := range ars {
+		if ar == nil {
+			continue
+		}
+
+		var arid uuid.UUID
+		if f := entries[i].file; f != nil {
+			arid, err = amb.requestArchiveFile(ar, f, uid, bb)
+		} else {
+			arid, err = amb.rq.RequestAnalysis(ar, uid, string(RequestOriginDataloom))
+		}
+		switch {
+		case err == nil:
+			b.Items[i].Result = BatchItemCreated
+			b.Items[i].AnalysisRequestId = &arid
+		case errors.Is(err, ErrHashAlreadyExist):
+			b.Items[i].Result = BatchItemDuplicate
+			b.Items[i].AnalysisRequestId = &arid
+		case bb.left < 0:
+			b.Items[i].Reason = "batch archive is too large uncompressed"
+		default:
+			zap.S().Errorw("Could not request analysis of batch item", "position", i, "error", err)
+			b.Items[i].Reason = "analysis request could not be created"
+		}
+	}
+
+	if err := amb.bs.createBatch(b); err != nil {
+		return analysisrequest.AnalysisBatchResponse{}, err
+	}
+	zap.S().Infow("Batch submitted", "batch_id", b.Id, "items", len(b.Items))
+
+	return analysisrequest.AnalysisBatchResponse{
+		Id: b.Id,
+		Items: lo.Map(b.Items, func(bi BatchItem, _ int) analysisrequest.AnalysisBatchItem {
+			return mapToBatchItem(bi)
+		}),
+	}, nil
+}
+
+// requestArchiveFile streams the file of the archive into the analysis request.
+func (amb *AnalysisManagerBatcher) requestArchiveFile(ar *analysisrequest.AnalysisRequest, f *zip.File, uid uuid.UUID, bb *batchBudget) (uuid.UUID, error) {
+	if bb.left < 0 {
+		return uuid.Nil, ErrBatchOverBudget
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer rc.Close()
+
+	// The size in the header is not to be trusted
+	r := budgetReader{r: io.LimitReader(rc, int64(f.UncompressedSize64)), bb: bb}
+	return amb.rq.RequestAnalysisUpload(ar, r, uid, string(RequestOriginDataloom))
+}
+
+// validateBatchItem returns why the item is rejected, or an empty string when
+// it is valid.
+func validateBatchItem(ar *analysisrequest.AnalysisRequest, themeCount int) string {
+	if ar.Title == "" {
+		return "title is missing"
+	}
+	if _, ok := config.Get().GetParser(ar.File.Mimetype); !ok {
+		return fmt.Sprintf("no parser for mimetype %q", ar.File.Mimetype)
+	}
+	if len(ar.Themes) != themeCount {
+		return "unknown theme selected"
+	}
+	if !hasValidAnalyzers(ar) {
+		return "invalid analyzer or missing analyzer input"
+	}
+	return ""
+}
+
+// validateBatchContent returns why the content the item carries is rejected.
+func validateBatchContent(ar *analysisrequest.AnalysisRequest) string {
+	if ar.File.Content == "" {
+		return "content is empty"
+	}
+	if _, err := base64.StdEncoding.DecodeString(ar.File.Content); err != nil {
+		return "content is not valid base64"
+	}
+	return ""
+}
+
+// GetBatch returns the result of every item of the batch and the progress of
+// the analysis requests it refers to.
+func (amb *AnalysisManagerBatcher) GetBatch(uid, bid uuid.UUID) (analysisrequest.AnalysisBatchProgress, error) {
+	b, err := amb.bs.getBatchByIdAndUserId(uid, bid)
+	if err != nil {
+		return analysisrequest.AnalysisBatchProgress{}, err
+	}
+
+	arids := lo.FilterMap(b.Items, func(bi BatchItem, _ int) (uuid.UUID, bool) {
+		if bi.AnalysisRequestId == nil {
+			return uuid.Nil, false
+		}
+		return *bi.AnalysisRequestId, true
+	})
+	as, err := amb.bs.getAnalysesByAnalysisRequestIds(lo.Uniq(arids))
+	if err != nil {
+		return analysisrequest.AnalysisBatchProgress{}, err
+	}
+	asByRequest := lo.GroupBy(as, func(a Analysis) uuid.UUID { return a.AnalysisRequestId })
+
+	bp := analysisrequest.AnalysisBatchProgress{
+		Id:        b.Id,
+		CreatedAt: b.CreatedAt,
+		Total:     len(b.Items),
+		Items:     make([]analysisrequest.AnalysisBatchItem, 0, len(b.Items)),
+	}
+	for _, bi := range b.Items {
+		item := mapToBatchItem(bi)
+
+		switch bi.Result {
+		case BatchItemCreated:
+			bp.Created++
+		case BatchItemDuplicate:
+			bp.Duplicate++
+		case BatchItemRejected:
+			bp.Rejected++
+		}
+
+		if bi.AnalysisRequestId != nil {
+			st := requestStatus(asByRequest[*bi.AnalysisRequestId])
+			item.Status = string(st)
+			switch st {
+			case AnalysisWaiting:
+				bp.Waiting++
+			case AnalysisInprogress:
+				bp.Inprogress++
+			case AnalysisFinished:
+				bp.Finished++
+			case AnalysisError:
+				bp.Error++
+			}
+		}
+
+		bp.Items = append(bp.Items, item)
+	}
+	bp.Done = bp.Waiting == 0 && bp.Inprogress == 0
+
+	return bp, nil
+}
+
+// requestStatus sums up the analyses of an analysis request. It is finished
+// once every analysis is, and failed once any of them failed.
+func requestStatus(as []Analysis) AnalysisStatus {
+	if len(as) == 0 {
+		return AnalysisWaiting
+	}
+	if lo.ContainsBy(as, func(a Analysis) bool { return a.Status == AnalysisError }) {
+		return AnalysisError
+	}
+	if lo.EveryBy(as, func(a Analysis) bool { return a.Status == AnalysisFinished }) {
+		return AnalysisFinished
+	}
+	if lo.EveryBy(as, func(a Analysis) bool { return a.Status == AnalysisWaiting && len(a.Jobs) == 0 }) {
+		return AnalysisWaiting
+	}
+	return AnalysisInprogress
+}
+
+func mapToBatchItem(bi BatchItem) analysisrequest.AnalysisBatchItem {
+	return analysisrequest.AnalysisBatchItem{
+		Index:  bi.Position,
+		Title:  bi.Title,
+		Id:     bi.AnalysisRequestId,
+		Result: string(bi.Result),
+		Reason: bi.Reason,
+	}
+}
+
+// readBatchArchive reads the items of a batch archive from its manifest. Items
+// whose file is missing are rejected, not the whole archive.
+func readBatchArchive(r io.ReaderAt, size int64) ([]batchEntry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		zap.S().Errorw("Could not read batch archive", "error", err)
+		return nil, ErrInvalidManifest
+	}
+
+	files := lo.SliceToMap(zr.File, func(f *zip.File) (string, *zip.File) {
+		return f.Name, f
+	})
+
+	mf, ok := files[batchManifestName]
+	if !ok || mf.UncompressedSize64 > maxBatchManifestBytes {
+		return nil, ErrInvalidManifest
+	}
+	var m analysisrequest.BatchManifest
+	if err := readZipJson(mf, &m); err != nil {
+		zap.S().Errorw("Could not decode batch manifest", "error", err)
+		return nil, ErrInvalidManifest
+	}
+
+	// What the files claim to take up, read files are counted again
+	var claimed uint64
+	entries := make([]batchEntry, 0, len(m.Items))
+	for _, mi := range m.Items {
+		e := batchEntry{
+			ard: analysisrequest.AnalysisRequestDataloom{
+				Title:       mi.Title,
+				ContentType: mi.ContentType,
+				Category:    mi.Category,
+				File: analysisrequest.File{
+					Mimetype: mi.Mimetype,
+				},
+				ThemeIds: mi.ThemeIds,
+			},
+		}
+
+		f, ok := files[mi.File]
+		if !ok || mi.File == batchManifestName {
+			e.reason = fmt.Sprintf("file %q is not in the archive", mi.File)
+			entries = append(entries, e)
+			continue
+		}
+
+		switch {
+		case f.UncompressedSize64 == 0:
+			e.reason = fmt.Sprintf("file %q is empty", mi.File)
+		case f.UncompressedSize64 > uint64(config.Get().Data.MaxUploadBytes):
+			e.reason = fmt.Sprintf("file %q is too large", mi.File)
+		case claimed+f.UncompressedSize64 > uint64(config.Get().Data.MaxBatchBytes):
+			e.reason = fmt.Sprintf("file %q does not fit in the batch", mi.File)
+		default:
+			claimed += f.UncompressedSize64
+			e.file = f
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func readZipJson(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// The size in the header is not to be trusted
+	b, err := io.ReadAll(io.LimitReader(rc, int64(f.UncompressedSize64)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
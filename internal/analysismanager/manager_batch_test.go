@@ -0,0 +1,232 @@
+package analysismanager
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/analysisrequest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	batchId   = uuid.MustParse("5b0e2b8e-4f0c-4a67-9f6a-0c3b7d0e1f21")
+	batchArid = uuid.MustParse("6a3d6f3c-2b7a-4d8e-8e7f-1c2d3e4f5a6b")
+)
+
+func batchItem(title, mimetype, content string, themeIds ...uuid.UUID) analysisrequest.AnalysisRequestDataloom {
+	return analysisrequest.AnalysisRequestDataloom{
+		Title:       title,
+		ContentType: analysisrequest.BOOK,
+		Category:    "fiction",
+		File: analysisrequest.File{
+			Content:  base64.StdEncoding.EncodeToString([]byte(content)),
+			Mimetype: mimetype,
+		},
+		ThemeIds: themeIds,
+	}
+}
+
+func TestSubmitBatchValidatesEveryItem(t *testing.T) {
+	mockBs := NewMockbatchStore(t)
+	mockRq := NewMockbatchRequester(t)
+	mockTs := NewMockthemeService(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amb := NewAnalysisManagerBatcher(mockBs, mockRq, mockTs)
+
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil).Once()
+	mockRq.EXPECT().RequestAnalysis(mock.MatchedBy(func(ar *analysisrequest.AnalysisRequest) bool {
+		return ar.Title == "Book one" && len(ar.Themes) == 1
+	}), rerunUserId, string(RequestOriginDataloom)).Return(batchArid, nil)
+	mockRq.EXPECT().RequestAnalysis(mock.MatchedBy(func(ar *analysisrequest.AnalysisRequest) bool {
+		return ar.Title == "Book two"
+	}), rerunUserId, string(RequestOriginDataloom)).Return(rerunArid, ErrHashAlreadyExist)
+	mockBs.EXPECT().createBatch(mock.Anything).RunAndReturn(func(b *Batch) error {
+		assert.Equal(t, rerunUserId, b.UserId)
+		b.Id = batchId
+		return nil
+	})
+
+	br, err := amb.SubmitDataloom(rerunUserId, []analysisrequest.AnalysisRequestDataloom{
+		batchItem("Book one", "freetext", "A gun", rerunThemeId),
+		batchItem("Book two", "freetext", "A knife", rerunThemeId),
+		batchItem("Book three", "epub", "A gun", rerunThemeId),
+		batchItem("Book four", "freetext", "A gun", otherThemeId),
+		batchItem("", "freetext", "A gun", rerunThemeId),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, batchId, br.Id)
+	assert.Len(t, br.Items, 5)
+	assert.Equal(t, string(BatchItemCreated), br.Items[0].Result)
+	assert.Equal(t, batchArid, *br.Items[0].Id)
+	assert.Equal(t, string(BatchItemDuplicate), br.Items[1].Result)
+	assert.Equal(t, rerunArid, *br.Items[1].Id)
+	assert.Equal(t, string(BatchItemRejected), br.Items[2].Result)
+	assert.Equal(t, `no parser for mimetype "epub"`, br.Items[2].Reason)
+	assert.Nil(t, br.Items[2].Id)
+	assert.Equal(t, "unknown theme selected", br.Items[3].Reason)
+	assert.Equal(t, "title is missing", br.Items[4].Reason)
+	assert.Equal(t, 4, br.Items[4].Index)
+}
+
+func TestSubmitBatchRefusesEmptyBatch(t *testing.T) {
+	mockBs := NewMockbatchStore(t)
+	mockRq := NewMockbatchRequester(t)
+	mockTs := NewMockthemeService(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amb := NewAnalysisManagerBatcher(mockBs, mockRq, mockTs)
+
+	_, err := amb.SubmitDataloom(rerunUserId, nil)
+	assert.ErrorIs(t, err, ErrEmptyBatch)
+}
+
+func TestReadBatchArchive(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	mf, err := zw.Create(batchManifestName)
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewEncoder(mf).Encode(analysisrequest.BatchManifest{
+		Items: []analysisrequest.BatchManifestItem{
+			{File: "books/one.txt", Title: "Book one", Mimetype: "freetext", ThemeIds: []uuid.UUID{rerunThemeId}},
+			{File: "books/missing.txt", Title: "Book two", Mimetype: "freetext"},
+		},
+	}))
+	f, err := zw.Create("books/one.txt")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("A gun"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	entries, err := readBatchArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "Book one", entries[0].ard.Title)
+	assert.Equal(t, "books/one.txt", entries[0].file.Name)
+	assert.Empty(t, entries[0].ard.File.Content)
+	assert.Equal(t, []uuid.UUID{rerunThemeId}, entries[0].ard.ThemeIds)
+	assert.Empty(t, entries[0].reason)
+	assert.Equal(t, `file "books/missing.txt" is not in the archive`, entries[1].reason)
+
+	_, err = readBatchArchive(bytes.NewReader([]byte("not a zip")), 9)
+	assert.ErrorIs(t, err, ErrInvalidManifest)
+}
+
+func batchArchive(t *testing.T, files map[string]string, items ...analysisrequest.BatchManifestItem) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	mf, err := zw.Create(batchManifestName)
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewEncoder(mf).Encode(analysisrequest.BatchManifest{Items: items}))
+	for name, content := range files {
+		f, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestSubmitBatchArchiveStreamsFiles(t *testing.T) {
+	mockBs := NewMockbatchStore(t)
+	mockRq := NewMockbatchRequester(t)
+	mockTs := NewMockthemeService(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amb := NewAnalysisManagerBatcher(mockBs, mockRq, mockTs)
+
+	archive := batchArchive(t, map[string]string{"one.txt": "A gun"},
+		analysisrequest.BatchManifestItem{File: "one.txt", Title: "Book one", Mimetype: "freetext", ThemeIds: []uuid.UUID{rerunThemeId}},
+	)
+
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	mockRq.EXPECT().RequestAnalysisUpload(mock.Anything, mock.Anything, rerunUserId, string(RequestOriginDataloom)).
+		RunAndReturn(func(ar *analysisrequest.AnalysisRequest, r io.Reader, _ uuid.UUID, _ string) (uuid.UUID, error) {
+			b, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, "A gun", string(b))
+			assert.Equal(t, "Book one", ar.Title)
+			return batchArid, nil
+		})
+	mockRq.AssertNotCalled(t, "RequestAnalysis")
+	mockBs.EXPECT().createBatch(mock.Anything).Return(nil)
+
+	br, err := amb.SubmitDataloomArchive(rerunUserId, bytes.NewReader(archive), int64(len(archive)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, string(BatchItemCreated), br.Items[0].Result)
+}
+
+func TestReadBatchArchiveKeepsToBudget(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/batchbudget.yaml")
+
+	archive := batchArchive(t, map[string]string{"one.txt": "A gun", "two.txt": "A knife", "empty.txt": ""},
+		analysisrequest.BatchManifestItem{File: "one.txt", Title: "Book one", Mimetype: "freetext"},
+		analysisrequest.BatchManifestItem{File: "two.txt", Title: "Book two", Mimetype: "freetext"},
+		analysisrequest.BatchManifestItem{File: "empty.txt", Title: "Book three", Mimetype: "freetext"},
+	)
+
+	entries, err := readBatchArchive(bytes.NewReader(archive), int64(len(archive)))
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries[0].reason)
+	assert.Equal(t, `file "two.txt" does not fit in the batch`, entries[1].reason)
+	assert.Equal(t, `file "empty.txt" is empty`, entries[2].reason)
+}
+
+func TestBudgetReaderFailsOnceUsedUp(t *testing.T) {
+	bb := &batchBudget{left: 4}
+
+	_, err := io.ReadAll(budgetReader{r: bytes.NewReader([]byte("A knife")), bb: bb})
+
+	assert.ErrorIs(t, err, ErrBatchOverBudget)
+}
+
+func TestGetBatchProgress(t *testing.T) {
+	mockBs := NewMockbatchStore(t)
+	mockRq := NewMockbatchRequester(t)
+	mockTs := NewMockthemeService(t)
+
+	amb := NewAnalysisManagerBatcher(mockBs, mockRq, mockTs)
+
+	mockBs.EXPECT().getBatchByIdAndUserId(rerunUserId, batchId).Return(Batch{
+		Id:     batchId,
+		UserId: rerunUserId,
+		Items: []BatchItem{
+			{Position: 0, Title: "Book one", AnalysisRequestId: &batchArid, Result: BatchItemCreated},
+			{Position: 1, Title: "Book two", AnalysisRequestId: &rerunArid, Result: BatchItemDuplicate},
+			{Position: 2, Title: "Book three", Result: BatchItemRejected, Reason: "title is missing"},
+		},
+	}, nil)
+	mockBs.EXPECT().getAnalysesByAnalysisRequestIds([]uuid.UUID{batchArid, rerunArid}).Return([]Analysis{
+		{AnalysisRequestId: batchArid, Status: AnalysisFinished},
+		{AnalysisRequestId: batchArid, Status: AnalysisWaiting, Jobs: JobsProgress{{Status: AnalysisWaiting}}},
+		{AnalysisRequestId: rerunArid, Status: AnalysisFinished},
+	}, nil)
+
+	bp, err := amb.GetBatch(rerunUserId, batchId)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, bp.Total)
+	assert.Equal(t, 1, bp.Created)
+	assert.Equal(t, 1, bp.Duplicate)
+	assert.Equal(t, 1, bp.Rejected)
+	assert.Equal(t, 1, bp.Inprogress)
+	assert.Equal(t, 1, bp.Finished)
+	assert.False(t, bp.Done)
+	assert.Equal(t, string(AnalysisInprogress), bp.Items[0].Status)
+	assert.Equal(t, string(AnalysisFinished), bp.Items[1].Status)
+	assert.Empty(t, bp.Items[2].Status)
+}
@@ -1,17 +1,22 @@
 package analysismanager
 
 import (
-	"crypto/md5"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/ssemanager"
 	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analysisrequest"
 	"github.com/guardlight/server/pkg/parsercontract"
 	"github.com/samber/lo"
@@ -23,11 +28,13 @@ var (
 	ErrInvalidAnalyzer  = errors.New("invalid analyzer selected")
 	ErrParserMarshal    = errors.New("error marshaling parser data")
 	ErrHashAlreadyExist = errors.New("hash already exist")
+	ErrFileTooLarge     = errors.New("file too large")
 )
 
 type analysisRequestStore interface {
 	createAnalysisRequest(analysisRequest *AnalysisRequest) error
-	getAnalysisRequestIdByHash(hash string) (uuid.UUID, error)
+	getAnalysisRequestIdByHash(uid uuid.UUID, hash string) (uuid.UUID, error)
+	getSharedAnalysisRequestByHash(uid uuid.UUID, hash string) (AnalysisRequest, bool, error)
 }
 
 type jobManagerRequester interface {
@@ -35,19 +42,30 @@ type jobManagerRequester interface {
 	jobmanager.IdCreater
 }
 
+type contentStore interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Delete(ctx context.Context, name string) error
+}
+
 type AnalysisManagerRequester struct {
 	jobMananger jobManagerRequester
 	ars         analysisRequestStore
 	ts          themeService
 	sse         sseEventSender
+	aa          analyzeAllocator
+	cs          contentStore
+	wn          webhookNotifier
 }
 
-func NewAnalysisManangerRequester(jobMananger jobManagerRequester, ars analysisRequestStore, sse sseEventSender, ts themeService) *AnalysisManagerRequester {
+func NewAnalysisManangerRequester(jobMananger jobManagerRequester, ars analysisRequestStore, sse sseEventSender, ts themeService, aa analyzeAllocator, cs contentStore, wn webhookNotifier) *AnalysisManagerRequester {
 	return &AnalysisManagerRequester{
 		jobMananger: jobMananger,
 		ars:         ars,
 		sse:         sse,
 		ts:          ts,
+		aa:          aa,
+		cs:          cs,
+		wn:          wn,
 	}
 }
 
@@ -65,32 +83,7 @@ func (am *AnalysisManagerRequester) RequestAnalysisDataloom(ardDto *analysisrequ
 		return uuid.Nil, err
 	}
 
-	ard.Themes = lo.FilterMap(userThemes, func(ut theme.ThemeDto, _ int) (analysisrequest.Theme, bool) {
-		if lo.ContainsBy(ardDto.ThemeIds, func(ti uuid.UUID) bool { return ti == ut.Id }) {
-			return analysisrequest.Theme{
-				Id:    ut.Id,
-				Title: ut.Title,
-				Analyzers: lo.FilterMap(ut.Analyzers, func(ta theme.AnalyzerDto, _ int) (analysisrequest.Analyzer, bool) {
-					if ta.ChangeStatus == theme.Same || ta.ChangeStatus == theme.Changed {
-						return analysisrequest.Analyzer{
-							Key: ta.Key,
-							Inputs: lo.FilterMap(ta.Inputs, func(tai theme.AnalyzerInputDto, _ int) (analysisrequest.AnalyzerInput, bool) {
-								if tai.ChangeStatus == theme.Same || tai.ChangeStatus == theme.Changed {
-									return analysisrequest.AnalyzerInput{
-										Key:   tai.Key,
-										Value: tai.Value,
-									}, true
-								}
-								return analysisrequest.AnalyzerInput{}, false
-							}),
-						}, true
-					}
-					return analysisrequest.Analyzer{}, false
-				}),
-			}, true
-		}
-		return analysisrequest.Theme{}, false
-	})
+	ard.Themes = selectThemes(userThemes, ardDto.ThemeIds)
 
 	return am.RequestAnalysis(ard, ui, string(RequestOriginDataloom))
 }
@@ -113,7 +106,56 @@ func (am *AnalysisManagerRequester) RequestAnalysis(arDto *analysisrequest.Analy
 	}
 	rawData := createRawData(arDto, bContent)
 
-	arid, err := am.ars.getAnalysisRequestIdByHash(rawData.Hash)
+	return am.createRequest(arDto, rawData, p, ui, requestOrigin)
+}
+
+// RequestAnalysisUpload requests an analysis of content that is streamed in.
+// The content goes to the content store while it is hashed, the parser job
+// refers to it instead of carrying it.
+func (am *AnalysisManagerRequester) RequestAnalysisUpload(arDto *analysisrequest.AnalysisRequest, r io.Reader, ui uuid.UUID, requestOrigin string) (uuid.UUID, error) {
+	p, ok := config.Get().GetParser(arDto.File.Mimetype)
+	if !ok {
+		zap.S().Errorw("Invalid parser specified", "parser_type", arDto.File.Mimetype)
+		return uuid.Nil, ErrInvalidParser
+	}
+
+	if !hasValidAnalyzers(arDto) {
+		return uuid.Nil, ErrInvalidAnalyzer
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	ref := uuid.New().String()
+	h := sha256.New()
+	lr := &io.LimitedReader{R: r, N: config.Get().Data.MaxUploadBytes + 1}
+	if err := am.cs.Put(ctx, ref, io.TeeReader(lr, h)); err != nil {
+		zap.S().Errorw("Could not store upload", "error", err)
+		deleteStoredContent(am.cs, ref)
+		return uuid.Nil, err
+	}
+	if lr.N == 0 {
+		deleteStoredContent(am.cs, ref)
+		return uuid.Nil, ErrFileTooLarge
+	}
+
+	rawData := RawData{
+		Id:                uuid.Nil,
+		AnalysisRequestId: uuid.Nil,
+		FileType:          arDto.File.Mimetype,
+		Hash:              hex.EncodeToString(h.Sum(nil)),
+		ContentRef:        ref,
+	}
+
+	arid, err := am.createRequest(arDto, rawData, p, ui, requestOrigin)
+	if err != nil {
+		deleteStoredContent(am.cs, ref)
+	}
+	return arid, err
+}
+
+func (am *AnalysisManagerRequester) createRequest(arDto *analysisrequest.AnalysisRequest, rawData RawData, p config.Parser, ui uuid.UUID, requestOrigin string) (uuid.UUID, error) {
+	arid, err := am.ars.getAnalysisRequestIdByHash(ui, rawData.Hash)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -134,6 +176,17 @@ func (am *AnalysisManagerRequester) RequestAnalysis(arDto *analysisrequest.Analy
 		RawData:       rawData,
 		Analysis:      analysisParts,
 	}
+
+	if config.Get().Data.ShareResults {
+		sar, ok, err := am.ars.getSharedAnalysisRequestByHash(ui, rawData.Hash)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if ok {
+			return am.requestSharedAnalysis(ar, sar)
+		}
+	}
+
 	err = am.ars.createAnalysisRequest(ar)
 	if err != nil {
 		return uuid.Nil, err
@@ -149,10 +202,11 @@ func (am *AnalysisManagerRequester) RequestAnalysis(arDto *analysisrequest.Analy
 			JobId:      jobId,
 			AnalysisId: ar.Id,
 			Content:    arDto.File.Content,
+			ContentRef: rawData.ContentRef,
 		},
 	}
 	gk := fmt.Sprintf("parser.%s", p.Type)
-	err = am.jobMananger.EnqueueJob(jobId, jobmanager.Parse, gk, jd)
+	err = am.jobMananger.EnqueueJob(jobId, jobmanager.Parse, gk, jd, ar.jobMeta())
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -162,10 +216,84 @@ func (am *AnalysisManagerRequester) RequestAnalysis(arDto *analysisrequest.Analy
 		Action: ssemanager.ActionAnalysisRequested,
 		Data:   ar.Id.String(),
 	})
+	am.wn.Notify(ui, requestEvent(webhook.EventAnalysisRequested, *ar))
 
 	return ar.Id, nil
 }
 
+// requestSharedAnalysis creates the analysis request from the request of
+// another user with the same content. The processed text is reused, as are the
+// finished analyses with the same analyzer and inputs. Only the remaining
+// analyses are analyzed. Nothing of the other request, including its id, ends
+// up with the user.
+func (am *AnalysisManagerRequester) requestSharedAnalysis(ar *AnalysisRequest, sar AnalysisRequest) (uuid.UUID, error) {
+	ar.RawData.ProcessedText = sar.RawData.ProcessedText
+	for i, a := range ar.Analysis {
+		sa, ok := lo.Find(sar.Analysis, func(sa Analysis) bool {
+			return sa.Status == AnalysisFinished && sa.AnalyzerKey == a.AnalyzerKey && slices.Equal(sa.Inputs, a.Inputs)
+		})
+		if !ok {
+			continue
+		}
+		ar.Analysis[i].Status = sa.Status
+		// Overrides are the other user's, only the reporter's score is shared
+		ar.Analysis[i].Score = sa.ReporterScore
+		ar.Analysis[i].ReporterScore = sa.ReporterScore
+		ar.Analysis[i].Content = sa.Content
+		ar.Analysis[i].Findings = sa.Findings
+	}
+
+	err := am.ars.createAnalysisRequest(ar)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	zap.S().Infow("Analysis request created from shared results", "analysis_request_id", ar.Id)
+
+	if lo.ContainsBy(ar.Analysis, func(a Analysis) bool { return len(a.Jobs) == 0 }) {
+		am.aa.allocateAnalyzeJobs(ar.Id, ar.RawData.ProcessedText)
+	}
+
+	am.sse.SendEvent(ar.UserId, ssemanager.SseEvent{
+		Type:   ssemanager.TypeUpdate,
+		Action: ssemanager.ActionAnalysisRequested,
+		Data:   ar.Id.String(),
+	})
+	am.wn.Notify(ar.UserId, requestEvent(webhook.EventAnalysisRequested, *ar))
+
+	return ar.Id, nil
+}
+
+// selectThemes maps the themes of the user with one of the ids to the themes of
+// an analysis request, with their current analyzers and inputs.
+func selectThemes(userThemes []theme.ThemeDto, themeIds []uuid.UUID) []analysisrequest.Theme {
+	return lo.FilterMap(userThemes, func(ut theme.ThemeDto, _ int) (analysisrequest.Theme, bool) {
+		if lo.Contains(themeIds, ut.Id) {
+			return analysisrequest.Theme{
+				Id:    ut.Id,
+				Title: ut.Title,
+				Analyzers: lo.FilterMap(ut.Analyzers, func(ta theme.AnalyzerDto, _ int) (analysisrequest.Analyzer, bool) {
+					if ta.ChangeStatus == theme.Same || ta.ChangeStatus == theme.Changed {
+						return analysisrequest.Analyzer{
+							Key: ta.Key,
+							Inputs: lo.FilterMap(ta.Inputs, func(tai theme.AnalyzerInputDto, _ int) (analysisrequest.AnalyzerInput, bool) {
+								if tai.ChangeStatus == theme.Same || tai.ChangeStatus == theme.Changed {
+									return analysisrequest.AnalyzerInput{
+										Key:   tai.Key,
+										Value: tai.Value,
+									}, true
+								}
+								return analysisrequest.AnalyzerInput{}, false
+							}),
+						}, true
+					}
+					return analysisrequest.Analyzer{}, false
+				}),
+			}, true
+		}
+		return analysisrequest.Theme{}, false
+	})
+}
+
 func createAnalysis(arDto *analysisrequest.AnalysisRequest) []Analysis {
 	as := make([]Analysis, 0)
 
@@ -195,7 +323,7 @@ func createAnalysis(arDto *analysisrequest.AnalysisRequest) []Analysis {
 }
 
 func createRawData(arDto *analysisrequest.AnalysisRequest, bdata []byte) RawData {
-	hash := md5.Sum(bdata)
+	hash := sha256.Sum256(bdata)
 
 	return RawData{
 		Id:                uuid.Nil,
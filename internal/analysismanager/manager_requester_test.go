@@ -1,15 +1,20 @@
 package analysismanager
 
 import (
+	"context"
 	"encoding/base64"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analysisrequest"
 	"github.com/guardlight/server/pkg/parsercontract"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAnalysisRequestParsersAndAnalyzersSuccess(t *testing.T) {
@@ -17,11 +22,14 @@ func TestAnalysisRequestParsersAndAnalyzersSuccess(t *testing.T) {
 	mockJobManager := NewMockjobManagerRequester(t)
 	mockSsem := NewMocksseEventSender(t)
 	mockTs := NewMockthemeService(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockCs := NewMockcontentStore(t)
+	mockWn := NewMockwebhookNotifier(t)
 	config.SetupConfig("../../testdata/envs/analysismanangerequester.yaml")
 
 	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
 
-	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs)
+	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs, mockAa, mockCs, mockWn)
 
 	t.Run("parserFailed", func(t *testing.T) {
 		ar := &analysisrequest.AnalysisRequest{
@@ -103,11 +111,14 @@ func TestAnalysisRequestSuccess(t *testing.T) {
 	mockJobManager := NewMockjobManagerRequester(t)
 	mockSsem := NewMocksseEventSender(t)
 	mockTs := NewMockthemeService(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockCs := NewMockcontentStore(t)
+	mockWn := NewMockwebhookNotifier(t)
 	config.SetupConfig("../../testdata/envs/analysismanangerequester.yaml")
 
 	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
 
-	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs)
+	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs, mockAa, mockCs, mockWn)
 
 	jobId := uuid.MustParse("0e4240a2-a099-4501-b373-7d982b5d5d5d")
 	mockJobManager.EXPECT().CreateId().Return(jobId)
@@ -155,7 +166,7 @@ func TestAnalysisRequestSuccess(t *testing.T) {
 
 	rawData := RawData{
 		Content:  []byte("Running and walking"),
-		Hash:     "8640144a9e60ba45c3cea6f444987b41",
+		Hash:     "d93e8952af1d7c40e181d12b914deefbeb747c41527c676d0bda75852aeef283",
 		FileType: "freetext",
 	}
 
@@ -218,10 +229,201 @@ func TestAnalysisRequestSuccess(t *testing.T) {
 		},
 	}
 
-	mockJobManager.EXPECT().EnqueueJob(jobId, jobmanager.Parse, "parser.freetext", pData).Return(nil)
+	mockJobManager.EXPECT().EnqueueJob(jobId, jobmanager.Parse, "parser.freetext", pData, jobmanager.JobMeta{UserId: userId, AnalysisRequestId: analysisId, Priority: jobmanager.PriorityInteractive}).Return(nil)
 
 	aid, err := analyzerRequester.RequestAnalysis(ar, userId, string(RequestOriginUser))
 
 	assert.NoError(t, err)
 	assert.NotNil(t, aid)
 }
+
+func sharedAnalysisRequest() *analysisrequest.AnalysisRequest {
+	return &analysisrequest.AnalysisRequest{
+		Title:       "test analysis",
+		ContentType: analysisrequest.BOOK,
+		File: analysisrequest.File{
+			Content:  base64.StdEncoding.EncodeToString([]byte("Running and walking")),
+			Mimetype: "freetext",
+		},
+		Themes: []analysisrequest.Theme{
+			{
+				Title: "Test Theme",
+				Id:    uuid.MustParse("2864d1b0-411a-4c6c-932a-61acddd67019"),
+				Analyzers: []analysisrequest.Analyzer{
+					{
+						Key: "word_search",
+						Inputs: []analysisrequest.AnalyzerInput{
+							{Key: "strict_words", Value: "Running, Walking"},
+							{Key: "threshold", Value: "1"},
+						},
+					},
+				},
+			},
+			{
+				Title: "Test Swim Theme",
+				Id:    uuid.MustParse("3ab4a569-4de4-4206-a4fe-b4d2ddac3f6c"),
+				Analyzers: []analysisrequest.Analyzer{
+					{
+						Key: "word_search",
+						Inputs: []analysisrequest.AnalyzerInput{
+							{Key: "strict_words", Value: "Swimming, Drowning"},
+							{Key: "threshold", Value: "1"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAnalysisRequestDuplicateOfSameUser(t *testing.T) {
+	mockAnalysisRecordSaver := NewMockanalysisRequestStore(t)
+	mockJobManager := NewMockjobManagerRequester(t)
+	mockSsem := NewMocksseEventSender(t)
+	mockTs := NewMockthemeService(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockCs := NewMockcontentStore(t)
+	mockWn := NewMockwebhookNotifier(t)
+	config.SetupConfig("../../testdata/envs/sharedresults.yaml")
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	existingId := uuid.MustParse("75d25964-6d59-4f88-97f8-dfd3afe96c62")
+
+	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs, mockAa, mockCs, mockWn)
+
+	mockAnalysisRecordSaver.EXPECT().getAnalysisRequestIdByHash(userId, "d93e8952af1d7c40e181d12b914deefbeb747c41527c676d0bda75852aeef283").Return(existingId, nil)
+	mockAnalysisRecordSaver.AssertNotCalled(t, "getSharedAnalysisRequestByHash")
+	mockAnalysisRecordSaver.AssertNotCalled(t, "createAnalysisRequest")
+
+	aid, err := analyzerRequester.RequestAnalysis(sharedAnalysisRequest(), userId, string(RequestOriginUser))
+
+	assert.ErrorIs(t, err, ErrHashAlreadyExist)
+	assert.Equal(t, existingId, aid)
+}
+
+func TestAnalysisRequestSharesResultsOfOtherUser(t *testing.T) {
+	mockAnalysisRecordSaver := NewMockanalysisRequestStore(t)
+	mockJobManager := NewMockjobManagerRequester(t)
+	mockSsem := NewMocksseEventSender(t)
+	mockTs := NewMockthemeService(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockCs := NewMockcontentStore(t)
+	mockWn := NewMockwebhookNotifier(t)
+	config.SetupConfig("../../testdata/envs/sharedresults.yaml")
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	otherArid := uuid.MustParse("0d1c6f3e-6a5b-4c7e-9f2a-3b8e1d4c5a60")
+	newArid := uuid.MustParse("75d25964-6d59-4f88-97f8-dfd3afe96c62")
+	hash := "d93e8952af1d7c40e181d12b914deefbeb747c41527c676d0bda75852aeef283"
+
+	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs, mockAa, mockCs, mockWn)
+
+	mockAnalysisRecordSaver.EXPECT().getAnalysisRequestIdByHash(userId, hash).Return(uuid.Nil, nil)
+	mockAnalysisRecordSaver.EXPECT().getSharedAnalysisRequestByHash(userId, hash).Return(AnalysisRequest{
+		Id:      otherArid,
+		UserId:  uuid.MustParse("fc28fb4c-2280-49f5-a3ba-f99ed8f8843c"),
+		RawData: RawData{ProcessedText: "Running and walking"},
+		Analysis: []Analysis{
+			{
+				AnalysisRequestId: otherArid,
+				AnalyzerKey:       "word_search",
+				Status:            AnalysisFinished,
+				Score:             1,
+				ReporterScore:     1,
+				Content:           Content{"Running"},
+				Inputs:            Inputs{{Key: "strict_words", Value: "Running, Walking"}, {Key: "threshold", Value: "1"}},
+				Jobs:              JobsProgress{{JobId: uuid.MustParse("45826a77-8377-4cce-9388-6f8f2154f998"), Status: AnalysisFinished}},
+			},
+		},
+	}, true, nil)
+
+	mockAnalysisRecordSaver.EXPECT().createAnalysisRequest(mock.Anything).RunAndReturn(func(ar *AnalysisRequest) error {
+		assert.Equal(t, userId, ar.UserId)
+		assert.Equal(t, "Running and walking", ar.RawData.ProcessedText)
+		assert.Empty(t, ar.Analysis[0].Jobs)
+		assert.Equal(t, AnalysisFinished, ar.Analysis[0].Status)
+		assert.Equal(t, float32(1), ar.Analysis[0].Score)
+		assert.Equal(t, AnalysisWaiting, ar.Analysis[1].Status)
+		assert.Empty(t, ar.Analysis[1].Jobs)
+		ar.Id = newArid
+		return nil
+	})
+	mockAa.EXPECT().allocateAnalyzeJobs(newArid, "Running and walking")
+	mockSsem.EXPECT().SendEvent(userId, mock.Anything)
+	mockWn.EXPECT().Notify(userId, mock.MatchedBy(func(e webhook.Event) bool {
+		return e.Type == webhook.EventAnalysisRequested && e.AnalysisRequestId == newArid
+	}))
+	mockJobManager.AssertNotCalled(t, "EnqueueJob")
+
+	aid, err := analyzerRequester.RequestAnalysis(sharedAnalysisRequest(), userId, string(RequestOriginUser))
+
+	assert.NoError(t, err)
+	assert.Equal(t, newArid, aid)
+}
+
+func TestAnalysisRequestUpload(t *testing.T) {
+	mockAnalysisRecordSaver := NewMockanalysisRequestStore(t)
+	mockJobManager := NewMockjobManagerRequester(t)
+	mockSsem := NewMocksseEventSender(t)
+	mockTs := NewMockthemeService(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockCs := NewMockcontentStore(t)
+	mockWn := NewMockwebhookNotifier(t)
+	config.SetupConfig("../../testdata/envs/upload.yaml")
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+	arid := uuid.MustParse("75d25964-6d59-4f88-97f8-dfd3afe96c62")
+	jobId := uuid.MustParse("45826a77-8377-4cce-9388-6f8f2154f998")
+
+	analyzerRequester := NewAnalysisManangerRequester(mockJobManager, mockAnalysisRecordSaver, mockSsem, mockTs, mockAa, mockCs, mockWn)
+
+	var ref string
+	storeContent := func(_ context.Context, name string, r io.Reader) error {
+		ref = name
+		_, err := io.ReadAll(r)
+		return err
+	}
+
+	ar := sharedAnalysisRequest()
+	ar.File.Content = ""
+
+	t.Run("tooLarge", func(t *testing.T) {
+		mockCs.EXPECT().Put(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(storeContent).Once()
+		mockCs.EXPECT().Delete(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, name string) error {
+			assert.Equal(t, ref, name)
+			return nil
+		}).Once()
+
+		aid, err := analyzerRequester.RequestAnalysisUpload(ar, strings.NewReader("Running and walking"), userId, string(RequestOriginUser))
+
+		assert.ErrorIs(t, err, ErrFileTooLarge)
+		assert.Equal(t, uuid.Nil, aid)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockCs.EXPECT().Put(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(storeContent).Once()
+		mockAnalysisRecordSaver.EXPECT().getAnalysisRequestIdByHash(userId, "f4ccae29e1bb0c20a124570a1b43f4347ea94bba9f84ffdfddd9c7445b126128").Return(uuid.Nil, nil)
+		mockAnalysisRecordSaver.EXPECT().createAnalysisRequest(mock.Anything).RunAndReturn(func(a *AnalysisRequest) error {
+			assert.Equal(t, ref, a.RawData.ContentRef)
+			assert.Empty(t, a.RawData.Content)
+			a.Id = arid
+			return nil
+		})
+		mockJobManager.EXPECT().CreateId().Return(jobId)
+		mockJobManager.EXPECT().EnqueueJob(jobId, jobmanager.Parse, "parser.freetext", mock.Anything, mock.Anything).RunAndReturn(func(_ uuid.UUID, _ jobmanager.JobType, _ string, data any, _ jobmanager.JobMeta) error {
+			pjd := data.(jobmanager.ParserJobData)
+			assert.Equal(t, ref, pjd.ParserData.ContentRef)
+			assert.Empty(t, pjd.ParserData.Content)
+			return nil
+		})
+		mockSsem.EXPECT().SendEvent(userId, mock.Anything)
+		mockWn.EXPECT().Notify(userId, mock.MatchedBy(func(e webhook.Event) bool {
+			return e.Type == webhook.EventAnalysisRequested && e.AnalysisRequestId == arid
+		}))
+
+		aid, err := analyzerRequester.RequestAnalysisUpload(ar, strings.NewReader("Running"), userId, string(RequestOriginUser))
+
+		assert.NoError(t, err)
+		assert.Equal(t, arid, aid)
+	})
+}
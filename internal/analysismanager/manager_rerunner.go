@@ -0,0 +1,163 @@
+package analysismanager
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/ssemanager"
+	"github.com/guardlight/server/pkg/analysisrequest"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotParsed          = errors.New("analysis request is not parsed yet")
+	ErrInvalidTheme       = errors.New("invalid theme selected")
+	ErrAnalysisInProgress = errors.New("analysis is still in progress")
+	ErrTextExported       = errors.New("processed text was exported")
+	ErrTextPurged         = errors.New("processed text was purged by a retention rule")
+)
+
+type analysisRerunStore interface {
+	getAnalysesByAnalysisIdAndUserId(uid, arid uuid.UUID) (AnalysisRequest, error)
+	getProcessedText(arid uuid.UUID) (string, error)
+	getAnalysisRequestIdsByThemeId(uid, tid uuid.UUID) ([]uuid.UUID, error)
+	replaceAnalyses(arid uuid.UUID, themeIds []uuid.UUID, as []Analysis, cancelJobs func(tx *gorm.DB, aids []uuid.UUID) error) error
+}
+
+type analyzeAllocator interface {
+	allocateAnalyzeJobs(ai uuid.UUID, text string)
+}
+
+// AnalysisManagerRerunner analyzes the processed text of existing analysis
+// requests again with the current version of their themes.
+type AnalysisManagerRerunner struct {
+	rs  analysisRerunStore
+	aa  analyzeAllocator
+	ts  themeService
+	sse sseEventSender
+	jc  jobCanceller
+	cb  cancelBroadcaster
+}
+
+func NewAnalysisManagerRerunner(rs analysisRerunStore, aa analyzeAllocator, ts themeService, sse sseEventSender, jc jobCanceller, cb cancelBroadcaster) *AnalysisManagerRerunner {
+	return &AnalysisManagerRerunner{
+		rs:  rs,
+		aa:  aa,
+		ts:  ts,
+		sse: sse,
+		jc:  jc,
+		cb:  cb,
+	}
+}
+
+// Rerun replaces the analyses of the themes with new ones and analyzes the
+// processed text again. The replaced analyses are kept in the history, the
+// jobs still running for them are cancelled.
+func (amr *AnalysisManagerRerunner) Rerun(uid, arid uuid.UUID, themeIds []uuid.UUID) error {
+	ar, err := amr.rs.getAnalysesByAnalysisIdAndUserId(uid, arid)
+	if err != nil {
+		return err
+	}
+
+	text, err := amr.rs.getProcessedText(arid)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		switch ar.RawData.textState() {
+		case TextPurged:
+			return ErrTextPurged
+		case TextExported:
+			return ErrTextExported
+		default:
+			return ErrNotParsed
+		}
+	}
+
+	userThemes, err := amr.ts.GetAllThemesByUserId(uid)
+	if err != nil {
+		return err
+	}
+	arDto := &analysisrequest.AnalysisRequest{
+		Themes: selectThemes(userThemes, lo.Uniq(themeIds)),
+	}
+	if len(arDto.Themes) == 0 || len(arDto.Themes) != len(lo.Uniq(themeIds)) {
+		return ErrInvalidTheme
+	}
+	if !hasValidAnalyzers(arDto) {
+		return ErrInvalidAnalyzer
+	}
+
+	busy := lo.ContainsBy(ar.Analysis, func(a Analysis) bool {
+		return lo.Contains(themeIds, a.ThemeId) && !a.done()
+	})
+	if busy {
+		return ErrAnalysisInProgress
+	}
+
+	as := lo.Map(createAnalysis(arDto), func(a Analysis, _ int) Analysis {
+		a.AnalysisRequestId = arid
+		return a
+	})
+	var jids []uuid.UUID
+	err = amr.rs.replaceAnalyses(arid, themeIds, as, func(tx *gorm.DB, aids []uuid.UUID) error {
+		var err error
+		jids, err = amr.jc.CancelJobsByAnalysisIds(tx, aids)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(jids) > 0 {
+		err := amr.cb.Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{
+			JobIds: jids,
+			Reason: "analysis rerun",
+		})
+		if err != nil {
+			zap.S().Errorw("Could not tell adapters about cancelled jobs", "analysis_request_id", arid, "error", err)
+		}
+	}
+
+	amr.aa.allocateAnalyzeJobs(arid, text)
+
+	zap.S().Infow("Analysis request rerun", "analysis_request_id", arid, "theme_ids", themeIds)
+	amr.sse.SendEvent(uid, ssemanager.SseEvent{
+		Type:   ssemanager.TypeUpdate,
+		Action: ssemanager.ActionAnalysisRequested,
+		Data:   arid.String(),
+	})
+
+	return nil
+}
+
+// RerunTheme reruns the theme on every analysis request of the user that uses
+// it. Requests that cannot be rerun right now are skipped.
+func (amr *AnalysisManagerRerunner) RerunTheme(uid, tid uuid.UUID) (analysisrequest.AnalysisRerunResponse, error) {
+	arids, err := amr.rs.getAnalysisRequestIdsByThemeId(uid, tid)
+	if err != nil {
+		return analysisrequest.AnalysisRerunResponse{}, err
+	}
+
+	resp := analysisrequest.AnalysisRerunResponse{
+		Rerun:   []uuid.UUID{},
+		Skipped: []uuid.UUID{},
+	}
+	for _, arid := range arids {
+		err := amr.Rerun(uid, arid, []uuid.UUID{tid})
+		switch {
+		case err == nil:
+			resp.Rerun = append(resp.Rerun, arid)
+		case errors.Is(err, ErrNotParsed), errors.Is(err, ErrAnalysisInProgress),
+			errors.Is(err, ErrTextExported), errors.Is(err, ErrTextPurged):
+			zap.S().Infow("Skipping rerun of analysis request", "analysis_request_id", arid, "reason", err)
+			resp.Skipped = append(resp.Skipped, arid)
+		default:
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,218 @@
+package analysismanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/ssemanager"
+	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var (
+	rerunUserId  = uuid.MustParse("fc28fb4c-2280-49f5-a3ba-f99ed8f8843c")
+	rerunArid    = uuid.MustParse("78aa39f6-af28-4f01-8809-2e30e7f225d0")
+	rerunThemeId = uuid.MustParse("09a8c66d-d0df-435f-87e2-4f5f17c8c0f1")
+	otherThemeId = uuid.MustParse("c0b4f4a4-5e3c-4c25-9c5e-0b5d3a3e7c11")
+)
+
+func rerunThemes() []theme.ThemeDto {
+	return []theme.ThemeDto{
+		{
+			Id:    rerunThemeId,
+			Title: "Violence",
+			Analyzers: []theme.AnalyzerDto{
+				{
+					Key:          "word_search",
+					ChangeStatus: theme.Changed,
+					Inputs: []theme.AnalyzerInputDto{
+						{Key: "strict_words", Value: "gun,knife", ChangeStatus: theme.Changed},
+						{Key: "threshold", Value: "1", ChangeStatus: theme.Same},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRerunReplacesAnalysesOfTheme(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id: rerunArid,
+		Analysis: []Analysis{
+			{ThemeId: rerunThemeId, Status: AnalysisFinished},
+			{ThemeId: otherThemeId, Status: AnalysisInprogress},
+		},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("This is a book", nil)
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	mockRs.EXPECT().replaceAnalyses(rerunArid, []uuid.UUID{rerunThemeId}, mock.MatchedBy(func(as []Analysis) bool {
+		return len(as) == 1 &&
+			as[0].AnalysisRequestId == rerunArid &&
+			as[0].Status == AnalysisWaiting &&
+			as[0].Inputs[0].Value == "gun,knife"
+	}), mock.Anything).Return(nil)
+	mockAa.EXPECT().allocateAnalyzeJobs(rerunArid, "This is a book")
+	mockSse.EXPECT().SendEvent(rerunUserId, ssemanager.SseEvent{
+		Type:   ssemanager.TypeUpdate,
+		Action: ssemanager.ActionAnalysisRequested,
+		Data:   rerunArid.String(),
+	})
+
+	err := amr.Rerun(rerunUserId, rerunArid, []uuid.UUID{rerunThemeId})
+	assert.NoError(t, err)
+}
+
+func TestRerunCancelsJobsOfReplacedAnalyses(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	aid := uuid.New()
+	jid := uuid.New()
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id:       rerunArid,
+		Analysis: []Analysis{{Id: aid, ThemeId: rerunThemeId, Status: AnalysisFinished}},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("This is a book", nil)
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	mockRs.EXPECT().replaceAnalyses(rerunArid, []uuid.UUID{rerunThemeId}, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ uuid.UUID, _ []uuid.UUID, _ []Analysis, cancelJobs func(tx *gorm.DB, aids []uuid.UUID) error) error {
+			return cancelJobs(nil, []uuid.UUID{aid})
+		})
+	mockJc.EXPECT().CancelJobsByAnalysisIds((*gorm.DB)(nil), []uuid.UUID{aid}).Return([]uuid.UUID{jid}, nil)
+	mockCb.EXPECT().Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{
+		JobIds: []uuid.UUID{jid},
+		Reason: "analysis rerun",
+	}).Return(nil)
+	mockAa.EXPECT().allocateAnalyzeJobs(rerunArid, "This is a book")
+	mockSse.EXPECT().SendEvent(rerunUserId, mock.Anything)
+
+	err := amr.Rerun(rerunUserId, rerunArid, []uuid.UUID{rerunThemeId})
+	assert.NoError(t, err)
+}
+
+func TestRerunKeepsJobsWhenReplaceFails(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id:       rerunArid,
+		Analysis: []Analysis{{ThemeId: rerunThemeId, Status: AnalysisFinished}},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("This is a book", nil)
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	// Another rerun got the lock first
+	mockRs.EXPECT().replaceAnalyses(rerunArid, []uuid.UUID{rerunThemeId}, mock.Anything, mock.Anything).Return(ErrAnalysisInProgress)
+	mockCb.AssertNotCalled(t, "Broadcast")
+	mockAa.AssertNotCalled(t, "allocateAnalyzeJobs")
+
+	err := amr.Rerun(rerunUserId, rerunArid, []uuid.UUID{rerunThemeId})
+	assert.ErrorIs(t, err, ErrAnalysisInProgress)
+}
+
+func TestRerunRefusesUnfinishedAnalyses(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id:       rerunArid,
+		Analysis: []Analysis{{ThemeId: rerunThemeId, Status: AnalysisInprogress}},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("This is a book", nil)
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	mockRs.AssertNotCalled(t, "replaceAnalyses")
+	mockAa.AssertNotCalled(t, "allocateAnalyzeJobs")
+
+	err := amr.Rerun(rerunUserId, rerunArid, []uuid.UUID{rerunThemeId})
+	assert.ErrorIs(t, err, ErrAnalysisInProgress)
+}
+
+func TestRerunRefusesPurgedText(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	purgedAt := time.Now()
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id:      rerunArid,
+		RawData: RawData{PurgedAt: &purgedAt, PurgedBy: "purge-books"},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("", nil)
+
+	err := amr.Rerun(rerunUserId, rerunArid, []uuid.UUID{rerunThemeId})
+	assert.ErrorIs(t, err, ErrTextPurged)
+}
+
+func TestRerunThemeSkipsUnparsedRequests(t *testing.T) {
+	mockRs := NewMockanalysisRerunStore(t)
+	mockAa := NewMockanalyzeAllocator(t)
+	mockTs := NewMockthemeService(t)
+	mockSse := NewMocksseEventSender(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	amr := NewAnalysisManagerRerunner(mockRs, mockAa, mockTs, mockSse, mockJc, mockCb)
+
+	unparsed := uuid.MustParse("e007bc38-0373-4da6-895e-c76e9ee331e7")
+	mockRs.EXPECT().getAnalysisRequestIdsByThemeId(rerunUserId, rerunThemeId).Return([]uuid.UUID{unparsed, rerunArid}, nil)
+
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, unparsed).Return(AnalysisRequest{Id: unparsed}, nil)
+	mockRs.EXPECT().getProcessedText(unparsed).Return("", nil)
+
+	mockRs.EXPECT().getAnalysesByAnalysisIdAndUserId(rerunUserId, rerunArid).Return(AnalysisRequest{
+		Id:       rerunArid,
+		Analysis: []Analysis{{ThemeId: rerunThemeId, Status: AnalysisError}},
+	}, nil)
+	mockRs.EXPECT().getProcessedText(rerunArid).Return("This is a book", nil)
+	mockTs.EXPECT().GetAllThemesByUserId(rerunUserId).Return(rerunThemes(), nil)
+	mockRs.EXPECT().replaceAnalyses(rerunArid, []uuid.UUID{rerunThemeId}, mock.Anything, mock.Anything).Return(nil)
+	mockAa.EXPECT().allocateAnalyzeJobs(rerunArid, "This is a book")
+	mockSse.EXPECT().SendEvent(rerunUserId, mock.Anything)
+
+	resp, err := amr.RerunTheme(rerunUserId, rerunThemeId)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{rerunArid}, resp.Rerun)
+	assert.Equal(t, []uuid.UUID{unparsed}, resp.Skipped)
+}
@@ -77,9 +77,9 @@ func (_c *MockanalysisGetter_getAnalysesByAnalysisIdAndUserId_Call) RunAndReturn
 	return _c
 }
 
-// getAnalysesByUserId provides a mock function with given fields: id, pag, catType, catCat, query, sc
-func (_m *MockanalysisGetter) getAnalysesByUserId(id uuid.UUID, pag Pagination, catType string, catCat string, query string, sc string) (AnalysisResultPaginated, error) {
-	ret := _m.Called(id, pag, catType, catCat, query, sc)
+// getAnalysesByUserId provides a mock function with given fields: id, pag, catType, catCat, query, verdict, sort
+func (_m *MockanalysisGetter) getAnalysesByUserId(id uuid.UUID, pag Pagination, catType string, catCat string, query string, verdict Verdict, sort string) (AnalysisResultPaginated, error) {
+	ret := _m.Called(id, pag, catType, catCat, query, verdict, sort)
 
 	if len(ret) == 0 {
 		panic("no return value specified for getAnalysesByUserId")
@@ -87,17 +87,17 @@ func (_m *MockanalysisGetter) getAnalysesByUserId(id uuid.UUID, pag Pagination,
 
 	var r0 AnalysisResultPaginated
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, Pagination, string, string, string, string) (AnalysisResultPaginated, error)); ok {
-		return rf(id, pag, catType, catCat, query, sc)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, Pagination, string, string, string, Verdict, string) (AnalysisResultPaginated, error)); ok {
+		return rf(id, pag, catType, catCat, query, verdict, sort)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID, Pagination, string, string, string, string) AnalysisResultPaginated); ok {
-		r0 = rf(id, pag, catType, catCat, query, sc)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, Pagination, string, string, string, Verdict, string) AnalysisResultPaginated); ok {
+		r0 = rf(id, pag, catType, catCat, query, verdict, sort)
 	} else {
 		r0 = ret.Get(0).(AnalysisResultPaginated)
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID, Pagination, string, string, string, string) error); ok {
-		r1 = rf(id, pag, catType, catCat, query, sc)
+	if rf, ok := ret.Get(1).(func(uuid.UUID, Pagination, string, string, string, Verdict, string) error); ok {
+		r1 = rf(id, pag, catType, catCat, query, verdict, sort)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -116,14 +116,15 @@ type MockanalysisGetter_getAnalysesByUserId_Call struct {
 //   - catType string
 //   - catCat string
 //   - query string
-//   - sc string
-func (_e *MockanalysisGetter_Expecter) getAnalysesByUserId(id interface{}, pag interface{}, catType interface{}, catCat interface{}, query interface{}, sc interface{}) *MockanalysisGetter_getAnalysesByUserId_Call {
-	return &MockanalysisGetter_getAnalysesByUserId_Call{Call: _e.mock.On("getAnalysesByUserId", id, pag, catType, catCat, query, sc)}
+//   - verdict Verdict
+//   - sort string
+func (_e *MockanalysisGetter_Expecter) getAnalysesByUserId(id interface{}, pag interface{}, catType interface{}, catCat interface{}, query interface{}, verdict interface{}, sort interface{}) *MockanalysisGetter_getAnalysesByUserId_Call {
+	return &MockanalysisGetter_getAnalysesByUserId_Call{Call: _e.mock.On("getAnalysesByUserId", id, pag, catType, catCat, query, verdict, sort)}
 }
 
-func (_c *MockanalysisGetter_getAnalysesByUserId_Call) Run(run func(id uuid.UUID, pag Pagination, catType string, catCat string, query string, sc string)) *MockanalysisGetter_getAnalysesByUserId_Call {
+func (_c *MockanalysisGetter_getAnalysesByUserId_Call) Run(run func(id uuid.UUID, pag Pagination, catType string, catCat string, query string, verdict Verdict, sort string)) *MockanalysisGetter_getAnalysesByUserId_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(Pagination), args[2].(string), args[3].(string), args[4].(string), args[5].(string))
+		run(args[0].(uuid.UUID), args[1].(Pagination), args[2].(string), args[3].(string), args[4].(string), args[5].(Verdict), args[6].(string))
 	})
 	return _c
 }
@@ -133,7 +134,124 @@ func (_c *MockanalysisGetter_getAnalysesByUserId_Call) Return(_a0 AnalysisResult
 	return _c
 }
 
-func (_c *MockanalysisGetter_getAnalysesByUserId_Call) RunAndReturn(run func(uuid.UUID, Pagination, string, string, string, string) (AnalysisResultPaginated, error)) *MockanalysisGetter_getAnalysesByUserId_Call {
+func (_c *MockanalysisGetter_getAnalysesByUserId_Call) RunAndReturn(run func(uuid.UUID, Pagination, string, string, string, Verdict, string) (AnalysisResultPaginated, error)) *MockanalysisGetter_getAnalysesByUserId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getScoreOverridesByAnalysisRequestId provides a mock function with given fields: uid, arid
+func (_m *MockanalysisGetter) getScoreOverridesByAnalysisRequestId(uid uuid.UUID, arid uuid.UUID) ([]ScoreOverride, error) {
+	ret := _m.Called(uid, arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getScoreOverridesByAnalysisRequestId")
+	}
+
+	var r0 []ScoreOverride
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) ([]ScoreOverride, error)); ok {
+		return rf(uid, arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) []ScoreOverride); ok {
+		r0 = rf(uid, arid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ScoreOverride)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(uid, arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getScoreOverridesByAnalysisRequestId'
+type MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call struct {
+	*mock.Call
+}
+
+// getScoreOverridesByAnalysisRequestId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - arid uuid.UUID
+func (_e *MockanalysisGetter_Expecter) getScoreOverridesByAnalysisRequestId(uid interface{}, arid interface{}) *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call {
+	return &MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call{Call: _e.mock.On("getScoreOverridesByAnalysisRequestId", uid, arid)}
+}
+
+func (_c *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call) Run(run func(uid uuid.UUID, arid uuid.UUID)) *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call) Return(_a0 []ScoreOverride, _a1 error) *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) ([]ScoreOverride, error)) *MockanalysisGetter_getScoreOverridesByAnalysisRequestId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// searchAnalysesByUserId provides a mock function with given fields: uid, query, pag
+func (_m *MockanalysisGetter) searchAnalysesByUserId(uid uuid.UUID, query string, pag Pagination) (AnalysisSearchPaginated, error) {
+	ret := _m.Called(uid, query, pag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for searchAnalysesByUserId")
+	}
+
+	var r0 AnalysisSearchPaginated
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, Pagination) (AnalysisSearchPaginated, error)); ok {
+		return rf(uid, query, pag)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, Pagination) AnalysisSearchPaginated); ok {
+		r0 = rf(uid, query, pag)
+	} else {
+		r0 = ret.Get(0).(AnalysisSearchPaginated)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string, Pagination) error); ok {
+		r1 = rf(uid, query, pag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisGetter_searchAnalysesByUserId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'searchAnalysesByUserId'
+type MockanalysisGetter_searchAnalysesByUserId_Call struct {
+	*mock.Call
+}
+
+// searchAnalysesByUserId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - query string
+//   - pag Pagination
+func (_e *MockanalysisGetter_Expecter) searchAnalysesByUserId(uid interface{}, query interface{}, pag interface{}) *MockanalysisGetter_searchAnalysesByUserId_Call {
+	return &MockanalysisGetter_searchAnalysesByUserId_Call{Call: _e.mock.On("searchAnalysesByUserId", uid, query, pag)}
+}
+
+func (_c *MockanalysisGetter_searchAnalysesByUserId_Call) Run(run func(uid uuid.UUID, query string, pag Pagination)) *MockanalysisGetter_searchAnalysesByUserId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string), args[2].(Pagination))
+	})
+	return _c
+}
+
+func (_c *MockanalysisGetter_searchAnalysesByUserId_Call) Return(_a0 AnalysisSearchPaginated, _a1 error) *MockanalysisGetter_searchAnalysesByUserId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisGetter_searchAnalysesByUserId_Call) RunAndReturn(run func(uuid.UUID, string, Pagination) (AnalysisSearchPaginated, error)) *MockanalysisGetter_searchAnalysesByUserId_Call {
 	_c.Call.Return(run)
 	return _c
 }
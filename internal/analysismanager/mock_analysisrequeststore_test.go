@@ -66,9 +66,9 @@ func (_c *MockanalysisRequestStore_createAnalysisRequest_Call) RunAndReturn(run
 	return _c
 }
 
-// getAnalysisRequestIdByHash provides a mock function with given fields: hash
-func (_m *MockanalysisRequestStore) getAnalysisRequestIdByHash(hash string) (uuid.UUID, error) {
-	ret := _m.Called(hash)
+// getAnalysisRequestIdByHash provides a mock function with given fields: uid, hash
+func (_m *MockanalysisRequestStore) getAnalysisRequestIdByHash(uid uuid.UUID, hash string) (uuid.UUID, error) {
+	ret := _m.Called(uid, hash)
 
 	if len(ret) == 0 {
 		panic("no return value specified for getAnalysisRequestIdByHash")
@@ -76,19 +76,19 @@ func (_m *MockanalysisRequestStore) getAnalysisRequestIdByHash(hash string) (uui
 
 	var r0 uuid.UUID
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (uuid.UUID, error)); ok {
-		return rf(hash)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) (uuid.UUID, error)); ok {
+		return rf(uid, hash)
 	}
-	if rf, ok := ret.Get(0).(func(string) uuid.UUID); ok {
-		r0 = rf(hash)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) uuid.UUID); ok {
+		r0 = rf(uid, hash)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(uuid.UUID)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(hash)
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string) error); ok {
+		r1 = rf(uid, hash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,14 +102,15 @@ type MockanalysisRequestStore_getAnalysisRequestIdByHash_Call struct {
 }
 
 // getAnalysisRequestIdByHash is a helper method to define mock.On call
+//   - uid uuid.UUID
 //   - hash string
-func (_e *MockanalysisRequestStore_Expecter) getAnalysisRequestIdByHash(hash interface{}) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
-	return &MockanalysisRequestStore_getAnalysisRequestIdByHash_Call{Call: _e.mock.On("getAnalysisRequestIdByHash", hash)}
+func (_e *MockanalysisRequestStore_Expecter) getAnalysisRequestIdByHash(uid interface{}, hash interface{}) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
+	return &MockanalysisRequestStore_getAnalysisRequestIdByHash_Call{Call: _e.mock.On("getAnalysisRequestIdByHash", uid, hash)}
 }
 
-func (_c *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call) Run(run func(hash string)) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
+func (_c *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call) Run(run func(uid uuid.UUID, hash string)) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(uuid.UUID), args[1].(string))
 	})
 	return _c
 }
@@ -119,7 +120,71 @@ func (_c *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call) Return(_a0 u
 	return _c
 }
 
-func (_c *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call) RunAndReturn(run func(string) (uuid.UUID, error)) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
+func (_c *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call) RunAndReturn(run func(uuid.UUID, string) (uuid.UUID, error)) *MockanalysisRequestStore_getAnalysisRequestIdByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getSharedAnalysisRequestByHash provides a mock function with given fields: uid, hash
+func (_m *MockanalysisRequestStore) getSharedAnalysisRequestByHash(uid uuid.UUID, hash string) (AnalysisRequest, bool, error) {
+	ret := _m.Called(uid, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getSharedAnalysisRequestByHash")
+	}
+
+	var r0 AnalysisRequest
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) (AnalysisRequest, bool, error)); ok {
+		return rf(uid, hash)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) AnalysisRequest); ok {
+		r0 = rf(uid, hash)
+	} else {
+		r0 = ret.Get(0).(AnalysisRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string) bool); ok {
+		r1 = rf(uid, hash)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(uuid.UUID, string) error); ok {
+		r2 = rf(uid, hash)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getSharedAnalysisRequestByHash'
+type MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call struct {
+	*mock.Call
+}
+
+// getSharedAnalysisRequestByHash is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - hash string
+func (_e *MockanalysisRequestStore_Expecter) getSharedAnalysisRequestByHash(uid interface{}, hash interface{}) *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call {
+	return &MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call{Call: _e.mock.On("getSharedAnalysisRequestByHash", uid, hash)}
+}
+
+func (_c *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call) Run(run func(uid uuid.UUID, hash string)) *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call) Return(_a0 AnalysisRequest, _a1 bool, _a2 error) *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call) RunAndReturn(run func(uuid.UUID, string) (AnalysisRequest, bool, error)) *MockanalysisRequestStore_getSharedAnalysisRequestByHash_Call {
 	_c.Call.Return(run)
 	return _c
 }
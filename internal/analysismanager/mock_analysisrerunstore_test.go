@@ -0,0 +1,257 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+	gorm "gorm.io/gorm"
+)
+
+// MockanalysisRerunStore is an autogenerated mock type for the analysisRerunStore type
+type MockanalysisRerunStore struct {
+	mock.Mock
+}
+
+type MockanalysisRerunStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockanalysisRerunStore) EXPECT() *MockanalysisRerunStore_Expecter {
+	return &MockanalysisRerunStore_Expecter{mock: &_m.Mock}
+}
+
+// getAnalysesByAnalysisIdAndUserId provides a mock function with given fields: uid, arid
+func (_m *MockanalysisRerunStore) getAnalysesByAnalysisIdAndUserId(uid uuid.UUID, arid uuid.UUID) (AnalysisRequest, error) {
+	ret := _m.Called(uid, arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getAnalysesByAnalysisIdAndUserId")
+	}
+
+	var r0 AnalysisRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) (AnalysisRequest, error)); ok {
+		return rf(uid, arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) AnalysisRequest); ok {
+		r0 = rf(uid, arid)
+	} else {
+		r0 = ret.Get(0).(AnalysisRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(uid, arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getAnalysesByAnalysisIdAndUserId'
+type MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call struct {
+	*mock.Call
+}
+
+// getAnalysesByAnalysisIdAndUserId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - arid uuid.UUID
+func (_e *MockanalysisRerunStore_Expecter) getAnalysesByAnalysisIdAndUserId(uid interface{}, arid interface{}) *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call {
+	return &MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call{Call: _e.mock.On("getAnalysesByAnalysisIdAndUserId", uid, arid)}
+}
+
+func (_c *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call) Run(run func(uid uuid.UUID, arid uuid.UUID)) *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call) Return(_a0 AnalysisRequest, _a1 error) *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) (AnalysisRequest, error)) *MockanalysisRerunStore_getAnalysesByAnalysisIdAndUserId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getAnalysisRequestIdsByThemeId provides a mock function with given fields: uid, tid
+func (_m *MockanalysisRerunStore) getAnalysisRequestIdsByThemeId(uid uuid.UUID, tid uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(uid, tid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getAnalysisRequestIdsByThemeId")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(uid, tid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(uid, tid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(uid, tid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getAnalysisRequestIdsByThemeId'
+type MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call struct {
+	*mock.Call
+}
+
+// getAnalysisRequestIdsByThemeId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - tid uuid.UUID
+func (_e *MockanalysisRerunStore_Expecter) getAnalysisRequestIdsByThemeId(uid interface{}, tid interface{}) *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call {
+	return &MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call{Call: _e.mock.On("getAnalysisRequestIdsByThemeId", uid, tid)}
+}
+
+func (_c *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call) Run(run func(uid uuid.UUID, tid uuid.UUID)) *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call) Return(_a0 []uuid.UUID, _a1 error) *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) ([]uuid.UUID, error)) *MockanalysisRerunStore_getAnalysisRequestIdsByThemeId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getProcessedText provides a mock function with given fields: arid
+func (_m *MockanalysisRerunStore) getProcessedText(arid uuid.UUID) (string, error) {
+	ret := _m.Called(arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getProcessedText")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (string, error)); ok {
+		return rf(arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) string); ok {
+		r0 = rf(arid)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisRerunStore_getProcessedText_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getProcessedText'
+type MockanalysisRerunStore_getProcessedText_Call struct {
+	*mock.Call
+}
+
+// getProcessedText is a helper method to define mock.On call
+//   - arid uuid.UUID
+func (_e *MockanalysisRerunStore_Expecter) getProcessedText(arid interface{}) *MockanalysisRerunStore_getProcessedText_Call {
+	return &MockanalysisRerunStore_getProcessedText_Call{Call: _e.mock.On("getProcessedText", arid)}
+}
+
+func (_c *MockanalysisRerunStore_getProcessedText_Call) Run(run func(arid uuid.UUID)) *MockanalysisRerunStore_getProcessedText_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getProcessedText_Call) Return(_a0 string, _a1 error) *MockanalysisRerunStore_getProcessedText_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_getProcessedText_Call) RunAndReturn(run func(uuid.UUID) (string, error)) *MockanalysisRerunStore_getProcessedText_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// replaceAnalyses provides a mock function with given fields: arid, themeIds, as, cancelJobs
+func (_m *MockanalysisRerunStore) replaceAnalyses(arid uuid.UUID, themeIds []uuid.UUID, as []Analysis, cancelJobs func(*gorm.DB, []uuid.UUID) error) error {
+	ret := _m.Called(arid, themeIds, as, cancelJobs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for replaceAnalyses")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, []uuid.UUID, []Analysis, func(*gorm.DB, []uuid.UUID) error) error); ok {
+		r0 = rf(arid, themeIds, as, cancelJobs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockanalysisRerunStore_replaceAnalyses_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'replaceAnalyses'
+type MockanalysisRerunStore_replaceAnalyses_Call struct {
+	*mock.Call
+}
+
+// replaceAnalyses is a helper method to define mock.On call
+//   - arid uuid.UUID
+//   - themeIds []uuid.UUID
+//   - as []Analysis
+//   - cancelJobs func(*gorm.DB , []uuid.UUID) error
+func (_e *MockanalysisRerunStore_Expecter) replaceAnalyses(arid interface{}, themeIds interface{}, as interface{}, cancelJobs interface{}) *MockanalysisRerunStore_replaceAnalyses_Call {
+	return &MockanalysisRerunStore_replaceAnalyses_Call{Call: _e.mock.On("replaceAnalyses", arid, themeIds, as, cancelJobs)}
+}
+
+func (_c *MockanalysisRerunStore_replaceAnalyses_Call) Run(run func(arid uuid.UUID, themeIds []uuid.UUID, as []Analysis, cancelJobs func(*gorm.DB, []uuid.UUID) error)) *MockanalysisRerunStore_replaceAnalyses_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].([]uuid.UUID), args[2].([]Analysis), args[3].(func(*gorm.DB, []uuid.UUID) error))
+	})
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_replaceAnalyses_Call) Return(_a0 error) *MockanalysisRerunStore_replaceAnalyses_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockanalysisRerunStore_replaceAnalyses_Call) RunAndReturn(run func(uuid.UUID, []uuid.UUID, []Analysis, func(*gorm.DB, []uuid.UUID) error) error) *MockanalysisRerunStore_replaceAnalyses_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockanalysisRerunStore creates a new instance of MockanalysisRerunStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockanalysisRerunStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockanalysisRerunStore {
+	mock := &MockanalysisRerunStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
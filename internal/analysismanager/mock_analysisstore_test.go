@@ -3,8 +3,12 @@
 package analysismanager
 
 import (
-	uuid "github.com/google/uuid"
+	analyzercontract "github.com/guardlight/server/pkg/analyzercontract"
+	gorm "gorm.io/gorm"
+
 	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
 )
 
 // MockanalysisStore is an autogenerated mock type for the analysisStore type
@@ -20,6 +24,99 @@ func (_m *MockanalysisStore) EXPECT() *MockanalysisStore_Expecter {
 	return &MockanalysisStore_Expecter{mock: &_m.Mock}
 }
 
+// failAnalyses provides a mock function with given fields: tx, fw
+func (_m *MockanalysisStore) failAnalyses(tx *gorm.DB, fw failedWork) error {
+	ret := _m.Called(tx, fw)
+
+	if len(ret) == 0 {
+		panic("no return value specified for failAnalyses")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gorm.DB, failedWork) error); ok {
+		r0 = rf(tx, fw)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockanalysisStore_failAnalyses_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'failAnalyses'
+type MockanalysisStore_failAnalyses_Call struct {
+	*mock.Call
+}
+
+// failAnalyses is a helper method to define mock.On call
+//   - tx *gorm.DB
+//   - fw failedWork
+func (_e *MockanalysisStore_Expecter) failAnalyses(tx interface{}, fw interface{}) *MockanalysisStore_failAnalyses_Call {
+	return &MockanalysisStore_failAnalyses_Call{Call: _e.mock.On("failAnalyses", tx, fw)}
+}
+
+func (_c *MockanalysisStore_failAnalyses_Call) Run(run func(tx *gorm.DB, fw failedWork)) *MockanalysisStore_failAnalyses_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*gorm.DB), args[1].(failedWork))
+	})
+	return _c
+}
+
+func (_c *MockanalysisStore_failAnalyses_Call) Return(_a0 error) *MockanalysisStore_failAnalyses_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockanalysisStore_failAnalyses_Call) RunAndReturn(run func(*gorm.DB, failedWork) error) *MockanalysisStore_failAnalyses_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// failAnalysis provides a mock function with given fields: aid
+func (_m *MockanalysisStore) failAnalysis(aid uuid.UUID) error {
+	ret := _m.Called(aid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for failAnalysis")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
+		r0 = rf(aid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockanalysisStore_failAnalysis_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'failAnalysis'
+type MockanalysisStore_failAnalysis_Call struct {
+	*mock.Call
+}
+
+// failAnalysis is a helper method to define mock.On call
+//   - aid uuid.UUID
+func (_e *MockanalysisStore_Expecter) failAnalysis(aid interface{}) *MockanalysisStore_failAnalysis_Call {
+	return &MockanalysisStore_failAnalysis_Call{Call: _e.mock.On("failAnalysis", aid)}
+}
+
+func (_c *MockanalysisStore_failAnalysis_Call) Run(run func(aid uuid.UUID)) *MockanalysisStore_failAnalysis_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisStore_failAnalysis_Call) Return(_a0 error) *MockanalysisStore_failAnalysis_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockanalysisStore_failAnalysis_Call) RunAndReturn(run func(uuid.UUID) error) *MockanalysisStore_failAnalysis_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // getAllAnalysisByAnalysisRecordId provides a mock function with given fields: id
 func (_m *MockanalysisStore) getAllAnalysisByAnalysisRecordId(id uuid.UUID) ([]Analysis, error) {
 	ret := _m.Called(id)
@@ -134,6 +231,62 @@ func (_c *MockanalysisStore_getAllAnalysisById_Call) RunAndReturn(run func(uuid.
 	return _c
 }
 
+// getAnalysisRequestById provides a mock function with given fields: arid
+func (_m *MockanalysisStore) getAnalysisRequestById(arid uuid.UUID) (AnalysisRequest, error) {
+	ret := _m.Called(arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getAnalysisRequestById")
+	}
+
+	var r0 AnalysisRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (AnalysisRequest, error)); ok {
+		return rf(arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) AnalysisRequest); ok {
+		r0 = rf(arid)
+	} else {
+		r0 = ret.Get(0).(AnalysisRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockanalysisStore_getAnalysisRequestById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getAnalysisRequestById'
+type MockanalysisStore_getAnalysisRequestById_Call struct {
+	*mock.Call
+}
+
+// getAnalysisRequestById is a helper method to define mock.On call
+//   - arid uuid.UUID
+func (_e *MockanalysisStore_Expecter) getAnalysisRequestById(arid interface{}) *MockanalysisStore_getAnalysisRequestById_Call {
+	return &MockanalysisStore_getAnalysisRequestById_Call{Call: _e.mock.On("getAnalysisRequestById", arid)}
+}
+
+func (_c *MockanalysisStore_getAnalysisRequestById_Call) Run(run func(arid uuid.UUID)) *MockanalysisStore_getAnalysisRequestById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockanalysisStore_getAnalysisRequestById_Call) Return(_a0 AnalysisRequest, _a1 error) *MockanalysisStore_getAnalysisRequestById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockanalysisStore_getAnalysisRequestById_Call) RunAndReturn(run func(uuid.UUID) (AnalysisRequest, error)) *MockanalysisStore_getAnalysisRequestById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // getReporterKeyByAnalysisId provides a mock function with given fields: aid
 func (_m *MockanalysisStore) getReporterKeyByAnalysisId(aid uuid.UUID) (string, error) {
 	ret := _m.Called(aid)
@@ -248,56 +401,9 @@ func (_c *MockanalysisStore_getUserIdByAnalysisId_Call) RunAndReturn(run func(uu
 	return _c
 }
 
-// updateAllAnalysesStatusByAnalysisRequestId provides a mock function with given fields: arid, status
-func (_m *MockanalysisStore) updateAllAnalysesStatusByAnalysisRequestId(arid uuid.UUID, status AnalysisStatus) error {
-	ret := _m.Called(arid, status)
-
-	if len(ret) == 0 {
-		panic("no return value specified for updateAllAnalysesStatusByAnalysisRequestId")
-	}
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, AnalysisStatus) error); ok {
-		r0 = rf(arid, status)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
-// MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateAllAnalysesStatusByAnalysisRequestId'
-type MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call struct {
-	*mock.Call
-}
-
-// updateAllAnalysesStatusByAnalysisRequestId is a helper method to define mock.On call
-//   - arid uuid.UUID
-//   - status AnalysisStatus
-func (_e *MockanalysisStore_Expecter) updateAllAnalysesStatusByAnalysisRequestId(arid interface{}, status interface{}) *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call {
-	return &MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call{Call: _e.mock.On("updateAllAnalysesStatusByAnalysisRequestId", arid, status)}
-}
-
-func (_c *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call) Run(run func(arid uuid.UUID, status AnalysisStatus)) *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call {
-	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(AnalysisStatus))
-	})
-	return _c
-}
-
-func (_c *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call) Return(_a0 error) *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call {
-	_c.Call.Return(_a0)
-	return _c
-}
-
-func (_c *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call) RunAndReturn(run func(uuid.UUID, AnalysisStatus) error) *MockanalysisStore_updateAllAnalysesStatusByAnalysisRequestId_Call {
-	_c.Call.Return(run)
-	return _c
-}
-
-// updateAnalysisJobProgress provides a mock function with given fields: aid, jid, status, content
-func (_m *MockanalysisStore) updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string) (bool, error) {
-	ret := _m.Called(aid, jid, status, content)
+// updateAnalysisJobProgress provides a mock function with given fields: aid, jid, status, content, findings
+func (_m *MockanalysisStore) updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string, findings []analyzercontract.Finding) (bool, error) {
+	ret := _m.Called(aid, jid, status, content, findings)
 
 	if len(ret) == 0 {
 		panic("no return value specified for updateAnalysisJobProgress")
@@ -305,17 +411,17 @@ func (_m *MockanalysisStore) updateAnalysisJobProgress(aid uuid.UUID, jid uuid.U
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string) (bool, error)); ok {
-		return rf(aid, jid, status, content)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string, []analyzercontract.Finding) (bool, error)); ok {
+		return rf(aid, jid, status, content, findings)
 	}
-	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string) bool); ok {
-		r0 = rf(aid, jid, status, content)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string, []analyzercontract.Finding) bool); ok {
+		r0 = rf(aid, jid, status, content, findings)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string) error); ok {
-		r1 = rf(aid, jid, status, content)
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID, AnalysisStatus, []string, []analyzercontract.Finding) error); ok {
+		r1 = rf(aid, jid, status, content, findings)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -333,13 +439,14 @@ type MockanalysisStore_updateAnalysisJobProgress_Call struct {
 //   - jid uuid.UUID
 //   - status AnalysisStatus
 //   - content []string
-func (_e *MockanalysisStore_Expecter) updateAnalysisJobProgress(aid interface{}, jid interface{}, status interface{}, content interface{}) *MockanalysisStore_updateAnalysisJobProgress_Call {
-	return &MockanalysisStore_updateAnalysisJobProgress_Call{Call: _e.mock.On("updateAnalysisJobProgress", aid, jid, status, content)}
+//   - findings []analyzercontract.Finding
+func (_e *MockanalysisStore_Expecter) updateAnalysisJobProgress(aid interface{}, jid interface{}, status interface{}, content interface{}, findings interface{}) *MockanalysisStore_updateAnalysisJobProgress_Call {
+	return &MockanalysisStore_updateAnalysisJobProgress_Call{Call: _e.mock.On("updateAnalysisJobProgress", aid, jid, status, content, findings)}
 }
 
-func (_c *MockanalysisStore_updateAnalysisJobProgress_Call) Run(run func(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string)) *MockanalysisStore_updateAnalysisJobProgress_Call {
+func (_c *MockanalysisStore_updateAnalysisJobProgress_Call) Run(run func(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string, findings []analyzercontract.Finding)) *MockanalysisStore_updateAnalysisJobProgress_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(AnalysisStatus), args[3].([]string))
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(AnalysisStatus), args[3].([]string), args[4].([]analyzercontract.Finding))
 	})
 	return _c
 }
@@ -349,7 +456,7 @@ func (_c *MockanalysisStore_updateAnalysisJobProgress_Call) Return(_a0 bool, _a1
 	return _c
 }
 
-func (_c *MockanalysisStore_updateAnalysisJobProgress_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, AnalysisStatus, []string) (bool, error)) *MockanalysisStore_updateAnalysisJobProgress_Call {
+func (_c *MockanalysisStore_updateAnalysisJobProgress_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, AnalysisStatus, []string, []analyzercontract.Finding) (bool, error)) *MockanalysisStore_updateAnalysisJobProgress_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -448,12 +555,12 @@ func (_c *MockanalysisStore_updateProcessedText_Call) RunAndReturn(run func(uuid
 	return _c
 }
 
-// updateScore provides a mock function with given fields: analysisId, score
-func (_m *MockanalysisStore) updateScore(analysisId uuid.UUID, score float32) error {
+// updateReporterScore provides a mock function with given fields: analysisId, score
+func (_m *MockanalysisStore) updateReporterScore(analysisId uuid.UUID, score float32) error {
 	ret := _m.Called(analysisId, score)
 
 	if len(ret) == 0 {
-		panic("no return value specified for updateScore")
+		panic("no return value specified for updateReporterScore")
 	}
 
 	var r0 error
@@ -466,31 +573,31 @@ func (_m *MockanalysisStore) updateScore(analysisId uuid.UUID, score float32) er
 	return r0
 }
 
-// MockanalysisStore_updateScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateScore'
-type MockanalysisStore_updateScore_Call struct {
+// MockanalysisStore_updateReporterScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateReporterScore'
+type MockanalysisStore_updateReporterScore_Call struct {
 	*mock.Call
 }
 
-// updateScore is a helper method to define mock.On call
+// updateReporterScore is a helper method to define mock.On call
 //   - analysisId uuid.UUID
 //   - score float32
-func (_e *MockanalysisStore_Expecter) updateScore(analysisId interface{}, score interface{}) *MockanalysisStore_updateScore_Call {
-	return &MockanalysisStore_updateScore_Call{Call: _e.mock.On("updateScore", analysisId, score)}
+func (_e *MockanalysisStore_Expecter) updateReporterScore(analysisId interface{}, score interface{}) *MockanalysisStore_updateReporterScore_Call {
+	return &MockanalysisStore_updateReporterScore_Call{Call: _e.mock.On("updateReporterScore", analysisId, score)}
 }
 
-func (_c *MockanalysisStore_updateScore_Call) Run(run func(analysisId uuid.UUID, score float32)) *MockanalysisStore_updateScore_Call {
+func (_c *MockanalysisStore_updateReporterScore_Call) Run(run func(analysisId uuid.UUID, score float32)) *MockanalysisStore_updateReporterScore_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(uuid.UUID), args[1].(float32))
 	})
 	return _c
 }
 
-func (_c *MockanalysisStore_updateScore_Call) Return(_a0 error) *MockanalysisStore_updateScore_Call {
+func (_c *MockanalysisStore_updateReporterScore_Call) Return(_a0 error) *MockanalysisStore_updateReporterScore_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockanalysisStore_updateScore_Call) RunAndReturn(run func(uuid.UUID, float32) error) *MockanalysisStore_updateScore_Call {
+func (_c *MockanalysisStore_updateReporterScore_Call) RunAndReturn(run func(uuid.UUID, float32) error) *MockanalysisStore_updateReporterScore_Call {
 	_c.Call.Return(run)
 	return _c
 }
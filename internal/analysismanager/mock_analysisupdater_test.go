@@ -5,6 +5,7 @@ package analysismanager
 import (
 	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
+	gorm "gorm.io/gorm"
 )
 
 // MockanalysisUpdater is an autogenerated mock type for the analysisUpdater type
@@ -20,22 +21,32 @@ func (_m *MockanalysisUpdater) EXPECT() *MockanalysisUpdater_Expecter {
 	return &MockanalysisUpdater_Expecter{mock: &_m.Mock}
 }
 
-// deleteAnalysisRequestById provides a mock function with given fields: arid, uid
-func (_m *MockanalysisUpdater) deleteAnalysisRequestById(arid uuid.UUID, uid uuid.UUID) error {
-	ret := _m.Called(arid, uid)
+// deleteAnalysisRequestById provides a mock function with given fields: arid, uid, cancelJobs
+func (_m *MockanalysisUpdater) deleteAnalysisRequestById(arid uuid.UUID, uid uuid.UUID, cancelJobs func(*gorm.DB) error) (string, error) {
+	ret := _m.Called(arid, uid, cancelJobs)
 
 	if len(ret) == 0 {
 		panic("no return value specified for deleteAnalysisRequestById")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) error); ok {
-		r0 = rf(arid, uid)
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, func(*gorm.DB) error) (string, error)); ok {
+		return rf(arid, uid, cancelJobs)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, func(*gorm.DB) error) string); ok {
+		r0 = rf(arid, uid, cancelJobs)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID, func(*gorm.DB) error) error); ok {
+		r1 = rf(arid, uid, cancelJobs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MockanalysisUpdater_deleteAnalysisRequestById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'deleteAnalysisRequestById'
@@ -46,38 +57,39 @@ type MockanalysisUpdater_deleteAnalysisRequestById_Call struct {
 // deleteAnalysisRequestById is a helper method to define mock.On call
 //   - arid uuid.UUID
 //   - uid uuid.UUID
-func (_e *MockanalysisUpdater_Expecter) deleteAnalysisRequestById(arid interface{}, uid interface{}) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
-	return &MockanalysisUpdater_deleteAnalysisRequestById_Call{Call: _e.mock.On("deleteAnalysisRequestById", arid, uid)}
+//   - cancelJobs func(*gorm.DB) error
+func (_e *MockanalysisUpdater_Expecter) deleteAnalysisRequestById(arid interface{}, uid interface{}, cancelJobs interface{}) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
+	return &MockanalysisUpdater_deleteAnalysisRequestById_Call{Call: _e.mock.On("deleteAnalysisRequestById", arid, uid, cancelJobs)}
 }
 
-func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) Run(run func(arid uuid.UUID, uid uuid.UUID)) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
+func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) Run(run func(arid uuid.UUID, uid uuid.UUID, cancelJobs func(*gorm.DB) error)) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(func(*gorm.DB) error))
 	})
 	return _c
 }
 
-func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) Return(_a0 error) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
-	_c.Call.Return(_a0)
+func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) Return(_a0 string, _a1 error) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) error) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
+func (_c *MockanalysisUpdater_deleteAnalysisRequestById_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, func(*gorm.DB) error) (string, error)) *MockanalysisUpdater_deleteAnalysisRequestById_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// updateScore provides a mock function with given fields: id, score
-func (_m *MockanalysisUpdater) updateScore(id uuid.UUID, score float32) error {
-	ret := _m.Called(id, score)
+// overrideScore provides a mock function with given fields: uid, aid, score, reason
+func (_m *MockanalysisUpdater) overrideScore(uid uuid.UUID, aid uuid.UUID, score float32, reason string) error {
+	ret := _m.Called(uid, aid, score, reason)
 
 	if len(ret) == 0 {
-		panic("no return value specified for updateScore")
+		panic("no return value specified for overrideScore")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, float32) error); ok {
-		r0 = rf(id, score)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, float32, string) error); ok {
+		r0 = rf(uid, aid, score, reason)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -85,31 +97,81 @@ func (_m *MockanalysisUpdater) updateScore(id uuid.UUID, score float32) error {
 	return r0
 }
 
-// MockanalysisUpdater_updateScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateScore'
-type MockanalysisUpdater_updateScore_Call struct {
+// MockanalysisUpdater_overrideScore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'overrideScore'
+type MockanalysisUpdater_overrideScore_Call struct {
 	*mock.Call
 }
 
-// updateScore is a helper method to define mock.On call
-//   - id uuid.UUID
+// overrideScore is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - aid uuid.UUID
 //   - score float32
-func (_e *MockanalysisUpdater_Expecter) updateScore(id interface{}, score interface{}) *MockanalysisUpdater_updateScore_Call {
-	return &MockanalysisUpdater_updateScore_Call{Call: _e.mock.On("updateScore", id, score)}
+//   - reason string
+func (_e *MockanalysisUpdater_Expecter) overrideScore(uid interface{}, aid interface{}, score interface{}, reason interface{}) *MockanalysisUpdater_overrideScore_Call {
+	return &MockanalysisUpdater_overrideScore_Call{Call: _e.mock.On("overrideScore", uid, aid, score, reason)}
+}
+
+func (_c *MockanalysisUpdater_overrideScore_Call) Run(run func(uid uuid.UUID, aid uuid.UUID, score float32, reason string)) *MockanalysisUpdater_overrideScore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(float32), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockanalysisUpdater_overrideScore_Call) Return(_a0 error) *MockanalysisUpdater_overrideScore_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockanalysisUpdater_overrideScore_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, float32, string) error) *MockanalysisUpdater_overrideScore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// revertScoreOverride provides a mock function with given fields: uid, aid, reason
+func (_m *MockanalysisUpdater) revertScoreOverride(uid uuid.UUID, aid uuid.UUID, reason string) error {
+	ret := _m.Called(uid, aid, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for revertScoreOverride")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, string) error); ok {
+		r0 = rf(uid, aid, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockanalysisUpdater_revertScoreOverride_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'revertScoreOverride'
+type MockanalysisUpdater_revertScoreOverride_Call struct {
+	*mock.Call
+}
+
+// revertScoreOverride is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - aid uuid.UUID
+//   - reason string
+func (_e *MockanalysisUpdater_Expecter) revertScoreOverride(uid interface{}, aid interface{}, reason interface{}) *MockanalysisUpdater_revertScoreOverride_Call {
+	return &MockanalysisUpdater_revertScoreOverride_Call{Call: _e.mock.On("revertScoreOverride", uid, aid, reason)}
 }
 
-func (_c *MockanalysisUpdater_updateScore_Call) Run(run func(id uuid.UUID, score float32)) *MockanalysisUpdater_updateScore_Call {
+func (_c *MockanalysisUpdater_revertScoreOverride_Call) Run(run func(uid uuid.UUID, aid uuid.UUID, reason string)) *MockanalysisUpdater_revertScoreOverride_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(float32))
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(string))
 	})
 	return _c
 }
 
-func (_c *MockanalysisUpdater_updateScore_Call) Return(_a0 error) *MockanalysisUpdater_updateScore_Call {
+func (_c *MockanalysisUpdater_revertScoreOverride_Call) Return(_a0 error) *MockanalysisUpdater_revertScoreOverride_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockanalysisUpdater_updateScore_Call) RunAndReturn(run func(uuid.UUID, float32) error) *MockanalysisUpdater_updateScore_Call {
+func (_c *MockanalysisUpdater_revertScoreOverride_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, string) error) *MockanalysisUpdater_revertScoreOverride_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,69 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockanalyzeAllocator is an autogenerated mock type for the analyzeAllocator type
+type MockanalyzeAllocator struct {
+	mock.Mock
+}
+
+type MockanalyzeAllocator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockanalyzeAllocator) EXPECT() *MockanalyzeAllocator_Expecter {
+	return &MockanalyzeAllocator_Expecter{mock: &_m.Mock}
+}
+
+// allocateAnalyzeJobs provides a mock function with given fields: ai, text
+func (_m *MockanalyzeAllocator) allocateAnalyzeJobs(ai uuid.UUID, text string) {
+	_m.Called(ai, text)
+}
+
+// MockanalyzeAllocator_allocateAnalyzeJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'allocateAnalyzeJobs'
+type MockanalyzeAllocator_allocateAnalyzeJobs_Call struct {
+	*mock.Call
+}
+
+// allocateAnalyzeJobs is a helper method to define mock.On call
+//   - ai uuid.UUID
+//   - text string
+func (_e *MockanalyzeAllocator_Expecter) allocateAnalyzeJobs(ai interface{}, text interface{}) *MockanalyzeAllocator_allocateAnalyzeJobs_Call {
+	return &MockanalyzeAllocator_allocateAnalyzeJobs_Call{Call: _e.mock.On("allocateAnalyzeJobs", ai, text)}
+}
+
+func (_c *MockanalyzeAllocator_allocateAnalyzeJobs_Call) Run(run func(ai uuid.UUID, text string)) *MockanalyzeAllocator_allocateAnalyzeJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockanalyzeAllocator_allocateAnalyzeJobs_Call) Return() *MockanalyzeAllocator_allocateAnalyzeJobs_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockanalyzeAllocator_allocateAnalyzeJobs_Call) RunAndReturn(run func(uuid.UUID, string)) *MockanalyzeAllocator_allocateAnalyzeJobs_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockanalyzeAllocator creates a new instance of MockanalyzeAllocator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockanalyzeAllocator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockanalyzeAllocator {
+	mock := &MockanalyzeAllocator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,161 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	io "io"
+
+	analysisrequest "github.com/guardlight/server/pkg/analysisrequest"
+
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockbatchRequester is an autogenerated mock type for the batchRequester type
+type MockbatchRequester struct {
+	mock.Mock
+}
+
+type MockbatchRequester_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockbatchRequester) EXPECT() *MockbatchRequester_Expecter {
+	return &MockbatchRequester_Expecter{mock: &_m.Mock}
+}
+
+// RequestAnalysis provides a mock function with given fields: arDto, ui, requestOrigin
+func (_m *MockbatchRequester) RequestAnalysis(arDto *analysisrequest.AnalysisRequest, ui uuid.UUID, requestOrigin string) (uuid.UUID, error) {
+	ret := _m.Called(arDto, ui, requestOrigin)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestAnalysis")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*analysisrequest.AnalysisRequest, uuid.UUID, string) (uuid.UUID, error)); ok {
+		return rf(arDto, ui, requestOrigin)
+	}
+	if rf, ok := ret.Get(0).(func(*analysisrequest.AnalysisRequest, uuid.UUID, string) uuid.UUID); ok {
+		r0 = rf(arDto, ui, requestOrigin)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*analysisrequest.AnalysisRequest, uuid.UUID, string) error); ok {
+		r1 = rf(arDto, ui, requestOrigin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockbatchRequester_RequestAnalysis_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequestAnalysis'
+type MockbatchRequester_RequestAnalysis_Call struct {
+	*mock.Call
+}
+
+// RequestAnalysis is a helper method to define mock.On call
+//   - arDto *analysisrequest.AnalysisRequest
+//   - ui uuid.UUID
+//   - requestOrigin string
+func (_e *MockbatchRequester_Expecter) RequestAnalysis(arDto interface{}, ui interface{}, requestOrigin interface{}) *MockbatchRequester_RequestAnalysis_Call {
+	return &MockbatchRequester_RequestAnalysis_Call{Call: _e.mock.On("RequestAnalysis", arDto, ui, requestOrigin)}
+}
+
+func (_c *MockbatchRequester_RequestAnalysis_Call) Run(run func(arDto *analysisrequest.AnalysisRequest, ui uuid.UUID, requestOrigin string)) *MockbatchRequester_RequestAnalysis_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*analysisrequest.AnalysisRequest), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockbatchRequester_RequestAnalysis_Call) Return(_a0 uuid.UUID, _a1 error) *MockbatchRequester_RequestAnalysis_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockbatchRequester_RequestAnalysis_Call) RunAndReturn(run func(*analysisrequest.AnalysisRequest, uuid.UUID, string) (uuid.UUID, error)) *MockbatchRequester_RequestAnalysis_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequestAnalysisUpload provides a mock function with given fields: arDto, r, ui, requestOrigin
+func (_m *MockbatchRequester) RequestAnalysisUpload(arDto *analysisrequest.AnalysisRequest, r io.Reader, ui uuid.UUID, requestOrigin string) (uuid.UUID, error) {
+	ret := _m.Called(arDto, r, ui, requestOrigin)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestAnalysisUpload")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*analysisrequest.AnalysisRequest, io.Reader, uuid.UUID, string) (uuid.UUID, error)); ok {
+		return rf(arDto, r, ui, requestOrigin)
+	}
+	if rf, ok := ret.Get(0).(func(*analysisrequest.AnalysisRequest, io.Reader, uuid.UUID, string) uuid.UUID); ok {
+		r0 = rf(arDto, r, ui, requestOrigin)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*analysisrequest.AnalysisRequest, io.Reader, uuid.UUID, string) error); ok {
+		r1 = rf(arDto, r, ui, requestOrigin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockbatchRequester_RequestAnalysisUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequestAnalysisUpload'
+type MockbatchRequester_RequestAnalysisUpload_Call struct {
+	*mock.Call
+}
+
+// RequestAnalysisUpload is a helper method to define mock.On call
+//   - arDto *analysisrequest.AnalysisRequest
+//   - r io.Reader
+//   - ui uuid.UUID
+//   - requestOrigin string
+func (_e *MockbatchRequester_Expecter) RequestAnalysisUpload(arDto interface{}, r interface{}, ui interface{}, requestOrigin interface{}) *MockbatchRequester_RequestAnalysisUpload_Call {
+	return &MockbatchRequester_RequestAnalysisUpload_Call{Call: _e.mock.On("RequestAnalysisUpload", arDto, r, ui, requestOrigin)}
+}
+
+func (_c *MockbatchRequester_RequestAnalysisUpload_Call) Run(run func(arDto *analysisrequest.AnalysisRequest, r io.Reader, ui uuid.UUID, requestOrigin string)) *MockbatchRequester_RequestAnalysisUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*analysisrequest.AnalysisRequest), args[1].(io.Reader), args[2].(uuid.UUID), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *MockbatchRequester_RequestAnalysisUpload_Call) Return(_a0 uuid.UUID, _a1 error) *MockbatchRequester_RequestAnalysisUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockbatchRequester_RequestAnalysisUpload_Call) RunAndReturn(run func(*analysisrequest.AnalysisRequest, io.Reader, uuid.UUID, string) (uuid.UUID, error)) *MockbatchRequester_RequestAnalysisUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockbatchRequester creates a new instance of MockbatchRequester. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockbatchRequester(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockbatchRequester {
+	mock := &MockbatchRequester{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
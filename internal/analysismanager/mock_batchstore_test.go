@@ -0,0 +1,196 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockbatchStore is an autogenerated mock type for the batchStore type
+type MockbatchStore struct {
+	mock.Mock
+}
+
+type MockbatchStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockbatchStore) EXPECT() *MockbatchStore_Expecter {
+	return &MockbatchStore_Expecter{mock: &_m.Mock}
+}
+
+// createBatch provides a mock function with given fields: b
+func (_m *MockbatchStore) createBatch(b *Batch) error {
+	ret := _m.Called(b)
+
+	if len(ret) == 0 {
+		panic("no return value specified for createBatch")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*Batch) error); ok {
+		r0 = rf(b)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockbatchStore_createBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'createBatch'
+type MockbatchStore_createBatch_Call struct {
+	*mock.Call
+}
+
+// createBatch is a helper method to define mock.On call
+//   - b *Batch
+func (_e *MockbatchStore_Expecter) createBatch(b interface{}) *MockbatchStore_createBatch_Call {
+	return &MockbatchStore_createBatch_Call{Call: _e.mock.On("createBatch", b)}
+}
+
+func (_c *MockbatchStore_createBatch_Call) Run(run func(b *Batch)) *MockbatchStore_createBatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*Batch))
+	})
+	return _c
+}
+
+func (_c *MockbatchStore_createBatch_Call) Return(_a0 error) *MockbatchStore_createBatch_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockbatchStore_createBatch_Call) RunAndReturn(run func(*Batch) error) *MockbatchStore_createBatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getAnalysesByAnalysisRequestIds provides a mock function with given fields: arids
+func (_m *MockbatchStore) getAnalysesByAnalysisRequestIds(arids []uuid.UUID) ([]Analysis, error) {
+	ret := _m.Called(arids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getAnalysesByAnalysisRequestIds")
+	}
+
+	var r0 []Analysis
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID) ([]Analysis, error)); ok {
+		return rf(arids)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID) []Analysis); ok {
+		r0 = rf(arids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Analysis)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID) error); ok {
+		r1 = rf(arids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockbatchStore_getAnalysesByAnalysisRequestIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getAnalysesByAnalysisRequestIds'
+type MockbatchStore_getAnalysesByAnalysisRequestIds_Call struct {
+	*mock.Call
+}
+
+// getAnalysesByAnalysisRequestIds is a helper method to define mock.On call
+//   - arids []uuid.UUID
+func (_e *MockbatchStore_Expecter) getAnalysesByAnalysisRequestIds(arids interface{}) *MockbatchStore_getAnalysesByAnalysisRequestIds_Call {
+	return &MockbatchStore_getAnalysesByAnalysisRequestIds_Call{Call: _e.mock.On("getAnalysesByAnalysisRequestIds", arids)}
+}
+
+func (_c *MockbatchStore_getAnalysesByAnalysisRequestIds_Call) Run(run func(arids []uuid.UUID)) *MockbatchStore_getAnalysesByAnalysisRequestIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockbatchStore_getAnalysesByAnalysisRequestIds_Call) Return(_a0 []Analysis, _a1 error) *MockbatchStore_getAnalysesByAnalysisRequestIds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockbatchStore_getAnalysesByAnalysisRequestIds_Call) RunAndReturn(run func([]uuid.UUID) ([]Analysis, error)) *MockbatchStore_getAnalysesByAnalysisRequestIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getBatchByIdAndUserId provides a mock function with given fields: uid, bid
+func (_m *MockbatchStore) getBatchByIdAndUserId(uid uuid.UUID, bid uuid.UUID) (Batch, error) {
+	ret := _m.Called(uid, bid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getBatchByIdAndUserId")
+	}
+
+	var r0 Batch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) (Batch, error)); ok {
+		return rf(uid, bid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) Batch); ok {
+		r0 = rf(uid, bid)
+	} else {
+		r0 = ret.Get(0).(Batch)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(uid, bid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockbatchStore_getBatchByIdAndUserId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getBatchByIdAndUserId'
+type MockbatchStore_getBatchByIdAndUserId_Call struct {
+	*mock.Call
+}
+
+// getBatchByIdAndUserId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - bid uuid.UUID
+func (_e *MockbatchStore_Expecter) getBatchByIdAndUserId(uid interface{}, bid interface{}) *MockbatchStore_getBatchByIdAndUserId_Call {
+	return &MockbatchStore_getBatchByIdAndUserId_Call{Call: _e.mock.On("getBatchByIdAndUserId", uid, bid)}
+}
+
+func (_c *MockbatchStore_getBatchByIdAndUserId_Call) Run(run func(uid uuid.UUID, bid uuid.UUID)) *MockbatchStore_getBatchByIdAndUserId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockbatchStore_getBatchByIdAndUserId_Call) Return(_a0 Batch, _a1 error) *MockbatchStore_getBatchByIdAndUserId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockbatchStore_getBatchByIdAndUserId_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) (Batch, error)) *MockbatchStore_getBatchByIdAndUserId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockbatchStore creates a new instance of MockbatchStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockbatchStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockbatchStore {
+	mock := &MockbatchStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,79 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockcancelBroadcaster is an autogenerated mock type for the cancelBroadcaster type
+type MockcancelBroadcaster struct {
+	mock.Mock
+}
+
+type MockcancelBroadcaster_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockcancelBroadcaster) EXPECT() *MockcancelBroadcaster_Expecter {
+	return &MockcancelBroadcaster_Expecter{mock: &_m.Mock}
+}
+
+// Broadcast provides a mock function with given fields: topic, payload
+func (_m *MockcancelBroadcaster) Broadcast(topic string, payload interface{}) error {
+	ret := _m.Called(topic, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Broadcast")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}) error); ok {
+		r0 = rf(topic, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockcancelBroadcaster_Broadcast_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Broadcast'
+type MockcancelBroadcaster_Broadcast_Call struct {
+	*mock.Call
+}
+
+// Broadcast is a helper method to define mock.On call
+//   - topic string
+//   - payload interface{}
+func (_e *MockcancelBroadcaster_Expecter) Broadcast(topic interface{}, payload interface{}) *MockcancelBroadcaster_Broadcast_Call {
+	return &MockcancelBroadcaster_Broadcast_Call{Call: _e.mock.On("Broadcast", topic, payload)}
+}
+
+func (_c *MockcancelBroadcaster_Broadcast_Call) Run(run func(topic string, payload interface{})) *MockcancelBroadcaster_Broadcast_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(interface{}))
+	})
+	return _c
+}
+
+func (_c *MockcancelBroadcaster_Broadcast_Call) Return(_a0 error) *MockcancelBroadcaster_Broadcast_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockcancelBroadcaster_Broadcast_Call) RunAndReturn(run func(string, interface{}) error) *MockcancelBroadcaster_Broadcast_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockcancelBroadcaster creates a new instance of MockcancelBroadcaster. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockcancelBroadcaster(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockcancelBroadcaster {
+	mock := &MockcancelBroadcaster{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,81 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Mockcancellations is an autogenerated mock type for the cancellations type
+type Mockcancellations struct {
+	mock.Mock
+}
+
+type Mockcancellations_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Mockcancellations) EXPECT() *Mockcancellations_Expecter {
+	return &Mockcancellations_Expecter{mock: &_m.Mock}
+}
+
+// Cancelled provides a mock function with given fields: id
+func (_m *Mockcancellations) Cancelled(id uuid.UUID) bool {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Cancelled")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(uuid.UUID) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Mockcancellations_Cancelled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Cancelled'
+type Mockcancellations_Cancelled_Call struct {
+	*mock.Call
+}
+
+// Cancelled is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *Mockcancellations_Expecter) Cancelled(id interface{}) *Mockcancellations_Cancelled_Call {
+	return &Mockcancellations_Cancelled_Call{Call: _e.mock.On("Cancelled", id)}
+}
+
+func (_c *Mockcancellations_Cancelled_Call) Run(run func(id uuid.UUID)) *Mockcancellations_Cancelled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Mockcancellations_Cancelled_Call) Return(_a0 bool) *Mockcancellations_Cancelled_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Mockcancellations_Cancelled_Call) RunAndReturn(run func(uuid.UUID) bool) *Mockcancellations_Cancelled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockcancellations creates a new instance of Mockcancellations. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockcancellations(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Mockcancellations {
+	mock := &Mockcancellations{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockcontentRemover is an autogenerated mock type for the contentRemover type
+type MockcontentRemover struct {
+	mock.Mock
+}
+
+type MockcontentRemover_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockcontentRemover) EXPECT() *MockcontentRemover_Expecter {
+	return &MockcontentRemover_Expecter{mock: &_m.Mock}
+}
+
+// Delete provides a mock function with given fields: ctx, name
+func (_m *MockcontentRemover) Delete(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockcontentRemover_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockcontentRemover_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockcontentRemover_Expecter) Delete(ctx interface{}, name interface{}) *MockcontentRemover_Delete_Call {
+	return &MockcontentRemover_Delete_Call{Call: _e.mock.On("Delete", ctx, name)}
+}
+
+func (_c *MockcontentRemover_Delete_Call) Run(run func(ctx context.Context, name string)) *MockcontentRemover_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockcontentRemover_Delete_Call) Return(_a0 error) *MockcontentRemover_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockcontentRemover_Delete_Call) RunAndReturn(run func(context.Context, string) error) *MockcontentRemover_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockcontentRemover creates a new instance of MockcontentRemover. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockcontentRemover(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockcontentRemover {
+	mock := &MockcontentRemover{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
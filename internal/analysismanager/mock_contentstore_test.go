@@ -0,0 +1,132 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	context "context"
+	io "io"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockcontentStore is an autogenerated mock type for the contentStore type
+type MockcontentStore struct {
+	mock.Mock
+}
+
+type MockcontentStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockcontentStore) EXPECT() *MockcontentStore_Expecter {
+	return &MockcontentStore_Expecter{mock: &_m.Mock}
+}
+
+// Delete provides a mock function with given fields: ctx, name
+func (_m *MockcontentStore) Delete(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockcontentStore_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockcontentStore_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockcontentStore_Expecter) Delete(ctx interface{}, name interface{}) *MockcontentStore_Delete_Call {
+	return &MockcontentStore_Delete_Call{Call: _e.mock.On("Delete", ctx, name)}
+}
+
+func (_c *MockcontentStore_Delete_Call) Run(run func(ctx context.Context, name string)) *MockcontentStore_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockcontentStore_Delete_Call) Return(_a0 error) *MockcontentStore_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockcontentStore_Delete_Call) RunAndReturn(run func(context.Context, string) error) *MockcontentStore_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Put provides a mock function with given fields: ctx, name, r
+func (_m *MockcontentStore) Put(ctx context.Context, name string, r io.Reader) error {
+	ret := _m.Called(ctx, name, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Put")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader) error); ok {
+		r0 = rf(ctx, name, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockcontentStore_Put_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Put'
+type MockcontentStore_Put_Call struct {
+	*mock.Call
+}
+
+// Put is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - r io.Reader
+func (_e *MockcontentStore_Expecter) Put(ctx interface{}, name interface{}, r interface{}) *MockcontentStore_Put_Call {
+	return &MockcontentStore_Put_Call{Call: _e.mock.On("Put", ctx, name, r)}
+}
+
+func (_c *MockcontentStore_Put_Call) Run(run func(ctx context.Context, name string, r io.Reader)) *MockcontentStore_Put_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(io.Reader))
+	})
+	return _c
+}
+
+func (_c *MockcontentStore_Put_Call) Return(_a0 error) *MockcontentStore_Put_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockcontentStore_Put_Call) RunAndReturn(run func(context.Context, string, io.Reader) error) *MockcontentStore_Put_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockcontentStore creates a new instance of MockcontentStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockcontentStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockcontentStore {
+	mock := &MockcontentStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
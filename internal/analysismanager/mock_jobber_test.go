@@ -6,6 +6,8 @@ import (
 	jobmanager "github.com/guardlight/server/internal/jobmanager"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -69,17 +71,17 @@ func (_c *Mockjobber_CreateId_Call) RunAndReturn(run func() uuid.UUID) *Mockjobb
 	return _c
 }
 
-// EnqueueJob provides a mock function with given fields: id, jType, groupKey, data
-func (_m *Mockjobber) EnqueueJob(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}) error {
-	ret := _m.Called(id, jType, groupKey, data)
+// EnqueueJob provides a mock function with given fields: id, jType, groupKey, data, meta
+func (_m *Mockjobber) EnqueueJob(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}, meta jobmanager.JobMeta) error {
+	ret := _m.Called(id, jType, groupKey, data, meta)
 
 	if len(ret) == 0 {
 		panic("no return value specified for EnqueueJob")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, jobmanager.JobType, string, interface{}) error); ok {
-		r0 = rf(id, jType, groupKey, data)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, jobmanager.JobType, string, interface{}, jobmanager.JobMeta) error); ok {
+		r0 = rf(id, jType, groupKey, data, meta)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -97,13 +99,14 @@ type Mockjobber_EnqueueJob_Call struct {
 //   - jType jobmanager.JobType
 //   - groupKey string
 //   - data interface{}
-func (_e *Mockjobber_Expecter) EnqueueJob(id interface{}, jType interface{}, groupKey interface{}, data interface{}) *Mockjobber_EnqueueJob_Call {
-	return &Mockjobber_EnqueueJob_Call{Call: _e.mock.On("EnqueueJob", id, jType, groupKey, data)}
+//   - meta jobmanager.JobMeta
+func (_e *Mockjobber_Expecter) EnqueueJob(id interface{}, jType interface{}, groupKey interface{}, data interface{}, meta interface{}) *Mockjobber_EnqueueJob_Call {
+	return &Mockjobber_EnqueueJob_Call{Call: _e.mock.On("EnqueueJob", id, jType, groupKey, data, meta)}
 }
 
-func (_c *Mockjobber_EnqueueJob_Call) Run(run func(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{})) *Mockjobber_EnqueueJob_Call {
+func (_c *Mockjobber_EnqueueJob_Call) Run(run func(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}, meta jobmanager.JobMeta)) *Mockjobber_EnqueueJob_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(jobmanager.JobType), args[2].(string), args[3].(interface{}))
+		run(args[0].(uuid.UUID), args[1].(jobmanager.JobType), args[2].(string), args[3].(interface{}), args[4].(jobmanager.JobMeta))
 	})
 	return _c
 }
@@ -113,7 +116,119 @@ func (_c *Mockjobber_EnqueueJob_Call) Return(_a0 error) *Mockjobber_EnqueueJob_C
 	return _c
 }
 
-func (_c *Mockjobber_EnqueueJob_Call) RunAndReturn(run func(uuid.UUID, jobmanager.JobType, string, interface{}) error) *Mockjobber_EnqueueJob_Call {
+func (_c *Mockjobber_EnqueueJob_Call) RunAndReturn(run func(uuid.UUID, jobmanager.JobType, string, interface{}, jobmanager.JobMeta) error) *Mockjobber_EnqueueJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAverageJobDuration provides a mock function with given fields: groupKey
+func (_m *Mockjobber) GetAverageJobDuration(groupKey string) (time.Duration, error) {
+	ret := _m.Called(groupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAverageJobDuration")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (time.Duration, error)); ok {
+		return rf(groupKey)
+	}
+	if rf, ok := ret.Get(0).(func(string) time.Duration); ok {
+		r0 = rf(groupKey)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(groupKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Mockjobber_GetAverageJobDuration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAverageJobDuration'
+type Mockjobber_GetAverageJobDuration_Call struct {
+	*mock.Call
+}
+
+// GetAverageJobDuration is a helper method to define mock.On call
+//   - groupKey string
+func (_e *Mockjobber_Expecter) GetAverageJobDuration(groupKey interface{}) *Mockjobber_GetAverageJobDuration_Call {
+	return &Mockjobber_GetAverageJobDuration_Call{Call: _e.mock.On("GetAverageJobDuration", groupKey)}
+}
+
+func (_c *Mockjobber_GetAverageJobDuration_Call) Run(run func(groupKey string)) *Mockjobber_GetAverageJobDuration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *Mockjobber_GetAverageJobDuration_Call) Return(_a0 time.Duration, _a1 error) *Mockjobber_GetAverageJobDuration_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Mockjobber_GetAverageJobDuration_Call) RunAndReturn(run func(string) (time.Duration, error)) *Mockjobber_GetAverageJobDuration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsInprogress provides a mock function with given fields: id
+func (_m *Mockjobber) IsInprogress(id uuid.UUID) (bool, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsInprogress")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (bool, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Mockjobber_IsInprogress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsInprogress'
+type Mockjobber_IsInprogress_Call struct {
+	*mock.Call
+}
+
+// IsInprogress is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *Mockjobber_Expecter) IsInprogress(id interface{}) *Mockjobber_IsInprogress_Call {
+	return &Mockjobber_IsInprogress_Call{Call: _e.mock.On("IsInprogress", id)}
+}
+
+func (_c *Mockjobber_IsInprogress_Call) Run(run func(id uuid.UUID)) *Mockjobber_IsInprogress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Mockjobber_IsInprogress_Call) Return(_a0 bool, _a1 error) *Mockjobber_IsInprogress_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Mockjobber_IsInprogress_Call) RunAndReturn(run func(uuid.UUID) (bool, error)) *Mockjobber_IsInprogress_Call {
 	_c.Call.Return(run)
 	return _c
 }
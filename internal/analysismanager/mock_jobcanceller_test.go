@@ -0,0 +1,154 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+	gorm "gorm.io/gorm"
+)
+
+// MockjobCanceller is an autogenerated mock type for the jobCanceller type
+type MockjobCanceller struct {
+	mock.Mock
+}
+
+type MockjobCanceller_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockjobCanceller) EXPECT() *MockjobCanceller_Expecter {
+	return &MockjobCanceller_Expecter{mock: &_m.Mock}
+}
+
+// CancelJobsByAnalysisIds provides a mock function with given fields: tx, aids
+func (_m *MockjobCanceller) CancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(tx, aids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelJobsByAnalysisIds")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gorm.DB, []uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(tx, aids)
+	}
+	if rf, ok := ret.Get(0).(func(*gorm.DB, []uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(tx, aids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gorm.DB, []uuid.UUID) error); ok {
+		r1 = rf(tx, aids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobCanceller_CancelJobsByAnalysisIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelJobsByAnalysisIds'
+type MockjobCanceller_CancelJobsByAnalysisIds_Call struct {
+	*mock.Call
+}
+
+// CancelJobsByAnalysisIds is a helper method to define mock.On call
+//   - tx *gorm.DB
+//   - aids []uuid.UUID
+func (_e *MockjobCanceller_Expecter) CancelJobsByAnalysisIds(tx interface{}, aids interface{}) *MockjobCanceller_CancelJobsByAnalysisIds_Call {
+	return &MockjobCanceller_CancelJobsByAnalysisIds_Call{Call: _e.mock.On("CancelJobsByAnalysisIds", tx, aids)}
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisIds_Call) Run(run func(tx *gorm.DB, aids []uuid.UUID)) *MockjobCanceller_CancelJobsByAnalysisIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*gorm.DB), args[1].([]uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisIds_Call) Return(_a0 []uuid.UUID, _a1 error) *MockjobCanceller_CancelJobsByAnalysisIds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisIds_Call) RunAndReturn(run func(*gorm.DB, []uuid.UUID) ([]uuid.UUID, error)) *MockjobCanceller_CancelJobsByAnalysisIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CancelJobsByAnalysisRequestId provides a mock function with given fields: tx, arid
+func (_m *MockjobCanceller) CancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(tx, arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelJobsByAnalysisRequestId")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gorm.DB, uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(tx, arid)
+	}
+	if rf, ok := ret.Get(0).(func(*gorm.DB, uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(tx, arid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gorm.DB, uuid.UUID) error); ok {
+		r1 = rf(tx, arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobCanceller_CancelJobsByAnalysisRequestId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelJobsByAnalysisRequestId'
+type MockjobCanceller_CancelJobsByAnalysisRequestId_Call struct {
+	*mock.Call
+}
+
+// CancelJobsByAnalysisRequestId is a helper method to define mock.On call
+//   - tx *gorm.DB
+//   - arid uuid.UUID
+func (_e *MockjobCanceller_Expecter) CancelJobsByAnalysisRequestId(tx interface{}, arid interface{}) *MockjobCanceller_CancelJobsByAnalysisRequestId_Call {
+	return &MockjobCanceller_CancelJobsByAnalysisRequestId_Call{Call: _e.mock.On("CancelJobsByAnalysisRequestId", tx, arid)}
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisRequestId_Call) Run(run func(tx *gorm.DB, arid uuid.UUID)) *MockjobCanceller_CancelJobsByAnalysisRequestId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*gorm.DB), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisRequestId_Call) Return(_a0 []uuid.UUID, _a1 error) *MockjobCanceller_CancelJobsByAnalysisRequestId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobCanceller_CancelJobsByAnalysisRequestId_Call) RunAndReturn(run func(*gorm.DB, uuid.UUID) ([]uuid.UUID, error)) *MockjobCanceller_CancelJobsByAnalysisRequestId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockjobCanceller creates a new instance of MockjobCanceller. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockjobCanceller(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockjobCanceller {
+	mock := &MockjobCanceller{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,153 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	jobmanager "github.com/guardlight/server/internal/jobmanager"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockjobHistoryGetter is an autogenerated mock type for the jobHistoryGetter type
+type MockjobHistoryGetter struct {
+	mock.Mock
+}
+
+type MockjobHistoryGetter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockjobHistoryGetter) EXPECT() *MockjobHistoryGetter_Expecter {
+	return &MockjobHistoryGetter_Expecter{mock: &_m.Mock}
+}
+
+// GetAverageJobDuration provides a mock function with given fields: groupKey
+func (_m *MockjobHistoryGetter) GetAverageJobDuration(groupKey string) (time.Duration, error) {
+	ret := _m.Called(groupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAverageJobDuration")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (time.Duration, error)); ok {
+		return rf(groupKey)
+	}
+	if rf, ok := ret.Get(0).(func(string) time.Duration); ok {
+		r0 = rf(groupKey)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(groupKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobHistoryGetter_GetAverageJobDuration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAverageJobDuration'
+type MockjobHistoryGetter_GetAverageJobDuration_Call struct {
+	*mock.Call
+}
+
+// GetAverageJobDuration is a helper method to define mock.On call
+//   - groupKey string
+func (_e *MockjobHistoryGetter_Expecter) GetAverageJobDuration(groupKey interface{}) *MockjobHistoryGetter_GetAverageJobDuration_Call {
+	return &MockjobHistoryGetter_GetAverageJobDuration_Call{Call: _e.mock.On("GetAverageJobDuration", groupKey)}
+}
+
+func (_c *MockjobHistoryGetter_GetAverageJobDuration_Call) Run(run func(groupKey string)) *MockjobHistoryGetter_GetAverageJobDuration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobHistoryGetter_GetAverageJobDuration_Call) Return(_a0 time.Duration, _a1 error) *MockjobHistoryGetter_GetAverageJobDuration_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobHistoryGetter_GetAverageJobDuration_Call) RunAndReturn(run func(string) (time.Duration, error)) *MockjobHistoryGetter_GetAverageJobDuration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetJobTimeline provides a mock function with given fields: arid
+func (_m *MockjobHistoryGetter) GetJobTimeline(arid uuid.UUID) ([]jobmanager.JobTimeline, error) {
+	ret := _m.Called(arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJobTimeline")
+	}
+
+	var r0 []jobmanager.JobTimeline
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]jobmanager.JobTimeline, error)); ok {
+		return rf(arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []jobmanager.JobTimeline); ok {
+		r0 = rf(arid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]jobmanager.JobTimeline)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobHistoryGetter_GetJobTimeline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetJobTimeline'
+type MockjobHistoryGetter_GetJobTimeline_Call struct {
+	*mock.Call
+}
+
+// GetJobTimeline is a helper method to define mock.On call
+//   - arid uuid.UUID
+func (_e *MockjobHistoryGetter_Expecter) GetJobTimeline(arid interface{}) *MockjobHistoryGetter_GetJobTimeline_Call {
+	return &MockjobHistoryGetter_GetJobTimeline_Call{Call: _e.mock.On("GetJobTimeline", arid)}
+}
+
+func (_c *MockjobHistoryGetter_GetJobTimeline_Call) Run(run func(arid uuid.UUID)) *MockjobHistoryGetter_GetJobTimeline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobHistoryGetter_GetJobTimeline_Call) Return(_a0 []jobmanager.JobTimeline, _a1 error) *MockjobHistoryGetter_GetJobTimeline_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobHistoryGetter_GetJobTimeline_Call) RunAndReturn(run func(uuid.UUID) ([]jobmanager.JobTimeline, error)) *MockjobHistoryGetter_GetJobTimeline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockjobHistoryGetter creates a new instance of MockjobHistoryGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockjobHistoryGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockjobHistoryGetter {
+	mock := &MockjobHistoryGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
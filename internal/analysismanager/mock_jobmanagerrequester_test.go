@@ -69,17 +69,17 @@ func (_c *MockjobManagerRequester_CreateId_Call) RunAndReturn(run func() uuid.UU
 	return _c
 }
 
-// EnqueueJob provides a mock function with given fields: id, jType, groupKey, data
-func (_m *MockjobManagerRequester) EnqueueJob(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}) error {
-	ret := _m.Called(id, jType, groupKey, data)
+// EnqueueJob provides a mock function with given fields: id, jType, groupKey, data, meta
+func (_m *MockjobManagerRequester) EnqueueJob(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}, meta jobmanager.JobMeta) error {
+	ret := _m.Called(id, jType, groupKey, data, meta)
 
 	if len(ret) == 0 {
 		panic("no return value specified for EnqueueJob")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, jobmanager.JobType, string, interface{}) error); ok {
-		r0 = rf(id, jType, groupKey, data)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, jobmanager.JobType, string, interface{}, jobmanager.JobMeta) error); ok {
+		r0 = rf(id, jType, groupKey, data, meta)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -97,13 +97,14 @@ type MockjobManagerRequester_EnqueueJob_Call struct {
 //   - jType jobmanager.JobType
 //   - groupKey string
 //   - data interface{}
-func (_e *MockjobManagerRequester_Expecter) EnqueueJob(id interface{}, jType interface{}, groupKey interface{}, data interface{}) *MockjobManagerRequester_EnqueueJob_Call {
-	return &MockjobManagerRequester_EnqueueJob_Call{Call: _e.mock.On("EnqueueJob", id, jType, groupKey, data)}
+//   - meta jobmanager.JobMeta
+func (_e *MockjobManagerRequester_Expecter) EnqueueJob(id interface{}, jType interface{}, groupKey interface{}, data interface{}, meta interface{}) *MockjobManagerRequester_EnqueueJob_Call {
+	return &MockjobManagerRequester_EnqueueJob_Call{Call: _e.mock.On("EnqueueJob", id, jType, groupKey, data, meta)}
 }
 
-func (_c *MockjobManagerRequester_EnqueueJob_Call) Run(run func(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{})) *MockjobManagerRequester_EnqueueJob_Call {
+func (_c *MockjobManagerRequester_EnqueueJob_Call) Run(run func(id uuid.UUID, jType jobmanager.JobType, groupKey string, data interface{}, meta jobmanager.JobMeta)) *MockjobManagerRequester_EnqueueJob_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(jobmanager.JobType), args[2].(string), args[3].(interface{}))
+		run(args[0].(uuid.UUID), args[1].(jobmanager.JobType), args[2].(string), args[3].(interface{}), args[4].(jobmanager.JobMeta))
 	})
 	return _c
 }
@@ -113,7 +114,7 @@ func (_c *MockjobManagerRequester_EnqueueJob_Call) Return(_a0 error) *MockjobMan
 	return _c
 }
 
-func (_c *MockjobManagerRequester_EnqueueJob_Call) RunAndReturn(run func(uuid.UUID, jobmanager.JobType, string, interface{}) error) *MockjobManagerRequester_EnqueueJob_Call {
+func (_c *MockjobManagerRequester_EnqueueJob_Call) RunAndReturn(run func(uuid.UUID, jobmanager.JobType, string, interface{}, jobmanager.JobMeta) error) *MockjobManagerRequester_EnqueueJob_Call {
 	_c.Call.Return(run)
 	return _c
 }
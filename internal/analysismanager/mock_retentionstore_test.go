@@ -0,0 +1,214 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+	gorm "gorm.io/gorm"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockretentionStore is an autogenerated mock type for the retentionStore type
+type MockretentionStore struct {
+	mock.Mock
+}
+
+type MockretentionStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockretentionStore) EXPECT() *MockretentionStore_Expecter {
+	return &MockretentionStore_Expecter{mock: &_m.Mock}
+}
+
+// deleteExpiredAnalysisRequest provides a mock function with given fields: arid, cancelJobs
+func (_m *MockretentionStore) deleteExpiredAnalysisRequest(arid uuid.UUID, cancelJobs func(*gorm.DB) error) (string, error) {
+	ret := _m.Called(arid, cancelJobs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for deleteExpiredAnalysisRequest")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, func(*gorm.DB) error) (string, error)); ok {
+		return rf(arid, cancelJobs)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, func(*gorm.DB) error) string); ok {
+		r0 = rf(arid, cancelJobs)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, func(*gorm.DB) error) error); ok {
+		r1 = rf(arid, cancelJobs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockretentionStore_deleteExpiredAnalysisRequest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'deleteExpiredAnalysisRequest'
+type MockretentionStore_deleteExpiredAnalysisRequest_Call struct {
+	*mock.Call
+}
+
+// deleteExpiredAnalysisRequest is a helper method to define mock.On call
+//   - arid uuid.UUID
+//   - cancelJobs func(*gorm.DB) error
+func (_e *MockretentionStore_Expecter) deleteExpiredAnalysisRequest(arid interface{}, cancelJobs interface{}) *MockretentionStore_deleteExpiredAnalysisRequest_Call {
+	return &MockretentionStore_deleteExpiredAnalysisRequest_Call{Call: _e.mock.On("deleteExpiredAnalysisRequest", arid, cancelJobs)}
+}
+
+func (_c *MockretentionStore_deleteExpiredAnalysisRequest_Call) Run(run func(arid uuid.UUID, cancelJobs func(*gorm.DB) error)) *MockretentionStore_deleteExpiredAnalysisRequest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(func(*gorm.DB) error))
+	})
+	return _c
+}
+
+func (_c *MockretentionStore_deleteExpiredAnalysisRequest_Call) Return(_a0 string, _a1 error) *MockretentionStore_deleteExpiredAnalysisRequest_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockretentionStore_deleteExpiredAnalysisRequest_Call) RunAndReturn(run func(uuid.UUID, func(*gorm.DB) error) (string, error)) *MockretentionStore_deleteExpiredAnalysisRequest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getRetentionCandidates provides a mock function with given fields: r, cutoff, offset, limit
+func (_m *MockretentionStore) getRetentionCandidates(r retentionRule, cutoff time.Time, offset int, limit int) ([]AnalysisRequest, error) {
+	ret := _m.Called(r, cutoff, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getRetentionCandidates")
+	}
+
+	var r0 []AnalysisRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(retentionRule, time.Time, int, int) ([]AnalysisRequest, error)); ok {
+		return rf(r, cutoff, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(retentionRule, time.Time, int, int) []AnalysisRequest); ok {
+		r0 = rf(r, cutoff, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]AnalysisRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(retentionRule, time.Time, int, int) error); ok {
+		r1 = rf(r, cutoff, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockretentionStore_getRetentionCandidates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getRetentionCandidates'
+type MockretentionStore_getRetentionCandidates_Call struct {
+	*mock.Call
+}
+
+// getRetentionCandidates is a helper method to define mock.On call
+//   - r retentionRule
+//   - cutoff time.Time
+//   - offset int
+//   - limit int
+func (_e *MockretentionStore_Expecter) getRetentionCandidates(r interface{}, cutoff interface{}, offset interface{}, limit interface{}) *MockretentionStore_getRetentionCandidates_Call {
+	return &MockretentionStore_getRetentionCandidates_Call{Call: _e.mock.On("getRetentionCandidates", r, cutoff, offset, limit)}
+}
+
+func (_c *MockretentionStore_getRetentionCandidates_Call) Run(run func(r retentionRule, cutoff time.Time, offset int, limit int)) *MockretentionStore_getRetentionCandidates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(retentionRule), args[1].(time.Time), args[2].(int), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockretentionStore_getRetentionCandidates_Call) Return(_a0 []AnalysisRequest, _a1 error) *MockretentionStore_getRetentionCandidates_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockretentionStore_getRetentionCandidates_Call) RunAndReturn(run func(retentionRule, time.Time, int, int) ([]AnalysisRequest, error)) *MockretentionStore_getRetentionCandidates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// purgeRawData provides a mock function with given fields: arid, rule
+func (_m *MockretentionStore) purgeRawData(arid uuid.UUID, rule string) (string, error) {
+	ret := _m.Called(arid, rule)
+
+	if len(ret) == 0 {
+		panic("no return value specified for purgeRawData")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) (string, error)); ok {
+		return rf(arid, rule)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string) string); ok {
+		r0 = rf(arid, rule)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string) error); ok {
+		r1 = rf(arid, rule)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockretentionStore_purgeRawData_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'purgeRawData'
+type MockretentionStore_purgeRawData_Call struct {
+	*mock.Call
+}
+
+// purgeRawData is a helper method to define mock.On call
+//   - arid uuid.UUID
+//   - rule string
+func (_e *MockretentionStore_Expecter) purgeRawData(arid interface{}, rule interface{}) *MockretentionStore_purgeRawData_Call {
+	return &MockretentionStore_purgeRawData_Call{Call: _e.mock.On("purgeRawData", arid, rule)}
+}
+
+func (_c *MockretentionStore_purgeRawData_Call) Run(run func(arid uuid.UUID, rule string)) *MockretentionStore_purgeRawData_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockretentionStore_purgeRawData_Call) Return(_a0 string, _a1 error) *MockretentionStore_purgeRawData_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockretentionStore_purgeRawData_Call) RunAndReturn(run func(uuid.UUID, string) (string, error)) *MockretentionStore_purgeRawData_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockretentionStore creates a new instance of MockretentionStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockretentionStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockretentionStore {
+	mock := &MockretentionStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,364 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockstatsStore is an autogenerated mock type for the statsStore type
+type MockstatsStore struct {
+	mock.Mock
+}
+
+type MockstatsStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockstatsStore) EXPECT() *MockstatsStore_Expecter {
+	return &MockstatsStore_Expecter{mock: &_m.Mock}
+}
+
+// countRequestsBy provides a mock function with given fields: uid, d
+func (_m *MockstatsStore) countRequestsBy(uid uuid.UUID, d statsDimension) ([]statsCount, error) {
+	ret := _m.Called(uid, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for countRequestsBy")
+	}
+
+	var r0 []statsCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, statsDimension) ([]statsCount, error)); ok {
+		return rf(uid, d)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, statsDimension) []statsCount); ok {
+		r0 = rf(uid, d)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]statsCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, statsDimension) error); ok {
+		r1 = rf(uid, d)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockstatsStore_countRequestsBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'countRequestsBy'
+type MockstatsStore_countRequestsBy_Call struct {
+	*mock.Call
+}
+
+// countRequestsBy is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - d statsDimension
+func (_e *MockstatsStore_Expecter) countRequestsBy(uid interface{}, d interface{}) *MockstatsStore_countRequestsBy_Call {
+	return &MockstatsStore_countRequestsBy_Call{Call: _e.mock.On("countRequestsBy", uid, d)}
+}
+
+func (_c *MockstatsStore_countRequestsBy_Call) Run(run func(uid uuid.UUID, d statsDimension)) *MockstatsStore_countRequestsBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(statsDimension))
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_countRequestsBy_Call) Return(_a0 []statsCount, _a1 error) *MockstatsStore_countRequestsBy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockstatsStore_countRequestsBy_Call) RunAndReturn(run func(uuid.UUID, statsDimension) ([]statsCount, error)) *MockstatsStore_countRequestsBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getProcessingTimes provides a mock function with given fields: uid
+func (_m *MockstatsStore) getProcessingTimes(uid uuid.UUID) ([]statsProcessingRow, error) {
+	ret := _m.Called(uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getProcessingTimes")
+	}
+
+	var r0 []statsProcessingRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]statsProcessingRow, error)); ok {
+		return rf(uid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []statsProcessingRow); ok {
+		r0 = rf(uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]statsProcessingRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockstatsStore_getProcessingTimes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getProcessingTimes'
+type MockstatsStore_getProcessingTimes_Call struct {
+	*mock.Call
+}
+
+// getProcessingTimes is a helper method to define mock.On call
+//   - uid uuid.UUID
+func (_e *MockstatsStore_Expecter) getProcessingTimes(uid interface{}) *MockstatsStore_getProcessingTimes_Call {
+	return &MockstatsStore_getProcessingTimes_Call{Call: _e.mock.On("getProcessingTimes", uid)}
+}
+
+func (_c *MockstatsStore_getProcessingTimes_Call) Run(run func(uid uuid.UUID)) *MockstatsStore_getProcessingTimes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_getProcessingTimes_Call) Return(_a0 []statsProcessingRow, _a1 error) *MockstatsStore_getProcessingTimes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockstatsStore_getProcessingTimes_Call) RunAndReturn(run func(uuid.UUID) ([]statsProcessingRow, error)) *MockstatsStore_getProcessingTimes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getTopTermsByTheme provides a mock function with given fields: uid, n
+func (_m *MockstatsStore) getTopTermsByTheme(uid uuid.UUID, n int) ([]statsTermRow, error) {
+	ret := _m.Called(uid, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getTopTermsByTheme")
+	}
+
+	var r0 []statsTermRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) ([]statsTermRow, error)); ok {
+		return rf(uid, n)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, int) []statsTermRow); ok {
+		r0 = rf(uid, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]statsTermRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, int) error); ok {
+		r1 = rf(uid, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockstatsStore_getTopTermsByTheme_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getTopTermsByTheme'
+type MockstatsStore_getTopTermsByTheme_Call struct {
+	*mock.Call
+}
+
+// getTopTermsByTheme is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - n int
+func (_e *MockstatsStore_Expecter) getTopTermsByTheme(uid interface{}, n interface{}) *MockstatsStore_getTopTermsByTheme_Call {
+	return &MockstatsStore_getTopTermsByTheme_Call{Call: _e.mock.On("getTopTermsByTheme", uid, n)}
+}
+
+func (_c *MockstatsStore_getTopTermsByTheme_Call) Run(run func(uid uuid.UUID, n int)) *MockstatsStore_getTopTermsByTheme_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_getTopTermsByTheme_Call) Return(_a0 []statsTermRow, _a1 error) *MockstatsStore_getTopTermsByTheme_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockstatsStore_getTopTermsByTheme_Call) RunAndReturn(run func(uuid.UUID, int) ([]statsTermRow, error)) *MockstatsStore_getTopTermsByTheme_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getVerdictTrend provides a mock function with given fields: uid, bucket, since
+func (_m *MockstatsStore) getVerdictTrend(uid uuid.UUID, bucket string, since time.Time) ([]statsTrendRow, error) {
+	ret := _m.Called(uid, bucket, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getVerdictTrend")
+	}
+
+	var r0 []statsTrendRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, time.Time) ([]statsTrendRow, error)); ok {
+		return rf(uid, bucket, since)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, string, time.Time) []statsTrendRow); ok {
+		r0 = rf(uid, bucket, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]statsTrendRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, string, time.Time) error); ok {
+		r1 = rf(uid, bucket, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockstatsStore_getVerdictTrend_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getVerdictTrend'
+type MockstatsStore_getVerdictTrend_Call struct {
+	*mock.Call
+}
+
+// getVerdictTrend is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - bucket string
+//   - since time.Time
+func (_e *MockstatsStore_Expecter) getVerdictTrend(uid interface{}, bucket interface{}, since interface{}) *MockstatsStore_getVerdictTrend_Call {
+	return &MockstatsStore_getVerdictTrend_Call{Call: _e.mock.On("getVerdictTrend", uid, bucket, since)}
+}
+
+func (_c *MockstatsStore_getVerdictTrend_Call) Run(run func(uid uuid.UUID, bucket string, since time.Time)) *MockstatsStore_getVerdictTrend_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_getVerdictTrend_Call) Return(_a0 []statsTrendRow, _a1 error) *MockstatsStore_getVerdictTrend_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockstatsStore_getVerdictTrend_Call) RunAndReturn(run func(uuid.UUID, string, time.Time) ([]statsTrendRow, error)) *MockstatsStore_getVerdictTrend_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// refreshStatsRollup provides a mock function with no fields
+func (_m *MockstatsStore) refreshStatsRollup() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for refreshStatsRollup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockstatsStore_refreshStatsRollup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'refreshStatsRollup'
+type MockstatsStore_refreshStatsRollup_Call struct {
+	*mock.Call
+}
+
+// refreshStatsRollup is a helper method to define mock.On call
+func (_e *MockstatsStore_Expecter) refreshStatsRollup() *MockstatsStore_refreshStatsRollup_Call {
+	return &MockstatsStore_refreshStatsRollup_Call{Call: _e.mock.On("refreshStatsRollup")}
+}
+
+func (_c *MockstatsStore_refreshStatsRollup_Call) Run(run func()) *MockstatsStore_refreshStatsRollup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_refreshStatsRollup_Call) Return(_a0 error) *MockstatsStore_refreshStatsRollup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockstatsStore_refreshStatsRollup_Call) RunAndReturn(run func() error) *MockstatsStore_refreshStatsRollup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// usesStatsRollup provides a mock function with no fields
+func (_m *MockstatsStore) usesStatsRollup() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for usesStatsRollup")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockstatsStore_usesStatsRollup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'usesStatsRollup'
+type MockstatsStore_usesStatsRollup_Call struct {
+	*mock.Call
+}
+
+// usesStatsRollup is a helper method to define mock.On call
+func (_e *MockstatsStore_Expecter) usesStatsRollup() *MockstatsStore_usesStatsRollup_Call {
+	return &MockstatsStore_usesStatsRollup_Call{Call: _e.mock.On("usesStatsRollup")}
+}
+
+func (_c *MockstatsStore_usesStatsRollup_Call) Run(run func()) *MockstatsStore_usesStatsRollup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockstatsStore_usesStatsRollup_Call) Return(_a0 bool) *MockstatsStore_usesStatsRollup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockstatsStore_usesStatsRollup_Call) RunAndReturn(run func() bool) *MockstatsStore_usesStatsRollup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockstatsStore creates a new instance of MockstatsStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockstatsStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockstatsStore {
+	mock := &MockstatsStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
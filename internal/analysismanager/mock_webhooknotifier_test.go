@@ -0,0 +1,71 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package analysismanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+
+	webhook "github.com/guardlight/server/internal/webhook"
+)
+
+// MockwebhookNotifier is an autogenerated mock type for the webhookNotifier type
+type MockwebhookNotifier struct {
+	mock.Mock
+}
+
+type MockwebhookNotifier_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockwebhookNotifier) EXPECT() *MockwebhookNotifier_Expecter {
+	return &MockwebhookNotifier_Expecter{mock: &_m.Mock}
+}
+
+// Notify provides a mock function with given fields: userId, e
+func (_m *MockwebhookNotifier) Notify(userId uuid.UUID, e webhook.Event) {
+	_m.Called(userId, e)
+}
+
+// MockwebhookNotifier_Notify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Notify'
+type MockwebhookNotifier_Notify_Call struct {
+	*mock.Call
+}
+
+// Notify is a helper method to define mock.On call
+//   - userId uuid.UUID
+//   - e webhook.Event
+func (_e *MockwebhookNotifier_Expecter) Notify(userId interface{}, e interface{}) *MockwebhookNotifier_Notify_Call {
+	return &MockwebhookNotifier_Notify_Call{Call: _e.mock.On("Notify", userId, e)}
+}
+
+func (_c *MockwebhookNotifier_Notify_Call) Run(run func(userId uuid.UUID, e webhook.Event)) *MockwebhookNotifier_Notify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(webhook.Event))
+	})
+	return _c
+}
+
+func (_c *MockwebhookNotifier_Notify_Call) Return() *MockwebhookNotifier_Notify_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockwebhookNotifier_Notify_Call) RunAndReturn(run func(uuid.UUID, webhook.Event)) *MockwebhookNotifier_Notify_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockwebhookNotifier creates a new instance of MockwebhookNotifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockwebhookNotifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockwebhookNotifier {
+	mock := &MockwebhookNotifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
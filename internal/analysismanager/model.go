@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/guardlight/server/pkg/analyzercontract"
 )
 
 type AnalysisRequestStepType string
@@ -37,6 +39,17 @@ const (
 	AnalysisError      AnalysisStatus = "error"
 )
 
+// Verdict is the outcome of an analysis request over all of its themes.
+type Verdict string
+
+const (
+	VerdictPending     Verdict = "pending"
+	VerdictApproved    Verdict = "approved"
+	VerdictFlagged     Verdict = "flagged"
+	VerdictNeedsReview Verdict = "needs_review"
+	VerdictError       Verdict = "error"
+)
+
 type RequestOrigin string
 
 const (
@@ -46,6 +59,23 @@ const (
 	RequestOriginExternal RequestOrigin = "external"
 )
 
+// jobMeta returns the meta of the jobs of an analysis request. Requests a user
+// is waiting for go before bulk imports.
+func (ar AnalysisRequest) jobMeta() jobmanager.JobMeta {
+	p := jobmanager.PriorityNormal
+	switch RequestOrigin(ar.RequestOrigin) {
+	case RequestOriginUser:
+		p = jobmanager.PriorityInteractive
+	case RequestOriginDataloom:
+		p = jobmanager.PriorityBackfill
+	}
+	return jobmanager.JobMeta{
+		UserId:            ar.UserId,
+		AnalysisRequestId: ar.Id,
+		Priority:          p,
+	}
+}
+
 type AnalysisRequest struct {
 	Id            uuid.UUID  `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
 	UserId        uuid.UUID  `gorm:"column:user_id"`
@@ -53,20 +83,74 @@ type AnalysisRequest struct {
 	Category      string     `gorm:"column:category"`
 	Title         string     `gorm:"column:title"`
 	ContentType   string     `gorm:"column:content_type"`
+	Verdict       Verdict    `gorm:"column:verdict;index;default:pending"`
 	RawData       RawData    `gorm:"foreignKey:AnalysisRequestId"`
 	Analysis      []Analysis `gorm:"foreignKey:AnalysisRequestId"`
-	CreatedAt     time.Time  `gorm:"column:created_at"`
+	// Analyses replaced by a rerun
+	History []AnalysisHistory `gorm:"foreignKey:AnalysisRequestId"`
+	// The overrides are an audit trail, they outlive the request
+	ScoreOverrides []ScoreOverride `gorm:"foreignKey:AnalysisRequestId;constraint:-"`
+	CreatedAt      time.Time       `gorm:"column:created_at"`
+	// When the report decided the verdict, nil while it is pending
+	FinishedAt *time.Time `gorm:"column:finished_at;index"`
 }
 
 type RawData struct {
 	Id                uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
 	AnalysisRequestId uuid.UUID `gorm:"column:analysis_request_id;primaryKey;type:uuid"`
-	Hash              string    `gorm:"column:hash"`
-	Content           []byte    `gorm:"column:content;type:bytea"`
-	FileType          string    `gorm:"column:file_type"`
-	ProcessedText     string    `gorm:"column:processed_text"`
+	Hash              string    `gorm:"column:hash;index"`
+	// Content and ProcessedText are encrypted at rest when encryption is enabled
+	Content []byte `gorm:"column:content;type:bytea;serializer:encrypted"`
+	// ContentRef names the uploaded content in the content store, the content
+	// column is empty then.
+	ContentRef    string `gorm:"column:content_ref"`
+	FileType      string `gorm:"column:file_type"`
+	ProcessedText string `gorm:"column:processed_text;serializer:encrypted"`
+	// Where the processed text was exported to, see RawDataManager
+	ExportState ExportState `gorm:"column:export_state;index;default:pending"`
+	ExportRef   string      `gorm:"column:export_ref"`
+	ExportedAt  *time.Time  `gorm:"column:exported_at"`
+	// The content and processed text were removed by the retention rule PurgedBy
+	PurgedAt *time.Time `gorm:"column:purged_at;index"`
+	PurgedBy string     `gorm:"column:purged_by"`
+	// Only loaded along with the text state, see preloadTextState
+	HasText bool `gorm:"->;-:migration;column:has_text"`
 }
 
+// TextState tells what happened to the processed text of a request.
+type TextState string
+
+const (
+	TextPending   TextState = "pending"
+	TextAvailable TextState = "available"
+	TextExported  TextState = "exported"
+	TextPurged    TextState = "purged"
+)
+
+func (rd RawData) textState() TextState {
+	switch {
+	case rd.PurgedAt != nil:
+		return TextPurged
+	case rd.HasText:
+		return TextAvailable
+	case rd.ExportState == ExportExported:
+		return TextExported
+	default:
+		return TextPending
+	}
+}
+
+type ExportState string
+
+const (
+	ExportPending  ExportState = "pending"
+	ExportExported ExportState = "exported"
+	// The text could not be encoded, it is not tried again
+	ExportFailed ExportState = "failed"
+)
+
+// Analysis is the result of an analyzer for a theme. Score only differs from
+// ReporterScore while it is overridden by hand.
 type Analysis struct {
 	Id                uuid.UUID      `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
 	AnalysisRequestId uuid.UUID      `gorm:"column:analysis_request_id;primaryKey;type:uuid"`
@@ -74,11 +158,79 @@ type Analysis struct {
 	ThemeId           uuid.UUID      `gorm:"column:theme_id"`
 	Status            AnalysisStatus `gorm:"column:status"`
 	Score             float32        `gorm:"column:score"`
+	ReporterScore     float32        `gorm:"column:reporter_score"`
+	Overridden        bool           `gorm:"column:overridden"`
 	Content           Content        `gorm:"column:content;type:jsonb"`
+	Findings          Findings       `gorm:"column:findings;type:jsonb;default:'[]'"`
 	Inputs            Inputs         `gorm:"column:inputs;type:jsonb"`
 	Jobs              JobsProgress   `gorm:"column:jobs;type:jsonb"`
 }
 
+// AnalysisHistory is an analysis that was replaced when its theme was rerun.
+type AnalysisHistory struct {
+	Id                uuid.UUID      `gorm:"column:id;primaryKey;type:uuid"`
+	AnalysisRequestId uuid.UUID      `gorm:"column:analysis_request_id;type:uuid;index"`
+	AnalyzerKey       string         `gorm:"column:analyzer_key"`
+	ThemeId           uuid.UUID      `gorm:"column:theme_id"`
+	Status            AnalysisStatus `gorm:"column:status"`
+	Score             float32        `gorm:"column:score"`
+	ReporterScore     float32        `gorm:"column:reporter_score"`
+	Overridden        bool           `gorm:"column:overridden"`
+	Content           Content        `gorm:"column:content;type:jsonb"`
+	Findings          Findings       `gorm:"column:findings;type:jsonb;default:'[]'"`
+	Inputs            Inputs         `gorm:"column:inputs;type:jsonb"`
+	Jobs              JobsProgress   `gorm:"column:jobs;type:jsonb"`
+	ReplacedAt        time.Time      `gorm:"column:replaced_at"`
+}
+
+// ScoreOverride records a manual change of the score of an analysis, or the
+// revert back to the score of the reporter.
+type ScoreOverride struct {
+	Id                uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	AnalysisId        uuid.UUID `gorm:"column:analysis_id;type:uuid;index"`
+	AnalysisRequestId uuid.UUID `gorm:"column:analysis_request_id;type:uuid;index"`
+	UserId            uuid.UUID `gorm:"column:user_id;type:uuid"`
+	ReporterScore     float32   `gorm:"column:reporter_score"`
+	PreviousScore     float32   `gorm:"column:previous_score"`
+	Score             float32   `gorm:"column:score"`
+	Reason            string    `gorm:"column:reason"`
+	Reverted          bool      `gorm:"column:reverted"`
+	CreatedAt         time.Time `gorm:"column:created_at"`
+}
+
+type BatchItemResult string
+
+const (
+	BatchItemCreated   BatchItemResult = "created"
+	BatchItemDuplicate BatchItemResult = "duplicate"
+	BatchItemRejected  BatchItemResult = "rejected"
+)
+
+// Batch groups the analysis requests submitted together.
+type Batch struct {
+	Id        uuid.UUID   `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserId    uuid.UUID   `gorm:"column:user_id;type:uuid;index"`
+	Items     []BatchItem `gorm:"foreignKey:BatchId;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time   `gorm:"column:created_at"`
+}
+
+// BatchItem is the outcome of a single submission of a batch. Rejected items
+// have no analysis request, duplicates refer to the existing one.
+type BatchItem struct {
+	Id                uuid.UUID       `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	BatchId           uuid.UUID       `gorm:"column:batch_id;type:uuid;index"`
+	Position          int             `gorm:"column:position"`
+	Title             string          `gorm:"column:title"`
+	AnalysisRequestId *uuid.UUID      `gorm:"column:analysis_request_id;type:uuid"`
+	Result            BatchItemResult `gorm:"column:result"`
+	Reason            string          `gorm:"column:reason"`
+}
+
+// done reports whether the analysis will not change anymore.
+func (a Analysis) done() bool {
+	return a.Status == AnalysisFinished || a.Status == AnalysisError
+}
+
 type AnalysisInput struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -104,9 +256,29 @@ func (c *Content) Scan(src interface{}) error {
 	return json.Unmarshal(src.([]byte), &c)
 }
 
+// Findings are the structured matches of an analysis, Content holds the
+// flagged sentences next to them.
+type Findings []analyzercontract.Finding
+
+func (f Findings) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *Findings) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	return json.Unmarshal(src.([]byte), &f)
+}
+
+// SingleJobProgress is a job of an analysis. Start and End are the character
+// offsets of the chunk of the job into the processed text.
 type SingleJobProgress struct {
-	JobId  uuid.UUID      `json:"jobId"`
-	Status AnalysisStatus `json:"status"`
+	JobId      uuid.UUID      `json:"jobId"`
+	Status     AnalysisStatus `json:"status"`
+	ChunkIndex int            `json:"chunkIndex"`
+	Start      int            `json:"start"`
+	End        int            `json:"end"`
 }
 type JobsProgress []SingleJobProgress
 
@@ -125,6 +297,21 @@ type AnalysisResultPaginated struct {
 	Requests   []AnalysisRequest
 }
 
+type AnalysisSearchPaginated struct {
+	Limit      int
+	Page       int
+	TotalPages int
+	Hits       []AnalysisSearchHit
+}
+
+// AnalysisSearchHit is a request whose processed text matched a search, with
+// the highlighted fragments of the text.
+type AnalysisSearchHit struct {
+	Request AnalysisRequest
+	Snippet string
+	Rank    float32
+}
+
 type Pagination struct {
 	Limit int
 	Page  int
@@ -147,3 +334,57 @@ func (p *Pagination) GetPage() int {
 	}
 	return p.Page
 }
+
+type statsCount struct {
+	Key   string
+	Count int
+}
+
+type statsTrendRow struct {
+	Bucket  time.Time
+	Verdict Verdict
+	Count   int
+}
+
+type statsTermRow struct {
+	ThemeId uuid.UUID
+	Term    string
+	Matches int
+}
+
+type statsProcessingRow struct {
+	Type       jobmanager.JobType
+	GroupKey   string
+	Jobs       int
+	AvgSeconds float64
+}
+
+// RetentionReport lists what the retention rules remove on their next run.
+type RetentionReport struct {
+	GeneratedAt time.Time             `json:"generatedAt"`
+	Rules       []RetentionRuleReport `json:"rules"`
+	Items       []RetentionItem       `json:"items"`
+}
+
+// RetentionRuleReport counts the requests due for a rule, at most a batch.
+type RetentionRuleReport struct {
+	Name      string          `json:"name"`
+	Action    RetentionAction `json:"action"`
+	AfterDays int             `json:"afterDays"`
+	Since     string          `json:"since"`
+	Due       int             `json:"due"`
+}
+
+type RetentionItem struct {
+	AnalysisRequestId uuid.UUID       `json:"analysisRequestId"`
+	UserId            uuid.UUID       `json:"userId"`
+	Title             string          `json:"title"`
+	Category          string          `json:"category"`
+	ContentType       string          `json:"contentType"`
+	Verdict           Verdict         `json:"verdict"`
+	Action            RetentionAction `json:"action"`
+	Rule              string          `json:"rule"`
+	CreatedAt         time.Time       `json:"createdAt"`
+	FinishedAt        *time.Time      `json:"finishedAt"`
+	ExpiredAt         time.Time       `json:"expiredAt"`
+}
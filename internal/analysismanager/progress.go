@@ -0,0 +1,143 @@
+package analysismanager
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/analysisresult"
+	"github.com/samber/lo"
+)
+
+type jobDurationGetter interface {
+	GetAverageJobDuration(groupKey string) (time.Duration, error)
+}
+
+// analyzerEstimate is what the ETA of the jobs of an analyzer is based on.
+type analyzerEstimate struct {
+	avg         time.Duration
+	concurrency int
+}
+
+// stageOrder sorts the stages, the stage of a request is that of its least
+// advanced analysis.
+var stageOrder = []AnalysisRequestStepType{Parse, Analyze, Report, Done}
+
+// analysisStage tells where an analysis is. The jobs are only there after the
+// text is parsed and the score only after the reporter is done.
+func analysisStage(a Analysis) AnalysisRequestStepType {
+	switch {
+	case a.Status == AnalysisError:
+		return Done
+	case len(a.Jobs) == 0:
+		return Parse
+	case lo.SomeBy(a.Jobs, func(j SingleJobProgress) bool { return j.Status != AnalysisFinished }):
+		return Analyze
+	case a.Score == 0 && !a.Overridden:
+		return Report
+	default:
+		return Done
+	}
+}
+
+// estimates looks up the recent job durations of the analyzers that still
+// have jobs to run.
+func estimates(dg jobDurationGetter, as []Analysis) map[string]analyzerEstimate {
+	es := make(map[string]analyzerEstimate)
+	for _, a := range as {
+		if _, ok := es[a.AnalyzerKey]; ok || analysisStage(a) != Analyze {
+			continue
+		}
+		gk := fmt.Sprintf("analyzer.%s", a.AnalyzerKey)
+		avg, err := dg.GetAverageJobDuration(gk)
+		if err != nil {
+			avg = 0
+		}
+		c, _ := config.Get().GetConcurrency(gk)
+		es[a.AnalyzerKey] = analyzerEstimate{avg: avg, concurrency: max(1, c)}
+	}
+	return es
+}
+
+// buildProgress counts the finished jobs of the analyses. Jobs of the same
+// analyzer share its concurrency, different analyzers run side by side, so
+// the request takes as long as its slowest analyzer.
+func buildProgress(arid uuid.UUID, as []Analysis, es map[string]analyzerEstimate) analysisresult.AnalysisProgress {
+	p := analysisresult.AnalysisProgress{
+		AnalysisRequestId: arid,
+		Stage:             string(Parse),
+		Analyses:          make([]analysisresult.AnalyzerProgress, 0, len(as)),
+	}
+	if len(as) == 0 {
+		return p
+	}
+
+	stage := len(stageOrder) - 1
+	remaining := make(map[string]int)
+	for _, a := range as {
+		s := analysisStage(a)
+		stage = min(stage, lo.IndexOf(stageOrder, s))
+
+		completed := lo.CountBy(a.Jobs, func(j SingleJobProgress) bool { return j.Status == AnalysisFinished })
+		ap := analysisresult.AnalyzerProgress{
+			AnalysisId:  a.Id,
+			ThemeId:     a.ThemeId,
+			AnalyzerKey: a.AnalyzerKey,
+			Stage:       string(s),
+			Status:      string(a.Status),
+			Completed:   completed,
+			Total:       len(a.Jobs),
+			Percentage:  percentage(completed, len(a.Jobs)),
+		}
+		if s == Analyze {
+			remaining[a.AnalyzerKey] += len(a.Jobs) - completed
+			ap.EtaSeconds = eta(len(a.Jobs)-completed, es[a.AnalyzerKey])
+		} else if s != Parse {
+			ap.EtaSeconds = lo.ToPtr(0)
+		}
+
+		p.Completed += completed
+		p.Total += len(a.Jobs)
+		p.Analyses = append(p.Analyses, ap)
+	}
+	p.Stage = string(stageOrder[stage])
+	p.Percentage = percentage(p.Completed, p.Total)
+
+	switch stageOrder[stage] {
+	case Parse:
+		// Nothing is known before the text is chunked
+	case Analyze:
+		for k, r := range remaining {
+			e := eta(r, es[k])
+			if e == nil {
+				p.EtaSeconds = nil
+				break
+			}
+			if p.EtaSeconds == nil || *e > *p.EtaSeconds {
+				p.EtaSeconds = e
+			}
+		}
+	default:
+		p.EtaSeconds = lo.ToPtr(0)
+	}
+
+	return p
+}
+
+func percentage(completed, total int) float32 {
+	if total == 0 {
+		return 0
+	}
+	return float32(math.Round(float64(completed)/float64(total)*1000) / 10)
+}
+
+// eta is nil while no job of the analyzer finished yet.
+func eta(remaining int, e analyzerEstimate) *int {
+	if e.avg == 0 {
+		return nil
+	}
+	rounds := (remaining + e.concurrency - 1) / max(1, e.concurrency)
+	return lo.ToPtr(int(math.Ceil((time.Duration(rounds) * e.avg).Seconds())))
+}
@@ -0,0 +1,113 @@
+package analysismanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var (
+	progressArid  = uuid.MustParse("3c9f1f7e-7a41-4d4b-8f0e-2b1d5c6a7e01")
+	progressAidA  = uuid.MustParse("3c9f1f7e-7a41-4d4b-8f0e-2b1d5c6a7e02")
+	progressAidB  = uuid.MustParse("3c9f1f7e-7a41-4d4b-8f0e-2b1d5c6a7e03")
+	progressTheme = uuid.MustParse("3c9f1f7e-7a41-4d4b-8f0e-2b1d5c6a7e04")
+)
+
+func jobsProgress(finished, total int) JobsProgress {
+	js := make(JobsProgress, total)
+	for i := range js {
+		js[i] = SingleJobProgress{JobId: uuid.New(), Status: AnalysisWaiting, ChunkIndex: i}
+		if i < finished {
+			js[i].Status = AnalysisFinished
+		}
+	}
+	return js
+}
+
+func TestBuildProgress(t *testing.T) {
+	as := []Analysis{
+		{Id: progressAidA, ThemeId: progressTheme, AnalyzerKey: "word_search", Status: AnalysisInprogress, Jobs: jobsProgress(3, 10)},
+		{Id: progressAidB, ThemeId: progressTheme, AnalyzerKey: "word_search", Status: AnalysisFinished, Jobs: jobsProgress(2, 2)},
+	}
+
+	p := buildProgress(progressArid, as, map[string]analyzerEstimate{
+		"word_search": {avg: 2 * time.Second, concurrency: 4},
+	})
+
+	assert.Equal(t, progressArid, p.AnalysisRequestId)
+	assert.Equal(t, string(Analyze), p.Stage)
+	assert.Equal(t, 5, p.Completed)
+	assert.Equal(t, 12, p.Total)
+	assert.Equal(t, float32(41.7), p.Percentage)
+	// 7 jobs left on 4 workers take two rounds
+	assert.Equal(t, 4, *p.EtaSeconds)
+	assert.Equal(t, string(Analyze), p.Analyses[0].Stage)
+	assert.Equal(t, float32(30), p.Analyses[0].Percentage)
+	// Finished but not reported yet
+	assert.Equal(t, string(Report), p.Analyses[1].Stage)
+	assert.Equal(t, 0, *p.Analyses[1].EtaSeconds)
+}
+
+func TestBuildProgressWithoutDurations(t *testing.T) {
+	p := buildProgress(progressArid, []Analysis{
+		{Id: progressAidA, AnalyzerKey: "word_search", Status: AnalysisInprogress, Jobs: jobsProgress(0, 4)},
+	}, map[string]analyzerEstimate{"word_search": {concurrency: 1}})
+
+	assert.Equal(t, string(Analyze), p.Stage)
+	assert.Nil(t, p.EtaSeconds)
+
+	p = buildProgress(progressArid, []Analysis{
+		{Id: progressAidA, AnalyzerKey: "word_search", Status: AnalysisWaiting},
+	}, nil)
+
+	assert.Equal(t, string(Parse), p.Stage)
+	assert.Equal(t, 0, p.Total)
+	assert.Nil(t, p.EtaSeconds)
+
+	p = buildProgress(progressArid, []Analysis{
+		{Id: progressAidA, AnalyzerKey: "word_search", Status: AnalysisFinished, Score: -1, Jobs: jobsProgress(2, 2)},
+	}, nil)
+
+	assert.Equal(t, string(Done), p.Stage)
+	assert.Equal(t, float32(100), p.Percentage)
+	assert.Equal(t, 0, *p.EtaSeconds)
+}
+
+func TestAnalysisProgress(t *testing.T) {
+	mars := NewMockanalysisGetter(t)
+	marsu := NewMockanalysisUpdater(t)
+	mts := NewMockthemeService(t)
+	mjc := NewMockjobCanceller(t)
+	mjh := NewMockjobHistoryGetter(t)
+	mcb := NewMockcancelBroadcaster(t)
+	mcr := NewMockcontentRemover(t)
+	config.SetupConfig("../../testdata/envs/analysisresults.yaml")
+
+	analyzerResults := NewAnalysisResultService(mars, marsu, mts, mjc, mjh, mcb, mcr)
+
+	userId := uuid.MustParse("f6bec23c-5106-4805-980f-9c9c1c050af4")
+
+	mars.EXPECT().getAnalysesByAnalysisIdAndUserId(userId, progressArid).Return(AnalysisRequest{
+		Id: progressArid,
+		Analysis: []Analysis{
+			{Id: progressAidA, AnalyzerKey: "word_search", Status: AnalysisInprogress, Jobs: jobsProgress(1, 9)},
+		},
+	}, nil)
+	mjh.EXPECT().GetAverageJobDuration("analyzer.word_search").Return(1500*time.Millisecond, nil)
+
+	p, err := analyzerResults.GetProgress(userId, progressArid)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.Completed)
+	assert.Equal(t, 9, p.Total)
+	// 8 jobs left on 4 workers
+	assert.Equal(t, 3, *p.EtaSeconds)
+
+	mars.EXPECT().getAnalysesByAnalysisIdAndUserId(userId, progressAidA).Return(AnalysisRequest{}, gorm.ErrRecordNotFound)
+	_, err = analyzerResults.GetProgress(userId, progressAidA)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
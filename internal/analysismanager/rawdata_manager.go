@@ -1,23 +1,28 @@
 package analysismanager
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/export"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type RawDataManager struct {
-	tc taskCreater
-	db *gorm.DB
+	tc     taskCreater
+	db     *gorm.DB
+	format export.Format
+	sink   func() (export.Sink, error)
 }
 
 type taskCreater interface {
@@ -26,17 +31,28 @@ type taskCreater interface {
 
 func NewRawDataManager(tc taskCreater, db *gorm.DB) *RawDataManager {
 	rdm := &RawDataManager{
-		tc: tc,
-		db: db,
+		tc:   tc,
+		db:   db,
+		sink: newExportSink,
 	}
 
-	zap.S().Infow("Processed text exporting", "status", config.Get().Data.ExportProcessedText)
+	zap.S().Infow("Processed text exporting", "status", config.Get().Data.ExportProcessedText, "sink", config.Get().Data.ExportSink, "format", config.Get().Data.ExportFormat)
 	if config.Get().Data.ExportProcessedText {
-		_, err := tc.NewJob(
+		// An unknown format or sink would only fail once the export runs
+		f, err := export.NewFormat(config.Get().Data.ExportFormat)
+		if err != nil {
+			zap.S().Fatalw("Invalid export format", "error", err)
+		}
+		if err := export.ValidateSink(config.Get().Data.ExportSink); err != nil {
+			zap.S().Fatalw("Invalid export sink", "error", err)
+		}
+		rdm.format = f
+
+		_, err = tc.NewJob(
 			gocron.DurationJob(
 				30*time.Second,
 			),
-			gocron.NewTask(rdm.exportProcessedTextToFile),
+			gocron.NewTask(rdm.exportProcessedText),
 			gocron.WithSingletonMode(gocron.LimitModeReschedule),
 		)
 		if err != nil {
@@ -47,84 +63,154 @@ func NewRawDataManager(tc taskCreater, db *gorm.DB) *RawDataManager {
 	return rdm
 }
 
-type exportData struct {
-	Id            uuid.UUID `gorm:"column:id"`
-	Category      string    `gorm:"column:category"`
-	Title         string    `gorm:"column:title"`
-	ProcessedText string    `gorm:"column:processed_text"`
+// newExportSink opens the configured sink for a single export run.
+func newExportSink() (export.Sink, error) {
+	dc := config.Get().Data
+	switch dc.ExportSink {
+	case "", export.SinkDirectory:
+		return export.NewDirSink(dc.ExportPath), nil
+	case export.ArchiveTar, export.ArchiveZip:
+		return export.NewArchiveSink(dc.ExportPath, dc.ExportSink, time.Now())
+	case export.SinkS3:
+		return export.NewS3Sink(export.S3Options{
+			Endpoint:  dc.ExportS3.Endpoint,
+			Region:    dc.ExportS3.Region,
+			Bucket:    dc.ExportS3.Bucket,
+			Prefix:    dc.ExportS3.Prefix,
+			AccessKey: dc.ExportS3.AccessKey,
+			SecretKey: dc.ExportS3.SecretKey,
+			PathStyle: dc.ExportS3.PathStyle,
+			Timeout:   30 * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown export sink %s", dc.ExportSink)
+	}
 }
 
-func (rdm *RawDataManager) exportProcessedTextToFile() {
+// exportProcessedText exports the requests that are parsed and have a
+// verdict, so the findings are known. The export state is only updated once
+// the sink is closed.
+func (rdm *RawDataManager) exportProcessedText() {
 	runLogger := zap.S().With("run_id", uuid.New().String())
 
-	runLogger.Infow("Starting exporting raw data processed text to file")
-	var exportDatas []exportData
+	runLogger.Infow("Starting exporting raw data processed text")
+	var ars []AnalysisRequest
 
 	err := rdm.db.
-		Table("analysis_requests").
-		Select("analysis_requests.id, analysis_requests.category, analysis_requests.title, raw_data.processed_text").
-		Joins("LEFT JOIN raw_data ON raw_data.analysis_request_id = analysis_requests.id").
-		Where("raw_data.processed_text <> ?", "EXPORTED").
+		Joins("JOIN raw_data ON raw_data.analysis_request_id = analysis_requests.id").
+		Where("raw_data.export_state = ? AND raw_data.processed_text <> '' AND analysis_requests.verdict <> ?", ExportPending, VerdictPending).
+		Preload("RawData").
+		Preload("Analysis").
+		Order("analysis_requests.created_at").
 		Limit(5).
-		Scan(&exportDatas).Error
+		Find(&ars).Error
 	if err != nil {
 		runLogger.Errorw("Problem getting export rawdata", "error", err)
 		return
 	}
 
-	if len(exportDatas) == 0 {
+	if len(ars) == 0 {
 		runLogger.Infow("No rawdata processed text to export")
+		return
 	}
 
-	for _, ed := range exportDatas {
-		filePath := makeFilePath(config.Get().Data.ExportPath, ed.Category, stripLeadingArticle(ed.Title))
-		err := writeToTextToFile(filePath, ed.ProcessedText)
+	sink, err := rdm.sink()
+	if err != nil {
+		runLogger.Errorw("Could not open export sink", "error", err)
+		return
+	}
+
+	refs := make(map[uuid.UUID]string)
+	for _, ar := range ars {
+		data, err := rdm.format.Encode(exportDocument(ar))
 		if err != nil {
-			zap.S().Errorw("Could not write text to file", "error", err, "analysis_request_id", ed.Id, "filepath", filePath)
-			return
+			runLogger.Errorw("Could not encode processed text", "error", err, "analysis_request_id", ar.Id)
+			rdm.updateExportState(ar.Id, ExportFailed, "")
+			continue
 		}
 
-		// Update raw_data to clear processed and content text.
-		res := rdm.db.
-			Model(&RawData{}).
-			Where("analysis_request_id = ?", ed.Id).
-			Updates(RawData{ProcessedText: "EXPORTED"})
-		if res.Error != nil {
-			runLogger.Errorw("Could not update processed text", "error", res.Error)
-			return
+		name := makeExportPath("", ar.Category, stripLeadingArticle(ar.Title), rdm.format.Extension())
+		ref, err := sink.Put(context.Background(), name, data)
+		if err != nil {
+			// The sink itself is failing, the rest is tried on the next run
+			runLogger.Errorw("Could not write processed text", "error", err, "analysis_request_id", ar.Id, "name", name)
+			break
 		}
+		refs[ar.Id] = ref
+	}
 
-		if res.RowsAffected == 0 {
-			runLogger.Errorw("No records updated", "analysis_request_id", ed.Id)
-			return
-		}
-		runLogger.Infow("Exported and updated text", "analysis_request_id", ed.Id, "filepath", filePath)
+	if err := sink.Close(); err != nil {
+		runLogger.Errorw("Could not close export sink", "error", err)
+		return
 	}
 
+	for arid, ref := range refs {
+		if rdm.updateExportState(arid, ExportExported, ref) {
+			runLogger.Infow("Exported processed text", "analysis_request_id", arid, "ref", ref)
+		}
+	}
 }
 
-func writeToTextToFile(path, text string) error {
-	dir := filepath.Dir(path)
-	err := os.MkdirAll(dir, 0755)
-	if err != nil {
-		zap.S().Errorw("Error creating directory", "error", err, "filepath", path)
+// updateExportState clears the processed text of exported requests, unless
+// it is configured to be kept.
+func (rdm *RawDataManager) updateExportState(arid uuid.UUID, state ExportState, ref string) bool {
+	u := map[string]interface{}{
+		"export_state": state,
+	}
+	if state == ExportExported {
+		u["export_ref"] = ref
+		u["exported_at"] = time.Now()
+		if !config.Get().Data.ExportKeepText {
+			u["processed_text"] = ""
+		}
 	}
 
-	err = os.WriteFile(path, []byte(text), 0644)
-	if err != nil {
-		zap.S().Errorw("Error writing to path", "error", err, "filepath", path)
+	res := rdm.db.Model(&RawData{}).Where("analysis_request_id = ?", arid).Updates(u)
+	if res.Error != nil {
+		zap.S().Errorw("Could not update export state", "error", res.Error, "analysis_request_id", arid)
+		return false
+	}
+	if res.RowsAffected == 0 {
+		zap.S().Errorw("No records updated", "analysis_request_id", arid)
+		return false
+	}
+	return true
+}
+
+func exportDocument(ar AnalysisRequest) export.Document {
+	return export.Document{
+		Id:          ar.Id,
+		Title:       ar.Title,
+		Category:    ar.Category,
+		ContentType: ar.ContentType,
+		Verdict:     string(ar.Verdict),
+		CreatedAt:   ar.CreatedAt,
+		Text:        ar.RawData.ProcessedText,
+		Analyses: lo.Map(ar.Analysis, func(a Analysis, _ int) export.Analysis {
+			return export.Analysis{
+				AnalyzerKey: a.AnalyzerKey,
+				ThemeId:     a.ThemeId,
+				Status:      string(a.Status),
+				Score:       a.Score,
+				Findings:    a.Findings,
+			}
+		}),
 	}
-	return nil
 }
 
 func makeFilePath(basePath, category, title string) string {
+	return makeExportPath(basePath, category, title, ".txt")
+}
+
+// makeExportPath spreads the files over directories by category and the
+// first two letters of the title.
+func makeExportPath(basePath, category, title, ext string) string {
 	safeTitle := buildSlugTitle(title)
 
-	firstLetter := string([]rune(safeTitle)[0])
-	secondLetter := string([]rune(safeTitle)[1])
-	letterDir := fmt.Sprintf("%s%s", firstLetter, secondLetter)
+	letters := []rune(safeTitle)
+	letterDir := string(letters[:min(2, len(letters))])
 
-	filename := fmt.Sprintf("%s.txt", safeTitle)
+	filename := safeTitle + ext
 
 	safeCategory := strings.ReplaceAll(strings.ToLower(category), " ", "_")
 
@@ -150,9 +236,10 @@ func buildSlugTitle(title string) string {
 		}
 	}
 	slug := b.String()
-	if len(slug) == 0 {
+	// Counted in letters, a single letter can take more than one byte
+	if n := utf8.RuneCountInString(slug); n == 0 {
 		return "zz"
-	} else if len(slug) == 1 {
+	} else if n == 1 {
 		return fmt.Sprintf("%sz", slug)
 
 	} else {
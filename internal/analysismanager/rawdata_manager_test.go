@@ -56,7 +56,9 @@ func TestFileNameBuilding(t *testing.T) {
 	assert.Equal(t, "/books/thriller/bo/book_name_2020.txt", filenameSpecialChars)
 }
 
-func TestWriteToFile(t *testing.T) {
-	err := writeToTextToFile("/docker-compose/guardlight/hello-test-file.txt", "hello")
-	assert.NoError(t, err)
+func TestMakeExportPath(t *testing.T) {
+	assert.Equal(t, "fantasy/al/alice_in_wonderland.md", makeExportPath("", "Fantasy", stripLeadingArticle("Alice in Wonderland"), ".md"))
+	assert.Equal(t, "/export/science_fiction/bo/book.jsonl", makeExportPath("/export", "Science Fiction", stripLeadingArticle("The Book"), ".jsonl"))
+	assert.Equal(t, "drama/éz/éz.txt", makeExportPath("", "Drama", stripLeadingArticle("É"), ".txt"))
+	assert.Equal(t, "drama/zz/zz.txt", makeExportPath("", "Drama", stripLeadingArticle("?!"), ".txt"))
 }
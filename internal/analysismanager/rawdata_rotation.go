@@ -0,0 +1,68 @@
+package analysismanager
+
+import (
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/encryption"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storedRawData are the columns of the raw data as they are stored, without
+// decrypting them.
+type storedRawData struct {
+	Id            uuid.UUID
+	Content       []byte
+	ProcessedText string
+}
+
+// ReencryptRawData moves the content and processed text of every row to the
+// current key, or back to plaintext when encryption is disabled. Every batch
+// is its own transaction, a stopped rotation continues where it was.
+func ReencryptRawData(db *gorm.DB, batchSize int) (int, error) {
+	rotated := 0
+	last := uuid.Nil
+	for {
+		var rows []storedRawData
+		err := db.Table("raw_data").
+			Select("id, content, processed_text").
+			Where("id > ?", last).
+			Order("id").
+			Limit(batchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return rotated, err
+		}
+		if len(rows) == 0 {
+			return rotated, nil
+		}
+		last = rows[len(rows)-1].Id
+
+		ids := lo.FilterMap(rows, func(r storedRawData, _ int) (uuid.UUID, bool) {
+			return r.Id, encryption.Stale(r.Content) || encryption.StaleText(r.ProcessedText)
+		})
+		if len(ids) == 0 {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var rds []RawData
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&rds).Error; err != nil {
+				return err
+			}
+			for _, rd := range rds {
+				if err := tx.Model(&rd).Select("content", "processed_text").Updates(&rd).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return rotated, err
+		}
+
+		rotated += len(ids)
+		zap.S().Infow("Re-encrypted raw data", "rows", len(ids), "total", rotated)
+	}
+}
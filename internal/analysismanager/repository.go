@@ -2,35 +2,270 @@ package analysismanager
 
 import (
 	"errors"
+	"fmt"
+	"html"
 	"math"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/encryption"
+	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/pkg/analyzercontract"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// requestAssociations are deleted along with an analysis request, the score
+// overrides are kept for the audit.
+var requestAssociations = []string{"RawData", "Analysis", "History"}
+
 type AnalysisManagerRepository struct {
 	db *gorm.DB
+	// text search configuration of the processed text index
+	searchLanguage string
+	verdictRule    VerdictRule
+	// Encrypted text can not be searched
+	searchDisabled bool
+	// Stats are counted from the materialized rollups
+	statsRollup bool
 }
 
 func NewAnalysisManagerRepository(db *gorm.DB) *AnalysisManagerRepository {
+	hadVerdict := db.Migrator().HasColumn(&AnalysisRequest{}, "verdict")
+	hadReporterScore := db.Migrator().HasColumn(&Analysis{}, "reporter_score")
+	hadExportState := db.Migrator().HasColumn(&RawData{}, "export_state")
+	hadFinishedAt := db.Migrator().HasColumn(&AnalysisRequest{}, "finished_at")
+	hadHashIndex := db.Migrator().HasIndex(&RawData{}, "Hash")
+	hadOverrideConstraint := db.Migrator().HasConstraint(&ScoreOverride{}, "fk_analysis_requests_score_overrides")
+
 	if err := db.AutoMigrate(
 		&AnalysisRequest{},
 		&RawData{},
 		&Analysis{},
+		&AnalysisHistory{},
+		&Batch{},
+		&BatchItem{},
+		&ScoreOverride{},
 	); err != nil {
 		zap.S().DPanicw("Problem automigrating the tables", "error", err)
 	}
 
-	return &AnalysisManagerRepository{
-		db: db,
+	// Scores changed by hand before there were overrides can not be told apart
+	if !hadReporterScore {
+		for _, t := range []string{"analyses", "analysis_histories"} {
+			if err := db.Exec(fmt.Sprintf("UPDATE %s SET reporter_score = score", t)).Error; err != nil {
+				zap.S().DPanicw("Problem backfilling the reporter scores", "table", t, "error", err)
+			}
+		}
+	}
+
+	// Exported text used to be replaced by a marker
+	if !hadExportState {
+		if err := db.Exec("UPDATE raw_data SET export_state = ?, processed_text = '' WHERE processed_text = 'EXPORTED'", ExportExported).Error; err != nil {
+			zap.S().DPanicw("Problem backfilling the export state", "error", err)
+		}
+	}
+
+	// Hashes used to be md5, which is 32 characters long. They were not indexed
+	// yet either. The content of parsed requests is gone, their hash is cleared
+	// so they do not all match the hash of empty content.
+	if !hadHashIndex {
+		if err := db.Exec("UPDATE raw_data SET hash = encode(sha256(content), 'hex') WHERE length(hash) = 32 AND length(content) > 0").Error; err != nil {
+			zap.S().DPanicw("Problem rehashing the raw data", "error", err)
+		}
+		if err := db.Exec("UPDATE raw_data SET hash = '' WHERE length(hash) = 32").Error; err != nil {
+			zap.S().DPanicw("Problem clearing the hashes of parsed raw data", "error", err)
+		}
+	}
+
+	// Deleting a request used to take its score overrides along
+	if hadOverrideConstraint {
+		if err := db.Exec("ALTER TABLE score_overrides DROP CONSTRAINT fk_analysis_requests_score_overrides").Error; err != nil {
+			zap.S().DPanicw("Problem dropping the score override constraint", "error", err)
+		}
+	}
+
+	amr := &AnalysisManagerRepository{
+		db:          db,
+		verdictRule: parseVerdictRule(config.Get().Data.VerdictRule),
+	}
+	// Encryption requires the search to be turned off, see the config
+	if encryption.Sealing() || !config.Get().Data.Search {
+		dropSearchIndex(db)
+		amr.searchDisabled = true
+	} else {
+		amr.searchLanguage = setupSearchIndex(db, config.Get().Data.SearchLanguage)
+	}
+
+	amr.statsRollup = setupStatsRollup(db, config.Get().Data.StatsRollup)
+
+	// Requests from before the verdict column start out pending
+	if !hadVerdict {
+		amr.backfillVerdicts()
+	}
+
+	// When older reports finished is unknown, retention counts from the request
+	if !hadFinishedAt {
+		if err := db.Exec("UPDATE analysis_requests SET finished_at = created_at WHERE verdict <> ?", VerdictPending).Error; err != nil {
+			zap.S().DPanicw("Problem backfilling the finished times", "error", err)
+		}
+	}
+
+	return amr
+}
+
+func (amr AnalysisManagerRepository) backfillVerdicts() {
+	var ars []AnalysisRequest
+	err := amr.db.Preload("Analysis").FindInBatches(&ars, 200, func(tx *gorm.DB, _ int) error {
+		for _, ar := range ars {
+			v := aggregateVerdict(ar.Analysis, amr.verdictRule)
+			if err := amr.db.Model(&AnalysisRequest{}).Where("id = ?", ar.Id).Update("verdict", v).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		zap.S().DPanicw("Problem backfilling the verdicts", "error", err)
+	}
+}
+
+// refreshVerdict recomputes the verdict of the request from its analyses. The
+// request is locked so concurrent reporter results do not overwrite each other
+// with a stale verdict. The request finished when the verdict is first decided,
+// a rerun starts it over.
+func (amr AnalysisManagerRepository) refreshVerdict(tx *gorm.DB, arid uuid.UUID) error {
+	var ar AnalysisRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", arid).First(&ar).Error; err != nil {
+		return err
+	}
+
+	var as []Analysis
+	if err := tx.Where("analysis_request_id = ?", arid).Find(&as).Error; err != nil {
+		return err
+	}
+
+	v := aggregateVerdict(as, amr.verdictRule)
+	// A rerun makes the request pending, and so unfinished, again
+	finishedAt := gorm.Expr("NULL")
+	if v != VerdictPending {
+		finishedAt = gorm.Expr("coalesce(finished_at, ?)", tx.NowFunc())
+	}
+
+	return tx.Model(&AnalysisRequest{}).Where("id = ?", arid).Updates(map[string]interface{}{
+		"verdict":     v,
+		"finished_at": finishedAt,
+	}).Error
+}
+
+// preloadTextState loads the state of the processed text without the text.
+func preloadTextState(db *gorm.DB) *gorm.DB {
+	return db.Select("id, analysis_request_id, export_state, exported_at, purged_at, purged_by, processed_text <> '' AS has_text")
+}
+
+var searchLanguagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// setupSearchIndex adds the generated tsvector column of the processed text and
+// its GIN index. The column is rebuilt when the language changed. It returns
+// the language in use, which is 'simple' when the configured one is unknown.
+func setupSearchIndex(db *gorm.DB, lang string) string {
+	// The language ends up in the column definition, so it can not be a parameter
+	var known int64
+	if searchLanguagePattern.MatchString(lang) {
+		if err := db.Raw("SELECT count(*) FROM pg_ts_config WHERE cfgname = ?", lang).Scan(&known).Error; err != nil {
+			zap.S().Errorw("Could not look up the search language", "language", lang, "error", err)
+		}
+	}
+	if known == 0 {
+		zap.S().Warnw("Unknown search language, falling back to simple", "language", lang)
+		lang = "simple"
+	}
+
+	cfg := fmt.Sprintf("'%s'::regconfig", lang)
+
+	var current string
+	if err := db.Raw("SELECT coalesce(generation_expression, '') FROM information_schema.columns WHERE table_name = 'raw_data' AND column_name = 'processed_text_tsv'").Scan(&current).Error; err != nil {
+		zap.S().Errorw("Could not get the search column", "error", err)
+	}
+	// Postgres keeps its own rendering of the expression, only the language matters
+	if current != "" && !strings.Contains(current, cfg) {
+		zap.S().Infow("Search language changed, rebuilding the search column", "language", lang)
+		if err := db.Exec("ALTER TABLE raw_data DROP COLUMN processed_text_tsv").Error; err != nil {
+			zap.S().DPanicw("Problem dropping the search column", "error", err)
+		}
+	}
+
+	if err := db.Exec(fmt.Sprintf("ALTER TABLE raw_data ADD COLUMN IF NOT EXISTS processed_text_tsv tsvector GENERATED ALWAYS AS (to_tsvector(%s, coalesce(processed_text, ''))) STORED", cfg)).Error; err != nil {
+		zap.S().DPanicw("Problem adding the search column", "error", err)
 	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_raw_data_processed_text_tsv ON raw_data USING GIN (processed_text_tsv)").Error; err != nil {
+		zap.S().DPanicw("Problem creating the search index", "error", err)
+	}
+
+	return lang
+}
+
+// dropSearchIndex removes the search column when search is turned off, its
+// words would leak the encrypted text.
+func dropSearchIndex(db *gorm.DB) {
+	if err := db.Exec("ALTER TABLE raw_data DROP COLUMN IF EXISTS processed_text_tsv").Error; err != nil {
+		zap.S().DPanicw("Problem dropping the search column", "error", err)
+	}
+}
+
+var statsRollupViews = []string{"analysis_stats_rollup", "analysis_terms_rollup"}
+
+// setupStatsRollup creates the materialized rollups the stats are counted from
+// on large libraries. They are dropped when disabled, so they are not refreshed
+// for nothing. It returns whether the rollups can be used.
+func setupStatsRollup(db *gorm.DB, enabled bool) bool {
+	if !enabled {
+		for _, v := range statsRollupViews {
+			if err := db.Exec("DROP MATERIALIZED VIEW IF EXISTS " + v).Error; err != nil {
+				zap.S().Errorw("Could not drop the stats rollup", "view", v, "error", err)
+			}
+		}
+		return false
+	}
+
+	stmts := []string{
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS analysis_stats_rollup AS
+		SELECT user_id, date_trunc('day', created_at) AS day, coalesce(content_type, '') AS content_type,
+			coalesce(category, '') AS category, coalesce(verdict, '') AS verdict, count(*) AS requests
+		FROM analysis_requests
+		GROUP BY 1, 2, 3, 4, 5`,
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_analysis_stats_rollup ON analysis_stats_rollup (user_id, day, content_type, category, verdict)",
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS analysis_terms_rollup AS
+		SELECT ar.user_id, a.theme_id, lower(f->>'term') AS term, count(*) AS matches
+		FROM analyses a
+		JOIN analysis_requests ar ON ar.id = a.analysis_request_id
+		CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(a.findings) = 'array' THEN a.findings ELSE '[]' END) f
+		WHERE coalesce(f->>'term', '') <> ''
+		GROUP BY 1, 2, 3`,
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_analysis_terms_rollup ON analysis_terms_rollup (user_id, theme_id, term)",
+	}
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			zap.S().Errorw("Could not set up the stats rollup, counting from the analyses", "error", err)
+			return false
+		}
+	}
+	return true
 }
 
 func (amr AnalysisManagerRepository) createAnalysisRequest(analysisRequest *AnalysisRequest) error {
+	// Shared results can bring finished analyses along
+	analysisRequest.Verdict = aggregateVerdict(analysisRequest.Analysis, amr.verdictRule)
+	if analysisRequest.Verdict != VerdictPending {
+		now := amr.db.NowFunc()
+		analysisRequest.FinishedAt = &now
+	}
 	if err := amr.db.Create(analysisRequest).Error; err != nil {
 		zap.S().Errorw("Could not create analysis request", "error", err)
 		return err
@@ -114,25 +349,41 @@ func (amr AnalysisManagerRepository) updateAnalysisJobs(ai uuid.UUID, jbs []Sing
 	return nil
 }
 
-func (amr AnalysisManagerRepository) updateAllAnalysesStatusByAnalysisRequestId(arid uuid.UUID, status AnalysisStatus) error {
-	res := amr.db.
-		Model(Analysis{}).
-		Where("analysis_request_id = ?", arid).
-		Updates(Analysis{Status: status})
+// mergeJobResults adds the results of a job to those of the analysis.
+// Overlapping chunks report the matches in their overlap twice, they are told
+// apart by their offsets. A sentence is left out when every match in it was
+// reported already, sentences that are repeated in the text are kept.
+func mergeJobResults(con Content, fs Findings, content []string, findings []analyzercontract.Finding) (Content, Findings) {
+	key := func(f analyzercontract.Finding) string {
+		return fmt.Sprintf("%s:%d:%d", f.Term, f.Start, f.End)
+	}
+	seen := lo.SliceToMap(fs, func(f analyzercontract.Finding) (string, bool) { return key(f), true })
 
-	if res.Error != nil {
-		zap.S().Errorw("Could not update analysis status", "error", res.Error)
-		return res.Error
+	newFs := append(Findings{}, fs...)
+	reported := map[string]bool{}
+	for _, f := range findings {
+		if seen[key(f)] {
+			if _, ok := reported[f.Sentence]; !ok {
+				reported[f.Sentence] = true
+			}
+			continue
+		}
+		seen[key(f)] = true
+		reported[f.Sentence] = false
+		newFs = append(newFs, f)
 	}
 
-	if res.RowsAffected == 0 {
-		zap.S().Errorw("No records updated", "analysis_request_id", arid)
-		return errors.New("no records affected after update")
+	newCon := append(Content{}, con...)
+	for _, c := range content {
+		if reported[c] {
+			continue
+		}
+		newCon = append(newCon, c)
 	}
-	return nil
+	return newCon, newFs
 }
 
-func (amr AnalysisManagerRepository) updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string) (bool, error) {
+func (amr AnalysisManagerRepository) updateAnalysisJobProgress(aid uuid.UUID, jid uuid.UUID, status AnalysisStatus, content []string, findings []analyzercontract.Finding) (bool, error) {
 	a := Analysis{Id: aid}
 	if err := amr.db.First(&a).Error; err != nil {
 		return false, err
@@ -140,20 +391,20 @@ func (amr AnalysisManagerRepository) updateAnalysisJobProgress(aid uuid.UUID, ji
 
 	newJs := lo.Map(a.Jobs, func(s SingleJobProgress, _ int) SingleJobProgress {
 		if s.JobId == jid {
-			return SingleJobProgress{
-				JobId:  jid,
-				Status: status,
-			}
+			s.Status = status
 		}
 		return s
 	})
 
-	newCon := append(a.Content, content...)
+	newCon, newFs := mergeJobResults(a.Content, a.Findings, content, findings)
 
 	completedJobs := lo.CountBy(newJs, func(j SingleJobProgress) bool { return j.Status == AnalysisFinished })
 
 	newStatus := func() AnalysisStatus {
-		if completedJobs == len(newJs) {
+		if a.Status == AnalysisError {
+			// The other jobs of a failed analysis can still finish
+			return AnalysisError
+		} else if completedJobs == len(newJs) {
 			return AnalysisFinished
 		} else {
 			return AnalysisInprogress
@@ -164,9 +415,10 @@ func (amr AnalysisManagerRepository) updateAnalysisJobProgress(aid uuid.UUID, ji
 	}
 
 	resp := amr.db.Model(&a).Updates(Analysis{
-		Jobs:    newJs,
-		Content: newCon,
-		Status:  newStatus,
+		Jobs:     newJs,
+		Content:  newCon,
+		Findings: newFs,
+		Status:   newStatus,
 	})
 
 	if resp.Error != nil {
@@ -181,7 +433,50 @@ func (amr AnalysisManagerRepository) updateAnalysisJobProgress(aid uuid.UUID, ji
 	return newStatus == AnalysisFinished, nil
 }
 
-func (amr AnalysisManagerRepository) getAnalysesByUserId(id uuid.UUID, pag Pagination, catType, catCat, query, sc string) (AnalysisResultPaginated, error) {
+// failAnalysis fails an analysis that no jobs could be created for.
+func (amr AnalysisManagerRepository) failAnalysis(aid uuid.UUID) error {
+	return amr.db.Transaction(func(tx *gorm.DB) error {
+		return amr.failAnalyses(tx, failedWork{analysisIds: []uuid.UUID{aid}})
+	})
+}
+
+// failAnalyses sets the analyses that waited on failed work to error, with
+// the failed jobs among their jobs, and refreshes the verdicts of their
+// requests.
+func (amr AnalysisManagerRepository) failAnalyses(tx *gorm.DB, fw failedWork) error {
+	var as []Analysis
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("status <> ?", AnalysisError).
+		Where(tx.Where("id IN ?", fw.analysisIds).Or("analysis_request_id IN ?", fw.requestIds)).
+		Find(&as).Error
+	if err != nil {
+		zap.S().Errorw("Could not get analyses of failed jobs", "error", err)
+		return err
+	}
+
+	for _, a := range as {
+		js := lo.Map(a.Jobs, func(s SingleJobProgress, _ int) SingleJobProgress {
+			if lo.Contains(fw.jobIds, s.JobId) {
+				s.Status = AnalysisError
+			}
+			return s
+		})
+		if err := tx.Model(&Analysis{Id: a.Id}).Updates(Analysis{Status: AnalysisError, Jobs: js}).Error; err != nil {
+			zap.S().Errorw("Could not fail analysis", "analysis_id", a.Id, "error", err)
+			return err
+		}
+	}
+
+	for _, arid := range lo.Uniq(lo.Map(as, func(a Analysis, _ int) uuid.UUID { return a.AnalysisRequestId })) {
+		if err := amr.refreshVerdict(tx, arid); err != nil {
+			zap.S().Errorw("Could not update verdict", "analysis_request_id", arid, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (amr AnalysisManagerRepository) getAnalysesByUserId(id uuid.UUID, pag Pagination, catType, catCat, query string, verdict Verdict, sort string) (AnalysisResultPaginated, error) {
 
 	var fuzzCatType = "%" + catType + "%"
 	var fuzzCatCat = "%" + catCat + "%"
@@ -199,20 +494,19 @@ func (amr AnalysisManagerRepository) getAnalysesByUserId(id uuid.UUID, pag Pagin
 		dbQ = dbQ.Where("title ILIKE ?", fuzzQuery)
 	}
 
-	if sc == "BAD" {
-		dbQ = dbQ.Where("NOT EXISTS (SELECT 1 FROM analyses WHERE analyses.analysis_request_id = analysis_requests.id AND analyses.score > ?)", -1)
-	}
-	if sc == "MIXED" {
-		dbQ = dbQ.Where("EXISTS (SELECT 1 FROM analyses WHERE analyses.analysis_request_id = analysis_requests.id AND analyses.score > ?)", 0)
-	}
-	if sc == "GOOD" {
-		dbQ = dbQ.Where("NOT EXISTS (SELECT 1 FROM analyses WHERE analyses.analysis_request_id = analysis_requests.id AND analyses.score < ?)", 1)
+	if len(verdict) > 0 {
+		dbQ = dbQ.Where("verdict = ?", verdict)
 	}
 
 	dbQ = dbQ.Session(&gorm.Session{})
 
+	order := "created_at DESC"
+	if sort == "verdict" {
+		order = verdictSeverityOrder + ", created_at DESC"
+	}
+
 	var ars []AnalysisRequest
-	if err := dbQ.Offset(pag.GetOffset()).Limit(pag.GetLimit()).Order("created_at DESC").Preload("Analysis").Find(&ars).Error; err != nil {
+	if err := dbQ.Offset(pag.GetOffset()).Limit(pag.GetLimit()).Order(order).Preload("Analysis").Preload("RawData", preloadTextState).Find(&ars).Error; err != nil {
 		zap.S().Errorw("Could not get analyses", "user_id", id)
 		return AnalysisResultPaginated{}, err
 	}
@@ -232,12 +526,114 @@ func (amr AnalysisManagerRepository) getAnalysesByUserId(id uuid.UUID, pag Pagin
 	}, nil
 }
 
+// The headline marks the matches with characters from the private use area,
+// they are taken out of the text first. The text is escaped before the marks
+// become <mark> tags, see highlightSnippet.
+const (
+	searchMarkStart = "\ue000"
+	searchMarkStop  = "\ue001"
+)
+
+var searchHeadlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=25, MinWords=10, MaxFragments=3, FragmentDelimiter=\" ... \"", searchMarkStart, searchMarkStop)
+
+var snippetMarks = strings.NewReplacer(searchMarkStart, "<mark>", searchMarkStop, "</mark>")
+
+// highlightSnippet escapes the headline for HTML and marks the matches.
+func highlightSnippet(headline string) string {
+	return snippetMarks.Replace(html.EscapeString(headline))
+}
+
+type searchRow struct {
+	Id      uuid.UUID `gorm:"column:id"`
+	Rank    float32   `gorm:"column:rank"`
+	Snippet string    `gorm:"column:snippet"`
+}
+
+// searchAnalysesByUserId finds the requests of the user whose processed text
+// matches the web search style query, best matches first. Only the page of
+// hits gets a headline, building one reads the whole text.
+func (amr AnalysisManagerRepository) searchAnalysesByUserId(uid uuid.UUID, query string, pag Pagination) (AnalysisSearchPaginated, error) {
+	if amr.searchDisabled {
+		return AnalysisSearchPaginated{}, ErrSearchUnavailable
+	}
+
+	lang := amr.searchLanguage
+
+	var rows []searchRow
+	if err := amr.db.Raw(`
+		SELECT hits.id, hits.rank, ts_headline(?::regconfig, translate(rd.processed_text, ?, ''), websearch_to_tsquery(?::regconfig, ?), ?) AS snippet
+		FROM (
+			SELECT ar.id, ar.created_at, ts_rank(rd.processed_text_tsv, websearch_to_tsquery(?::regconfig, ?)) AS rank
+			FROM analysis_requests ar
+			JOIN raw_data rd ON rd.analysis_request_id = ar.id
+			WHERE ar.user_id = ? AND rd.processed_text_tsv @@ websearch_to_tsquery(?::regconfig, ?)
+			ORDER BY rank DESC, ar.created_at DESC
+			OFFSET ? LIMIT ?
+		) hits
+		JOIN raw_data rd ON rd.analysis_request_id = hits.id
+		ORDER BY hits.rank DESC, hits.created_at DESC`,
+		lang, searchMarkStart+searchMarkStop, lang, query, searchHeadlineOptions,
+		lang, query,
+		uid, lang, query,
+		pag.GetOffset(), pag.GetLimit(),
+	).Scan(&rows).Error; err != nil {
+		zap.S().Errorw("Could not search analyses", "user_id", uid, "error", err)
+		return AnalysisSearchPaginated{}, err
+	}
+
+	var totalRows int64
+	if err := amr.db.Raw(`
+		SELECT count(*)
+		FROM analysis_requests ar
+		JOIN raw_data rd ON rd.analysis_request_id = ar.id
+		WHERE ar.user_id = ? AND rd.processed_text_tsv @@ websearch_to_tsquery(?::regconfig, ?)`,
+		uid, lang, query,
+	).Scan(&totalRows).Error; err != nil {
+		zap.S().Errorw("Could not count search hits", "error", err)
+		return AnalysisSearchPaginated{}, err
+	}
+
+	hits := make([]AnalysisSearchHit, 0, len(rows))
+	if len(rows) > 0 {
+		var ars []AnalysisRequest
+		if err := amr.db.
+			Preload("Analysis").
+			Preload("RawData", preloadTextState).
+			Where("user_id = ? AND id IN ?", uid, lo.Map(rows, func(r searchRow, _ int) uuid.UUID { return r.Id })).
+			Find(&ars).Error; err != nil {
+			zap.S().Errorw("Could not get searched analyses", "user_id", uid, "error", err)
+			return AnalysisSearchPaginated{}, err
+		}
+
+		arm := lo.KeyBy(ars, func(ar AnalysisRequest) uuid.UUID { return ar.Id })
+		for _, r := range rows {
+			ar, ok := arm[r.Id]
+			if !ok {
+				continue
+			}
+			hits = append(hits, AnalysisSearchHit{
+				Request: ar,
+				Snippet: highlightSnippet(r.Snippet),
+				Rank:    r.Rank,
+			})
+		}
+	}
+
+	return AnalysisSearchPaginated{
+		Limit:      pag.GetLimit(),
+		Page:       pag.GetPage(),
+		TotalPages: int(math.Ceil(float64(totalRows) / float64(pag.GetLimit()))),
+		Hits:       hits,
+	}, nil
+}
+
 func (amr AnalysisManagerRepository) getAnalysesByAnalysisIdAndUserId(uid, aid uuid.UUID) (AnalysisRequest, error) {
 	var ar AnalysisRequest
 
 	resp := amr.db.
 		Model(AnalysisRequest{}).
 		Preload("Analysis").
+		Preload("RawData", preloadTextState).
 		Where("user_id = ? AND id = ?", uid, aid).
 		First(&ar)
 
@@ -248,6 +644,16 @@ func (amr AnalysisManagerRepository) getAnalysesByAnalysisIdAndUserId(uid, aid u
 	return ar, nil
 }
 
+func (amr AnalysisManagerRepository) getAnalysisRequestById(arid uuid.UUID) (AnalysisRequest, error) {
+	var ar AnalysisRequest
+
+	if err := amr.db.Model(AnalysisRequest{}).Where("id = ?", arid).First(&ar).Error; err != nil {
+		zap.S().Errorw("Could not get analysis request", "analysis_request_id", arid, "error", err)
+		return AnalysisRequest{}, err
+	}
+	return ar, nil
+}
+
 func (amr AnalysisManagerRepository) getUserIdByAnalysisId(analysisId uuid.UUID) (uuid.UUID, error) {
 	var userId string
 
@@ -266,10 +672,15 @@ func (amr AnalysisManagerRepository) getUserIdByAnalysisId(analysisId uuid.UUID)
 	return uuid.MustParse(userId), nil
 }
 
-func (amr AnalysisManagerRepository) getAnalysisRequestIdByHash(hash string) (uuid.UUID, error) {
+// getAnalysisRequestIdByHash returns the analysis request of the user with the
+// same content, or uuid.Nil when the user has none.
+func (amr AnalysisManagerRepository) getAnalysisRequestIdByHash(uid uuid.UUID, hash string) (uuid.UUID, error) {
 	var rawData RawData
 
-	result := amr.db.Model(&RawData{}).Where("hash = ?", hash).First(&rawData)
+	result := amr.db.Model(&RawData{}).
+		Joins("JOIN analysis_requests ON analysis_requests.id = raw_data.analysis_request_id").
+		Where("raw_data.hash = ? AND analysis_requests.user_id = ?", hash, uid).
+		First(&rawData)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return uuid.Nil, nil
@@ -281,37 +692,526 @@ func (amr AnalysisManagerRepository) getAnalysisRequestIdByHash(hash string) (uu
 	return rawData.AnalysisRequestId, nil
 }
 
-func (amr AnalysisManagerRepository) updateScore(analysisId uuid.UUID, score float32) error {
+// getSharedAnalysisRequestByHash returns the latest parsed analysis request of
+// another user with the same content.
+func (amr AnalysisManagerRepository) getSharedAnalysisRequestByHash(uid uuid.UUID, hash string) (AnalysisRequest, bool, error) {
+	var ar AnalysisRequest
 
-	err := amr.db.
-		Model(Analysis{
-			Id: analysisId,
-		}).
-		Updates(Analysis{Score: score}).Error
+	result := amr.db.Model(&AnalysisRequest{}).
+		Preload("RawData").
+		Preload("Analysis").
+		Joins("JOIN raw_data ON raw_data.analysis_request_id = analysis_requests.id").
+		Where("raw_data.hash = ? AND raw_data.processed_text <> '' AND analysis_requests.user_id <> ?", hash, uid).
+		Order("analysis_requests.created_at DESC").
+		First(&ar)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return AnalysisRequest{}, false, nil
+		}
+		zap.S().Errorw("Could not find shared analysis request by hash", "raw_hash", hash, "error", result.Error)
+		return AnalysisRequest{}, false, result.Error
+	}
+
+	return ar, true, nil
+}
+
+// updateReporterScore stores the score of the reporter. An overridden score
+// stays until the override is reverted.
+func (amr AnalysisManagerRepository) updateReporterScore(analysisId uuid.UUID, score float32) error {
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		var a Analysis
+		if err := tx.Select("id", "analysis_request_id").Where("id = ?", analysisId).First(&a).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(Analysis{Id: analysisId}).Updates(map[string]interface{}{
+			"reporter_score": score,
+			"score":          gorm.Expr("CASE WHEN overridden THEN score ELSE ? END", score),
+		}).Error; err != nil {
+			return err
+		}
+
+		return amr.refreshVerdict(tx, a.AnalysisRequestId)
+	})
 
 	if err != nil {
-		zap.S().Errorw("Could not resolve user ID from analysis ID", "analysis_id", analysisId, "error", err)
+		zap.S().Errorw("Could not update the score", "analysis_id", analysisId, "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (amr AnalysisManagerRepository) deleteAnalysisRequestById(arid, uid uuid.UUID) error {
-	var recCount int64
-	if err := amr.db.Where("id = ? and user_id = ?", arid, uid).Model(AnalysisRequest{}).Count(&recCount).Error; err != nil {
-		zap.S().Errorw("Could not get analysis record for request and user id", "error", err)
+// getOwnedAnalysisForUpdate locks the analysis, it is not found when it is not
+// part of a request of the user.
+func getOwnedAnalysisForUpdate(tx *gorm.DB, uid, aid uuid.UUID) (Analysis, error) {
+	var a Analysis
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "analyses"}}).
+		Joins("JOIN analysis_requests ON analysis_requests.id = analyses.analysis_request_id").
+		Where("analyses.id = ? AND analysis_requests.user_id = ?", aid, uid).
+		First(&a).Error
+	return a, err
+}
+
+// overrideScore sets the score of the analysis by hand and records who did it
+// and why.
+func (amr AnalysisManagerRepository) overrideScore(uid, aid uuid.UUID, score float32, reason string) error {
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		a, err := getOwnedAnalysisForUpdate(tx, uid, aid)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(&ScoreOverride{
+			AnalysisId:        a.Id,
+			AnalysisRequestId: a.AnalysisRequestId,
+			UserId:            uid,
+			ReporterScore:     a.ReporterScore,
+			PreviousScore:     a.Score,
+			Score:             score,
+			Reason:            reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Analysis{}).Where("id = ?", a.Id).Updates(map[string]interface{}{
+			"score":      score,
+			"overridden": true,
+		}).Error; err != nil {
+			return err
+		}
+
+		return amr.refreshVerdict(tx, a.AnalysisRequestId)
+	})
+	if err != nil {
+		zap.S().Errorw("Could not override the score", "analysis_id", aid, "error", err)
 		return err
 	}
+	return nil
+}
+
+// revertScoreOverride puts the score of the reporter back.
+func (amr AnalysisManagerRepository) revertScoreOverride(uid, aid uuid.UUID, reason string) error {
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		a, err := getOwnedAnalysisForUpdate(tx, uid, aid)
+		if err != nil {
+			return err
+		}
+		if !a.Overridden {
+			return ErrNotOverridden
+		}
+
+		if err := tx.Create(&ScoreOverride{
+			AnalysisId:        a.Id,
+			AnalysisRequestId: a.AnalysisRequestId,
+			UserId:            uid,
+			ReporterScore:     a.ReporterScore,
+			PreviousScore:     a.Score,
+			Score:             a.ReporterScore,
+			Reason:            reason,
+			Reverted:          true,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Analysis{}).Where("id = ?", a.Id).Updates(map[string]interface{}{
+			"score":      a.ReporterScore,
+			"overridden": false,
+		}).Error; err != nil {
+			return err
+		}
+
+		return amr.refreshVerdict(tx, a.AnalysisRequestId)
+	})
+	if err != nil {
+		zap.S().Errorw("Could not revert the score override", "analysis_id", aid, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (amr AnalysisManagerRepository) getScoreOverridesByAnalysisRequestId(uid, arid uuid.UUID) ([]ScoreOverride, error) {
+	var ar AnalysisRequest
+	err := amr.db.
+		Preload("ScoreOverrides", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Select("id").
+		Where("id = ? AND user_id = ?", arid, uid).
+		First(&ar).Error
+	if err != nil {
+		zap.S().Errorw("Could not get score overrides", "analysis_request_id", arid, "error", err)
+		return nil, err
+	}
+	return ar.ScoreOverrides, nil
+}
+
+func (amr AnalysisManagerRepository) getProcessedText(arid uuid.UUID) (string, error) {
+	var rd RawData
+	if err := amr.db.Model(&RawData{}).Select("processed_text").Where("analysis_request_id = ?", arid).First(&rd).Error; err != nil {
+		zap.S().Errorw("Could not get processed text", "analysis_request_id", arid, "error", err)
+		return "", err
+	}
+	return rd.ProcessedText, nil
+}
 
-	if recCount == 0 {
-		return errors.New("no record found for request id and user id")
+// getAnalysisRequestIdsByThemeId returns the analysis requests of the user that
+// have an analysis of the theme.
+func (amr AnalysisManagerRepository) getAnalysisRequestIdsByThemeId(uid, tid uuid.UUID) ([]uuid.UUID, error) {
+	var arids []uuid.UUID
+	err := amr.db.Model(&AnalysisRequest{}).
+		Where("user_id = ?", uid).
+		Where("EXISTS (SELECT 1 FROM analyses WHERE analyses.analysis_request_id = analysis_requests.id AND analyses.theme_id = ?)", tid).
+		Order("created_at").
+		Pluck("id", &arids).Error
+	if err != nil {
+		zap.S().Errorw("Could not get analysis requests by theme", "theme_id", tid, "error", err)
+		return nil, err
 	}
+	return arids, nil
+}
+
+// replaceAnalyses moves the analyses of the themes to the history and adds the
+// new analyses in their place. Analyses that are not done are not replaced, the
+// jobs still around for the replaced ones are cancelled along.
+func (amr AnalysisManagerRepository) replaceAnalyses(arid uuid.UUID, themeIds []uuid.UUID, as []Analysis, cancelJobs func(tx *gorm.DB, aids []uuid.UUID) error) error {
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		var old []Analysis
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("analysis_request_id = ? AND theme_id IN ?", arid, themeIds).Find(&old).Error; err != nil {
+			return err
+		}
+		// Checked again under the lock, so a rerun never runs twice
+		if lo.ContainsBy(old, func(a Analysis) bool { return !a.done() }) {
+			return ErrAnalysisInProgress
+		}
+
+		if len(old) > 0 {
+			if err := cancelJobs(tx, lo.Map(old, func(a Analysis, _ int) uuid.UUID { return a.Id })); err != nil {
+				return err
+			}
+
+			now := tx.NowFunc()
+			ahs := lo.Map(old, func(a Analysis, _ int) AnalysisHistory {
+				return AnalysisHistory{
+					Id:                a.Id,
+					AnalysisRequestId: a.AnalysisRequestId,
+					AnalyzerKey:       a.AnalyzerKey,
+					ThemeId:           a.ThemeId,
+					Status:            a.Status,
+					Score:             a.Score,
+					ReporterScore:     a.ReporterScore,
+					Overridden:        a.Overridden,
+					Content:           a.Content,
+					Findings:          a.Findings,
+					Inputs:            a.Inputs,
+					Jobs:              a.Jobs,
+					ReplacedAt:        now,
+				}
+			})
+			if err := tx.Create(&ahs).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("analysis_request_id = ? AND theme_id IN ?", arid, themeIds).Delete(&Analysis{}).Error; err != nil {
+				return err
+			}
+		}
 
-	if err := amr.db.Select(clause.Associations).Delete(&AnalysisRequest{Id: arid}).Error; err != nil {
-		zap.S().Errorw("Could not delete analysis request", "error", err)
+		if len(as) > 0 {
+			if err := tx.Create(&as).Error; err != nil {
+				return err
+			}
+		}
+		return amr.refreshVerdict(tx, arid)
+	})
+	if err != nil {
+		zap.S().Errorw("Could not replace analyses", "analysis_request_id", arid, "error", err)
 		return err
 	}
+	return nil
+}
 
+// deleteAnalysisRequestById deletes the analysis request of the user. The jobs
+// of the request are cancelled first, in the same transaction. The stored
+// content the raw data referred to is returned for removal.
+func (amr AnalysisManagerRepository) deleteAnalysisRequestById(arid, uid uuid.UUID, cancelJobs func(tx *gorm.DB) error) (string, error) {
+	var rd RawData
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		var ars []AnalysisRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ? and user_id = ?", arid, uid).Find(&ars).Error; err != nil {
+			zap.S().Errorw("Could not get analysis record for request and user id", "error", err)
+			return err
+		}
+
+		if len(ars) == 0 {
+			return errors.New("no record found for request id and user id")
+		}
+
+		if err := tx.Select("content_ref").Where("analysis_request_id = ?", arid).Limit(1).Find(&rd).Error; err != nil {
+			zap.S().Errorw("Could not get raw data", "analysis_request_id", arid, "error", err)
+			return err
+		}
+
+		if err := cancelJobs(tx); err != nil {
+			zap.S().Errorw("Could not cancel jobs of analysis request", "error", err)
+			return err
+		}
+
+		if err := tx.Select(requestAssociations).Delete(&AnalysisRequest{Id: arid}).Error; err != nil {
+			zap.S().Errorw("Could not delete analysis request", "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return rd.ContentRef, nil
+}
+
+// getRetentionCandidates returns a page of the requests the rule matches that
+// are older than the cutoff, oldest first. Purged requests are left out when
+// the rule purges.
+func (amr AnalysisManagerRepository) getRetentionCandidates(r retentionRule, cutoff time.Time, offset, limit int) ([]AnalysisRequest, error) {
+	age := "analysis_requests.finished_at"
+	if r.since == retentionSinceRequested {
+		age = "analysis_requests.created_at"
+	}
+
+	q := amr.db.Model(&AnalysisRequest{}).
+		Joins("JOIN raw_data ON raw_data.analysis_request_id = analysis_requests.id").
+		Where(age+" < ?", cutoff)
+	if r.contentType != "" {
+		q = q.Where("lower(analysis_requests.content_type) = lower(?)", r.contentType)
+	}
+	if r.category != "" {
+		q = q.Where("lower(analysis_requests.category) = lower(?)", r.category)
+	}
+	if len(r.verdicts) > 0 {
+		q = q.Where("analysis_requests.verdict IN ?", r.verdicts)
+	}
+	if r.action == RetentionPurgeText {
+		q = q.Where("raw_data.purged_at IS NULL")
+	}
+
+	var ars []AnalysisRequest
+	if err := q.Order(age + ", analysis_requests.id").Offset(offset).Limit(limit).Find(&ars).Error; err != nil {
+		zap.S().Errorw("Could not get retention candidates", "rule", r.name, "error", err)
+		return nil, err
+	}
+	return ars, nil
+}
+
+// purgeRawData removes the content and processed text of the request. It
+// returns the content in the content store that is to be removed as well.
+func (amr AnalysisManagerRepository) purgeRawData(arid uuid.UUID, rule string) (string, error) {
+	var rd RawData
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("content_ref").Where("analysis_request_id = ? AND purged_at IS NULL", arid).First(&rd).Error; err != nil {
+			return err
+		}
+		return tx.Model(&RawData{}).Where("analysis_request_id = ?", arid).Updates(map[string]interface{}{
+			"content":        []byte{},
+			"content_ref":    "",
+			"processed_text": "",
+			"purged_at":      tx.NowFunc(),
+			"purged_by":      rule,
+		}).Error
+	})
+	if err != nil {
+		zap.S().Errorw("Could not purge raw data", "analysis_request_id", arid, "error", err)
+		return "", err
+	}
+	return rd.ContentRef, nil
+}
+
+// deleteExpiredAnalysisRequest deletes the request regardless of its owner,
+// along with its jobs. It returns the content in the content store that is to
+// be removed as well.
+func (amr AnalysisManagerRepository) deleteExpiredAnalysisRequest(arid uuid.UUID, cancelJobs func(tx *gorm.DB) error) (string, error) {
+	var rd RawData
+	err := amr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", arid).First(&AnalysisRequest{}).Error; err != nil {
+			zap.S().Errorw("Could not get analysis request", "analysis_request_id", arid, "error", err)
+			return err
+		}
+
+		if err := tx.Select("content_ref").Where("analysis_request_id = ?", arid).Limit(1).Find(&rd).Error; err != nil {
+			zap.S().Errorw("Could not get raw data", "analysis_request_id", arid, "error", err)
+			return err
+		}
+
+		if err := cancelJobs(tx); err != nil {
+			zap.S().Errorw("Could not cancel jobs of analysis request", "analysis_request_id", arid, "error", err)
+			return err
+		}
+
+		if err := tx.Select(requestAssociations).Delete(&AnalysisRequest{Id: arid}).Error; err != nil {
+			zap.S().Errorw("Could not delete analysis request", "analysis_request_id", arid, "error", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return rd.ContentRef, nil
+}
+
+func (amr AnalysisManagerRepository) createBatch(b *Batch) error {
+	if err := amr.db.Create(b).Error; err != nil {
+		zap.S().Errorw("Could not create batch", "error", err)
+		return err
+	}
 	return nil
 }
+
+func (amr AnalysisManagerRepository) getBatchByIdAndUserId(uid, bid uuid.UUID) (Batch, error) {
+	var b Batch
+
+	err := amr.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position")
+		}).
+		Where("id = ? AND user_id = ?", bid, uid).
+		First(&b).Error
+	if err != nil {
+		zap.S().Errorw("Could not get batch", "batch_id", bid, "user_id", uid, "error", err)
+		return Batch{}, err
+	}
+	return b, nil
+}
+
+func (amr AnalysisManagerRepository) getAnalysesByAnalysisRequestIds(arids []uuid.UUID) ([]Analysis, error) {
+	var as []Analysis
+	if len(arids) == 0 {
+		return as, nil
+	}
+
+	if err := amr.db.Where("analysis_request_id IN ?", arids).Find(&as).Error; err != nil {
+		zap.S().Errorw("Could not get analyses of analysis requests", "error", err)
+		return nil, err
+	}
+	return as, nil
+}
+
+// usesStatsRollup reports whether the counts, terms and trend come from the
+// rollups and lag behind.
+func (amr AnalysisManagerRepository) usesStatsRollup() bool {
+	return amr.statsRollup
+}
+
+// refreshStatsRollup refreshes the rollups without blocking the stats
+// queries.
+func (amr AnalysisManagerRepository) refreshStatsRollup() error {
+	if !amr.statsRollup {
+		return nil
+	}
+	for _, v := range statsRollupViews {
+		if err := amr.db.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + v).Error; err != nil {
+			zap.S().Errorw("Could not refresh the stats rollup", "view", v, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// requestStatsSource returns the table the requests are counted from, the
+// count and the time column of it.
+func (amr AnalysisManagerRepository) requestStatsSource() (string, string, string) {
+	if amr.statsRollup {
+		return "analysis_stats_rollup", "sum(requests)::bigint", "day"
+	}
+	return "analysis_requests", "count(*)", "created_at"
+}
+
+// countRequestsBy counts the requests of the user per content type, category
+// or verdict, the largest first.
+func (amr AnalysisManagerRepository) countRequestsBy(uid uuid.UUID, d statsDimension) ([]statsCount, error) {
+	table, count, _ := amr.requestStatsSource()
+
+	var cs []statsCount
+	err := amr.db.Raw(fmt.Sprintf(`
+		SELECT coalesce(%[1]s, '') AS key, %[2]s AS count
+		FROM %[3]s
+		WHERE user_id = ?
+		GROUP BY 1
+		ORDER BY 2 DESC, 1`, d, count, table), uid).Scan(&cs).Error
+	if err != nil {
+		zap.S().Errorw("Could not count the analysis requests", "dimension", d, "error", err)
+		return nil, err
+	}
+	return cs, nil
+}
+
+// getVerdictTrend counts the requests of the user since the time per bucket,
+// week or month, and verdict. Empty buckets are left out.
+func (amr AnalysisManagerRepository) getVerdictTrend(uid uuid.UUID, bucket string, since time.Time) ([]statsTrendRow, error) {
+	table, count, at := amr.requestStatsSource()
+
+	var rows []statsTrendRow
+	err := amr.db.Raw(fmt.Sprintf(`
+		SELECT date_trunc(?::text, %[1]s) AS bucket, coalesce(verdict, '') AS verdict, %[2]s AS count
+		FROM %[3]s
+		WHERE user_id = ? AND %[1]s >= ?
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, at, count, table), bucket, uid, since).Scan(&rows).Error
+	if err != nil {
+		zap.S().Errorw("Could not get the verdict trend", "bucket", bucket, "error", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// getTopTermsByTheme returns the n terms matched most per theme of the user.
+// Terms are counted case insensitively.
+func (amr AnalysisManagerRepository) getTopTermsByTheme(uid uuid.UUID, n int) ([]statsTermRow, error) {
+	counted := `
+		SELECT a.theme_id, lower(f->>'term') AS term, count(*) AS matches
+		FROM analyses a
+		JOIN analysis_requests ar ON ar.id = a.analysis_request_id
+		CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(a.findings) = 'array' THEN a.findings ELSE '[]' END) f
+		WHERE ar.user_id = ? AND coalesce(f->>'term', '') <> ''
+		GROUP BY 1, 2`
+	if amr.statsRollup {
+		counted = "SELECT theme_id, term, matches FROM analysis_terms_rollup WHERE user_id = ?"
+	}
+
+	var rows []statsTermRow
+	err := amr.db.Raw(`
+		SELECT theme_id, term, matches
+		FROM (
+			SELECT theme_id, term, matches, row_number() OVER (PARTITION BY theme_id ORDER BY matches DESC, term) AS rank
+			FROM (`+counted+`) c
+		) r
+		WHERE rank <= ?
+		ORDER BY theme_id, rank`, uid, n).Scan(&rows).Error
+	if err != nil {
+		zap.S().Errorw("Could not get the top terms", "error", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// getProcessingTimes averages how long the finished parse and analyze jobs of
+// the user took per group key, from being picked up until finished. Only the
+// jobs still in the job history count.
+func (amr AnalysisManagerRepository) getProcessingTimes(uid uuid.UUID) ([]statsProcessingRow, error) {
+	var rows []statsProcessingRow
+	err := amr.db.Raw(`
+		SELECT f.type, f.group_key, count(*) AS jobs, avg(extract(epoch FROM f.created_at - s.started_at))::float8 AS avg_seconds
+		FROM job_events f
+		JOIN analysis_requests ar ON ar.id = f.analysis_request_id
+		CROSS JOIN LATERAL (
+			SELECT max(created_at) AS started_at FROM job_events
+			WHERE job_id = f.job_id AND status = ? AND created_at <= f.created_at
+		) s
+		WHERE ar.user_id = ? AND f.status = ? AND f.type IN ? AND s.started_at IS NOT NULL
+		GROUP BY 1, 2
+		ORDER BY 1, 2`,
+		jobmanager.Inprogress, uid, jobmanager.Finished, []jobmanager.JobType{jobmanager.Parse, jobmanager.Analyze},
+	).Scan(&rows).Error
+	if err != nil {
+		zap.S().Errorw("Could not get the processing times", "error", err)
+		return nil, err
+	}
+	return rows, nil
+}
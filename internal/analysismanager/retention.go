@@ -0,0 +1,328 @@
+package analysismanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RetentionAction string
+
+const (
+	// Removes the content and processed text, the analyses are kept
+	RetentionPurgeText RetentionAction = "purge_text"
+	// Removes the whole analysis request
+	RetentionDeleteRequest RetentionAction = "delete_request"
+)
+
+const (
+	retentionSinceFinished  = "finished"
+	retentionSinceRequested = "requested"
+)
+
+type retentionRule struct {
+	name        string
+	action      RetentionAction
+	contentType string
+	category    string
+	verdicts    []Verdict
+	// Zero keeps the matched requests forever
+	after time.Duration
+	since string
+}
+
+// parseRetentionRules leaves out the invalid rules, so a typo never removes
+// more than configured.
+func parseRetentionRules(rcs []config.RetentionRule) []retentionRule {
+	rules := make([]retentionRule, 0, len(rcs))
+	for i, rc := range rcs {
+		r := retentionRule{
+			name:        rc.Name,
+			action:      RetentionAction(rc.Action),
+			contentType: strings.TrimSpace(rc.ContentType),
+			category:    strings.TrimSpace(rc.Category),
+			verdicts:    lo.Map(rc.Verdicts, func(v string, _ int) Verdict { return Verdict(v) }),
+			after:       time.Duration(rc.AfterDays) * 24 * time.Hour,
+			since:       lo.If(rc.Since == "", retentionSinceFinished).Else(rc.Since),
+		}
+		if r.name == "" {
+			r.name = fmt.Sprintf("%s-%d", r.action, i)
+		}
+
+		switch {
+		case r.action != RetentionPurgeText && r.action != RetentionDeleteRequest:
+			zap.S().Errorw("Ignoring retention rule with unknown action", "rule", r.name, "action", r.action)
+		case r.since != retentionSinceFinished && r.since != retentionSinceRequested:
+			zap.S().Errorw("Ignoring retention rule with unknown since", "rule", r.name, "since", r.since)
+		case rc.AfterDays < 0:
+			zap.S().Errorw("Ignoring retention rule with negative age", "rule", r.name, "after_days", rc.AfterDays)
+		case lo.ContainsBy(rules, func(o retentionRule) bool { return o.name == r.name }):
+			zap.S().Errorw("Ignoring retention rule with duplicate name", "rule", r.name)
+		default:
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func (r retentionRule) matches(ar AnalysisRequest) bool {
+	return (r.contentType == "" || strings.EqualFold(r.contentType, ar.ContentType)) &&
+		(r.category == "" || strings.EqualFold(r.category, ar.Category)) &&
+		(len(r.verdicts) == 0 || lo.Contains(r.verdicts, ar.Verdict))
+}
+
+// expiresAt returns when the rule removes the request, false when it keeps
+// the request for now.
+func (r retentionRule) expiresAt(ar AnalysisRequest) (time.Time, bool) {
+	if r.after == 0 {
+		return time.Time{}, false
+	}
+	if r.since == retentionSinceRequested {
+		return ar.CreatedAt.Add(r.after), true
+	}
+	if ar.FinishedAt == nil {
+		return time.Time{}, false
+	}
+	return ar.FinishedAt.Add(r.after), true
+}
+
+type retentionStore interface {
+	getRetentionCandidates(r retentionRule, cutoff time.Time, offset, limit int) ([]AnalysisRequest, error)
+	purgeRawData(arid uuid.UUID, rule string) (string, error)
+	deleteExpiredAnalysisRequest(arid uuid.UUID, cancelJobs func(tx *gorm.DB) error) (string, error)
+}
+
+type contentRemover interface {
+	Delete(ctx context.Context, name string) error
+}
+
+// RetentionManager purges and deletes analysis requests according to the
+// retention rules.
+type RetentionManager struct {
+	rs        retentionStore
+	cs        contentRemover
+	jc        jobCanceller
+	cb        cancelBroadcaster
+	rules     []retentionRule
+	batchSize int
+	now       func() time.Time
+}
+
+func NewRetentionManager(tc taskCreater, rs retentionStore, cs contentRemover, jc jobCanceller, cb cancelBroadcaster) *RetentionManager {
+	rm := &RetentionManager{
+		rs:        rs,
+		cs:        cs,
+		jc:        jc,
+		cb:        cb,
+		rules:     parseRetentionRules(config.Get().Retention.Rules),
+		batchSize: max(config.Get().Retention.BatchSize, 1),
+		now:       time.Now,
+	}
+
+	zap.S().Infow("Data retention", "rules", len(rm.rules))
+	if len(rm.rules) == 0 {
+		return rm
+	}
+
+	_, err := tc.NewJob(
+		gocron.DurationJob(
+			time.Duration(config.Get().Retention.IntervalMinutes)*time.Minute,
+		),
+		gocron.NewTask(rm.enforce),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		zap.S().Errorw("Could not schedule data retention", "error", err)
+	}
+
+	return rm
+}
+
+// Report lists what the next run removes, without removing anything.
+func (rm *RetentionManager) Report() (RetentionReport, error) {
+	return rm.plan()
+}
+
+func (rm *RetentionManager) enforce() {
+	runLogger := zap.S().With("run_id", uuid.New().String())
+
+	report, err := rm.plan()
+	if err != nil {
+		runLogger.Errorw("Could not plan data retention", "error", err)
+		return
+	}
+	if len(report.Items) == 0 {
+		runLogger.Infow("No data to remove")
+		return
+	}
+
+	for _, it := range report.Items {
+		var ref string
+		var err error
+		if it.Action == RetentionDeleteRequest {
+			ref, err = rm.deleteRequest(it.AnalysisRequestId)
+		} else {
+			ref, err = rm.rs.purgeRawData(it.AnalysisRequestId, it.Rule)
+		}
+		if err != nil {
+			runLogger.Errorw("Could not apply retention rule", "rule", it.Rule, "analysis_request_id", it.AnalysisRequestId, "error", err)
+			continue
+		}
+
+		deleteStoredContent(rm.cs, ref)
+		runLogger.Infow("Applied retention rule", "rule", it.Rule, "action", it.Action, "analysis_request_id", it.AnalysisRequestId)
+	}
+}
+
+// deleteRequest deletes the request and cancels its jobs, a request that is
+// removed some time after it was requested may still be analysed.
+func (rm *RetentionManager) deleteRequest(arid uuid.UUID) (string, error) {
+	var jids []uuid.UUID
+	ref, err := rm.rs.deleteExpiredAnalysisRequest(arid, func(tx *gorm.DB) error {
+		var err error
+		jids, err = rm.jc.CancelJobsByAnalysisRequestId(tx, arid)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(jids) == 0 {
+		return ref, nil
+	}
+
+	// The request is gone either way, workers only finish the jobs for nothing
+	err = rm.cb.Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{
+		JobIds: jids,
+		Reason: "analysis request deleted",
+	})
+	if err != nil {
+		zap.S().Errorw("Could not broadcast cancelled jobs", "analysis_request_id", arid, "error", err)
+	}
+	return ref, nil
+}
+
+// deleteStoredContent removes the content the raw data referred to, failing to
+// only leaves it behind in the store.
+func deleteStoredContent(cs contentRemover, ref string) {
+	if ref == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := cs.Delete(ctx, ref); err != nil {
+		zap.S().Errorw("Could not delete stored content", "content_ref", ref, "error", err)
+	}
+}
+
+// plan lists the requests that are due, at most a batch per rule. Requests
+// that are deleted are not purged as well.
+func (rm *RetentionManager) plan() (RetentionReport, error) {
+	now := rm.now()
+	report := RetentionReport{
+		GeneratedAt: now,
+		Rules:       []RetentionRuleReport{},
+		Items:       []RetentionItem{},
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, action := range []RetentionAction{RetentionDeleteRequest, RetentionPurgeText} {
+		for _, r := range rm.rules {
+			if r.action != action {
+				continue
+			}
+
+			items, err := rm.dueByRule(r, now, seen)
+			if err != nil {
+				return RetentionReport{}, err
+			}
+			report.Items = append(report.Items, items...)
+			report.Rules = append(report.Rules, RetentionRuleReport{
+				Name:      r.name,
+				Action:    r.action,
+				AfterDays: int(r.after / (24 * time.Hour)),
+				Since:     r.since,
+				Due:       len(items),
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// dueByRule pages through the requests old enough for the rule. A request
+// only counts for the rule when no other rule with the same action keeps it
+// longer.
+func (rm *RetentionManager) dueByRule(r retentionRule, now time.Time, seen map[uuid.UUID]bool) ([]RetentionItem, error) {
+	items := []RetentionItem{}
+	if r.after == 0 {
+		return items, nil
+	}
+
+	for offset := 0; len(items) < rm.batchSize; {
+		ars, err := rm.rs.getRetentionCandidates(r, now.Add(-r.after), offset, rm.batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ar := range ars {
+			d, expiresAt, ok := rm.decide(ar, r.action)
+			if !ok || d.name != r.name || now.Before(expiresAt) || seen[ar.Id] {
+				continue
+			}
+			seen[ar.Id] = true
+			items = append(items, RetentionItem{
+				AnalysisRequestId: ar.Id,
+				UserId:            ar.UserId,
+				Title:             ar.Title,
+				Category:          ar.Category,
+				ContentType:       ar.ContentType,
+				Verdict:           ar.Verdict,
+				Action:            r.action,
+				Rule:              r.name,
+				CreatedAt:         ar.CreatedAt,
+				FinishedAt:        ar.FinishedAt,
+				ExpiredAt:         expiresAt,
+			})
+			if len(items) == rm.batchSize {
+				break
+			}
+		}
+
+		if len(ars) < rm.batchSize {
+			break
+		}
+		offset += len(ars)
+	}
+
+	return items, nil
+}
+
+// decide returns the rule with the action that keeps the request the longest
+// and when it expires. It is false when a rule keeps the request for now.
+func (rm *RetentionManager) decide(ar AnalysisRequest, action RetentionAction) (retentionRule, time.Time, bool) {
+	var decider retentionRule
+	var expiresAt time.Time
+	for _, r := range rm.rules {
+		if r.action != action || !r.matches(ar) {
+			continue
+		}
+		at, ok := r.expiresAt(ar)
+		if !ok {
+			return r, time.Time{}, false
+		}
+		if decider.name == "" || at.After(expiresAt) {
+			decider = r
+			expiresAt = at
+		}
+	}
+	return decider, expiresAt, decider.name != ""
+}
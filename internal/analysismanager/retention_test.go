@@ -0,0 +1,188 @@
+package analysismanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var retentionNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func daysAgo(d int) *time.Time {
+	t := retentionNow.Add(-time.Duration(d) * 24 * time.Hour)
+	return &t
+}
+
+func retentionTestRules() []retentionRule {
+	return parseRetentionRules([]config.RetentionRule{
+		{Name: "purge-books", Action: "purge_text", ContentType: "book", AfterDays: 30},
+		{Name: "keep-flagged", Action: "purge_text", Verdicts: []string{"flagged"}, AfterDays: 90},
+		{Name: "delete-all", Action: "delete_request", AfterDays: 365, Since: "requested"},
+	})
+}
+
+func TestParseRetentionRules(t *testing.T) {
+	rules := parseRetentionRules([]config.RetentionRule{
+		{Name: "ok", Action: "purge_text", AfterDays: 30},
+		{Name: "unknown", Action: "archive", AfterDays: 30},
+		{Name: "since", Action: "purge_text", AfterDays: 30, Since: "uploaded"},
+		{Name: "negative", Action: "delete_request", AfterDays: -1},
+		{Name: "ok", Action: "delete_request", AfterDays: 30},
+		{Action: "delete_request", AfterDays: 365},
+	})
+
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "ok", rules[0].name)
+	assert.Equal(t, retentionSinceFinished, rules[0].since)
+	assert.Equal(t, 30*24*time.Hour, rules[0].after)
+	assert.Equal(t, "delete_request-5", rules[1].name)
+}
+
+func TestRetentionDecideKeepsLongest(t *testing.T) {
+	rm := &RetentionManager{rules: retentionTestRules()}
+
+	book := AnalysisRequest{ContentType: "Book", Verdict: VerdictApproved, FinishedAt: daysAgo(40)}
+	r, at, ok := rm.decide(book, RetentionPurgeText)
+	assert.True(t, ok)
+	assert.Equal(t, "purge-books", r.name)
+	assert.Equal(t, daysAgo(10).Unix(), at.Unix())
+
+	// Flagged books are kept longer
+	book.Verdict = VerdictFlagged
+	r, at, ok = rm.decide(book, RetentionPurgeText)
+	assert.True(t, ok)
+	assert.Equal(t, "keep-flagged", r.name)
+	assert.True(t, at.After(retentionNow))
+
+	// Requests that did not finish yet are kept
+	book.FinishedAt = nil
+	_, _, ok = rm.decide(book, RetentionPurgeText)
+	assert.False(t, ok)
+
+	_, _, ok = rm.decide(AnalysisRequest{ContentType: "movie", FinishedAt: daysAgo(40)}, RetentionPurgeText)
+	assert.False(t, ok)
+}
+
+func TestRetentionDecideKeepForever(t *testing.T) {
+	rm := &RetentionManager{rules: parseRetentionRules([]config.RetentionRule{
+		{Name: "delete-all", Action: "delete_request", AfterDays: 30},
+		{Name: "keep-flagged", Action: "delete_request", Verdicts: []string{"flagged"}},
+	})}
+
+	_, _, ok := rm.decide(AnalysisRequest{Verdict: VerdictFlagged, FinishedAt: daysAgo(400)}, RetentionDeleteRequest)
+	assert.False(t, ok)
+
+	r, _, ok := rm.decide(AnalysisRequest{Verdict: VerdictApproved, FinishedAt: daysAgo(400)}, RetentionDeleteRequest)
+	assert.True(t, ok)
+	assert.Equal(t, "delete-all", r.name)
+}
+
+func TestRetentionPlan(t *testing.T) {
+	mockRs := NewMockretentionStore(t)
+	rm := &RetentionManager{
+		rs:        mockRs,
+		rules:     retentionTestRules(),
+		batchSize: 10,
+		now:       func() time.Time { return retentionNow },
+	}
+
+	expired := AnalysisRequest{Id: uuid.New(), ContentType: "book", Verdict: VerdictApproved, CreatedAt: *daysAgo(400), FinishedAt: daysAgo(399)}
+	purged := AnalysisRequest{Id: uuid.New(), ContentType: "book", Verdict: VerdictApproved, CreatedAt: *daysAgo(50), FinishedAt: daysAgo(40)}
+	flagged := AnalysisRequest{Id: uuid.New(), ContentType: "book", Verdict: VerdictFlagged, CreatedAt: *daysAgo(50), FinishedAt: daysAgo(40)}
+
+	mockRs.EXPECT().getRetentionCandidates(mock.MatchedBy(func(r retentionRule) bool { return r.name == "delete-all" }), *daysAgo(365), 0, 10).Return([]AnalysisRequest{expired}, nil)
+	mockRs.EXPECT().getRetentionCandidates(mock.MatchedBy(func(r retentionRule) bool { return r.name == "purge-books" }), *daysAgo(30), 0, 10).Return([]AnalysisRequest{expired, purged, flagged}, nil)
+	mockRs.EXPECT().getRetentionCandidates(mock.MatchedBy(func(r retentionRule) bool { return r.name == "keep-flagged" }), *daysAgo(90), 0, 10).Return([]AnalysisRequest{}, nil)
+
+	report, err := rm.plan()
+
+	assert.NoError(t, err)
+	assert.Len(t, report.Items, 2)
+	assert.Equal(t, expired.Id, report.Items[0].AnalysisRequestId)
+	assert.Equal(t, RetentionDeleteRequest, report.Items[0].Action)
+	assert.Equal(t, purged.Id, report.Items[1].AnalysisRequestId)
+	assert.Equal(t, RetentionPurgeText, report.Items[1].Action)
+	assert.Equal(t, "purge-books", report.Items[1].Rule)
+	assert.Equal(t, []RetentionRuleReport{
+		{Name: "delete-all", Action: RetentionDeleteRequest, AfterDays: 365, Since: retentionSinceRequested, Due: 1},
+		{Name: "purge-books", Action: RetentionPurgeText, AfterDays: 30, Since: retentionSinceFinished, Due: 1},
+		{Name: "keep-flagged", Action: RetentionPurgeText, AfterDays: 90, Since: retentionSinceFinished, Due: 0},
+	}, report.Rules)
+}
+
+func TestRetentionEnforce(t *testing.T) {
+	mockRs := NewMockretentionStore(t)
+	mockCs := NewMockcontentRemover(t)
+	rm := &RetentionManager{
+		rs: mockRs,
+		cs: mockCs,
+		rules: parseRetentionRules([]config.RetentionRule{
+			{Name: "purge", Action: "purge_text", AfterDays: 30},
+		}),
+		batchSize: 2,
+		now:       func() time.Time { return retentionNow },
+	}
+
+	arids := []uuid.UUID{uuid.New(), uuid.New()}
+	page := func(ids ...uuid.UUID) []AnalysisRequest {
+		ars := []AnalysisRequest{}
+		for _, id := range ids {
+			ars = append(ars, AnalysisRequest{Id: id, FinishedAt: daysAgo(31)})
+		}
+		return ars
+	}
+	// Only a batch is purged per run
+	mockRs.EXPECT().getRetentionCandidates(mock.Anything, mock.Anything, 0, 2).Return(page(arids[0], arids[1]), nil)
+
+	mockRs.EXPECT().purgeRawData(arids[0], "purge").Return("uploads/a", nil)
+	mockRs.EXPECT().purgeRawData(arids[1], "purge").Return("", nil)
+	mockCs.EXPECT().Delete(mock.Anything, "uploads/a").Return(nil)
+
+	rm.enforce()
+}
+
+func TestRetentionDeleteCancelsJobs(t *testing.T) {
+	mockRs := NewMockretentionStore(t)
+	mockCs := NewMockcontentRemover(t)
+	mockJc := NewMockjobCanceller(t)
+	mockCb := NewMockcancelBroadcaster(t)
+	rm := &RetentionManager{
+		rs: mockRs,
+		cs: mockCs,
+		jc: mockJc,
+		cb: mockCb,
+		rules: parseRetentionRules([]config.RetentionRule{
+			{Name: "delete", Action: "delete_request", AfterDays: 30, Since: "requested"},
+		}),
+		batchSize: 2,
+		now:       func() time.Time { return retentionNow },
+	}
+
+	arid := uuid.New()
+	jids := []uuid.UUID{uuid.New()}
+	// Still analysing, a month after it was requested
+	mockRs.EXPECT().getRetentionCandidates(mock.Anything, mock.Anything, 0, 2).Return([]AnalysisRequest{{Id: arid, CreatedAt: *daysAgo(31)}}, nil)
+	mockRs.EXPECT().deleteExpiredAnalysisRequest(arid, mock.Anything).RunAndReturn(func(_ uuid.UUID, cancelJobs func(tx *gorm.DB) error) (string, error) {
+		return "uploads/a", cancelJobs(nil)
+	})
+	mockJc.EXPECT().CancelJobsByAnalysisRequestId((*gorm.DB)(nil), arid).Return(jids, nil)
+	mockCb.EXPECT().Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{JobIds: jids, Reason: "analysis request deleted"}).Return(nil)
+	mockCs.EXPECT().Delete(mock.Anything, "uploads/a").Return(nil)
+
+	rm.enforce()
+}
+
+func TestTextState(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, TextPending, RawData{}.textState())
+	assert.Equal(t, TextAvailable, RawData{HasText: true}.textState())
+	assert.Equal(t, TextAvailable, RawData{HasText: true, ExportState: ExportExported}.textState())
+	assert.Equal(t, TextExported, RawData{ExportState: ExportExported}.textState())
+	assert.Equal(t, TextPurged, RawData{ExportState: ExportExported, PurgedAt: &now}.textState())
+}
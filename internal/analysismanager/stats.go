@@ -0,0 +1,248 @@
+package analysismanager
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/guardlight/server/pkg/analysisresult"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidStatsBucket = errors.New("bucket must be week or month")
+
+const (
+	StatsBucketWeek  = "week"
+	StatsBucketMonth = "month"
+)
+
+type statsDimension string
+
+const (
+	statsByContentType statsDimension = "content_type"
+	statsByCategory    statsDimension = "category"
+	statsByVerdict     statsDimension = "verdict"
+)
+
+type statsStore interface {
+	countRequestsBy(uid uuid.UUID, d statsDimension) ([]statsCount, error)
+	getVerdictTrend(uid uuid.UUID, bucket string, since time.Time) ([]statsTrendRow, error)
+	getTopTermsByTheme(uid uuid.UUID, n int) ([]statsTermRow, error)
+	getProcessingTimes(uid uuid.UUID) ([]statsProcessingRow, error)
+	usesStatsRollup() bool
+	refreshStatsRollup() error
+}
+
+// StatsService aggregates the analyses of a user for the dashboard.
+type StatsService struct {
+	ss  statsStore
+	ts  themeService
+	loc *time.Location
+	now func() time.Time
+}
+
+// NewStatsService refreshes the stats rollup on the scheduler when the
+// repository counts from it.
+func NewStatsService(tc taskCreater, ss statsStore, ts themeService) *StatsService {
+	loc, err := time.LoadLocation(config.Get().Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	sts := &StatsService{
+		ss:  ss,
+		ts:  ts,
+		loc: loc,
+		now: time.Now,
+	}
+
+	if !ss.usesStatsRollup() {
+		return sts
+	}
+
+	_, err = tc.NewJob(
+		gocron.DurationJob(
+			time.Duration(max(config.Get().Data.StatsRollupMinutes, 1))*time.Minute,
+		),
+		gocron.NewTask(func() { _ = ss.refreshStatsRollup() }),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		zap.S().Errorw("Could not schedule the stats rollup refresh", "error", err)
+	}
+
+	return sts
+}
+
+// GetStats returns the stats of the user with a trend over the last periods
+// weeks or months, the current one included, and the top terms per theme.
+func (sts *StatsService) GetStats(uid uuid.UUID, bucket string, periods, terms int) (analysisresult.Stats, error) {
+	if bucket != StatsBucketWeek && bucket != StatsBucketMonth {
+		return analysisresult.Stats{}, ErrInvalidStatsBucket
+	}
+
+	stats := analysisresult.Stats{
+		GeneratedAt: sts.now(),
+		FromRollup:  sts.ss.usesStatsRollup(),
+	}
+
+	contentTypes, err := sts.ss.countRequestsBy(uid, statsByContentType)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	categories, err := sts.ss.countRequestsBy(uid, statsByCategory)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	verdicts, err := sts.ss.countRequestsBy(uid, statsByVerdict)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	stats.ContentTypes = mapToCountStats(contentTypes)
+	stats.Categories = mapToCountStats(categories)
+	sts.fillVerdicts(&stats, verdicts)
+
+	since := sts.bucketStart(stats.GeneratedAt, bucket, periods-1)
+	trend, err := sts.ss.getVerdictTrend(uid, bucket, since)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	stats.Trend = sts.fillTrend(trend, bucket, since, periods)
+
+	termRows, err := sts.ss.getTopTermsByTheme(uid, terms)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	if stats.Terms, err = sts.mapToThemeTerms(uid, termRows); err != nil {
+		return analysisresult.Stats{}, err
+	}
+
+	times, err := sts.ss.getProcessingTimes(uid)
+	if err != nil {
+		return analysisresult.Stats{}, err
+	}
+	stats.ProcessingTimes = mapToProcessingTimes(times)
+
+	return stats, nil
+}
+
+func mapToCountStats(cs []statsCount) []analysisresult.CountStat {
+	return lo.Map(cs, func(c statsCount, _ int) analysisresult.CountStat {
+		return analysisresult.CountStat{Key: c.Key, Count: c.Count}
+	})
+}
+
+// fillVerdicts sets the total and the ratios. The approved and flagged ratios
+// leave out the requests without a decided verdict.
+func (sts *StatsService) fillVerdicts(stats *analysisresult.Stats, verdicts []statsCount) {
+	stats.Total = lo.SumBy(verdicts, func(c statsCount) int { return c.Count })
+	stats.Verdicts = lo.Map(verdicts, func(c statsCount, _ int) analysisresult.VerdictStat {
+		return analysisresult.VerdictStat{Verdict: c.Key, Count: c.Count, Ratio: ratio(c.Count, stats.Total)}
+	})
+
+	count := func(v Verdict) int {
+		c, _ := lo.Find(verdicts, func(c statsCount) bool { return c.Key == string(v) })
+		return c.Count
+	}
+	approved, flagged := count(VerdictApproved), count(VerdictFlagged)
+	stats.ApprovedRatio = ratio(approved, approved+flagged)
+	stats.FlaggedRatio = ratio(flagged, approved+flagged)
+}
+
+func ratio(n, total int) float32 {
+	if total == 0 {
+		return 0
+	}
+	return float32(n) / float32(total)
+}
+
+// bucketStart returns the start of the bucket n buckets before the one of t.
+// Weeks start on monday like they do in Postgres.
+func (sts *StatsService) bucketStart(t time.Time, bucket string, n int) time.Time {
+	t = t.In(sts.loc)
+	if bucket == StatsBucketMonth {
+		return time.Date(t.Year(), t.Month()-time.Month(n), 1, 0, 0, 0, 0, sts.loc)
+	}
+	weekday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-weekday-7*n, 0, 0, 0, 0, sts.loc)
+}
+
+// fillTrend puts the counts in a point per bucket, empty buckets included.
+func (sts *StatsService) fillTrend(rows []statsTrendRow, bucket string, since time.Time, periods int) analysisresult.Trend {
+	trend := analysisresult.Trend{
+		Bucket: bucket,
+		Points: make([]analysisresult.TrendPoint, 0, periods),
+	}
+	for i := 0; i < periods; i++ {
+		start := sts.bucketStart(since, bucket, -i)
+		p := analysisresult.TrendPoint{Start: start, Verdicts: map[string]int{}}
+		for _, r := range rows {
+			if r.Bucket.Equal(start) {
+				p.Verdicts[string(r.Verdict)] += r.Count
+				p.Total += r.Count
+			}
+		}
+		trend.Points = append(trend.Points, p)
+	}
+	return trend
+}
+
+// mapToThemeTerms groups the terms by theme. Themes that were deleted since
+// are left out.
+func (sts *StatsService) mapToThemeTerms(uid uuid.UUID, rows []statsTermRow) ([]analysisresult.ThemeTerms, error) {
+	tts := []analysisresult.ThemeTerms{}
+	if len(rows) == 0 {
+		return tts, nil
+	}
+
+	themes, err := sts.ts.GetAllThemesByUserId(uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range themes {
+		ts := lo.Filter(rows, func(r statsTermRow, _ int) bool { return r.ThemeId == t.Id })
+		if len(ts) == 0 {
+			continue
+		}
+		tts = append(tts, analysisresult.ThemeTerms{
+			ThemeId: t.Id,
+			Title:   t.Title,
+			Terms: lo.Map(ts, func(r statsTermRow, _ int) analysisresult.TermStat {
+				return analysisresult.TermStat{Term: r.Term, Count: r.Matches}
+			}),
+		})
+	}
+	return tts, nil
+}
+
+// mapToProcessingTimes names the parsers and analyzers by their group keys,
+// parser.<type> and analyzer.<key>.
+func mapToProcessingTimes(rows []statsProcessingRow) []analysisresult.ProcessingTime {
+	pts := make([]analysisresult.ProcessingTime, 0, len(rows))
+	for _, r := range rows {
+		_, key, _ := strings.Cut(r.GroupKey, ".")
+		name := key
+		switch r.Type {
+		case jobmanager.Parse:
+			if p, ok := config.Get().GetParser(key); ok {
+				name = p.Name
+			}
+		case jobmanager.Analyze:
+			if a, ok := config.Get().GetAnalyzer(key); ok {
+				name = a.Name
+			}
+		}
+		pts = append(pts, analysisresult.ProcessingTime{
+			Type:      string(r.Type),
+			Key:       key,
+			Name:      name,
+			Jobs:      r.Jobs,
+			AverageMs: int64(r.AvgSeconds * 1000),
+		})
+	}
+	return pts
+}
@@ -0,0 +1,96 @@
+package analysismanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/pkg/analysisresult"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsGetStats(t *testing.T) {
+	config.SetupConfig("../../testdata/envs/analysisresults.yaml")
+	mockSs := NewMockstatsStore(t)
+	mockTs := NewMockthemeService(t)
+
+	// A wednesday
+	now := time.Date(2025, 6, 4, 15, 0, 0, 0, time.UTC)
+	sts := &StatsService{ss: mockSs, ts: mockTs, loc: time.UTC, now: func() time.Time { return now }}
+
+	uid := uuid.New()
+	tid := uuid.New()
+	since := time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC)
+
+	mockSs.EXPECT().usesStatsRollup().Return(false)
+	mockSs.EXPECT().countRequestsBy(uid, statsByContentType).Return([]statsCount{{Key: "book", Count: 5}}, nil)
+	mockSs.EXPECT().countRequestsBy(uid, statsByCategory).Return([]statsCount{{Key: "fantasy", Count: 3}, {Key: "", Count: 2}}, nil)
+	mockSs.EXPECT().countRequestsBy(uid, statsByVerdict).Return([]statsCount{
+		{Key: string(VerdictApproved), Count: 3},
+		{Key: string(VerdictFlagged), Count: 1},
+		{Key: string(VerdictPending), Count: 1},
+	}, nil)
+	mockSs.EXPECT().getVerdictTrend(uid, StatsBucketWeek, since).Return([]statsTrendRow{
+		{Bucket: since, Verdict: VerdictApproved, Count: 2},
+		{Bucket: since.AddDate(0, 0, 14), Verdict: VerdictApproved, Count: 1},
+		{Bucket: since.AddDate(0, 0, 14), Verdict: VerdictFlagged, Count: 1},
+	}, nil)
+	mockSs.EXPECT().getTopTermsByTheme(uid, 5).Return([]statsTermRow{
+		{ThemeId: tid, Term: "dragon", Matches: 4},
+		{ThemeId: tid, Term: "sword", Matches: 2},
+		{ThemeId: uuid.New(), Term: "deleted", Matches: 9},
+	}, nil)
+	mockTs.EXPECT().GetAllThemesByUserId(uid).Return([]theme.ThemeDto{{Id: tid, Title: "Violence"}}, nil)
+	mockSs.EXPECT().getProcessingTimes(uid).Return([]statsProcessingRow{
+		{Type: jobmanager.Analyze, GroupKey: "analyzer.word_search", Jobs: 4, AvgSeconds: 1.5},
+		{Type: jobmanager.Parse, GroupKey: "parser.freetext", Jobs: 2, AvgSeconds: 0.25},
+	}, nil)
+
+	s, err := sts.GetStats(uid, StatsBucketWeek, 3, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, s.Total)
+	assert.Equal(t, float32(0.75), s.ApprovedRatio)
+	assert.Equal(t, float32(0.25), s.FlaggedRatio)
+	assert.Equal(t, analysisresult.VerdictStat{Verdict: "pending", Count: 1, Ratio: 0.2}, s.Verdicts[2])
+	assert.Equal(t, []analysisresult.CountStat{{Key: "fantasy", Count: 3}, {Key: "", Count: 2}}, s.Categories)
+
+	assert.Equal(t, []analysisresult.TrendPoint{
+		{Start: since, Total: 2, Verdicts: map[string]int{"approved": 2}},
+		{Start: since.AddDate(0, 0, 7), Total: 0, Verdicts: map[string]int{}},
+		{Start: since.AddDate(0, 0, 14), Total: 2, Verdicts: map[string]int{"approved": 1, "flagged": 1}},
+	}, s.Trend.Points)
+
+	assert.Equal(t, []analysisresult.ThemeTerms{{
+		ThemeId: tid,
+		Title:   "Violence",
+		Terms:   []analysisresult.TermStat{{Term: "dragon", Count: 4}, {Term: "sword", Count: 2}},
+	}}, s.Terms)
+
+	assert.Equal(t, []analysisresult.ProcessingTime{
+		{Type: "analyze", Key: "word_search", Name: "Word Search Analyzer", Jobs: 4, AverageMs: 1500},
+		{Type: "parse", Key: "freetext", Name: "Freetext parsers", Jobs: 2, AverageMs: 250},
+	}, s.ProcessingTimes)
+}
+
+func TestStatsInvalidBucket(t *testing.T) {
+	sts := &StatsService{ss: NewMockstatsStore(t), ts: NewMockthemeService(t), loc: time.UTC, now: time.Now}
+
+	_, err := sts.GetStats(uuid.New(), "day", 12, 10)
+
+	assert.ErrorIs(t, err, ErrInvalidStatsBucket)
+}
+
+func TestStatsMonthBuckets(t *testing.T) {
+	sts := &StatsService{loc: time.UTC}
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), sts.bucketStart(now, StatsBucketMonth, 3))
+
+	trend := sts.fillTrend([]statsTrendRow{}, StatsBucketMonth, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), 4)
+	assert.Len(t, trend.Points, 4)
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), trend.Points[2].Start)
+}
@@ -0,0 +1,103 @@
+package analysismanager
+
+import (
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// VerdictRule decides how the verdicts of the themes of a request combine.
+type VerdictRule string
+
+const (
+	// VerdictRuleAny flags the request as soon as a single theme flags it
+	VerdictRuleAny VerdictRule = "any"
+	// VerdictRuleMajority follows the verdict of more than half of the themes
+	VerdictRuleMajority VerdictRule = "majority"
+	// VerdictRuleUnanimous only approves or flags when all themes agree
+	VerdictRuleUnanimous VerdictRule = "unanimous"
+)
+
+// parseVerdictRule falls back to VerdictRuleAny for unknown rules.
+func parseVerdictRule(r string) VerdictRule {
+	switch VerdictRule(r) {
+	case VerdictRuleAny, VerdictRuleMajority, VerdictRuleUnanimous:
+		return VerdictRule(r)
+	default:
+		zap.S().Warnw("Unknown verdict rule, falling back to any", "rule", r)
+		return VerdictRuleAny
+	}
+}
+
+// analysisVerdict reads the score of a single analysis. Reporters score 1 for
+// clean and -1 for flagged content, a score of 0 was not reported yet.
+func analysisVerdict(a Analysis) Verdict {
+	switch {
+	case a.Status == AnalysisError:
+		return VerdictError
+	case a.Score == 0:
+		return VerdictPending
+	case a.Score < 0:
+		return VerdictFlagged
+	case a.Score >= 1:
+		return VerdictApproved
+	default:
+		return VerdictNeedsReview
+	}
+}
+
+// aggregateVerdict combines the analyses of a request. A theme flags when any
+// of its analyzers does, the themes are combined with the rule. An error in any
+// analysis makes the whole request an error.
+func aggregateVerdict(as []Analysis, rule VerdictRule) Verdict {
+	if len(as) == 0 {
+		return VerdictPending
+	}
+
+	themes := lo.GroupBy(as, func(a Analysis) uuid.UUID { return a.ThemeId })
+	vs := make([]Verdict, 0, len(themes))
+	for _, tas := range themes {
+		vs = append(vs, combineAny(lo.Map(tas, func(a Analysis, _ int) Verdict { return analysisVerdict(a) })))
+	}
+
+	if lo.Contains(vs, VerdictError) {
+		return VerdictError
+	}
+
+	switch rule {
+	case VerdictRuleMajority:
+		for _, v := range []Verdict{VerdictFlagged, VerdictApproved} {
+			if lo.Count(vs, v)*2 > len(vs) {
+				return v
+			}
+		}
+		if lo.Contains(vs, VerdictPending) {
+			return VerdictPending
+		}
+		return VerdictNeedsReview
+	case VerdictRuleUnanimous:
+		if lo.Contains(vs, VerdictPending) {
+			return VerdictPending
+		}
+		if lo.EveryBy(vs, func(v Verdict) bool { return v == vs[0] }) && vs[0] != VerdictNeedsReview {
+			return vs[0]
+		}
+		return VerdictNeedsReview
+	default:
+		return combineAny(vs)
+	}
+}
+
+// combineAny returns the most severe verdict. A flag is final even while other
+// parts are still pending.
+func combineAny(vs []Verdict) Verdict {
+	for _, v := range []Verdict{VerdictError, VerdictFlagged, VerdictPending, VerdictNeedsReview} {
+		if lo.Contains(vs, v) {
+			return v
+		}
+	}
+	return VerdictApproved
+}
+
+// verdictSeverityOrder sorts the most severe verdicts first.
+const verdictSeverityOrder = "CASE verdict WHEN 'error' THEN 0 WHEN 'flagged' THEN 1 WHEN 'needs_review' THEN 2 WHEN 'pending' THEN 3 ELSE 4 END"
@@ -0,0 +1,73 @@
+package analysismanager
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	verdictThemeA = uuid.MustParse("0f6d2d1e-2f4b-4c53-9d0a-6f0f3b6a1c01")
+	verdictThemeB = uuid.MustParse("0f6d2d1e-2f4b-4c53-9d0a-6f0f3b6a1c02")
+	verdictThemeC = uuid.MustParse("0f6d2d1e-2f4b-4c53-9d0a-6f0f3b6a1c03")
+)
+
+func scored(tid uuid.UUID, score float32) Analysis {
+	return Analysis{ThemeId: tid, Status: AnalysisFinished, Score: score}
+}
+
+func TestAnalysisVerdict(t *testing.T) {
+	assert.Equal(t, VerdictPending, analysisVerdict(Analysis{Status: AnalysisInprogress}))
+	assert.Equal(t, VerdictError, analysisVerdict(Analysis{Status: AnalysisError, Score: 1}))
+	assert.Equal(t, VerdictFlagged, analysisVerdict(scored(verdictThemeA, -1)))
+	assert.Equal(t, VerdictApproved, analysisVerdict(scored(verdictThemeA, 1)))
+	assert.Equal(t, VerdictNeedsReview, analysisVerdict(scored(verdictThemeA, 0.5)))
+}
+
+func TestAggregateVerdictAny(t *testing.T) {
+	assert.Equal(t, VerdictPending, aggregateVerdict(nil, VerdictRuleAny))
+	assert.Equal(t, VerdictApproved, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, 1), scored(verdictThemeB, 1),
+	}, VerdictRuleAny))
+	// A flag is final while the other theme is still running
+	assert.Equal(t, VerdictFlagged, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, -1), {ThemeId: verdictThemeB, Status: AnalysisInprogress},
+	}, VerdictRuleAny))
+	assert.Equal(t, VerdictError, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, -1), {ThemeId: verdictThemeB, Status: AnalysisError},
+	}, VerdictRuleAny))
+}
+
+func TestAggregateVerdictMajority(t *testing.T) {
+	// The second analyzer of theme A flags the whole theme
+	assert.Equal(t, VerdictFlagged, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, 1), scored(verdictThemeA, -1), scored(verdictThemeB, -1), scored(verdictThemeC, 1),
+	}, VerdictRuleMajority))
+	assert.Equal(t, VerdictApproved, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, 1), scored(verdictThemeB, -1), scored(verdictThemeC, 1),
+	}, VerdictRuleMajority))
+	assert.Equal(t, VerdictNeedsReview, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, 1), scored(verdictThemeB, -1),
+	}, VerdictRuleMajority))
+	assert.Equal(t, VerdictPending, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, 1), {ThemeId: verdictThemeB}, scored(verdictThemeC, -1),
+	}, VerdictRuleMajority))
+}
+
+func TestAggregateVerdictUnanimous(t *testing.T) {
+	assert.Equal(t, VerdictFlagged, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, -1), scored(verdictThemeB, -1),
+	}, VerdictRuleUnanimous))
+	assert.Equal(t, VerdictNeedsReview, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, -1), scored(verdictThemeB, 1),
+	}, VerdictRuleUnanimous))
+	assert.Equal(t, VerdictPending, aggregateVerdict([]Analysis{
+		scored(verdictThemeA, -1), {ThemeId: verdictThemeB},
+	}, VerdictRuleUnanimous))
+}
+
+func TestParseVerdictRule(t *testing.T) {
+	assert.Equal(t, VerdictRuleMajority, parseVerdictRule("majority"))
+	assert.Equal(t, VerdictRuleAny, parseVerdictRule("most"))
+}
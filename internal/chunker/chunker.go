@@ -0,0 +1,293 @@
+// Package chunker splits processed text into the windows analyzers work on.
+// Chunks end on sentence boundaries where possible, so a sentence is never cut
+// in two unless it does not fit a window on its own.
+package chunker
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+type Unit string
+
+const (
+	Characters Unit = "characters"
+	Tokens     Unit = "tokens"
+
+	// DefaultEncoding is the tiktoken encoding used for token windows
+	DefaultEncoding = "cl100k_base"
+)
+
+var ErrInvalidSize = errors.New("chunk size must be positive")
+
+// sentence-ending punctuation (including optional closing quote) or a blank line
+var sentenceEnd = regexp.MustCompile(`[.!?]["')\]]?\s+|[。！？]["')\]」]?\s*|\n\s*\n\s*`)
+
+func init() {
+	// The encodings are embedded, nothing is downloaded at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Options size the chunks. Size and Overlap are counted in Unit, Overlap is
+// the amount of text at the end of a chunk that starts the next one again.
+type Options struct {
+	Size     int
+	Overlap  int
+	Unit     Unit
+	Encoding string
+}
+
+// Chunk is a window of the text. Start and End are character offsets into the
+// full text, End is exclusive.
+type Chunk struct {
+	Index int
+	Text  string
+	Start int
+	End   int
+}
+
+// span is a piece of the text by byte offsets, with its length in the unit of
+// the chunks.
+type span struct {
+	start, end int
+	length     int
+}
+
+// measure counts the units of a text. boundaries returns the byte offset after
+// every unit, so a text can be cut between them.
+type measure interface {
+	length(s string) int
+	boundaries(s string) []int
+}
+
+// Validate checks the options the way Split does, without any text to split.
+func Validate(opts Options) error {
+	if opts.Size <= 0 {
+		return ErrInvalidSize
+	}
+	_, err := measurer(opts)
+	return err
+}
+
+// Split cuts the text into chunks of at most opts.Size. The chunks cover the
+// whole text, each one starting with the overlap of the previous one.
+func Split(text string, opts Options) ([]Chunk, error) {
+	if opts.Size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	if text == "" {
+		return []Chunk{}, nil
+	}
+
+	m, err := measurer(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// An overlap as large as a chunk would never move forward
+	overlap := max(0, min(opts.Overlap, opts.Size/2))
+
+	pieces := make([]span, 0)
+	for _, s := range sentences(text) {
+		pieces = append(pieces, fit(text, s, opts.Size, m)...)
+	}
+
+	// Character offsets of the start of every piece and the end of the text
+	runeStarts := make([]int, len(pieces)+1)
+	for i, p := range pieces {
+		runeStarts[i+1] = runeStarts[i] + utf8.RuneCountInString(text[p.start:p.end])
+	}
+
+	chunks := make([]Chunk, 0)
+	first := 0
+	for first < len(pieces) {
+		last, length := first, pieces[first].length
+		for last+1 < len(pieces) && length+pieces[last+1].length <= opts.Size {
+			last++
+			length += pieces[last].length
+		}
+
+		start, end := pieces[first].start, pieces[last].end
+		chunks = append(chunks, Chunk{
+			Index: len(chunks),
+			Text:  text[start:end],
+			Start: runeStarts[first],
+			End:   runeStarts[last+1],
+		})
+		if last == len(pieces)-1 {
+			break
+		}
+
+		// Start the next chunk with the trailing pieces that fit the overlap,
+		// but always after the start of this one
+		next, ol := last+1, 0
+		for next-1 > first && ol+pieces[next-1].length <= overlap {
+			next--
+			ol += pieces[next].length
+		}
+		first = next
+	}
+
+	return chunks, nil
+}
+
+// sentences splits the text in sentences, the whitespace after a sentence
+// belongs to it so the sentences cover the whole text.
+func sentences(text string) []span {
+	ss := make([]span, 0)
+	start := 0
+	for _, m := range sentenceEnd.FindAllStringIndex(text, -1) {
+		ss = append(ss, span{start: start, end: m[1]})
+		start = m[1]
+	}
+	if start < len(text) {
+		ss = append(ss, span{start: start, end: len(text)})
+	}
+	return ss
+}
+
+// fit measures the sentence and splits it on words when it does not fit a
+// chunk, and words in parts when even those do not.
+func fit(text string, s span, size int, m measure) []span {
+	s.length = m.length(text[s.start:s.end])
+	if s.length <= size {
+		return []span{s}
+	}
+
+	pieces := make([]span, 0)
+	for _, w := range words(text, s) {
+		w.length = m.length(text[w.start:w.end])
+		if w.length <= size {
+			pieces = append(pieces, w)
+			continue
+		}
+		pieces = append(pieces, cut(text, w, size, m)...)
+	}
+	return pieces
+}
+
+// words splits a span after every run of whitespace.
+func words(text string, s span) []span {
+	ws := make([]span, 0)
+	start := s.start
+	inSpace := false
+	for i, r := range text[s.start:s.end] {
+		if unicode.IsSpace(r) {
+			inSpace = true
+			continue
+		}
+		if inSpace && s.start+i > start {
+			ws = append(ws, span{start: start, end: s.start + i})
+			start = s.start + i
+		}
+		inSpace = false
+	}
+	return append(ws, span{start: start, end: s.end})
+}
+
+// cut splits a span that has no boundaries left in parts of at most size
+// units. Parts only end between characters.
+func cut(text string, s span, size int, m measure) []span {
+	parts := make([]span, 0)
+	start, n := s.start, 0
+	for _, b := range m.boundaries(text[s.start:s.end]) {
+		n++
+		end := s.start + b
+		if n < size && end < s.end {
+			continue
+		}
+		for end > start && end < s.end && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		if end == start {
+			continue
+		}
+		parts = append(parts, span{start: start, end: end, length: m.length(text[start:end])})
+		start, n = end, 0
+	}
+	if start < s.end {
+		parts = append(parts, span{start: start, end: s.end, length: m.length(text[start:s.end])})
+	}
+	return parts
+}
+
+type characters struct{}
+
+func (characters) length(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func (characters) boundaries(s string) []int {
+	bs := make([]int, 0, len(s))
+	for i, r := range s {
+		bs = append(bs, i+utf8.RuneLen(r))
+	}
+	return bs
+}
+
+type tokens struct {
+	enc *tiktoken.Tiktoken
+}
+
+func (t tokens) length(s string) int {
+	return len(t.enc.EncodeOrdinary(s))
+}
+
+// boundaries relies on the tokens being byte level, together they decode to
+// exactly the bytes of the text.
+func (t tokens) boundaries(s string) []int {
+	tks := t.enc.EncodeOrdinary(s)
+	bs := make([]int, 0, len(tks))
+	b := 0
+	for _, tk := range tks {
+		b += len(t.enc.Decode([]int{tk}))
+		bs = append(bs, min(b, len(s)))
+	}
+	return bs
+}
+
+func measurer(opts Options) (measure, error) {
+	switch opts.Unit {
+	case "", Characters:
+		return characters{}, nil
+	case Tokens:
+		enc, err := encoding(opts.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return tokens{enc: enc}, nil
+	default:
+		return nil, errors.New("unknown chunk unit " + string(opts.Unit))
+	}
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktoken.Tiktoken{}
+)
+
+// encoding returns the tokenizer of the encoding, building one is expensive so
+// they are kept.
+func encoding(name string) (*tiktoken.Tiktoken, error) {
+	if name == "" {
+		name = DefaultEncoding
+	}
+
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = enc
+	return enc, nil
+}
@@ -0,0 +1,101 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertCovers checks the chunks point at their own text and together cover
+// the whole text.
+func assertCovers(t *testing.T, text string, chunks []Chunk) {
+	rs := []rune(text)
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, string(rs[c.Start:c.End]), c.Text)
+		if i > 0 {
+			assert.LessOrEqual(t, c.Start, chunks[i-1].End)
+			assert.Greater(t, c.Start, chunks[i-1].Start)
+		}
+	}
+	assert.Equal(t, 0, chunks[0].Start)
+	assert.Equal(t, len(rs), chunks[len(chunks)-1].End)
+}
+
+func TestSplitOnSentences(t *testing.T) {
+	text := "The gun was old. It was in the drawer! Nobody knew? Then she found it."
+
+	chunks, err := Split(text, Options{Size: 40})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"The gun was old. It was in the drawer! ",
+		"Nobody knew? Then she found it.",
+	}, []string{chunks[0].Text, chunks[1].Text})
+	assertCovers(t, text, chunks)
+}
+
+func TestSplitWithOverlap(t *testing.T) {
+	text := "One is here. Two is here. Three is here. Four is here."
+
+	chunks, err := Split(text, Options{Size: 30, Overlap: 15})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"One is here. Two is here. ",
+		"Two is here. Three is here. ",
+		"Three is here. Four is here.",
+	}, []string{chunks[0].Text, chunks[1].Text, chunks[2].Text})
+	assertCovers(t, text, chunks)
+}
+
+func TestSplitLongSentence(t *testing.T) {
+	text := "Ünder a very long sentence without any end " + strings.Repeat("ü", 25)
+
+	chunks, err := Split(text, Options{Size: 10, Overlap: 3})
+
+	assert.NoError(t, err)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(c.Text), 10)
+		assert.True(t, utf8.ValidString(c.Text))
+	}
+	assert.Equal(t, "Ünder a ", chunks[0].Text)
+	assertCovers(t, text, chunks)
+}
+
+func TestSplitTokens(t *testing.T) {
+	text := strings.Repeat("The knife is sharp. ", 20) + strings.Repeat("語", 30)
+
+	chunks, err := Split(text, Options{Size: 16, Unit: Tokens})
+
+	assert.NoError(t, err)
+	enc, err := encoding("")
+	assert.NoError(t, err)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, len(enc.EncodeOrdinary(c.Text)), 16)
+		assert.True(t, utf8.ValidString(c.Text))
+	}
+	assert.Equal(t, "The knife is sharp. The knife is sharp. ", chunks[0].Text)
+	assertCovers(t, text, chunks)
+}
+
+func TestSplitInvalidOptions(t *testing.T) {
+	_, err := Split("text", Options{Size: 0})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+
+	_, err = Split("text", Options{Size: 10, Unit: "lines"})
+	assert.Error(t, err)
+
+	chunks, err := Split("", Options{Size: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(Options{Size: 100, Unit: Tokens}))
+	assert.ErrorIs(t, Validate(Options{Size: 0}), ErrInvalidSize)
+	assert.Error(t, Validate(Options{Size: 100, Unit: "lines"}))
+	assert.Error(t, Validate(Options{Size: 100, Unit: Tokens, Encoding: "unknown_base"}))
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/creasty/defaults"
 	"github.com/google/uuid"
@@ -36,18 +40,115 @@ type GLConfig struct {
 	Reporters    []reporter   `koanf:"reporters"`
 	Users        []User       `koanf:"users"`
 	Data         data         `koanf:"data"`
+	Webhook      webhook      `koanf:"webhook"`
+	Retention    retention    `koanf:"retention"`
+	Encryption   encryption   `koanf:"encryption"`
 }
 
 type data struct {
 	ExportProcessedText bool   `koanf:"exportProcessedText" default:"false"`
 	ExportPath          string `koanf:"exportPath" default:"/data/books/processed"`
+	// Reuse the results of other users that analyzed the same content
+	ShareResults bool `koanf:"shareResults" default:"false"`
+	// Largest file accepted by the upload endpoint
+	MaxUploadBytes int64 `koanf:"maxUploadBytes" default:"67108864"`
+	// Most items accepted in a single batch submission
+	MaxBatchItems int `koanf:"maxBatchItems" default:"500"`
+	// Largest JSON batch accepted, and the most the files of a batch archive
+	// may take up once uncompressed
+	MaxBatchBytes int64 `koanf:"maxBatchBytes" default:"268435456"`
+	// Index the processed text for search, encryption requires it to be off
+	Search bool `koanf:"search" default:"true"`
+	// Postgres text search configuration the processed text is indexed with
+	SearchLanguage string `koanf:"searchLanguage" default:"english"`
+	// How the themes of a request combine into its verdict: any, majority or unanimous
+	VerdictRule string `koanf:"verdictRule" default:"any"`
+	// Where the processed text is exported to: directory, tar, zip or s3
+	ExportSink string `koanf:"exportSink" default:"directory"`
+	// How the processed text is exported: text, jsonl or markdown
+	ExportFormat string `koanf:"exportFormat" default:"text"`
+	// Keep the processed text in the database after it is exported
+	ExportKeepText bool     `koanf:"exportKeepText" default:"false"`
+	ExportS3       exportS3 `koanf:"exportS3"`
+	// Count the stats from materialized rollups, refreshed every
+	// StatsRollupMinutes, instead of the analyses themselves. For large libraries.
+	StatsRollup        bool `koanf:"statsRollup" default:"false"`
+	StatsRollupMinutes int  `koanf:"statsRollupMinutes" default:"15"`
+}
+
+// exportS3 points the export at a bucket of an S3 compatible API.
+type exportS3 struct {
+	Endpoint  string `koanf:"endpoint" default:"-"`
+	Region    string `koanf:"region" default:"us-east-1"`
+	Bucket    string `koanf:"bucket" default:"-"`
+	Prefix    string `koanf:"prefix" default:"-"`
+	AccessKey string `koanf:"accessKey" default:"-"`
+	SecretKey string `koanf:"secretKey" default:"-"`
+	PathStyle bool   `koanf:"pathStyle" default:"true"`
+}
+
+// webhook configures how the deliveries in the webhook outbox are sent.
+type webhook struct {
+	IntervalSeconds int `koanf:"intervalSeconds" default:"5"`
+	TimeoutSeconds  int `koanf:"timeoutSeconds" default:"10"`
+	// Most deliveries sent per interval
+	BatchSize int         `koanf:"batchSize" default:"20"`
+	Retry     RetryPolicy `koanf:"retry"`
+	// Let receivers on loopback, private and link local addresses be used
+	AllowPrivateNetworks bool `koanf:"allowPrivateNetworks" default:"false"`
+}
+
+// retention removes the content of old analysis requests. Without rules
+// everything is kept.
+type retention struct {
+	IntervalMinutes int `koanf:"intervalMinutes" default:"60"`
+	// Most requests a rule acts on per run
+	BatchSize int             `koanf:"batchSize" default:"100"`
+	Rules     []RetentionRule `koanf:"rules"`
+}
+
+// RetentionRule purges the text of, or deletes, the analysis requests it
+// matches once they are AfterDays old. Empty filters match every request. When
+// several rules with the same action match, the one keeping the request the
+// longest wins, an AfterDays of 0 keeps it forever.
+type RetentionRule struct {
+	Name string `koanf:"name" default:"-"`
+	// purge_text or delete_request
+	Action      string   `koanf:"action" default:"-"`
+	ContentType string   `koanf:"contentType" default:"-"`
+	Category    string   `koanf:"category" default:"-"`
+	Verdicts    []string `koanf:"verdicts" default:"-"`
+	AfterDays   int      `koanf:"afterDays" default:"-"`
+	// The age counts from when the report finished, the default, or from when
+	// the request was made: finished or requested
+	Since string `koanf:"since" default:"-"`
+}
+
+// encryption encrypts the content and processed text of analysis requests at
+// rest. Keys are 32 bytes, base64 encoded, set inline or read from a file.
+type encryption struct {
+	Enabled   bool   `koanf:"enabled" default:"false"`
+	KeyId     string `koanf:"keyId" default:"primary"`
+	MasterKey string `koanf:"masterKey" default:"-"`
+	KeyFile   string `koanf:"keyFile" default:"-"`
+	// Keys rotated out, they decrypt the rows that were not rotated yet
+	PreviousKeys []EncryptionKey `koanf:"previousKeys"`
+	// Rows re-encrypted per transaction by the rotate-keys command
+	RotateBatchSize int `koanf:"rotateBatchSize" default:"100"`
+}
+
+type EncryptionKey struct {
+	Id        string `koanf:"id" default:"-"`
+	MasterKey string `koanf:"masterKey" default:"-"`
+	KeyFile   string `koanf:"keyFile" default:"-"`
 }
 
 type nats struct {
-	Server   string `koanf:"server" default:"-"`
-	Port     int    `koanf:"port" default:"4222"`
-	User     string `koanf:"user" default:"-"`
-	Password string `koanf:"password" default:"-"`
+	Server         string `koanf:"server" default:"-"`
+	Port           int    `koanf:"port" default:"4222"`
+	User           string `koanf:"user" default:"-"`
+	Password       string `koanf:"password" default:"-"`
+	AckWaitSeconds int    `koanf:"ackWaitSeconds" default:"60"`
 }
 
 type User struct {
@@ -76,7 +177,49 @@ type database struct {
 }
 
 type orchestrator struct {
-	ScheduleRateCron string `koanf:"scheduleRateCron" default:"*/5 * * * * *"`
+	ScheduleRateCron  string         `koanf:"scheduleRateCron" default:"*/5 * * * * *"`
+	ListenForJobs     bool           `koanf:"listenForJobs" default:"true"`
+	ReconcileRateCron string         `koanf:"reconcileRateCron" default:"*/30 * * * * *"`
+	Retry             retryPolicies  `koanf:"retry"`
+	Runtime           adapterRuntime `koanf:"runtime"`
+	// Days the job events of a request are kept after its last one, 0 keeps
+	// them forever
+	HistoryRetentionDays int            `koanf:"historyRetentionDays" default:"30"`
+	Leader               leaderElection `koanf:"leader"`
+}
+
+// leaderElection makes sure only one replica orchestrates when several
+// replicas share the database.
+type leaderElection struct {
+	// Advisory lock id, replicas with the same id compete for the leadership
+	LockId               int64 `koanf:"lockId" default:"7419283"`
+	CheckIntervalSeconds int   `koanf:"checkIntervalSeconds" default:"5"`
+}
+
+// adapterRuntime configures how adapters with External=false are run by the
+// server itself.
+type adapterRuntime struct {
+	ReadyTimeoutSeconds int `koanf:"readyTimeoutSeconds" default:"10"`
+	IdleTimeoutSeconds  int `koanf:"idleTimeoutSeconds" default:"300"`
+	RestartDelaySeconds int `koanf:"restartDelaySeconds" default:"2"`
+}
+
+type retryPolicies struct {
+	Default RetryPolicy `koanf:"default"`
+	Parse   RetryPolicy `koanf:"parse"`
+	Analyze RetryPolicy `koanf:"analyze"`
+	Report  RetryPolicy `koanf:"report"`
+}
+
+// RetryPolicy describes how often and how fast a job is retried. Zero values
+// are inherited from the less specific policy (adapter -> job type -> default).
+type RetryPolicy struct {
+	MaxAttempts              int     `koanf:"maxAttempts" default:"-"`
+	InitialDelaySeconds      int     `koanf:"initialDelaySeconds" default:"-"`
+	MaxDelaySeconds          int     `koanf:"maxDelaySeconds" default:"-"`
+	BackoffMultiplier        float64 `koanf:"backoffMultiplier" default:"-"`
+	Jitter                   float64 `koanf:"jitter" default:"-"`
+	InprogressTimeoutSeconds int     `koanf:"inprogressTimeoutSeconds" default:"-"`
 }
 
 type jwt struct {
@@ -89,22 +232,28 @@ type console struct {
 }
 
 type Parser struct {
-	Image       string `koanf:"image" default:"-"`
-	External    bool   `koanf:"external" default:"-"`
-	Key         string `koanf:"key" default:"-"`
-	Name        string `koanf:"name" default:"-"`
-	Description string `koanf:"description" default:"-"`
-	Type        string `koanf:"type" default:"-"`
-	Concurrency int    `koanf:"concurrency" default:"-"`
+	Image       string      `koanf:"image" default:"-"`
+	External    bool        `koanf:"external" default:"-"`
+	Key         string      `koanf:"key" default:"-"`
+	Name        string      `koanf:"name" default:"-"`
+	Description string      `koanf:"description" default:"-"`
+	Type        string      `koanf:"type" default:"-"`
+	Concurrency int         `koanf:"concurrency" default:"-"`
+	Retry       RetryPolicy `koanf:"retry"`
+	Command     string      `koanf:"command" default:"-"`
+	Args        []string    `koanf:"args" default:"-"`
 }
 
 type reporter struct {
-	Image       string `koanf:"image" default:"-"`
-	External    bool   `koanf:"external" default:"-"`
-	Key         string `koanf:"key" default:"-"`
-	Name        string `koanf:"name" default:"-"`
-	Description string `koanf:"description" default:"-"`
-	Concurrency int    `koanf:"concurrency" default:"-"`
+	Image       string      `koanf:"image" default:"-"`
+	External    bool        `koanf:"external" default:"-"`
+	Key         string      `koanf:"key" default:"-"`
+	Name        string      `koanf:"name" default:"-"`
+	Description string      `koanf:"description" default:"-"`
+	Concurrency int         `koanf:"concurrency" default:"-"`
+	Retry       RetryPolicy `koanf:"retry"`
+	Command     string      `koanf:"command" default:"-"`
+	Args        []string    `koanf:"args" default:"-"`
 }
 
 type analyzer struct {
@@ -117,6 +266,16 @@ type analyzer struct {
 	Model         string          `koanf:"model" default:"-"`
 	Concurrency   int             `koanf:"concurrency" default:"-"`
 	Inputs        []AnalyzerInput `koanf:"inputs" default:"-"`
+	Retry         RetryPolicy     `koanf:"retry"`
+	Command       string          `koanf:"command" default:"-"`
+	Args          []string        `koanf:"args" default:"-"`
+
+	// ChunkOverlap is how much of the end of a chunk starts the next one,
+	// ChunkUnit counts it and ContextWindow in characters or tokens. Token
+	// windows use the tiktoken Encoding, cl100k_base when empty.
+	ChunkOverlap int    `koanf:"chunkOverlap" default:"-"`
+	ChunkUnit    string `koanf:"chunkUnit" default:"-"`
+	Encoding     string `koanf:"encoding" default:"-"`
 }
 
 type AnalyzerInput struct {
@@ -139,6 +298,8 @@ func SetupConfig(envFilePath string) {
 	}
 
 	configBasicAdapters(defaultedConfig)
+	configDefaultRetryPolicy(defaultedConfig)
+	configDefaultWebhookRetryPolicy(defaultedConfig)
 
 	// Load defaults variables
 	if err := k.Load(structs.Provider(defaultedConfig, "koanf"), nil); err != nil {
@@ -193,8 +354,8 @@ func SetupConfig(envFilePath string) {
 		zap.S().Infow("config", "config", ffc)
 	}
 
-	if err := validateAnalyzers(ffc); err != nil {
-		zap.S().Fatalw("Invalid analyzer config", "error", err)
+	if err := validateEncryption(ffc); err != nil {
+		zap.S().Fatalw("Invalid encryption config", "error", err)
 	}
 
 	conf = ffc
@@ -247,6 +408,116 @@ func (fc GLConfig) GetReporter(reporterKey string) (reporter, bool) {
 	})
 }
 
+// GetRetryPolicy resolves the retry policy for a job by layering the adapter
+// policy (found through the group key, e.g. "parser.epub") over the job type
+// policy over the default policy.
+func (fc GLConfig) GetRetryPolicy(jobType, groupKey string) RetryPolicy {
+	rp := fc.Orchestrator.Retry.Default
+
+	switch jobType {
+	case "parse":
+		rp = rp.merge(fc.Orchestrator.Retry.Parse)
+	case "analyze":
+		rp = rp.merge(fc.Orchestrator.Retry.Analyze)
+	case "report":
+		rp = rp.merge(fc.Orchestrator.Retry.Report)
+	}
+
+	kind, key, _ := strings.Cut(groupKey, ".")
+	switch kind {
+	case "parser":
+		if p, ok := fc.GetParser(key); ok {
+			rp = rp.merge(p.Retry)
+		}
+	case "analyzer":
+		if a, ok := fc.GetAnalyzer(key); ok {
+			rp = rp.merge(a.Retry)
+		}
+	case "reporter":
+		if r, ok := fc.GetReporter(key); ok {
+			rp = rp.merge(r.Retry)
+		}
+	}
+
+	return rp
+}
+
+// GetConcurrency returns the concurrency of the adapter a group key
+// (e.g. "analyzer.word_search") belongs to.
+func (fc GLConfig) GetConcurrency(groupKey string) (int, bool) {
+	kind, key, _ := strings.Cut(groupKey, ".")
+	switch kind {
+	case "parser":
+		if p, ok := fc.GetParser(key); ok {
+			return p.Concurrency, true
+		}
+	case "analyzer":
+		if a, ok := fc.GetAnalyzer(key); ok {
+			return a.Concurrency, true
+		}
+	case "reporter":
+		if r, ok := fc.GetReporter(key); ok {
+			return r.Concurrency, true
+		}
+	}
+	return 0, false
+}
+
+func (rp RetryPolicy) merge(o RetryPolicy) RetryPolicy {
+	if o.MaxAttempts > 0 {
+		rp.MaxAttempts = o.MaxAttempts
+	}
+	if o.InitialDelaySeconds > 0 {
+		rp.InitialDelaySeconds = o.InitialDelaySeconds
+	}
+	if o.MaxDelaySeconds > 0 {
+		rp.MaxDelaySeconds = o.MaxDelaySeconds
+	}
+	if o.BackoffMultiplier > 0 {
+		rp.BackoffMultiplier = o.BackoffMultiplier
+	}
+	if o.Jitter > 0 {
+		rp.Jitter = o.Jitter
+	}
+	if o.InprogressTimeoutSeconds > 0 {
+		rp.InprogressTimeoutSeconds = o.InprogressTimeoutSeconds
+	}
+	return rp
+}
+
+// Exhausted reports whether a job with the given retry count may not be retried again.
+func (rp RetryPolicy) Exhausted(retryCount int) bool {
+	return retryCount >= rp.MaxAttempts
+}
+
+// Backoff returns how long to wait before the next attempt after retryCount
+// failed ones.
+func (rp RetryPolicy) Backoff(retryCount int) time.Duration {
+	if retryCount <= 0 || rp.InitialDelaySeconds <= 0 {
+		return 0
+	}
+
+	mult := rp.BackoffMultiplier
+	if mult < 1 {
+		mult = 1
+	}
+
+	delay := float64(rp.InitialDelaySeconds) * math.Pow(mult, float64(retryCount-1))
+	if rp.MaxDelaySeconds > 0 {
+		delay = math.Min(delay, float64(rp.MaxDelaySeconds))
+	}
+
+	if rp.Jitter > 0 {
+		delay += delay * rp.Jitter * (rand.Float64()*2 - 1)
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
+
+func (rp RetryPolicy) InprogressTimeout() time.Duration {
+	return time.Duration(rp.InprogressTimeoutSeconds) * time.Second
+}
+
 func configBasicAdapters(defaultedConfig *GLConfig) {
 	defaultedConfig.Analyzers = append(defaultedConfig.Analyzers, analyzer{
 		Key:           "word_search",
@@ -293,6 +564,28 @@ func configBasicAdapters(defaultedConfig *GLConfig) {
 	})
 }
 
+func configDefaultRetryPolicy(defaultedConfig *GLConfig) {
+	defaultedConfig.Orchestrator.Retry.Default = RetryPolicy{
+		MaxAttempts:              3,
+		InitialDelaySeconds:      5,
+		MaxDelaySeconds:          300,
+		BackoffMultiplier:        2,
+		Jitter:                   0.2,
+		InprogressTimeoutSeconds: 60,
+	}
+}
+
+// Receivers can be down for a while, so deliveries are retried for about a day.
+func configDefaultWebhookRetryPolicy(defaultedConfig *GLConfig) {
+	defaultedConfig.Webhook.Retry = RetryPolicy{
+		MaxAttempts:         10,
+		InitialDelaySeconds: 30,
+		MaxDelaySeconds:     21600,
+		BackoffMultiplier:   2,
+		Jitter:              0.2,
+	}
+}
+
 func configSigningKey(k *koanf.Koanf) {
 	// Will be overriden if provided by Environment variable: GUARDLIGHT_CONSOLE_JWT_SIGNING_KEY
 	sMapKey := "console.jwt.signingKey"
@@ -323,9 +616,12 @@ func configAdminUser(k *koanf.Koanf) {
 	}
 }
 
-func validateAnalyzers(gc *GLConfig) error {
-	for _, _ = range gc.Analyzers {
-		// Validate analyzer input
+// validateEncryption makes turning search off explicit. The search index holds
+// the words of the processed text in plaintext, so it is dropped once the text
+// is encrypted.
+func validateEncryption(gc *GLConfig) error {
+	if gc.Encryption.Enabled && gc.Data.Search {
+		return errors.New("encryption turns search off, set data.search to false")
 	}
 	return nil
 }
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	rp := RetryPolicy{
+		MaxAttempts:         5,
+		InitialDelaySeconds: 5,
+		MaxDelaySeconds:     30,
+		BackoffMultiplier:   2,
+	}
+
+	assert.Equal(t, time.Duration(0), rp.Backoff(0))
+	assert.Equal(t, 5*time.Second, rp.Backoff(1))
+	assert.Equal(t, 10*time.Second, rp.Backoff(2))
+	assert.Equal(t, 20*time.Second, rp.Backoff(3))
+	assert.Equal(t, 30*time.Second, rp.Backoff(4))
+
+	t.Run("jitter", func(t *testing.T) {
+		rp.Jitter = 0.2
+		for range 20 {
+			d := rp.Backoff(2)
+			assert.GreaterOrEqual(t, d, 8*time.Second)
+			assert.LessOrEqual(t, d, 12*time.Second)
+		}
+	})
+}
+
+func TestValidateEncryption(t *testing.T) {
+	assert.NoError(t, validateEncryption(&GLConfig{Data: data{Search: true}}))
+	assert.NoError(t, validateEncryption(&GLConfig{Encryption: encryption{Enabled: true}}))
+	assert.Error(t, validateEncryption(&GLConfig{Encryption: encryption{Enabled: true}, Data: data{Search: true}}))
+}
@@ -0,0 +1,278 @@
+// Package encryption encrypts column values at rest with envelope encryption.
+// Every value gets its own data key, which is sealed with the master key. Only
+// the sealed data key and the id of the master key are stored with the value,
+// so rotating the master key never needs the old data keys in the clear.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/guardlight/server/internal/essential/config"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 12
+	// A sealed data key is the data key and the GCM tag
+	sealedKeySize = keySize + 16
+)
+
+var (
+	// magic starts every encrypted value, textPrefix every encrypted text
+	magic      = []byte("GLE1")
+	textPrefix = "glenc:v1:"
+
+	ErrUnknownKey   = errors.New("value is encrypted with an unknown key")
+	ErrNoKeys       = errors.New("value is encrypted but no keys are configured")
+	ErrInvalidValue = errors.New("encrypted value is malformed")
+)
+
+// Keyring holds the master keys. New values are sealed with the current key,
+// the others only open the values that were not rotated yet.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	kr := &Keyring{
+		current: current,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		aead, err := newAead(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		kr.keys[id] = aead
+	}
+	if _, ok := kr.keys[current]; !ok {
+		return nil, fmt.Errorf("current key %s is missing", current)
+	}
+	return kr, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
+
+// Seal encrypts the plaintext with a new data key. The additional data, the
+// column name, has to match when opening.
+//
+// Layout: magic | key id length | key id | nonce | sealed data key | nonce | ciphertext
+func (kr *Keyring) Seal(plaintext, ad []byte) ([]byte, error) {
+	dk := make([]byte, keySize)
+	keyNonce := make([]byte, nonceSize)
+	dataNonce := make([]byte, nonceSize)
+	for _, b := range [][]byte{dk, keyNonce, dataNonce} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := newAead(dk)
+	if err != nil {
+		return nil, err
+	}
+
+	id := kr.current
+	out := make([]byte, 0, len(magic)+1+len(id)+2*nonceSize+sealedKeySize+len(plaintext)+data.Overhead())
+	out = append(out, magic...)
+	out = append(out, byte(len(id)))
+	out = append(out, id...)
+	out = append(out, keyNonce...)
+	out = kr.keys[id].Seal(out, keyNonce, dk, []byte(id))
+	out = append(out, dataNonce...)
+	return data.Seal(out, dataNonce, plaintext, ad), nil
+}
+
+func (kr *Keyring) Open(value, ad []byte) ([]byte, error) {
+	id, rest, ok := parse(value)
+	if !ok {
+		return nil, ErrInvalidValue
+	}
+	master, ok := kr.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	dk, err := master.Open(nil, rest[:nonceSize], rest[nonceSize:nonceSize+sealedKeySize], []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAead(dk)
+	if err != nil {
+		return nil, err
+	}
+	rest = rest[nonceSize+sealedKeySize:]
+	return data.Open(nil, rest[:nonceSize], rest[nonceSize:], ad)
+}
+
+// parse splits an encrypted value in the key id and the part after it.
+func parse(value []byte) (string, []byte, bool) {
+	if !bytes.HasPrefix(value, magic) || len(value) < len(magic)+1 {
+		return "", nil, false
+	}
+	value = value[len(magic):]
+	n := int(value[0])
+	if len(value) < 1+n+2*nonceSize+sealedKeySize+16 {
+		return "", nil, false
+	}
+	return string(value[1 : 1+n]), value[1+n:], true
+}
+
+// KeyId returns the id of the master key the value is encrypted with, false
+// when the value is not encrypted.
+func KeyId(value []byte) (string, bool) {
+	id, _, ok := parse(value)
+	return id, ok
+}
+
+// KeyIdText is KeyId for values of text columns.
+func KeyIdText(value string) (string, bool) {
+	if !strings.HasPrefix(value, textPrefix) {
+		return "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(value[len(textPrefix):])
+	if err != nil {
+		return "", false
+	}
+	return KeyId(b)
+}
+
+type state struct {
+	kr *Keyring
+	// Whether new values are encrypted, existing ones can be read regardless
+	seal bool
+}
+
+var active atomic.Pointer[state]
+
+// Use makes the serializer encrypt new values with the keyring when seal is
+// set. Without keyring values are stored and read as they are.
+func Use(kr *Keyring, seal bool) {
+	active.Store(&state{kr: kr, seal: seal && kr != nil})
+}
+
+// Sealing reports whether new values are encrypted.
+func Sealing() bool {
+	s := active.Load()
+	return s != nil && s.seal
+}
+
+// CurrentKeyId is the id of the key new values are encrypted with, empty when
+// they are not.
+func CurrentKeyId() string {
+	s := active.Load()
+	if s == nil || !s.seal {
+		return ""
+	}
+	return s.kr.current
+}
+
+// Setup loads the keys of the config. The keys are kept when encryption is
+// disabled, so values encrypted before can still be read and rotated back to
+// plaintext.
+func Setup() error {
+	ec := config.Get().Encryption
+
+	keys := make(map[string][]byte)
+	for _, k := range append([]config.EncryptionKey{{Id: ec.KeyId, MasterKey: ec.MasterKey, KeyFile: ec.KeyFile}}, ec.PreviousKeys...) {
+		if k.MasterKey == "" && k.KeyFile == "" {
+			continue
+		}
+		if _, ok := keys[k.Id]; ok {
+			return fmt.Errorf("duplicate key id %s", k.Id)
+		}
+		key, err := loadKey(k)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", k.Id, err)
+		}
+		keys[k.Id] = key
+	}
+
+	if len(keys) == 0 {
+		if ec.Enabled {
+			return errors.New("encryption is enabled without a master key")
+		}
+		Use(nil, false)
+		return nil
+	}
+
+	current := ec.KeyId
+	if _, ok := keys[current]; !ok {
+		if ec.Enabled {
+			return fmt.Errorf("master key %s is missing", current)
+		}
+		// Only previous keys are left to decrypt with
+		for id := range keys {
+			current = id
+		}
+	}
+
+	kr, err := NewKeyring(current, keys)
+	if err != nil {
+		return err
+	}
+	Use(kr, ec.Enabled)
+	return nil
+}
+
+// loadKey reads a base64 encoded key from the config or the key file.
+func loadKey(k config.EncryptionKey) ([]byte, error) {
+	encoded := k.MasterKey
+	if k.KeyFile != "" {
+		b, err := os.ReadFile(k.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(b)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key is not base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// Stale reports whether the value is not encrypted the way new values are,
+// with another key or not at all. Empty values never are.
+func Stale(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+	id, _ := KeyId(value)
+	return id != CurrentKeyId()
+}
+
+// StaleText is Stale for values of text columns.
+func StaleText(value string) bool {
+	if value == "" {
+		return false
+	}
+	id, _ := KeyIdText(value)
+	return id != CurrentKeyId()
+}
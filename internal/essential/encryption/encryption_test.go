@@ -0,0 +1,139 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, keySize)
+	newKey = bytes.Repeat([]byte{2}, keySize)
+)
+
+type record struct {
+	Content []byte `gorm:"column:content;serializer:encrypted"`
+	Text    string `gorm:"column:text;serializer:encrypted"`
+}
+
+func keyring(t *testing.T, current string) *Keyring {
+	kr, err := NewKeyring(current, map[string][]byte{"old": oldKey, "new": newKey})
+	assert.NoError(t, err)
+	return kr
+}
+
+func field(t *testing.T, name string) *schema.Field {
+	s, err := schema.Parse(&record{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.NoError(t, err)
+	return s.LookUpField(name)
+}
+
+func TestSealOpen(t *testing.T) {
+	kr := keyring(t, "new")
+
+	sealed, err := kr.Seal([]byte("Alice was beginning to get very tired"), []byte("text"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "Alice")
+	id, ok := KeyId(sealed)
+	assert.True(t, ok)
+	assert.Equal(t, "new", id)
+
+	plain, err := kr.Open(sealed, []byte("text"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice was beginning to get very tired", string(plain))
+
+	// Every value has its own data key
+	again, err := kr.Seal([]byte("Alice was beginning to get very tired"), []byte("text"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	_, err = kr.Open(sealed, []byte("content"))
+	assert.Error(t, err)
+
+	_, err = kr.Open(sealed[:len(sealed)-20], []byte("text"))
+	assert.Error(t, err)
+
+	other, err := NewKeyring("old", map[string][]byte{"old": oldKey})
+	assert.NoError(t, err)
+	_, err = other.Open(sealed, []byte("text"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestNewKeyringValidates(t *testing.T) {
+	_, err := NewKeyring("new", map[string][]byte{"new": []byte("short")})
+	assert.Error(t, err)
+
+	_, err = NewKeyring("missing", map[string][]byte{"new": newKey})
+	assert.Error(t, err)
+}
+
+func TestSerializer(t *testing.T) {
+	defer Use(nil, false)
+	ctx := context.Background()
+	text := field(t, "Text")
+	content := field(t, "Content")
+
+	t.Run("plaintext", func(t *testing.T) {
+		Use(nil, false)
+
+		v, err := Serializer{}.Value(ctx, text, reflect.Value{}, "Alice")
+		assert.NoError(t, err)
+		assert.Equal(t, "Alice", v)
+
+		var r record
+		assert.NoError(t, Serializer{}.Scan(ctx, text, reflect.ValueOf(&r).Elem(), "Alice"))
+		assert.Equal(t, "Alice", r.Text)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		Use(keyring(t, "new"), true)
+
+		sealed, err := Serializer{}.Value(ctx, text, reflect.Value{}, "Alice")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sealed.(string), textPrefix))
+		assert.False(t, StaleText(sealed.(string)))
+
+		b, err := Serializer{}.Value(ctx, content, reflect.Value{}, []byte("%PDF"))
+		assert.NoError(t, err)
+		assert.False(t, Stale(b.([]byte)))
+
+		var r record
+		assert.NoError(t, Serializer{}.Scan(ctx, text, reflect.ValueOf(&r).Elem(), sealed))
+		assert.NoError(t, Serializer{}.Scan(ctx, content, reflect.ValueOf(&r).Elem(), b))
+		assert.Equal(t, "Alice", r.Text)
+		assert.Equal(t, []byte("%PDF"), r.Content)
+
+		// Empty values are kept empty, rows from before encryption stay readable
+		v, err := Serializer{}.Value(ctx, text, reflect.Value{}, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "", v)
+		assert.NoError(t, Serializer{}.Scan(ctx, text, reflect.ValueOf(&r).Elem(), "plain"))
+		assert.Equal(t, "plain", r.Text)
+		assert.True(t, StaleText("plain"))
+
+		// A value of one column can not be read as another
+		assert.Error(t, Serializer{}.Scan(ctx, content, reflect.ValueOf(&r).Elem(), []byte(sealed.(string))))
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		Use(keyring(t, "old"), true)
+		v, err := Serializer{}.Value(ctx, text, reflect.Value{}, "Alice")
+		assert.NoError(t, err)
+
+		Use(keyring(t, "new"), true)
+		assert.True(t, StaleText(v.(string)))
+		var r record
+		assert.NoError(t, Serializer{}.Scan(ctx, text, reflect.ValueOf(&r).Elem(), v))
+		assert.Equal(t, "Alice", r.Text)
+
+		// Without keys the encrypted value can not be read
+		Use(nil, false)
+		assert.ErrorIs(t, Serializer{}.Scan(ctx, text, reflect.ValueOf(&r).Elem(), v), ErrNoKeys)
+	})
+}
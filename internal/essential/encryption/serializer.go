@@ -0,0 +1,92 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer encrypts string and []byte fields tagged with
+// serializer:encrypted. Text columns get the value base64 encoded behind a
+// prefix. Empty values are stored as they are, so queries can still tell
+// them apart. Values that are not encrypted are read as they are, which keeps
+// the rows from before encryption readable.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var b []byte
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("can not decrypt %T of %s", dbValue, field.DBName)
+	}
+
+	plain, err := open(b, field.DBName)
+	if err != nil {
+		return fmt.Errorf("decrypting %s: %w", field.DBName, err)
+	}
+
+	fv := field.ReflectValueOf(ctx, dst)
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		fv.SetString(string(plain))
+	case reflect.Slice:
+		fv.SetBytes(plain)
+	default:
+		return fmt.Errorf("can not decrypt into %s", field.FieldType)
+	}
+	return nil
+}
+
+func open(value []byte, column string) ([]byte, error) {
+	if s := string(value); strings.HasPrefix(s, textPrefix) {
+		b, err := base64.StdEncoding.DecodeString(s[len(textPrefix):])
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		value = b
+	} else if _, ok := KeyId(value); !ok {
+		return value, nil
+	}
+
+	s := active.Load()
+	if s == nil || s.kr == nil {
+		return nil, ErrNoKeys
+	}
+	return s.kr.Open(value, []byte(column))
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	s := active.Load()
+	seal := s != nil && s.seal
+	switch v := fieldValue.(type) {
+	case string:
+		if v == "" || !seal {
+			return v, nil
+		}
+		b, err := s.kr.Seal([]byte(v), []byte(field.DBName))
+		if err != nil {
+			return nil, err
+		}
+		return textPrefix + base64.StdEncoding.EncodeToString(b), nil
+	case []byte:
+		if len(v) == 0 || !seal {
+			return v, nil
+		}
+		return s.kr.Seal(v, []byte(field.DBName))
+	default:
+		return nil, fmt.Errorf("can not encrypt %T of %s", fieldValue, field.DBName)
+	}
+}
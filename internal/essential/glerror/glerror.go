@@ -57,6 +57,10 @@ func UnauthorizedError() (int, gin.H) {
 	return http.StatusUnauthorized, NewError(errors.New("not authorized"), http.StatusUnauthorized, "Not authorized.").StructedError()
 }
 
+func ForbiddenError() (int, gin.H) {
+	return http.StatusForbidden, NewError(errors.New("forbidden"), http.StatusForbidden, "Forbidden.").StructedError()
+}
+
 func ResourceAlreadyExistError() (int, gin.H) {
 	return http.StatusBadRequest, NewError(errors.New("resource already exist"), http.StatusBadRequest, "Resource already exist.").StructedError()
 }
@@ -69,6 +73,14 @@ func ConflictError() (int, gin.H) {
 	return http.StatusConflict, NewError(errors.New("resource already exist"), http.StatusConflict, "resource already exist.").StructedError()
 }
 
+func StateConflictError() (int, gin.H) {
+	return http.StatusConflict, NewError(errors.New("resource state conflict"), http.StatusConflict, "Resource is busy or not ready.").StructedError()
+}
+
+func PayloadTooLargeError() (int, gin.H) {
+	return http.StatusRequestEntityTooLarge, NewError(errors.New("payload too large"), http.StatusRequestEntityTooLarge, "Payload too large.").StructedError()
+}
+
 func InvalidBodyError(err error) (int, gin.H) {
 	return http.StatusBadRequest, NewError(err, http.StatusBadRequest, "Bind error for request. See server logs").StructedError()
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/glerror"
 	"go.uber.org/zap"
 )
 
@@ -16,6 +17,7 @@ const (
 	ApiKeyHeader         = "x-api-key"
 	ConsoleApiCookieName = "guardlight_session"
 	ContextNameUserId    = "guardlight-user-id"
+	ContextNameUserRole  = "guardlight-user-role"
 )
 
 type UserRole string
@@ -104,6 +106,7 @@ func UseGuardlightAuth() gin.HandlerFunc {
 		}
 
 		ctx.Set(ContextNameUserId, sub)
+		ctx.Set(ContextNameUserRole, string(cl.Role))
 		ctx.Next()
 	}
 }
@@ -121,6 +124,7 @@ func UseGuardlightAuthApiKey() gin.HandlerFunc {
 		for _, us := range config.Get().Users {
 			if us.ApiKey == apiKey {
 				ctx.Set(ContextNameUserId, us.Id.String())
+				ctx.Set(ContextNameUserRole, us.Role)
 				ctx.Next()
 				return
 			}
@@ -131,3 +135,16 @@ func UseGuardlightAuthApiKey() gin.HandlerFunc {
 
 	}
 }
+
+// UseGuardlightRole only allows users with the given role. It has to be used
+// after one of the authentication middlewares.
+func UseGuardlightRole(role UserRole) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if UserRole(ctx.GetString(ContextNameUserRole)) != role {
+			zap.S().Errorw("User does not have the required role", "role", role, "user_id", ctx.GetString(ContextNameUserId))
+			ctx.AbortWithStatusJSON(glerror.ForbiddenError())
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,68 @@
+// Package export writes the processed text of analysis requests out of the
+// database. A Format encodes a document, a Sink stores the encoded documents
+// of an export run.
+package export
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/pkg/analyzercontract"
+)
+
+// Document is an analysis request with everything a format can export.
+type Document struct {
+	Id          uuid.UUID
+	Title       string
+	Category    string
+	ContentType string
+	Verdict     string
+	CreatedAt   time.Time
+	Text        string
+	Analyses    []Analysis
+}
+
+type Analysis struct {
+	AnalyzerKey string
+	ThemeId     uuid.UUID
+	Status      string
+	Score       float32
+	Findings    []analyzercontract.Finding
+}
+
+// Format encodes a document into the content of a single exported file.
+type Format interface {
+	Name() string
+	// Extension of the exported files, including the dot
+	Extension() string
+	Encode(d Document) ([]byte, error)
+}
+
+// Sink stores the documents of an export run under a relative name. Put
+// returns a reference to where the document ended up. A document only counts
+// as exported once Close succeeded, archives are only complete then.
+type Sink interface {
+	Put(ctx context.Context, name string, data []byte) (string, error)
+	Close() error
+}
+
+const (
+	FormatText     = "text"
+	FormatJsonl    = "jsonl"
+	FormatMarkdown = "markdown"
+)
+
+func NewFormat(name string) (Format, error) {
+	switch name {
+	case "", FormatText:
+		return textFormat{}, nil
+	case FormatJsonl:
+		return jsonlFormat{}, nil
+	case FormatMarkdown:
+		return markdownFormat{}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %s", name)
+	}
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type textFormat struct{}
+
+func (textFormat) Name() string      { return FormatText }
+func (textFormat) Extension() string { return ".txt" }
+
+func (textFormat) Encode(d Document) ([]byte, error) {
+	return []byte(d.Text), nil
+}
+
+// jsonlFormat writes a single line per document, so the exported files can be
+// concatenated into one corpus.
+type jsonlFormat struct{}
+
+type jsonlRecord struct {
+	Id          uuid.UUID       `json:"id"`
+	Title       string          `json:"title"`
+	Category    string          `json:"category"`
+	ContentType string          `json:"contentType"`
+	Verdict     string          `json:"verdict"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Analyses    []jsonlAnalysis `json:"analyses"`
+	Text        string          `json:"text"`
+}
+
+type jsonlAnalysis struct {
+	AnalyzerKey string    `json:"analyzerKey"`
+	ThemeId     uuid.UUID `json:"themeId"`
+	Status      string    `json:"status"`
+	Score       float32   `json:"score"`
+	Findings    int       `json:"findings"`
+}
+
+func (jsonlFormat) Name() string      { return FormatJsonl }
+func (jsonlFormat) Extension() string { return ".jsonl" }
+
+func (jsonlFormat) Encode(d Document) ([]byte, error) {
+	r := jsonlRecord{
+		Id:          d.Id,
+		Title:       d.Title,
+		Category:    d.Category,
+		ContentType: d.ContentType,
+		Verdict:     d.Verdict,
+		CreatedAt:   d.CreatedAt,
+		Analyses:    make([]jsonlAnalysis, 0, len(d.Analyses)),
+		Text:        d.Text,
+	}
+	for _, a := range d.Analyses {
+		r.Analyses = append(r.Analyses, jsonlAnalysis{
+			AnalyzerKey: a.AnalyzerKey,
+			ThemeId:     a.ThemeId,
+			Status:      a.Status,
+			Score:       a.Score,
+			Findings:    len(a.Findings),
+		})
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// markdownFormat writes the metadata and findings of the analyses above the
+// text.
+type markdownFormat struct{}
+
+func (markdownFormat) Name() string      { return FormatMarkdown }
+func (markdownFormat) Extension() string { return ".md" }
+
+func (markdownFormat) Encode(d Document) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownLine(d.Title))
+	fmt.Fprintf(&b, "- Category: %s\n", markdownLine(d.Category))
+	fmt.Fprintf(&b, "- Content type: %s\n", d.ContentType)
+	fmt.Fprintf(&b, "- Verdict: %s\n", d.Verdict)
+	fmt.Fprintf(&b, "- Requested: %s\n", d.CreatedAt.UTC().Format(time.RFC3339))
+
+	b.WriteString("\n## Findings\n")
+	if len(d.Analyses) == 0 {
+		b.WriteString("\nNo analyses.\n")
+	}
+	for _, a := range d.Analyses {
+		fmt.Fprintf(&b, "\n### %s\n\n", a.AnalyzerKey)
+		fmt.Fprintf(&b, "Theme %s, %s, score %g.\n\n", a.ThemeId, a.Status, a.Score)
+		if len(a.Findings) == 0 {
+			b.WriteString("Nothing found.\n")
+			continue
+		}
+		for _, f := range a.Findings {
+			fmt.Fprintf(&b, "- **%s** (%d-%d): %s\n", markdownLine(f.Term), f.Start, f.End, markdownLine(f.Sentence))
+		}
+	}
+
+	b.WriteString("\n## Text\n\n")
+	b.WriteString(d.Text)
+	if !strings.HasSuffix(d.Text, "\n") {
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+// markdownLine keeps a value on its own line of the markdown.
+func markdownLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDocument() Document {
+	return Document{
+		Id:          uuid.MustParse("674e46b6-a4f5-4b4f-bc16-c29ba80971c0"),
+		Title:       "Alice in\nWonderland",
+		Category:    "Fantasy",
+		ContentType: "book",
+		Verdict:     "flagged",
+		CreatedAt:   time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Text:        "Alice had a knife. The end.",
+		Analyses: []Analysis{
+			{
+				AnalyzerKey: "word_search",
+				ThemeId:     uuid.MustParse("bd2d2784-0fcb-4526-ad3c-76d00964f4de"),
+				Status:      "finished",
+				Score:       -1,
+				Findings:    []analyzercontract.Finding{{Term: "knife", Sentence: "Alice had a knife.", Start: 12, End: 17}},
+			},
+		},
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	f, err := NewFormat("")
+	assert.NoError(t, err)
+
+	b, err := f.Encode(testDocument())
+
+	assert.NoError(t, err)
+	assert.Equal(t, ".txt", f.Extension())
+	assert.Equal(t, "Alice had a knife. The end.", string(b))
+}
+
+func TestJsonlFormat(t *testing.T) {
+	f, err := NewFormat(FormatJsonl)
+	assert.NoError(t, err)
+
+	b, err := f.Encode(testDocument())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(b), "\n"))
+	assert.True(t, strings.HasSuffix(string(b), "\n"))
+
+	var r jsonlRecord
+	assert.NoError(t, json.Unmarshal(b, &r))
+	assert.Equal(t, "Alice in\nWonderland", r.Title)
+	assert.Equal(t, "flagged", r.Verdict)
+	assert.Equal(t, "Alice had a knife. The end.", r.Text)
+	assert.Equal(t, 1, r.Analyses[0].Findings)
+}
+
+func TestMarkdownFormat(t *testing.T) {
+	f, err := NewFormat(FormatMarkdown)
+	assert.NoError(t, err)
+
+	b, err := f.Encode(testDocument())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# Alice in Wonderland
+
+- Category: Fantasy
+- Content type: book
+- Verdict: flagged
+- Requested: 2025-03-01T12:00:00Z
+
+## Findings
+
+### word_search
+
+Theme bd2d2784-0fcb-4526-ad3c-76d00964f4de, finished, score -1.
+
+- **knife** (12-17): Alice had a knife.
+
+## Text
+
+Alice had a knife. The end.
+`, string(b))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewFormat("pdf")
+	assert.Error(t, err)
+}
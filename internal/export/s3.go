@@ -0,0 +1,189 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options point the S3 sink at a bucket of any S3 compatible API. Path style
+// addressing (endpoint/bucket/key) is what most self hosted stores expect.
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Timeout   time.Duration
+}
+
+// S3Sink uploads every document as its own object, signed with AWS signature
+// version 4.
+type S3Sink struct {
+	opts   S3Options
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Sink(opts S3Options) (*S3Sink, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, fmt.Errorf("s3 export needs an endpoint and a bucket")
+	}
+	if _, err := url.Parse(opts.Endpoint); err != nil {
+		return nil, err
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	return &S3Sink{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3Sink) Put(ctx context.Context, name string, data []byte) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", ErrInvalidName
+	}
+	key := path.Join(s.opts.Prefix, filepath.ToSlash(name))
+
+	u, err := url.Parse(s.opts.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	p := "/" + key
+	if s.opts.PathStyle {
+		p = "/" + s.opts.Bucket + p
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u, err = url.Parse(u.Scheme + "://" + u.Host + strings.TrimSuffix(u.Path, "/") + uriEncode(p))
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+	signV4(req, data, s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return "", fmt.Errorf("s3 responded with %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.opts.Bucket, key), nil
+}
+
+func (s *S3Sink) Close() error {
+	return nil
+}
+
+const amzDateFormat = "20060102T150405Z"
+
+// signV4 signs the request for S3 with the host, the x-amz-* and the other
+// headers set on the request.
+func signV4(req *http.Request, payload []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		// The sink sends no query parameters
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+secretKey), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+// uriEncode escapes everything but the unreserved characters and slashes, the
+// way S3 expects object keys in the canonical request.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
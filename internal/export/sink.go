@@ -0,0 +1,160 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrInvalidName = errors.New("export name must be a relative path inside the sink")
+
+// DirSink writes every document to its own file below a directory.
+type DirSink struct {
+	base string
+}
+
+func NewDirSink(base string) *DirSink {
+	return &DirSink{
+		base: base,
+	}
+}
+
+// Put writes to a temporary file first, a crashed export never leaves half a
+// file behind.
+func (ds *DirSink) Put(_ context.Context, name string, data []byte) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", ErrInvalidName
+	}
+
+	path := filepath.Join(ds.base, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	tmp := path + ".partial"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (ds *DirSink) Close() error {
+	return nil
+}
+
+const (
+	SinkDirectory = "directory"
+	SinkS3        = "s3"
+	ArchiveTar    = "tar"
+	ArchiveZip    = "zip"
+)
+
+// ValidateSink checks the name of a configured sink, before the first export
+// run tries to open it.
+func ValidateSink(name string) error {
+	switch name {
+	case "", SinkDirectory, SinkS3, ArchiveTar, ArchiveZip:
+		return nil
+	default:
+		return fmt.Errorf("unknown export sink %s", name)
+	}
+}
+
+// ArchiveSink collects the documents of a run in a single tar or zip archive
+// in a directory. The archive only gets its final name on Close, runs that
+// exported nothing leave no archive.
+type ArchiveSink struct {
+	path  string
+	f     *os.File
+	tw    *tar.Writer
+	zw    *zip.Writer
+	count int
+}
+
+func NewArchiveSink(dir, kind string, now time.Time) (*ArchiveSink, error) {
+	if kind != ArchiveTar && kind != ArchiveZip {
+		return nil, fmt.Errorf("unknown archive kind %s", kind)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("export-%s.%s", now.UTC().Format("20060102T150405.000Z"), kind))
+	f, err := os.Create(path + ".partial")
+	if err != nil {
+		return nil, err
+	}
+
+	as := &ArchiveSink{
+		path: path,
+		f:    f,
+	}
+	if kind == ArchiveTar {
+		as.tw = tar.NewWriter(f)
+	} else {
+		as.zw = zip.NewWriter(f)
+	}
+	return as, nil
+}
+
+func (as *ArchiveSink) Put(_ context.Context, name string, data []byte) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", ErrInvalidName
+	}
+	name = filepath.ToSlash(name)
+
+	if as.tw != nil {
+		err := as.tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err := as.tw.Write(data); err != nil {
+			return "", err
+		}
+	} else {
+		w, err := as.zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", err
+		}
+	}
+
+	as.count++
+	return as.path + "#" + name, nil
+}
+
+func (as *ArchiveSink) Close() error {
+	var err error
+	if as.tw != nil {
+		err = as.tw.Close()
+	} else {
+		err = as.zw.Close()
+	}
+	err = errors.Join(err, as.f.Close())
+
+	if err != nil || as.count == 0 {
+		return errors.Join(err, os.Remove(as.f.Name()))
+	}
+	return os.Rename(as.f.Name(), as.path)
+}
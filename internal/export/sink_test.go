@@ -0,0 +1,105 @@
+package export
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirSink(t *testing.T) {
+	dir := t.TempDir()
+	ds := NewDirSink(dir)
+
+	ref, err := ds.Put(context.Background(), "fantasy/al/alice.txt", []byte("hello"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "fantasy/al/alice.txt"), ref)
+	b, err := os.ReadFile(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.NoFileExists(t, ref+".partial")
+	assert.NoError(t, ds.Close())
+
+	_, err = ds.Put(context.Background(), "../escape.txt", []byte("hello"))
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestDirSinkWriteError(t *testing.T) {
+	dir := t.TempDir()
+	// A file where the category directory has to go
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fantasy"), []byte{}, 0644))
+
+	_, err := NewDirSink(dir).Put(context.Background(), "fantasy/al/alice.txt", []byte("hello"))
+
+	assert.Error(t, err)
+}
+
+func TestArchiveSink(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("tar", func(t *testing.T) {
+		dir := t.TempDir()
+		as, err := NewArchiveSink(dir, ArchiveTar, now)
+		assert.NoError(t, err)
+
+		ref, err := as.Put(context.Background(), "fantasy/al/alice.txt", []byte("hello"))
+		assert.NoError(t, err)
+		assert.NoError(t, as.Close())
+
+		path := filepath.Join(dir, "export-20250301T120000.000Z.tar")
+		assert.Equal(t, path+"#fantasy/al/alice.txt", ref)
+		f, err := os.Open(path)
+		assert.NoError(t, err)
+		defer f.Close()
+		tr := tar.NewReader(f)
+		h, err := tr.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, "fantasy/al/alice.txt", h.Name)
+		b, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(b))
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		dir := t.TempDir()
+		as, err := NewArchiveSink(dir, ArchiveZip, now)
+		assert.NoError(t, err)
+
+		_, err = as.Put(context.Background(), "fantasy/al/alice.md", []byte("# Alice"))
+		assert.NoError(t, err)
+		_, err = as.Put(context.Background(), "fantasy/bo/bob.md", []byte("# Bob"))
+		assert.NoError(t, err)
+		assert.NoError(t, as.Close())
+
+		zr, err := zip.OpenReader(filepath.Join(dir, "export-20250301T120000.000Z.zip"))
+		assert.NoError(t, err)
+		defer zr.Close()
+		assert.Len(t, zr.File, 2)
+		assert.Equal(t, "fantasy/bo/bob.md", zr.File[1].Name)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		dir := t.TempDir()
+		as, err := NewArchiveSink(dir, ArchiveZip, now)
+		assert.NoError(t, err)
+		assert.NoError(t, as.Close())
+
+		es, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, es)
+	})
+}
+
+func TestValidateSink(t *testing.T) {
+	for _, name := range []string{"", SinkDirectory, SinkS3, ArchiveTar, ArchiveZip} {
+		assert.NoError(t, ValidateSink(name), name)
+	}
+	assert.Error(t, ValidateSink("ftp"))
+}
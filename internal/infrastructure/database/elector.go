@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var ErrNotLeader = errors.New("not the leader")
+
+// Elector competes with the other replicas for a Postgres advisory lock. The
+// replica holding the lock is the leader. The lock is held by a dedicated
+// connection, so it is released as soon as the leader stops or loses its
+// connection and one of the other replicas takes over.
+type Elector struct {
+	dsn      string
+	lockId   int64
+	interval time.Duration
+	leader   atomic.Bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewElector(dsn string, lockId int64, interval time.Duration) *Elector {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Elector{
+		dsn:      dsn,
+		lockId:   lockId,
+		interval: interval,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go e.run(ctx)
+
+	return e
+}
+
+// IsLeader returns ErrNotLeader when another replica is the leader. It
+// satisfies the gocron Elector.
+func (e *Elector) IsLeader(_ context.Context) error {
+	if !e.leader.Load() {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// Close gives up the leadership.
+func (e *Elector) Close() {
+	e.cancel()
+	<-e.done
+}
+
+func (e *Elector) run(ctx context.Context) {
+	defer close(e.done)
+	for ctx.Err() == nil {
+		err := e.elect(ctx)
+		e.setLeader(false)
+		if ctx.Err() != nil {
+			return
+		}
+		zap.S().Errorw("Leader election stopped, reconnecting", "lock_id", e.lockId, "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+func (e *Elector) elect(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, e.dsn)
+	if err != nil {
+		return err
+	}
+	// Closing the session releases the lock
+	defer conn.Close(context.Background())
+
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+
+	for {
+		if e.leader.Load() {
+			// Losing the connection means losing the lock
+			if err := conn.Ping(ctx); err != nil {
+				return err
+			}
+		} else {
+			var locked bool
+			if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.lockId).Scan(&locked); err != nil {
+				return err
+			}
+			e.setLeader(locked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (e *Elector) setLeader(l bool) {
+	if e.leader.Swap(l) != l {
+		zap.S().Infow("Leadership changed", "leader", l, "lock_id", e.lockId)
+	}
+}
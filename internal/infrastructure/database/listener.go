@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// Listener keeps a dedicated connection open to receive Postgres
+// notifications on a single channel. It reconnects when the connection drops.
+type Listener struct {
+	dsn     string
+	channel string
+	notify  chan string
+	cancel  context.CancelFunc
+}
+
+func NewListener(dsn, channel string) *Listener {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Listener{
+		dsn:     dsn,
+		channel: channel,
+		notify:  make(chan string, 64),
+		cancel:  cancel,
+	}
+
+	go l.run(ctx)
+
+	return l
+}
+
+func (l *Listener) Notifications() <-chan string {
+	return l.notify
+}
+
+func (l *Listener) Close() {
+	l.cancel()
+}
+
+func (l *Listener) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		zap.S().Errorw("Database listener stopped, reconnecting", "channel", l.channel, "error", err)
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+	zap.S().Infow("Listening for database notifications", "channel", l.channel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Drop the notification when nobody keeps up, the reconciliation
+		// sweep will pick up whatever was missed.
+		select {
+		case l.notify <- n.Payload:
+		default:
+			zap.S().Warnw("Dropped database notification", "channel", l.channel)
+		}
+	}
+}
@@ -3,11 +3,15 @@ package integrationtests
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,11 +24,17 @@ import (
 	"github.com/guardlight/server/internal/essential/logging"
 	"github.com/guardlight/server/internal/essential/testcontainers"
 	"github.com/guardlight/server/internal/infrastructure/database"
+	"github.com/guardlight/server/internal/infrastructure/messaging"
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/scheduler"
 	"github.com/guardlight/server/internal/ssemanager"
 	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analysisrequest"
+	"github.com/guardlight/server/pkg/contentstore"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/guardlight/server/pkg/workqueue"
+	"github.com/guardlight/server/servers/natsmessaging"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -36,6 +46,23 @@ type TestSuiteAnalysisManagerIntegration struct {
 	db                        *gorm.DB
 	router                    *gin.Engine
 	analysisManagerRepository *analysismanager.AnalysisManagerRepository
+	ars                       *analysismanager.AnalysisResultService
+	amrr                      *analysismanager.AnalysisManagerRerunner
+	cb                        *recordingBroadcaster
+	cs                        *contentstore.Store
+}
+
+// recordingBroadcaster keeps the control messages instead of sending them.
+type recordingBroadcaster struct {
+	sync.Mutex
+	payloads []interface{}
+}
+
+func (rb *recordingBroadcaster) Broadcast(topic string, payload interface{}) error {
+	rb.Lock()
+	defer rb.Unlock()
+	rb.payloads = append(rb.payloads, payload)
+	return nil
 }
 
 func (s *TestSuiteAnalysisManagerIntegration) SetupSuite() {
@@ -66,11 +93,21 @@ func (s *TestSuiteAnalysisManagerIntegration) SetupSuite() {
 	tsr := theme.NewThemeRepository(s.db)
 
 	ts := theme.NewThemeService(tsr)
-	ars := analysismanager.NewAnalysisResultService(s.analysisManagerRepository, s.analysisManagerRepository, ts)
+	whs := webhook.NewWebhookService(webhook.NewWebhookRepository(s.db), sch.Gos)
+	err = natsmessaging.NewNatsServer()
+	s.Require().NoError(err)
+	ncon := messaging.InitNatsInProcess(natsmessaging.GetServer())
+	s.cs, err = contentstore.NewStore(ctx, ncon)
+	s.Require().NoError(err)
 
-	analysisManangerRequester := analysismanager.NewAnalysisManangerRequester(jobManager, s.analysisManagerRepository, ssem, ts)
+	s.cb = &recordingBroadcaster{}
+	s.ars = analysismanager.NewAnalysisResultService(s.analysisManagerRepository, s.analysisManagerRepository, ts, jobManager, jobManager, s.cb, s.cs)
+	ama := analysismanager.NewAnalysisManagerAllocator(ncon, s.analysisManagerRepository, jobManager, ssem, whs, workqueue.WatchCancellations(ncon))
+	s.amrr = analysismanager.NewAnalysisManagerRerunner(s.analysisManagerRepository, ama, ts, ssem, jobManager, s.cb)
 
-	analysismanager.NewAnalysisRequestController(s.router.Group(""), analysisManangerRequester, ars)
+	analysisManangerRequester := analysismanager.NewAnalysisManangerRequester(jobManager, s.analysisManagerRepository, ssem, ts, nil, nil, whs)
+
+	analysismanager.NewAnalysisRequestController(s.router.Group(""), analysisManangerRequester, s.ars, s.amrr, nil)
 
 	sqlDb, _ := s.db.DB()
 	fixtures, err := testfixtures.New(
@@ -240,3 +277,145 @@ func (s *TestSuiteAnalysisManagerIntegration) TestSubmitAnalysisRequestUntilPars
 	zap.S().Infow("analysis", "analysis", allAnalysis)
 
 }
+
+func (s *TestSuiteAnalysisManagerIntegration) TestDeleteAnalysisRequestCancelsJobs() {
+	ui := uuid.MustParse("be7954d2-9c1b-4e96-8605-14a11af397c2")
+	arid := uuid.New()
+	jid := uuid.New()
+
+	ref := arid.String()
+	s.Require().NoError(s.cs.Put(context.Background(), ref, bytes.NewReader([]byte("stored content"))))
+	s.Require().NoError(s.db.Create(&analysismanager.AnalysisRequest{
+		Id:      arid,
+		UserId:  ui,
+		Title:   "deleted analysis",
+		RawData: analysismanager.RawData{ContentRef: ref},
+	}).Error)
+	s.Require().NoError(s.db.Create(&jobmanager.Job{
+		Id:                jid,
+		Status:            jobmanager.Inprogress,
+		Type:              jobmanager.Parse,
+		GroupKey:          "parser.freetext",
+		Data:              []byte("{}"),
+		UserId:            ui,
+		AnalysisRequestId: arid,
+	}).Error)
+
+	err := s.ars.DeleteAnalysisRequestById(arid, ui)
+	s.Require().NoError(err)
+
+	var count int64
+	s.db.Model(&analysismanager.AnalysisRequest{}).Where("id = ?", arid).Count(&count)
+	s.Assert().Zero(count)
+	s.db.Model(&jobmanager.Job{}).Where("id = ?", jid).Count(&count)
+	s.Assert().Zero(count)
+
+	var dlj jobmanager.DeadLetterJob
+	s.Require().NoError(s.db.First(&dlj, "id = ?", jid).Error)
+	s.Assert().Equal(jobmanager.Cancelled, dlj.Status)
+	s.Assert().Contains(s.cb.payloads, controlcontract.CancelRequest{JobIds: []uuid.UUID{jid}, Reason: "analysis request deleted"})
+
+	_, err = s.cs.Get(context.Background(), ref)
+	s.Assert().Error(err)
+}
+
+func (s *TestSuiteAnalysisManagerIntegration) TestRerunCancelsJobsOfReplacedAnalysis() {
+	ui := uuid.MustParse("be7954d2-9c1b-4e96-8605-14a11af397c2")
+	tid := uuid.New()
+	arid := uuid.New()
+	aid := uuid.New()
+	jid := uuid.New()
+
+	s.Require().NoError(s.db.Create(&theme.Theme{
+		Id:     tid,
+		UserId: ui,
+		Title:  "Violence",
+		Analyzers: theme.Analyzers{{
+			Key: "word_search",
+			Inputs: []theme.AnalyzerInput{
+				{Key: "strict_words", Value: "gun"},
+				{Key: "threshold", Value: "1"},
+			},
+		}},
+	}).Error)
+	s.Require().NoError(s.db.Create(&analysismanager.AnalysisRequest{
+		Id:      arid,
+		UserId:  ui,
+		Title:   "rerun analysis",
+		RawData: analysismanager.RawData{ProcessedText: "He had a gun"},
+		Analysis: []analysismanager.Analysis{{
+			Id:          aid,
+			AnalyzerKey: "word_search",
+			ThemeId:     tid,
+			Status:      analysismanager.AnalysisFinished,
+		}},
+	}).Error)
+	// A reporter job that is still running for the finished analysis
+	s.Require().NoError(s.db.Create(&jobmanager.Job{
+		Id:                jid,
+		Status:            jobmanager.Inprogress,
+		Type:              jobmanager.Report,
+		GroupKey:          "reporter.word_count",
+		Data:              []byte(`{"reporterData":{"analysisId":"` + aid.String() + `"}}`),
+		UserId:            ui,
+		AnalysisRequestId: arid,
+	}).Error)
+
+	err := s.amrr.Rerun(ui, arid, []uuid.UUID{tid})
+	s.Require().NoError(err)
+
+	var count int64
+	s.db.Model(&jobmanager.Job{}).Where("id = ?", jid).Count(&count)
+	s.Assert().Zero(count)
+
+	var dlj jobmanager.DeadLetterJob
+	s.Require().NoError(s.db.First(&dlj, "id = ?", jid).Error)
+	s.Assert().Equal(jobmanager.Cancelled, dlj.Status)
+	s.Assert().Contains(s.cb.payloads, controlcontract.CancelRequest{JobIds: []uuid.UUID{jid}, Reason: "analysis rerun"})
+
+	err = s.amrr.Rerun(ui, arid, []uuid.UUID{tid})
+	s.Assert().ErrorIs(err, analysismanager.ErrAnalysisInProgress)
+}
+
+func (s *TestSuiteAnalysisManagerIntegration) TestRehashLegacyRawData() {
+	ui := uuid.MustParse("be7954d2-9c1b-4e96-8605-14a11af397c2")
+	pending := uuid.New()
+	parsed := uuid.New()
+
+	md5Of := func(b []byte) string {
+		h := md5.Sum(b)
+		return hex.EncodeToString(h[:])
+	}
+	s.Require().NoError(s.db.Create(&analysismanager.AnalysisRequest{
+		Id:      pending,
+		UserId:  ui,
+		Title:   "legacy pending",
+		RawData: analysismanager.RawData{Hash: md5Of([]byte("not parsed yet")), Content: []byte("not parsed yet")},
+	}).Error)
+	// The content of a parsed request was cleared
+	s.Require().NoError(s.db.Create(&analysismanager.AnalysisRequest{
+		Id:      parsed,
+		UserId:  ui,
+		Title:   "legacy parsed",
+		RawData: analysismanager.RawData{Hash: md5Of([]byte("parsed")), ProcessedText: "parsed"},
+	}).Error)
+
+	s.Require().NoError(s.db.Migrator().DropIndex(&analysismanager.RawData{}, "Hash"))
+	analysismanager.NewAnalysisManagerRepository(s.db)
+
+	hashOf := func(arid uuid.UUID) string {
+		var rd analysismanager.RawData
+		s.Require().NoError(s.db.First(&rd, "analysis_request_id = ?", arid).Error)
+		return rd.Hash
+	}
+	sum := sha256.Sum256([]byte("not parsed yet"))
+	s.Assert().Equal(hex.EncodeToString(sum[:]), hashOf(pending))
+	s.Assert().Empty(hashOf(parsed))
+	s.Assert().True(s.db.Migrator().HasIndex(&analysismanager.RawData{}, "Hash"))
+
+	// Nothing matches the hash of empty content
+	var count int64
+	sum = sha256.Sum256(nil)
+	s.db.Model(&analysismanager.RawData{}).Where("hash = ?", hex.EncodeToString(sum[:])).Count(&count)
+	s.Assert().Zero(count)
+}
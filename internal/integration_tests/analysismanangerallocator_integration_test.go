@@ -17,8 +17,10 @@ import (
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/scheduler"
 	"github.com/guardlight/server/internal/ssemanager"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analyzercontract"
 	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/guardlight/server/servers/natsmessaging"
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/suite"
@@ -61,7 +63,8 @@ func (sama *TestSuiteAnalysisManagerAllocatorIntegration) SetupSuite() {
 	sama.Require().NoError(err)
 	sama.ncon = messaging.InitNatsInProcess(natsmessaging.GetServer())
 	sama.analysisManagerRepository = analysismanager.NewAnalysisManagerRepository(sama.db)
-	_ = analysismanager.NewAnalysisManagerAllocator(sama.ncon, sama.analysisManagerRepository, jobManager, ssem)
+	whs := webhook.NewWebhookService(webhook.NewWebhookRepository(sama.db), sch.Gos)
+	_ = analysismanager.NewAnalysisManagerAllocator(sama.ncon, sama.analysisManagerRepository, jobManager, ssem, whs, workqueue.WatchCancellations(sama.ncon))
 
 	sqlDb, err := sama.db.DB()
 	sama.Require().NoError(err)
@@ -0,0 +1,51 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/internal/essential/logging"
+	"github.com/guardlight/server/internal/essential/testcontainers"
+	"github.com/guardlight/server/internal/infrastructure/database"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestSuiteElectorIntegration struct {
+	suite.Suite
+	dsn string
+}
+
+func (s *TestSuiteElectorIntegration) SetupSuite() {
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+	logging.SetupLogging("test")
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	sqlContainer, err := testcontainers.NewPostgresContainer(ctx)
+	s.Require().NoError(err)
+
+	s.dsn, err = sqlContainer.ConnectionString(ctx)
+	s.Require().NoError(err)
+}
+
+func TestElectorSuiteRun(t *testing.T) {
+	suite.Run(t, new(TestSuiteElectorIntegration))
+}
+
+func (s *TestSuiteElectorIntegration) TestOnlyOneLeaderWithFailover() {
+	ctx := context.Background()
+	isLeader := func(e *database.Elector) bool { return e.IsLeader(ctx) == nil }
+
+	first := database.NewElector(s.dsn, 42, 100*time.Millisecond)
+	s.Require().Eventually(func() bool { return isLeader(first) }, 5*time.Second, 50*time.Millisecond)
+
+	second := database.NewElector(s.dsn, 42, 100*time.Millisecond)
+	defer second.Close()
+	s.Never(func() bool { return isLeader(second) }, time.Second, 50*time.Millisecond)
+
+	first.Close()
+	s.False(isLeader(first))
+	s.Eventually(func() bool { return isLeader(second) }, 5*time.Second, 50*time.Millisecond)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-testfixtures/testfixtures/v3"
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/adapterruntime"
 	"github.com/guardlight/server/internal/analysismanager"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/essential/glsecurity"
@@ -28,9 +29,12 @@ import (
 	"github.com/guardlight/server/internal/scheduler"
 	"github.com/guardlight/server/internal/ssemanager"
 	"github.com/guardlight/server/internal/theme"
+	"github.com/guardlight/server/internal/webhook"
 	"github.com/guardlight/server/pkg/analysisrequest"
+	"github.com/guardlight/server/pkg/contentstore"
 	"github.com/guardlight/server/pkg/gladapters/analyzers"
 	"github.com/guardlight/server/pkg/gladapters/parsers"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/guardlight/server/servers/natsmessaging"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
@@ -78,6 +82,7 @@ func (s *TestSuiteMainIntegration) SetupSuite() {
 	jmr := jobmanager.NewJobManagerRepository(s.db)
 	amr := analysismanager.NewAnalysisManagerRepository(s.db)
 	tsr := theme.NewThemeRepository(s.db)
+	whr := webhook.NewWebhookRepository(s.db)
 
 	// Controller Groups
 	s.router = router.NewRouter(logging.GetLogger())
@@ -101,19 +106,26 @@ func (s *TestSuiteMainIntegration) SetupSuite() {
 		zap.S().Errorw("Could not create scheduler", "error", err)
 		s.Assert().NoError(err)
 	}
-	_, err = orchestrator.NewOrchestrator(jm, sch.Gos, nc)
+	rt, err := adapterruntime.NewProcessRuntime(adapterruntime.NatsDetails{Url: natsmessaging.GetNatsUrl()}, sch.Gos, jm)
+	s.Assert().NoError(err)
+	_, err = orchestrator.NewOrchestrator(jm, sch.Gos, nc, rt)
 	if err != nil {
 		zap.S().Errorw("Could not create orhestrator", "error", err)
 		s.Assert().NoError(err)
 	}
 	ts := theme.NewThemeService(tsr)
 	ssem := ssemanager.NewSseMananger()
-	ars := analysismanager.NewAnalysisResultService(amr, amr, ts)
-	am := analysismanager.NewAnalysisManangerRequester(jm, amr, ssem, ts)
-	_ = analysismanager.NewAnalysisManagerAllocator(ncon, amr, jm, ssem)
+	whs := webhook.NewWebhookService(whr, sch.Gos)
+	cs, err := contentstore.NewStore(context.Background(), ncon)
+	s.Assert().NoError(err)
+	ars := analysismanager.NewAnalysisResultService(amr, amr, ts, jm, jm, nc, cs)
+	ama := analysismanager.NewAnalysisManagerAllocator(ncon, amr, jm, ssem, whs, workqueue.WatchCancellations(ncon))
+	am := analysismanager.NewAnalysisManangerRequester(jm, amr, ssem, ts, ama, cs, whs)
+	amrr := analysismanager.NewAnalysisManagerRerunner(amr, ama, ts, ssem, jm, nc)
+	amb := analysismanager.NewAnalysisManagerBatcher(amr, am, ts)
 
 	// Controllers
-	analysismanager.NewAnalysisRequestController(baseGroup, am, ars)
+	analysismanager.NewAnalysisRequestController(baseGroup, am, ars, amrr, amb)
 
 	// Start the server
 	go router.LiveOrLetDie(s.router)
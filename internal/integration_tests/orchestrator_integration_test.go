@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-testfixtures/testfixtures/v3"
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/adapterruntime"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/essential/logging"
 	"github.com/guardlight/server/internal/essential/testcontainers"
@@ -87,7 +88,10 @@ func (s *TestSuiteOrchestratorIntegration) TestOrchestratorSetup() {
 	s.Assert().NoError(err)
 	nc := natsclient.NewNatsClient(ncon)
 
-	_, err = orchestrator.NewOrchestrator(s.jobManager, sch.Gos, nc)
+	rt, err := adapterruntime.NewProcessRuntime(adapterruntime.NatsDetails{Url: natsmessaging.GetNatsUrl()}, sch.Gos, s.jobManager)
+	s.Assert().NoError(err)
+
+	_, err = orchestrator.NewOrchestrator(s.jobManager, sch.Gos, nc, rt)
 	s.Assert().NoError(err)
 
 	var wg sync.WaitGroup
@@ -113,3 +117,35 @@ func (s *TestSuiteOrchestratorIntegration) TestOrchestratorSetup() {
 	sch.Gos.Shutdown()
 
 }
+
+func (s *TestSuiteOrchestratorIntegration) TestQueuedJobsWaitForBackoff() {
+	ready := jobmanager.Job{
+		Id:            uuid.New(),
+		Status:        jobmanager.Queued,
+		Type:          jobmanager.Parse,
+		GroupKey:      "parser.backoff",
+		Data:          []byte("{}"),
+		NextAttemptAt: time.Now().Add(-time.Minute),
+		UserId:        uuid.New(),
+	}
+	waiting := ready
+	waiting.Id = uuid.New()
+	waiting.NextAttemptAt = time.Now().Add(time.Hour)
+	delayed := ready
+	delayed.Id = uuid.New()
+	delayed.GroupKey = "parser.delayed"
+	delayed.NextAttemptAt = time.Now().Add(time.Hour)
+
+	s.Require().NoError(s.db.Create([]jobmanager.Job{ready, waiting, delayed}).Error)
+	defer s.db.Where("id IN ?", []uuid.UUID{ready.Id, waiting.Id, delayed.Id}).Delete(&jobmanager.Job{})
+
+	gks, err := s.jobManager.GetQueuedGroupKeys()
+	s.Assert().NoError(err)
+	s.Assert().Contains(gks, "parser.backoff")
+	s.Assert().NotContains(gks, "parser.delayed")
+
+	js, err := s.jobManager.GetQueuedJobs("parser.backoff", 10)
+	s.Assert().NoError(err)
+	s.Assert().Len(js, 1)
+	s.Assert().Equal(ready.Id, js[0].Id)
+}
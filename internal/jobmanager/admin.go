@@ -0,0 +1,192 @@
+package jobmanager
+
+import (
+	"github.com/google/uuid"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+type jobAdminStore interface {
+	getJob(id uuid.UUID) (Job, error)
+	getJobs(f JobFilter, pag Pagination) ([]Job, int, error)
+	requeueJobs(ids []uuid.UUID, sd string) (int, error)
+	moveToDeadLetter(ids []uuid.UUID, reason string) (int, error)
+	cancelJobs(ids []uuid.UUID, reason string) (int, error)
+	getDeadLetterJob(id uuid.UUID) (DeadLetterJob, error)
+	getDeadLetterJobs(f JobFilter, pag Pagination) ([]DeadLetterJob, int, error)
+	restoreFromDeadLetter(ids []uuid.UUID) (int, error)
+	notifyJobChange(jn JobNotification) error
+}
+
+type controlBroadcaster interface {
+	Broadcast(topic string, payload interface{}) error
+}
+
+// JobAdminService lets admins inspect and repair the job queue.
+type JobAdminService struct {
+	js jobAdminStore
+	cb controlBroadcaster
+}
+
+func NewJobAdminService(js jobAdminStore, cb controlBroadcaster) *JobAdminService {
+	return &JobAdminService{
+		js: js,
+		cb: cb,
+	}
+}
+
+func (jas *JobAdminService) GetJobs(f JobFilter, limit, page int) (JobsPaginated, error) {
+	pag := Pagination{Limit: limit, Page: page}
+	js, tp, err := jas.js.getJobs(f, pag)
+	if err != nil {
+		return JobsPaginated{}, err
+	}
+
+	return JobsPaginated{
+		Limit:      pag.GetLimit(),
+		Page:       pag.GetPage(),
+		TotalPages: tp,
+		Jobs: lo.Map(js, func(j Job, _ int) JobDto {
+			return mapJobToDto(j, false)
+		}),
+	}, nil
+}
+
+func (jas *JobAdminService) GetJob(id uuid.UUID) (JobDto, error) {
+	j, err := jas.js.getJob(id)
+	if err != nil {
+		return JobDto{}, err
+	}
+	return mapJobToDto(j, true), nil
+}
+
+func (jas *JobAdminService) RequeueJobs(ids []uuid.UUID) (int, error) {
+	n, err := jas.js.requeueJobs(ids, "requeued by admin")
+	if err != nil {
+		return 0, err
+	}
+	jas.js.notifyJobChange(JobNotification{Status: Queued})
+	jas.restored(ids)
+	return n, nil
+}
+
+func (jas *JobAdminService) CancelJobs(ids []uuid.UUID) (int, error) {
+	n, err := jas.js.cancelJobs(ids, "cancelled by admin")
+	if err != nil {
+		return 0, err
+	}
+	jas.js.notifyJobChange(JobNotification{Status: Cancelled})
+	jas.stop(ids, "cancelled by admin")
+	return n, nil
+}
+
+func (jas *JobAdminService) DeadLetterJobs(ids []uuid.UUID) (int, error) {
+	n, err := jas.js.moveToDeadLetter(ids, "moved to dead letter by admin")
+	if err != nil {
+		return 0, err
+	}
+	jas.js.notifyJobChange(JobNotification{Status: Error})
+	jas.stop(ids, "moved to dead letter by admin")
+	return n, nil
+}
+
+// stop tells the adapters to drop the jobs, their results are not used.
+func (jas *JobAdminService) stop(ids []uuid.UUID, reason string) {
+	err := jas.cb.Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{
+		JobIds: ids,
+		Reason: reason,
+	})
+	if err != nil {
+		zap.S().Errorw("Could not tell adapters to stop jobs", "error", err)
+	}
+}
+
+// restored tells the adapters to take the jobs again when they come in, even
+// when they were cancelled before.
+func (jas *JobAdminService) restored(ids []uuid.UUID) {
+	err := jas.cb.Broadcast(controlcontract.RestoreSubject, controlcontract.RestoreRequest{JobIds: ids})
+	if err != nil {
+		zap.S().Errorw("Could not tell adapters about restored jobs", "error", err)
+	}
+}
+
+func (jas *JobAdminService) GetDeadLetterJobs(f JobFilter, limit, page int) (JobsPaginated, error) {
+	pag := Pagination{Limit: limit, Page: page}
+	js, tp, err := jas.js.getDeadLetterJobs(f, pag)
+	if err != nil {
+		return JobsPaginated{}, err
+	}
+
+	return JobsPaginated{
+		Limit:      pag.GetLimit(),
+		Page:       pag.GetPage(),
+		TotalPages: tp,
+		Jobs: lo.Map(js, func(j DeadLetterJob, _ int) JobDto {
+			return mapDeadLetterJobToDto(j, false)
+		}),
+	}, nil
+}
+
+func (jas *JobAdminService) GetDeadLetterJob(id uuid.UUID) (JobDto, error) {
+	j, err := jas.js.getDeadLetterJob(id)
+	if err != nil {
+		return JobDto{}, err
+	}
+	return mapDeadLetterJobToDto(j, true), nil
+}
+
+func (jas *JobAdminService) RequeueDeadLetterJobs(ids []uuid.UUID) (int, error) {
+	n, err := jas.js.restoreFromDeadLetter(ids)
+	if err != nil {
+		return 0, err
+	}
+	jas.js.notifyJobChange(JobNotification{Status: Queued})
+	jas.restored(ids)
+	return n, nil
+}
+
+func mapJobToDto(j Job, withData bool) JobDto {
+	jd := JobDto{
+		Id:                j.Id,
+		Status:            j.Status,
+		StatusDescription: j.StatusDescription,
+		RetryCount:        j.RetryCount,
+		Type:              j.Type,
+		GroupKey:          j.GroupKey,
+		CreatedAt:         j.CreatedAt,
+		UpdatedAt:         j.UpdatedAt,
+		StartedAt:         j.StartedAt,
+		NextAttemptAt:     j.NextAttemptAt,
+		LeaseExpiresAt:    j.LeaseExpiresAt,
+		UserId:            j.UserId,
+		AnalysisRequestId: j.AnalysisRequestId,
+		Priority:          j.Priority,
+	}
+	if withData {
+		jd.Data = j.Data
+	}
+	return jd
+}
+
+func mapDeadLetterJobToDto(j DeadLetterJob, withData bool) JobDto {
+	jd := JobDto{
+		Id:                j.Id,
+		Status:            j.Status,
+		StatusDescription: j.StatusDescription,
+		RetryCount:        j.RetryCount,
+		Type:              j.Type,
+		GroupKey:          j.GroupKey,
+		CreatedAt:         j.CreatedAt,
+		UpdatedAt:         j.UpdatedAt,
+		UserId:            j.UserId,
+		AnalysisRequestId: j.AnalysisRequestId,
+		Priority:          j.Priority,
+		Reason:            j.Reason,
+		DeadLetteredAt:    &j.DeadLetteredAt,
+	}
+	if withData {
+		jd.Data = j.Data
+	}
+	return jd
+}
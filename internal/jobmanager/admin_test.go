@@ -0,0 +1,94 @@
+package jobmanager
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobAdminGetJobs(t *testing.T) {
+	mockJs := NewMockjobAdminStore(t)
+	jas := NewJobAdminService(mockJs, NewMockcontrolBroadcaster(t))
+
+	jobId := uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")
+	created := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	js := []Job{
+		{
+			Id:        jobId,
+			CreatedAt: created,
+			Status:    Queued,
+			Type:      Parse,
+			GroupKey:  "parser.freetext",
+			Data:      json.RawMessage(`{"type":"freetext"}`),
+		},
+	}
+
+	f := JobFilter{Status: Queued}
+	mockJs.EXPECT().getJobs(f, Pagination{Limit: 0, Page: 2}).Return(js, 3, nil)
+
+	res, err := jas.GetJobs(f, 0, 2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, JobsPaginated{
+		Limit:      25,
+		Page:       2,
+		TotalPages: 3,
+		Jobs: []JobDto{
+			{
+				Id:        jobId,
+				Status:    Queued,
+				Type:      Parse,
+				GroupKey:  "parser.freetext",
+				CreatedAt: created,
+			},
+		},
+	}, res)
+}
+
+func TestJobAdminGetJobWithData(t *testing.T) {
+	mockJs := NewMockjobAdminStore(t)
+	jas := NewJobAdminService(mockJs, NewMockcontrolBroadcaster(t))
+
+	jobId := uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")
+	mockJs.EXPECT().getJob(jobId).Return(Job{Id: jobId, Status: Inprogress, Data: json.RawMessage(`{"type":"freetext"}`)}, nil)
+
+	res, err := jas.GetJob(jobId)
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{"type":"freetext"}`), res.Data)
+}
+
+func TestJobAdminCancelJobs(t *testing.T) {
+	mockJs := NewMockjobAdminStore(t)
+	mockCb := NewMockcontrolBroadcaster(t)
+	jas := NewJobAdminService(mockJs, mockCb)
+
+	ids := []uuid.UUID{uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")}
+	mockJs.EXPECT().cancelJobs(ids, "cancelled by admin").Return(1, nil)
+	mockJs.EXPECT().notifyJobChange(JobNotification{Status: Cancelled}).Return(nil)
+	mockCb.EXPECT().Broadcast(controlcontract.CancelSubject, controlcontract.CancelRequest{JobIds: ids, Reason: "cancelled by admin"}).Return(nil)
+
+	n, err := jas.CancelJobs(ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestJobAdminRequeueDeadLetterJobs(t *testing.T) {
+	mockJs := NewMockjobAdminStore(t)
+	mockCb := NewMockcontrolBroadcaster(t)
+	jas := NewJobAdminService(mockJs, mockCb)
+
+	ids := []uuid.UUID{uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")}
+	mockJs.EXPECT().restoreFromDeadLetter(ids).Return(1, nil)
+	mockJs.EXPECT().notifyJobChange(JobNotification{Status: Queued}).Return(nil)
+	// Adapters skip jobs they saw cancelled until told otherwise
+	mockCb.EXPECT().Broadcast(controlcontract.RestoreSubject, controlcontract.RestoreRequest{JobIds: ids}).Return(nil)
+
+	n, err := jas.RequeueDeadLetterJobs(ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,188 @@
+package jobmanager
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/glerror"
+	"github.com/guardlight/server/internal/essential/glsecurity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type JobController struct {
+	jas *JobAdminService
+}
+
+func NewJobController(group *gin.RouterGroup, jas *JobAdminService) *JobController {
+	jc := &JobController{
+		jas: jas,
+	}
+
+	jobGroup := group.Group("job")
+	jobGroup.Use(glsecurity.UseGuardlightAuth(), glsecurity.UseGuardlightRole(glsecurity.Admin))
+	jobGroup.GET("", jc.jobs)
+	jobGroup.POST("/requeue", jc.requeueJobs)
+	jobGroup.POST("/cancel", jc.cancelJobs)
+	jobGroup.GET("/deadletter", jc.deadLetterJobs)
+	jobGroup.POST("/deadletter", jc.moveToDeadLetter)
+	jobGroup.POST("/deadletter/requeue", jc.requeueDeadLetterJobs)
+	jobGroup.GET("/deadletter/:jid", jc.deadLetterJobById)
+	jobGroup.GET("/:jid", jc.jobById)
+	jobGroup.POST("/:jid/requeue", jc.requeueJob)
+	jobGroup.POST("/:jid/cancel", jc.cancelJob)
+
+	return jc
+}
+
+func (jc *JobController) jobs(c *gin.Context) {
+	js, err := jc.jas.GetJobs(jobFilterFromQuery(c), queryInt(c, "limit"), queryInt(c, "page"))
+	if err != nil {
+		zap.S().Errorw("error getting jobs", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, js)
+}
+
+func (jc *JobController) jobById(c *gin.Context) {
+	jid, err := uuid.Parse(c.Param("jid"))
+	if err != nil {
+		zap.S().Errorw("Job id is not uuid", "error", err)
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	j, err := jc.jas.GetJob(jid)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, j)
+}
+
+func (jc *JobController) requeueJob(c *gin.Context) {
+	jc.singleJobAction(c, jc.jas.RequeueJobs)
+}
+
+func (jc *JobController) cancelJob(c *gin.Context) {
+	jc.singleJobAction(c, jc.jas.CancelJobs)
+}
+
+func (jc *JobController) requeueJobs(c *gin.Context) {
+	jc.multiJobAction(c, jc.jas.RequeueJobs)
+}
+
+func (jc *JobController) cancelJobs(c *gin.Context) {
+	jc.multiJobAction(c, jc.jas.CancelJobs)
+}
+
+func (jc *JobController) moveToDeadLetter(c *gin.Context) {
+	jc.multiJobAction(c, jc.jas.DeadLetterJobs)
+}
+
+func (jc *JobController) requeueDeadLetterJobs(c *gin.Context) {
+	jc.multiJobAction(c, jc.jas.RequeueDeadLetterJobs)
+}
+
+func (jc *JobController) deadLetterJobs(c *gin.Context) {
+	js, err := jc.jas.GetDeadLetterJobs(jobFilterFromQuery(c), queryInt(c, "limit"), queryInt(c, "page"))
+	if err != nil {
+		zap.S().Errorw("error getting dead letter jobs", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, js)
+}
+
+func (jc *JobController) deadLetterJobById(c *gin.Context) {
+	jid, err := uuid.Parse(c.Param("jid"))
+	if err != nil {
+		zap.S().Errorw("Job id is not uuid", "error", err)
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	j, err := jc.jas.GetDeadLetterJob(jid)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, j)
+}
+
+func (jc *JobController) singleJobAction(c *gin.Context, action func(ids []uuid.UUID) (int, error)) {
+	jid, err := uuid.Parse(c.Param("jid"))
+	if err != nil {
+		zap.S().Errorw("Job id is not uuid", "error", err)
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	n, err := action([]uuid.UUID{jid})
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	if n == 0 {
+		c.JSON(glerror.ResourceNotFoundError())
+		return
+	}
+
+	c.JSON(http.StatusOK, JobActionResult{Affected: n})
+}
+
+func (jc *JobController) multiJobAction(c *gin.Context, action func(ids []uuid.UUID) (int, error)) {
+	jids := &JobIds{}
+	err := glsecurity.ReuseBindAndValidate(c, jids)
+	if err != nil {
+		zap.S().Errorw("error validating job ids", "error", err)
+		return
+	}
+
+	if len(jids.Ids) == 0 {
+		c.JSON(glerror.BadRequestError())
+		return
+	}
+
+	n, err := action(jids.Ids)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, JobActionResult{Affected: n})
+}
+
+func respondJobError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(glerror.ResourceNotFoundError())
+		return
+	}
+	zap.S().Errorw("error handling job request", "error", err)
+	c.JSON(glerror.InternalServerError())
+}
+
+func jobFilterFromQuery(c *gin.Context) JobFilter {
+	return JobFilter{
+		Status:   JobStatus(c.Query("status")),
+		Type:     JobType(c.Query("type")),
+		GroupKey: c.Query("groupKey"),
+	}
+}
+
+func queryInt(c *gin.Context, key string) int {
+	v, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return 0
+	}
+	return v
+}
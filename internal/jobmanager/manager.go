@@ -1,46 +1,122 @@
 package jobmanager
 
 import (
+	"cmp"
 	"encoding/json"
+	"slices"
+	"sync"
 	"time"
 	"unsafe"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/reportercontract"
+	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // Public Interfaces
 
 type Enqueuer interface {
-	EnqueueJob(id uuid.UUID, jType JobType, groupKey string, data interface{}) error
+	EnqueueJob(id uuid.UUID, jType JobType, groupKey string, data interface{}, meta JobMeta) error
 }
 
 type IdCreater interface {
 	CreateId() uuid.UUID
 }
 
-type JobsGetter interface {
-	GetAllNonFinishedJobs() ([]Job, error)
+type QueuedJobsGetter interface {
+	GetInprogressCounts() (map[string]int, error)
+	GetQueuedGroupKeys() ([]string, error)
+	GetQueuedJobs(groupKey string, perUser int) ([]Job, error)
+	GetNextAttemptAt() (*time.Time, error)
+	GetInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error)
+}
+
+// JobCanceller cancels the jobs of work that is deleted or replaced, in the
+// transaction that does so.
+type JobCanceller interface {
+	CancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID) ([]uuid.UUID, error)
+	CancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID) ([]uuid.UUID, error)
+}
+
+type JobHistoryGetter interface {
+	GetJobTimeline(arid uuid.UUID) ([]JobTimeline, error)
+}
+
+// JobDurationGetter tells how long the recent jobs of a group key took.
+type JobDurationGetter interface {
+	GetAverageJobDuration(groupKey string) (time.Duration, error)
+}
+
+// InprogressChecker tells whether a job still waits on the result of an
+// adapter.
+type InprogressChecker interface {
+	IsInprogress(id uuid.UUID) (bool, error)
 }
 
 type JobUpdater interface {
 	UpdateJobStatus(id uuid.UUID, status JobStatus, desc string, retryCount int) error
 }
 
+// DeadLetterHandler fails the work that waited on jobs that failed for good.
+// FailJobs runs in the transaction that dead letters the jobs, JobsFailed once
+// it committed.
+type DeadLetterHandler interface {
+	FailJobs(tx *gorm.DB, jobs []DeadLetterJob) error
+	JobsFailed(jobs []DeadLetterJob)
+}
+
 // Private
 
 type jobStore interface {
 	saveJob(j *Job) error
+	getJob(id uuid.UUID) (Job, error)
+	isJobInprogress(id uuid.UUID) (bool, error)
 	getNotFinishedJobs() ([]Job, error)
-	updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int) error
+	getInprogressCounts() (map[string]int, error)
+	getQueuedGroupKeys() ([]string, error)
+	getNextAttemptAt() (*time.Time, error)
+	getQueuedJobs(groupKey string, perUser int) ([]Job, error)
+	getInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error)
+	notifyJobChange(jn JobNotification) error
+	updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int, naa time.Time) error
+	extendLease(id uuid.UUID, until time.Time) (bool, error)
 	deleteJob(id uuid.UUID) error
+	moveToDeadLetter(ids []uuid.UUID, reason string) (int, error)
+	cancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID, reason string) ([]uuid.UUID, error)
+	cancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID, reason string) ([]uuid.UUID, error)
+	getJobEventsByAnalysisRequestId(arid uuid.UUID) ([]JobEvent, error)
+	deleteJobHistoriesBefore(t time.Time) (int, error)
+	getAverageJobDuration(groupKey string, n int) (time.Duration, error)
+}
+
+type subscriber interface {
+	Subscribe(subj string, cb nats.MsgHandler) (*nats.Subscription, error)
 }
 
 type JobManager struct {
 	js jobStore
+
+	durationsMu sync.Mutex
+	durations   map[string]cachedDuration
+}
+
+type cachedDuration struct {
+	d  time.Duration
+	at time.Time
 }
 
+const (
+	// durationSamples is how many of the last finished jobs are averaged
+	durationSamples = 50
+	durationTtl     = 30 * time.Second
+)
+
 type taskCreater interface {
 	NewJob(jobDefinition gocron.JobDefinition, task gocron.Task, options ...gocron.JobOption) (gocron.Job, error)
 }
@@ -61,6 +137,17 @@ func NewJobMananger(js jobStore, tc taskCreater) *JobManager {
 		return nil
 	}
 
+	_, err = tc.NewJob(
+		gocron.DurationJob(
+			time.Hour,
+		),
+		gocron.NewTask(jm.cleanJobHistory),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return nil
+	}
+
 	return jm
 }
 
@@ -68,29 +155,103 @@ func (jm *JobManager) stopLongRunningJobs() {
 	zap.S().Infow("Stopping long running jobs")
 	js, _ := jm.js.getNotFinishedJobs()
 	for _, j := range js {
-		if j.Status == Inprogress {
-			if j.Type == Parse || j.Type == Analyze || j.Type == Report {
-				if time.Since(j.CreatedAt) > time.Minute {
-					if j.RetryCount > 2 {
-						zap.S().Infow("Stopping job", "job_id", j.Id)
-						jm.UpdateJobStatus(j.Id, Error, "Timed out", 3)
-					} else {
-						zap.S().Infow("retrying job", "job_id", j.Id)
-						jm.UpdateJobStatus(j.Id, Queued, "long running task", j.RetryCount+1)
-					}
-
-				}
-			}
+		if j.Status != Inprogress {
+			continue
+		}
+		if j.Type != Parse && j.Type != Analyze && j.Type != Report {
+			continue
+		}
+
+		rp := config.Get().GetRetryPolicy(string(j.Type), j.GroupKey)
+		if time.Now().Before(j.leaseExpiresAt(rp.InprogressTimeout())) {
+			continue
+		}
+
+		if rp.Exhausted(j.RetryCount + 1) {
+			zap.S().Infow("Stopping job", "job_id", j.Id)
+			jm.UpdateJobStatus(j.Id, Error, "Timed out", j.RetryCount+1)
+		} else {
+			zap.S().Infow("retrying job", "job_id", j.Id)
+			jm.UpdateJobStatus(j.Id, Queued, "long running task", j.RetryCount+1)
+		}
+	}
+}
+
+// ListenForHeartbeats extends the lease of jobs whose adapter reports it is
+// still working on them.
+func (jm *JobManager) ListenForHeartbeats(s subscriber) error {
+	subs := map[string]nats.MsgHandler{
+		parsercontract.HeartbeatSubject:   jm.processParserHeartbeat,
+		analyzercontract.HeartbeatSubject: jm.processAnalyzerHeartbeat,
+		reportercontract.HeartbeatSubject: jm.processReporterHeartbeat,
+	}
+	for subj, cb := range subs {
+		if _, err := s.Subscribe(subj, cb); err != nil {
+			zap.S().Errorw("Could not subscribe to heartbeats", "subject", subj, "error", err)
+			return err
 		}
+	}
+	return nil
+}
+
+func (jm *JobManager) processParserHeartbeat(m *nats.Msg) {
+	var hb parsercontract.ParserHeartbeat
+	if err := json.Unmarshal(m.Data, &hb); err != nil {
+		zap.S().Errorw("Could not unmarshal parser heartbeat", "error", err)
+		return
+	}
+	jm.ExtendLease(hb.JobId, time.Duration(hb.LeaseSeconds)*time.Second)
+}
+
+func (jm *JobManager) processAnalyzerHeartbeat(m *nats.Msg) {
+	var hb analyzercontract.AnalyzerHeartbeat
+	if err := json.Unmarshal(m.Data, &hb); err != nil {
+		zap.S().Errorw("Could not unmarshal analyzer heartbeat", "error", err)
+		return
+	}
+	jm.ExtendLease(hb.JobId, time.Duration(hb.LeaseSeconds)*time.Second)
+}
 
+func (jm *JobManager) processReporterHeartbeat(m *nats.Msg) {
+	var hb reportercontract.ReporterHeartbeat
+	if err := json.Unmarshal(m.Data, &hb); err != nil {
+		zap.S().Errorw("Could not unmarshal reporter heartbeat", "error", err)
+		return
 	}
+	jm.ExtendLease(hb.JobId, time.Duration(hb.LeaseSeconds)*time.Second)
+}
+
+// ExtendLease gives an inprogress job the requested lease from now on. The
+// lease is capped by the inprogress timeout of the job, a lease of 0 uses the
+// timeout.
+func (jm *JobManager) ExtendLease(id uuid.UUID, lease time.Duration) error {
+	j, err := jm.js.getJob(id)
+	if err != nil {
+		return err
+	}
+
+	timeout := config.Get().GetRetryPolicy(string(j.Type), j.GroupKey).InprogressTimeout()
+	if lease <= 0 || lease > timeout {
+		lease = timeout
+	}
+
+	ok, err := jm.js.extendLease(id, time.Now().Add(lease))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		zap.S().Debugw("Heartbeat of job that is not in progress", "job_id", id, "status", j.Status)
+		return nil
+	}
+	zap.S().Debugw("Job lease extended", "job_id", id, "lease", lease)
+	return nil
 }
 
 func (jm *JobManager) CreateId() uuid.UUID {
 	return uuid.New()
 }
 
-func (jm *JobManager) EnqueueJob(id uuid.UUID, jType JobType, groupKey string, data interface{}) error {
+func (jm *JobManager) EnqueueJob(id uuid.UUID, jType JobType, groupKey string, data interface{}, meta JobMeta) error {
 	jData, err := json.Marshal(data)
 	if err != nil {
 		zap.S().Errorw("Could not marshal job data", "error", err)
@@ -105,6 +266,9 @@ func (jm *JobManager) EnqueueJob(id uuid.UUID, jType JobType, groupKey string, d
 		GroupKey:          groupKey,
 		Type:              jType,
 		Data:              jData,
+		UserId:            meta.UserId,
+		AnalysisRequestId: meta.AnalysisRequestId,
+		Priority:          meta.Priority,
 	}
 
 	err = jm.js.saveJob(j)
@@ -112,28 +276,190 @@ func (jm *JobManager) EnqueueJob(id uuid.UUID, jType JobType, groupKey string, d
 		return err
 	}
 
-	zap.S().Infow("Job Enqueued", "job_id", id, "group_key", groupKey, "job_type", jType, "data_size", unsafe.Sizeof(data))
+	zap.S().Infow("Job Enqueued", "job_id", id, "group_key", groupKey, "job_type", jType, "priority", meta.Priority, "data_size", unsafe.Sizeof(data))
+
+	jm.js.notifyJobChange(JobNotification{JobId: id, Status: Queued, GroupKey: groupKey})
 	return nil
 }
 
-func (jm *JobManager) GetAllNonFinishedJobs() ([]Job, error) {
-	j, err := jm.js.getNotFinishedJobs()
-	if err != nil {
-		return nil, err
-	}
-	return j, nil
+func (jm *JobManager) GetInprogressCounts() (map[string]int, error) {
+	return jm.js.getInprogressCounts()
 }
 
+func (jm *JobManager) GetQueuedGroupKeys() ([]string, error) {
+	return jm.js.getQueuedGroupKeys()
+}
+
+// GetQueuedJobs returns the first perUser dispatchable jobs of every user in a
+// group key.
+func (jm *JobManager) GetQueuedJobs(groupKey string, perUser int) ([]Job, error) {
+	return jm.js.getQueuedJobs(groupKey, perUser)
+}
+
+// GetNextAttemptAt returns when the first queued job that is backed off can be
+// dispatched, nil when none is.
+func (jm *JobManager) GetNextAttemptAt() (*time.Time, error) {
+	return jm.js.getNextAttemptAt()
+}
+
+func (jm *JobManager) GetInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error) {
+	return jm.js.getInprogressUserCounts(groupKey)
+}
+
+// IsInprogress reports whether the job is in progress. A job that timed out
+// is queued again, and a finished job is gone.
+func (jm *JobManager) IsInprogress(id uuid.UUID) (bool, error) {
+	return jm.js.isJobInprogress(id)
+}
+
+// UpdateJobStatus updates the status of a job. A job that is queued again after
+// failing gets its next attempt delayed according to its retry policy.
 func (jm *JobManager) UpdateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int) error {
-	err := jm.js.updateJobStatus(id, s, sd, rc)
+	var naa time.Time
+	if s == Queued && rc > 0 {
+		j, err := jm.js.getJob(id)
+		if err != nil {
+			return err
+		}
+		naa = time.Now().Add(config.Get().GetRetryPolicy(string(j.Type), j.GroupKey).Backoff(rc))
+	}
+
+	err := jm.js.updateJobStatus(id, s, sd, rc, naa)
 	if err != nil {
 		return err
 	}
-	zap.S().Infow("Job Status Updated", "job_id", id, "status", s, "dessription", sd)
+	zap.S().Infow("Job Status Updated", "job_id", id, "status", s, "dessription", sd, "next_attempt_at", naa)
 
 	if s == Finished {
 		jm.js.deleteJob(id)
 	}
 
+	if s == Error {
+		if _, err := jm.js.moveToDeadLetter([]uuid.UUID{id}, sd); err != nil {
+			return err
+		}
+	}
+
+	// Inprogress only takes capacity, every other status can make room or
+	// add work for the orchestrator.
+	if s != Inprogress {
+		jm.js.notifyJobChange(JobNotification{JobId: id, Status: s})
+	}
+
 	return nil
 }
+
+// CancelJobsByAnalysisRequestId cancels all jobs of an analysis request in tx
+// and returns their ids, so adapters can be told to stop working on them.
+func (jm *JobManager) CancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID) ([]uuid.UUID, error) {
+	ids, err := jm.js.cancelJobsByAnalysisRequestId(tx, arid, "analysis request deleted")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		zap.S().Infow("Jobs Cancelled", "analysis_request_id", arid, "count", len(ids))
+	}
+	return ids, nil
+}
+
+// CancelJobsByAnalysisIds cancels the jobs still running for analyses that
+// are replaced in tx and returns their ids.
+func (jm *JobManager) CancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID) ([]uuid.UUID, error) {
+	ids, err := jm.js.cancelJobsByAnalysisIds(tx, aids, "analysis rerun")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		zap.S().Infow("Jobs Cancelled", "analysis_ids", aids, "count", len(ids))
+	}
+	return ids, nil
+}
+
+func (jm *JobManager) cleanJobHistory() {
+	rd := config.Get().Orchestrator.HistoryRetentionDays
+	if rd <= 0 {
+		return
+	}
+
+	n, err := jm.js.deleteJobHistoriesBefore(time.Now().AddDate(0, 0, -rd))
+	if err != nil {
+		return
+	}
+	zap.S().Infow("Job history cleaned", "deleted", n, "retention_days", rd)
+}
+
+// GetAverageJobDuration returns the average duration of the last finished jobs
+// of the group key, zero when none finished yet. Averages are kept for a short
+// while since every finished job asks for them.
+func (jm *JobManager) GetAverageJobDuration(groupKey string) (time.Duration, error) {
+	jm.durationsMu.Lock()
+	defer jm.durationsMu.Unlock()
+
+	if cd, ok := jm.durations[groupKey]; ok && time.Since(cd.at) < durationTtl {
+		return cd.d, nil
+	}
+
+	d, err := jm.js.getAverageJobDuration(groupKey, durationSamples)
+	if err != nil {
+		return 0, err
+	}
+
+	if jm.durations == nil {
+		jm.durations = make(map[string]cachedDuration)
+	}
+	jm.durations[groupKey] = cachedDuration{d: d, at: time.Now()}
+	return d, nil
+}
+
+// GetJobTimeline returns the history of every job of an analysis request.
+// Parse jobs come before analyze jobs, which come before report jobs, each in
+// the order they were queued.
+func (jm *JobManager) GetJobTimeline(arid uuid.UUID) ([]JobTimeline, error) {
+	jes, err := jm.js.getJobEventsByAnalysisRequestId(arid)
+	if err != nil {
+		return nil, err
+	}
+	return buildTimelines(jes), nil
+}
+
+func buildTimelines(jes []JobEvent) []JobTimeline {
+	jts := make([]JobTimeline, 0)
+	idx := make(map[uuid.UUID]int)
+
+	for _, je := range jes {
+		i, ok := idx[je.JobId]
+		if !ok {
+			i = len(jts)
+			idx[je.JobId] = i
+			jts = append(jts, JobTimeline{
+				JobId:    je.JobId,
+				Type:     je.Type,
+				GroupKey: je.GroupKey,
+				QueuedAt: je.CreatedAt,
+			})
+		}
+
+		jt := &jts[i]
+		jt.Status = je.Status
+		jt.StatusDescription = je.StatusDescription
+		jt.RetryCount = je.RetryCount
+		jt.Events = append(jt.Events, je)
+
+		switch je.Status {
+		case Inprogress:
+			jt.StartedAt = je.CreatedAt
+			jt.EndedAt = time.Time{}
+		case Finished, Error, Cancelled:
+			jt.EndedAt = je.CreatedAt
+		}
+	}
+
+	slices.SortStableFunc(jts, func(a, b JobTimeline) int {
+		if c := cmp.Compare(a.Type.stage(), b.Type.stage()); c != 0 {
+			return c
+		}
+		return a.QueuedAt.Compare(b.QueuedAt)
+	})
+
+	return jts
+}
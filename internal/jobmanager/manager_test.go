@@ -2,13 +2,16 @@ package jobmanager
 
 import (
 	"encoding/base64"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/pkg/parsercontract"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAnalysisRequestParsersAndAnalyzersSuccess(t *testing.T) {
@@ -32,6 +35,7 @@ func TestAnalysisRequestParsersAndAnalyzersSuccess(t *testing.T) {
 		},
 	}
 
+	userId := uuid.MustParse("fc28fb4c-2280-49f5-a3ba-f99ed8f8843c")
 	j := &Job{
 		Id:                jobId,
 		Status:            Queued,
@@ -40,11 +44,118 @@ func TestAnalysisRequestParsersAndAnalyzersSuccess(t *testing.T) {
 		Type:              "test",
 		GroupKey:          "test",
 		Data:              []byte("{\"type\":\"test\",\"topic\":\"test\",\"image\":\"test\",\"parserData\":{\"jobId\":\"d2efcbb9-c7e0-423c-95c3-a01e7723bedf\",\"analysisId\":\"4bc608a3-7f52-4dd4-97dc-ea01975d9f09\",\"content\":\"Q29udGVudCBnb2VzIGhlcmUgYXMgYnl0ZSBhcnJheQ==\"}}"),
+		UserId:            userId,
+		Priority:          PriorityInteractive,
 	}
 
 	mockJs.EXPECT().saveJob(j).Return(nil)
 
-	err := jm.EnqueueJob(jobId, "test", "test", pjd)
+	err := jm.EnqueueJob(jobId, "test", "test", pjd, JobMeta{UserId: userId, Priority: PriorityInteractive})
 
 	assert.NoError(t, err)
 }
+
+func TestBuildTimelines(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	pj := uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")
+	aj := uuid.MustParse("4bc608a3-7f52-4dd4-97dc-ea01975d9f09")
+	rj := uuid.MustParse("fc28fb4c-2280-49f5-a3ba-f99ed8f8843c")
+
+	jes := []JobEvent{
+		{JobId: pj, Type: Parse, Status: Queued, CreatedAt: at(0)},
+		{JobId: pj, Type: Parse, Status: Inprogress, CreatedAt: at(1)},
+		{JobId: pj, Type: Parse, Status: Queued, RetryCount: 1, StatusDescription: "timeout", CreatedAt: at(3)},
+		{JobId: pj, Type: Parse, Status: Inprogress, RetryCount: 1, CreatedAt: at(6)},
+		{JobId: pj, Type: Parse, Status: Finished, RetryCount: 1, CreatedAt: at(8)},
+		{JobId: rj, Type: Report, Status: Queued, CreatedAt: at(8)},
+		{JobId: aj, Type: Analyze, Status: Queued, CreatedAt: at(9)},
+		{JobId: aj, Type: Analyze, Status: Inprogress, CreatedAt: at(10)},
+	}
+
+	jts := buildTimelines(jes)
+
+	assert.Len(t, jts, 3)
+	assert.Equal(t, []uuid.UUID{pj, aj, rj}, []uuid.UUID{jts[0].JobId, jts[1].JobId, jts[2].JobId})
+
+	assert.Equal(t, Finished, jts[0].Status)
+	assert.Equal(t, 1, jts[0].RetryCount)
+	assert.Len(t, jts[0].Events, 5)
+	assert.Equal(t, 6*time.Second, jts[0].WaitDuration())
+	assert.Equal(t, 2*time.Second, jts[0].RunDuration(at(100)))
+
+	assert.False(t, jts[1].Ended())
+	assert.Equal(t, 5*time.Second, jts[1].RunDuration(at(15)))
+
+	assert.True(t, jts[2].StartedAt.IsZero())
+	assert.Equal(t, time.Duration(0), jts[2].RunDuration(at(15)))
+}
+
+func TestExtendLeaseIsCappedByTimeout(t *testing.T) {
+	mockJs := NewMockjobStore(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	jm := &JobManager{js: mockJs}
+	jobId := uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf")
+
+	mockJs.EXPECT().getJob(jobId).Return(Job{Id: jobId, Type: Analyze, GroupKey: "analyzer.word_search", Status: Inprogress}, nil)
+	mockJs.EXPECT().extendLease(jobId, mock.MatchedBy(func(until time.Time) bool {
+		return until.Before(time.Now().Add(61 * time.Second))
+	})).Return(true, nil)
+
+	err := jm.ExtendLease(jobId, time.Hour)
+	assert.NoError(t, err)
+}
+
+func TestStopLongRunningJobsKeepsLeasedJobs(t *testing.T) {
+	mockJs := NewMockjobStore(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	jm := &JobManager{js: mockJs}
+
+	mockJs.EXPECT().getNotFinishedJobs().Return([]Job{
+		{
+			Id:             uuid.MustParse("d2efcbb9-c7e0-423c-95c3-a01e7723bedf"),
+			Type:           Analyze,
+			GroupKey:       "analyzer.word_search",
+			Status:         Inprogress,
+			StartedAt:      time.Now().Add(-time.Hour),
+			LeaseExpiresAt: time.Now().Add(time.Minute),
+		},
+	}, nil)
+	mockJs.AssertNotCalled(t, "updateJobStatus")
+
+	jm.stopLongRunningJobs()
+}
+
+func TestGetAverageJobDurationIsKept(t *testing.T) {
+	mockJs := NewMockjobStore(t)
+
+	jm := &JobManager{js: mockJs}
+
+	mockJs.EXPECT().getAverageJobDuration("analyzer.word_search", durationSamples).Return(3*time.Second, nil).Once()
+
+	d, err := jm.GetAverageJobDuration("analyzer.word_search")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, err = jm.GetAverageJobDuration("analyzer.word_search")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, d)
+}
+
+func TestUpdateJobStatusReturnsDeadLetterError(t *testing.T) {
+	mockJs := NewMockjobStore(t)
+	mockTc := NewMocktaskCreater(t)
+	mockTc.EXPECT().NewJob(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	jm := NewJobMananger(mockJs, mockTc)
+
+	id := uuid.New()
+	mockJs.EXPECT().updateJobStatus(id, Error, "Timed out", 3, time.Time{}).Return(nil)
+	mockJs.EXPECT().moveToDeadLetter([]uuid.UUID{id}, "Timed out").Return(0, errors.New("db down"))
+
+	err := jm.UpdateJobStatus(id, Error, "Timed out", 3)
+
+	assert.Error(t, err)
+}
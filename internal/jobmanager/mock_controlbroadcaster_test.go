@@ -0,0 +1,79 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package jobmanager
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockcontrolBroadcaster is an autogenerated mock type for the controlBroadcaster type
+type MockcontrolBroadcaster struct {
+	mock.Mock
+}
+
+type MockcontrolBroadcaster_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockcontrolBroadcaster) EXPECT() *MockcontrolBroadcaster_Expecter {
+	return &MockcontrolBroadcaster_Expecter{mock: &_m.Mock}
+}
+
+// Broadcast provides a mock function with given fields: topic, payload
+func (_m *MockcontrolBroadcaster) Broadcast(topic string, payload interface{}) error {
+	ret := _m.Called(topic, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Broadcast")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}) error); ok {
+		r0 = rf(topic, payload)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockcontrolBroadcaster_Broadcast_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Broadcast'
+type MockcontrolBroadcaster_Broadcast_Call struct {
+	*mock.Call
+}
+
+// Broadcast is a helper method to define mock.On call
+//   - topic string
+//   - payload interface{}
+func (_e *MockcontrolBroadcaster_Expecter) Broadcast(topic interface{}, payload interface{}) *MockcontrolBroadcaster_Broadcast_Call {
+	return &MockcontrolBroadcaster_Broadcast_Call{Call: _e.mock.On("Broadcast", topic, payload)}
+}
+
+func (_c *MockcontrolBroadcaster_Broadcast_Call) Run(run func(topic string, payload interface{})) *MockcontrolBroadcaster_Broadcast_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(interface{}))
+	})
+	return _c
+}
+
+func (_c *MockcontrolBroadcaster_Broadcast_Call) Return(_a0 error) *MockcontrolBroadcaster_Broadcast_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockcontrolBroadcaster_Broadcast_Call) RunAndReturn(run func(string, interface{}) error) *MockcontrolBroadcaster_Broadcast_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockcontrolBroadcaster creates a new instance of MockcontrolBroadcaster. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockcontrolBroadcaster(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockcontrolBroadcaster {
+	mock := &MockcontrolBroadcaster{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
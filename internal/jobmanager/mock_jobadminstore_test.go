@@ -0,0 +1,552 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package jobmanager
+
+import (
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockjobAdminStore is an autogenerated mock type for the jobAdminStore type
+type MockjobAdminStore struct {
+	mock.Mock
+}
+
+type MockjobAdminStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockjobAdminStore) EXPECT() *MockjobAdminStore_Expecter {
+	return &MockjobAdminStore_Expecter{mock: &_m.Mock}
+}
+
+// cancelJobs provides a mock function with given fields: ids, reason
+func (_m *MockjobAdminStore) cancelJobs(ids []uuid.UUID, reason string) (int, error) {
+	ret := _m.Called(ids, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for cancelJobs")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) (int, error)); ok {
+		return rf(ids, reason)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) int); ok {
+		r0 = rf(ids, reason)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID, string) error); ok {
+		r1 = rf(ids, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_cancelJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'cancelJobs'
+type MockjobAdminStore_cancelJobs_Call struct {
+	*mock.Call
+}
+
+// cancelJobs is a helper method to define mock.On call
+//   - ids []uuid.UUID
+//   - reason string
+func (_e *MockjobAdminStore_Expecter) cancelJobs(ids interface{}, reason interface{}) *MockjobAdminStore_cancelJobs_Call {
+	return &MockjobAdminStore_cancelJobs_Call{Call: _e.mock.On("cancelJobs", ids, reason)}
+}
+
+func (_c *MockjobAdminStore_cancelJobs_Call) Run(run func(ids []uuid.UUID, reason string)) *MockjobAdminStore_cancelJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_cancelJobs_Call) Return(_a0 int, _a1 error) *MockjobAdminStore_cancelJobs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_cancelJobs_Call) RunAndReturn(run func([]uuid.UUID, string) (int, error)) *MockjobAdminStore_cancelJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getDeadLetterJob provides a mock function with given fields: id
+func (_m *MockjobAdminStore) getDeadLetterJob(id uuid.UUID) (DeadLetterJob, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getDeadLetterJob")
+	}
+
+	var r0 DeadLetterJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (DeadLetterJob, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) DeadLetterJob); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(DeadLetterJob)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_getDeadLetterJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getDeadLetterJob'
+type MockjobAdminStore_getDeadLetterJob_Call struct {
+	*mock.Call
+}
+
+// getDeadLetterJob is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *MockjobAdminStore_Expecter) getDeadLetterJob(id interface{}) *MockjobAdminStore_getDeadLetterJob_Call {
+	return &MockjobAdminStore_getDeadLetterJob_Call{Call: _e.mock.On("getDeadLetterJob", id)}
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJob_Call) Run(run func(id uuid.UUID)) *MockjobAdminStore_getDeadLetterJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJob_Call) Return(_a0 DeadLetterJob, _a1 error) *MockjobAdminStore_getDeadLetterJob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJob_Call) RunAndReturn(run func(uuid.UUID) (DeadLetterJob, error)) *MockjobAdminStore_getDeadLetterJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getDeadLetterJobs provides a mock function with given fields: f, pag
+func (_m *MockjobAdminStore) getDeadLetterJobs(f JobFilter, pag Pagination) ([]DeadLetterJob, int, error) {
+	ret := _m.Called(f, pag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getDeadLetterJobs")
+	}
+
+	var r0 []DeadLetterJob
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(JobFilter, Pagination) ([]DeadLetterJob, int, error)); ok {
+		return rf(f, pag)
+	}
+	if rf, ok := ret.Get(0).(func(JobFilter, Pagination) []DeadLetterJob); ok {
+		r0 = rf(f, pag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]DeadLetterJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(JobFilter, Pagination) int); ok {
+		r1 = rf(f, pag)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(JobFilter, Pagination) error); ok {
+		r2 = rf(f, pag)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockjobAdminStore_getDeadLetterJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getDeadLetterJobs'
+type MockjobAdminStore_getDeadLetterJobs_Call struct {
+	*mock.Call
+}
+
+// getDeadLetterJobs is a helper method to define mock.On call
+//   - f JobFilter
+//   - pag Pagination
+func (_e *MockjobAdminStore_Expecter) getDeadLetterJobs(f interface{}, pag interface{}) *MockjobAdminStore_getDeadLetterJobs_Call {
+	return &MockjobAdminStore_getDeadLetterJobs_Call{Call: _e.mock.On("getDeadLetterJobs", f, pag)}
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJobs_Call) Run(run func(f JobFilter, pag Pagination)) *MockjobAdminStore_getDeadLetterJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(JobFilter), args[1].(Pagination))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJobs_Call) Return(_a0 []DeadLetterJob, _a1 int, _a2 error) *MockjobAdminStore_getDeadLetterJobs_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockjobAdminStore_getDeadLetterJobs_Call) RunAndReturn(run func(JobFilter, Pagination) ([]DeadLetterJob, int, error)) *MockjobAdminStore_getDeadLetterJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getJob provides a mock function with given fields: id
+func (_m *MockjobAdminStore) getJob(id uuid.UUID) (Job, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getJob")
+	}
+
+	var r0 Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (Job, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) Job); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_getJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getJob'
+type MockjobAdminStore_getJob_Call struct {
+	*mock.Call
+}
+
+// getJob is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *MockjobAdminStore_Expecter) getJob(id interface{}) *MockjobAdminStore_getJob_Call {
+	return &MockjobAdminStore_getJob_Call{Call: _e.mock.On("getJob", id)}
+}
+
+func (_c *MockjobAdminStore_getJob_Call) Run(run func(id uuid.UUID)) *MockjobAdminStore_getJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_getJob_Call) Return(_a0 Job, _a1 error) *MockjobAdminStore_getJob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_getJob_Call) RunAndReturn(run func(uuid.UUID) (Job, error)) *MockjobAdminStore_getJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getJobs provides a mock function with given fields: f, pag
+func (_m *MockjobAdminStore) getJobs(f JobFilter, pag Pagination) ([]Job, int, error) {
+	ret := _m.Called(f, pag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getJobs")
+	}
+
+	var r0 []Job
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(JobFilter, Pagination) ([]Job, int, error)); ok {
+		return rf(f, pag)
+	}
+	if rf, ok := ret.Get(0).(func(JobFilter, Pagination) []Job); ok {
+		r0 = rf(f, pag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(JobFilter, Pagination) int); ok {
+		r1 = rf(f, pag)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(JobFilter, Pagination) error); ok {
+		r2 = rf(f, pag)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockjobAdminStore_getJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getJobs'
+type MockjobAdminStore_getJobs_Call struct {
+	*mock.Call
+}
+
+// getJobs is a helper method to define mock.On call
+//   - f JobFilter
+//   - pag Pagination
+func (_e *MockjobAdminStore_Expecter) getJobs(f interface{}, pag interface{}) *MockjobAdminStore_getJobs_Call {
+	return &MockjobAdminStore_getJobs_Call{Call: _e.mock.On("getJobs", f, pag)}
+}
+
+func (_c *MockjobAdminStore_getJobs_Call) Run(run func(f JobFilter, pag Pagination)) *MockjobAdminStore_getJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(JobFilter), args[1].(Pagination))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_getJobs_Call) Return(_a0 []Job, _a1 int, _a2 error) *MockjobAdminStore_getJobs_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockjobAdminStore_getJobs_Call) RunAndReturn(run func(JobFilter, Pagination) ([]Job, int, error)) *MockjobAdminStore_getJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// moveToDeadLetter provides a mock function with given fields: ids, reason
+func (_m *MockjobAdminStore) moveToDeadLetter(ids []uuid.UUID, reason string) (int, error) {
+	ret := _m.Called(ids, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for moveToDeadLetter")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) (int, error)); ok {
+		return rf(ids, reason)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) int); ok {
+		r0 = rf(ids, reason)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID, string) error); ok {
+		r1 = rf(ids, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_moveToDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'moveToDeadLetter'
+type MockjobAdminStore_moveToDeadLetter_Call struct {
+	*mock.Call
+}
+
+// moveToDeadLetter is a helper method to define mock.On call
+//   - ids []uuid.UUID
+//   - reason string
+func (_e *MockjobAdminStore_Expecter) moveToDeadLetter(ids interface{}, reason interface{}) *MockjobAdminStore_moveToDeadLetter_Call {
+	return &MockjobAdminStore_moveToDeadLetter_Call{Call: _e.mock.On("moveToDeadLetter", ids, reason)}
+}
+
+func (_c *MockjobAdminStore_moveToDeadLetter_Call) Run(run func(ids []uuid.UUID, reason string)) *MockjobAdminStore_moveToDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_moveToDeadLetter_Call) Return(_a0 int, _a1 error) *MockjobAdminStore_moveToDeadLetter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_moveToDeadLetter_Call) RunAndReturn(run func([]uuid.UUID, string) (int, error)) *MockjobAdminStore_moveToDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// notifyJobChange provides a mock function with given fields: jn
+func (_m *MockjobAdminStore) notifyJobChange(jn JobNotification) error {
+	ret := _m.Called(jn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for notifyJobChange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(JobNotification) error); ok {
+		r0 = rf(jn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockjobAdminStore_notifyJobChange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'notifyJobChange'
+type MockjobAdminStore_notifyJobChange_Call struct {
+	*mock.Call
+}
+
+// notifyJobChange is a helper method to define mock.On call
+//   - jn JobNotification
+func (_e *MockjobAdminStore_Expecter) notifyJobChange(jn interface{}) *MockjobAdminStore_notifyJobChange_Call {
+	return &MockjobAdminStore_notifyJobChange_Call{Call: _e.mock.On("notifyJobChange", jn)}
+}
+
+func (_c *MockjobAdminStore_notifyJobChange_Call) Run(run func(jn JobNotification)) *MockjobAdminStore_notifyJobChange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(JobNotification))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_notifyJobChange_Call) Return(_a0 error) *MockjobAdminStore_notifyJobChange_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockjobAdminStore_notifyJobChange_Call) RunAndReturn(run func(JobNotification) error) *MockjobAdminStore_notifyJobChange_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// requeueJobs provides a mock function with given fields: ids, sd
+func (_m *MockjobAdminStore) requeueJobs(ids []uuid.UUID, sd string) (int, error) {
+	ret := _m.Called(ids, sd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for requeueJobs")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) (int, error)); ok {
+		return rf(ids, sd)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) int); ok {
+		r0 = rf(ids, sd)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID, string) error); ok {
+		r1 = rf(ids, sd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_requeueJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'requeueJobs'
+type MockjobAdminStore_requeueJobs_Call struct {
+	*mock.Call
+}
+
+// requeueJobs is a helper method to define mock.On call
+//   - ids []uuid.UUID
+//   - sd string
+func (_e *MockjobAdminStore_Expecter) requeueJobs(ids interface{}, sd interface{}) *MockjobAdminStore_requeueJobs_Call {
+	return &MockjobAdminStore_requeueJobs_Call{Call: _e.mock.On("requeueJobs", ids, sd)}
+}
+
+func (_c *MockjobAdminStore_requeueJobs_Call) Run(run func(ids []uuid.UUID, sd string)) *MockjobAdminStore_requeueJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_requeueJobs_Call) Return(_a0 int, _a1 error) *MockjobAdminStore_requeueJobs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_requeueJobs_Call) RunAndReturn(run func([]uuid.UUID, string) (int, error)) *MockjobAdminStore_requeueJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// restoreFromDeadLetter provides a mock function with given fields: ids
+func (_m *MockjobAdminStore) restoreFromDeadLetter(ids []uuid.UUID) (int, error) {
+	ret := _m.Called(ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for restoreFromDeadLetter")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID) (int, error)); ok {
+		return rf(ids)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID) int); ok {
+		r0 = rf(ids)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID) error); ok {
+		r1 = rf(ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobAdminStore_restoreFromDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'restoreFromDeadLetter'
+type MockjobAdminStore_restoreFromDeadLetter_Call struct {
+	*mock.Call
+}
+
+// restoreFromDeadLetter is a helper method to define mock.On call
+//   - ids []uuid.UUID
+func (_e *MockjobAdminStore_Expecter) restoreFromDeadLetter(ids interface{}) *MockjobAdminStore_restoreFromDeadLetter_Call {
+	return &MockjobAdminStore_restoreFromDeadLetter_Call{Call: _e.mock.On("restoreFromDeadLetter", ids)}
+}
+
+func (_c *MockjobAdminStore_restoreFromDeadLetter_Call) Run(run func(ids []uuid.UUID)) *MockjobAdminStore_restoreFromDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobAdminStore_restoreFromDeadLetter_Call) Return(_a0 int, _a1 error) *MockjobAdminStore_restoreFromDeadLetter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobAdminStore_restoreFromDeadLetter_Call) RunAndReturn(run func([]uuid.UUID) (int, error)) *MockjobAdminStore_restoreFromDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockjobAdminStore creates a new instance of MockjobAdminStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockjobAdminStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockjobAdminStore {
+	mock := &MockjobAdminStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
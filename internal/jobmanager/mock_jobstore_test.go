@@ -3,8 +3,12 @@
 package jobmanager
 
 import (
-	uuid "github.com/google/uuid"
+	time "time"
+
 	mock "github.com/stretchr/testify/mock"
+	gorm "gorm.io/gorm"
+
+	uuid "github.com/google/uuid"
 )
 
 // MockjobStore is an autogenerated mock type for the jobStore type
@@ -20,6 +24,126 @@ func (_m *MockjobStore) EXPECT() *MockjobStore_Expecter {
 	return &MockjobStore_Expecter{mock: &_m.Mock}
 }
 
+// cancelJobsByAnalysisIds provides a mock function with given fields: tx, aids, reason
+func (_m *MockjobStore) cancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID, reason string) ([]uuid.UUID, error) {
+	ret := _m.Called(tx, aids, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for cancelJobsByAnalysisIds")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gorm.DB, []uuid.UUID, string) ([]uuid.UUID, error)); ok {
+		return rf(tx, aids, reason)
+	}
+	if rf, ok := ret.Get(0).(func(*gorm.DB, []uuid.UUID, string) []uuid.UUID); ok {
+		r0 = rf(tx, aids, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gorm.DB, []uuid.UUID, string) error); ok {
+		r1 = rf(tx, aids, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_cancelJobsByAnalysisIds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'cancelJobsByAnalysisIds'
+type MockjobStore_cancelJobsByAnalysisIds_Call struct {
+	*mock.Call
+}
+
+// cancelJobsByAnalysisIds is a helper method to define mock.On call
+//   - tx *gorm.DB
+//   - aids []uuid.UUID
+//   - reason string
+func (_e *MockjobStore_Expecter) cancelJobsByAnalysisIds(tx interface{}, aids interface{}, reason interface{}) *MockjobStore_cancelJobsByAnalysisIds_Call {
+	return &MockjobStore_cancelJobsByAnalysisIds_Call{Call: _e.mock.On("cancelJobsByAnalysisIds", tx, aids, reason)}
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisIds_Call) Run(run func(tx *gorm.DB, aids []uuid.UUID, reason string)) *MockjobStore_cancelJobsByAnalysisIds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*gorm.DB), args[1].([]uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisIds_Call) Return(_a0 []uuid.UUID, _a1 error) *MockjobStore_cancelJobsByAnalysisIds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisIds_Call) RunAndReturn(run func(*gorm.DB, []uuid.UUID, string) ([]uuid.UUID, error)) *MockjobStore_cancelJobsByAnalysisIds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// cancelJobsByAnalysisRequestId provides a mock function with given fields: tx, arid, reason
+func (_m *MockjobStore) cancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID, reason string) ([]uuid.UUID, error) {
+	ret := _m.Called(tx, arid, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for cancelJobsByAnalysisRequestId")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*gorm.DB, uuid.UUID, string) ([]uuid.UUID, error)); ok {
+		return rf(tx, arid, reason)
+	}
+	if rf, ok := ret.Get(0).(func(*gorm.DB, uuid.UUID, string) []uuid.UUID); ok {
+		r0 = rf(tx, arid, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*gorm.DB, uuid.UUID, string) error); ok {
+		r1 = rf(tx, arid, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_cancelJobsByAnalysisRequestId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'cancelJobsByAnalysisRequestId'
+type MockjobStore_cancelJobsByAnalysisRequestId_Call struct {
+	*mock.Call
+}
+
+// cancelJobsByAnalysisRequestId is a helper method to define mock.On call
+//   - tx *gorm.DB
+//   - arid uuid.UUID
+//   - reason string
+func (_e *MockjobStore_Expecter) cancelJobsByAnalysisRequestId(tx interface{}, arid interface{}, reason interface{}) *MockjobStore_cancelJobsByAnalysisRequestId_Call {
+	return &MockjobStore_cancelJobsByAnalysisRequestId_Call{Call: _e.mock.On("cancelJobsByAnalysisRequestId", tx, arid, reason)}
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisRequestId_Call) Run(run func(tx *gorm.DB, arid uuid.UUID, reason string)) *MockjobStore_cancelJobsByAnalysisRequestId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*gorm.DB), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisRequestId_Call) Return(_a0 []uuid.UUID, _a1 error) *MockjobStore_cancelJobsByAnalysisRequestId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_cancelJobsByAnalysisRequestId_Call) RunAndReturn(run func(*gorm.DB, uuid.UUID, string) ([]uuid.UUID, error)) *MockjobStore_cancelJobsByAnalysisRequestId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // deleteJob provides a mock function with given fields: id
 func (_m *MockjobStore) deleteJob(id uuid.UUID) error {
 	ret := _m.Called(id)
@@ -66,24 +190,194 @@ func (_c *MockjobStore_deleteJob_Call) RunAndReturn(run func(uuid.UUID) error) *
 	return _c
 }
 
-// getNotFinishedJobs provides a mock function with no fields
-func (_m *MockjobStore) getNotFinishedJobs() ([]Job, error) {
+// deleteJobHistoriesBefore provides a mock function with given fields: t
+func (_m *MockjobStore) deleteJobHistoriesBefore(t time.Time) (int, error) {
+	ret := _m.Called(t)
+
+	if len(ret) == 0 {
+		panic("no return value specified for deleteJobHistoriesBefore")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (int, error)); ok {
+		return rf(t)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) int); ok {
+		r0 = rf(t)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_deleteJobHistoriesBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'deleteJobHistoriesBefore'
+type MockjobStore_deleteJobHistoriesBefore_Call struct {
+	*mock.Call
+}
+
+// deleteJobHistoriesBefore is a helper method to define mock.On call
+//   - t time.Time
+func (_e *MockjobStore_Expecter) deleteJobHistoriesBefore(t interface{}) *MockjobStore_deleteJobHistoriesBefore_Call {
+	return &MockjobStore_deleteJobHistoriesBefore_Call{Call: _e.mock.On("deleteJobHistoriesBefore", t)}
+}
+
+func (_c *MockjobStore_deleteJobHistoriesBefore_Call) Run(run func(t time.Time)) *MockjobStore_deleteJobHistoriesBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_deleteJobHistoriesBefore_Call) Return(_a0 int, _a1 error) *MockjobStore_deleteJobHistoriesBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_deleteJobHistoriesBefore_Call) RunAndReturn(run func(time.Time) (int, error)) *MockjobStore_deleteJobHistoriesBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// extendLease provides a mock function with given fields: id, until
+func (_m *MockjobStore) extendLease(id uuid.UUID, until time.Time) (bool, error) {
+	ret := _m.Called(id, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for extendLease")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Time) (bool, error)); ok {
+		return rf(id, until)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Time) bool); ok {
+		r0 = rf(id, until)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, time.Time) error); ok {
+		r1 = rf(id, until)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_extendLease_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'extendLease'
+type MockjobStore_extendLease_Call struct {
+	*mock.Call
+}
+
+// extendLease is a helper method to define mock.On call
+//   - id uuid.UUID
+//   - until time.Time
+func (_e *MockjobStore_Expecter) extendLease(id interface{}, until interface{}) *MockjobStore_extendLease_Call {
+	return &MockjobStore_extendLease_Call{Call: _e.mock.On("extendLease", id, until)}
+}
+
+func (_c *MockjobStore_extendLease_Call) Run(run func(id uuid.UUID, until time.Time)) *MockjobStore_extendLease_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_extendLease_Call) Return(_a0 bool, _a1 error) *MockjobStore_extendLease_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_extendLease_Call) RunAndReturn(run func(uuid.UUID, time.Time) (bool, error)) *MockjobStore_extendLease_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getAverageJobDuration provides a mock function with given fields: groupKey, n
+func (_m *MockjobStore) getAverageJobDuration(groupKey string, n int) (time.Duration, error) {
+	ret := _m.Called(groupKey, n)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getAverageJobDuration")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) (time.Duration, error)); ok {
+		return rf(groupKey, n)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) time.Duration); ok {
+		r0 = rf(groupKey, n)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(groupKey, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getAverageJobDuration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getAverageJobDuration'
+type MockjobStore_getAverageJobDuration_Call struct {
+	*mock.Call
+}
+
+// getAverageJobDuration is a helper method to define mock.On call
+//   - groupKey string
+//   - n int
+func (_e *MockjobStore_Expecter) getAverageJobDuration(groupKey interface{}, n interface{}) *MockjobStore_getAverageJobDuration_Call {
+	return &MockjobStore_getAverageJobDuration_Call{Call: _e.mock.On("getAverageJobDuration", groupKey, n)}
+}
+
+func (_c *MockjobStore_getAverageJobDuration_Call) Run(run func(groupKey string, n int)) *MockjobStore_getAverageJobDuration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getAverageJobDuration_Call) Return(_a0 time.Duration, _a1 error) *MockjobStore_getAverageJobDuration_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getAverageJobDuration_Call) RunAndReturn(run func(string, int) (time.Duration, error)) *MockjobStore_getAverageJobDuration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getInprogressCounts provides a mock function with no fields
+func (_m *MockjobStore) getInprogressCounts() (map[string]int, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for getNotFinishedJobs")
+		panic("no return value specified for getInprogressCounts")
 	}
 
-	var r0 []Job
+	var r0 map[string]int
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]Job, error)); ok {
+	if rf, ok := ret.Get(0).(func() (map[string]int, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() []Job); ok {
+	if rf, ok := ret.Get(0).(func() map[string]int); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]Job)
+			r0 = ret.Get(0).(map[string]int)
 		}
 	}
 
@@ -96,29 +390,590 @@ func (_m *MockjobStore) getNotFinishedJobs() ([]Job, error) {
 	return r0, r1
 }
 
-// MockjobStore_getNotFinishedJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getNotFinishedJobs'
-type MockjobStore_getNotFinishedJobs_Call struct {
+// MockjobStore_getInprogressCounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getInprogressCounts'
+type MockjobStore_getInprogressCounts_Call struct {
 	*mock.Call
 }
 
-// getNotFinishedJobs is a helper method to define mock.On call
-func (_e *MockjobStore_Expecter) getNotFinishedJobs() *MockjobStore_getNotFinishedJobs_Call {
-	return &MockjobStore_getNotFinishedJobs_Call{Call: _e.mock.On("getNotFinishedJobs")}
+// getInprogressCounts is a helper method to define mock.On call
+func (_e *MockjobStore_Expecter) getInprogressCounts() *MockjobStore_getInprogressCounts_Call {
+	return &MockjobStore_getInprogressCounts_Call{Call: _e.mock.On("getInprogressCounts")}
 }
 
-func (_c *MockjobStore_getNotFinishedJobs_Call) Run(run func()) *MockjobStore_getNotFinishedJobs_Call {
+func (_c *MockjobStore_getInprogressCounts_Call) Run(run func()) *MockjobStore_getInprogressCounts_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *MockjobStore_getNotFinishedJobs_Call) Return(_a0 []Job, _a1 error) *MockjobStore_getNotFinishedJobs_Call {
+func (_c *MockjobStore_getInprogressCounts_Call) Return(_a0 map[string]int, _a1 error) *MockjobStore_getInprogressCounts_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockjobStore_getNotFinishedJobs_Call) RunAndReturn(run func() ([]Job, error)) *MockjobStore_getNotFinishedJobs_Call {
+func (_c *MockjobStore_getInprogressCounts_Call) RunAndReturn(run func() (map[string]int, error)) *MockjobStore_getInprogressCounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getInprogressUserCounts provides a mock function with given fields: groupKey
+func (_m *MockjobStore) getInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error) {
+	ret := _m.Called(groupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getInprogressUserCounts")
+	}
+
+	var r0 map[uuid.UUID]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (map[uuid.UUID]int, error)); ok {
+		return rf(groupKey)
+	}
+	if rf, ok := ret.Get(0).(func(string) map[uuid.UUID]int); ok {
+		r0 = rf(groupKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uuid.UUID]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(groupKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getInprogressUserCounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getInprogressUserCounts'
+type MockjobStore_getInprogressUserCounts_Call struct {
+	*mock.Call
+}
+
+// getInprogressUserCounts is a helper method to define mock.On call
+//   - groupKey string
+func (_e *MockjobStore_Expecter) getInprogressUserCounts(groupKey interface{}) *MockjobStore_getInprogressUserCounts_Call {
+	return &MockjobStore_getInprogressUserCounts_Call{Call: _e.mock.On("getInprogressUserCounts", groupKey)}
+}
+
+func (_c *MockjobStore_getInprogressUserCounts_Call) Run(run func(groupKey string)) *MockjobStore_getInprogressUserCounts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getInprogressUserCounts_Call) Return(_a0 map[uuid.UUID]int, _a1 error) *MockjobStore_getInprogressUserCounts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getInprogressUserCounts_Call) RunAndReturn(run func(string) (map[uuid.UUID]int, error)) *MockjobStore_getInprogressUserCounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getJob provides a mock function with given fields: id
+func (_m *MockjobStore) getJob(id uuid.UUID) (Job, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getJob")
+	}
+
+	var r0 Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (Job, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) Job); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getJob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getJob'
+type MockjobStore_getJob_Call struct {
+	*mock.Call
+}
+
+// getJob is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *MockjobStore_Expecter) getJob(id interface{}) *MockjobStore_getJob_Call {
+	return &MockjobStore_getJob_Call{Call: _e.mock.On("getJob", id)}
+}
+
+func (_c *MockjobStore_getJob_Call) Run(run func(id uuid.UUID)) *MockjobStore_getJob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getJob_Call) Return(_a0 Job, _a1 error) *MockjobStore_getJob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getJob_Call) RunAndReturn(run func(uuid.UUID) (Job, error)) *MockjobStore_getJob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getJobEventsByAnalysisRequestId provides a mock function with given fields: arid
+func (_m *MockjobStore) getJobEventsByAnalysisRequestId(arid uuid.UUID) ([]JobEvent, error) {
+	ret := _m.Called(arid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getJobEventsByAnalysisRequestId")
+	}
+
+	var r0 []JobEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]JobEvent, error)); ok {
+		return rf(arid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []JobEvent); ok {
+		r0 = rf(arid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]JobEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(arid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getJobEventsByAnalysisRequestId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getJobEventsByAnalysisRequestId'
+type MockjobStore_getJobEventsByAnalysisRequestId_Call struct {
+	*mock.Call
+}
+
+// getJobEventsByAnalysisRequestId is a helper method to define mock.On call
+//   - arid uuid.UUID
+func (_e *MockjobStore_Expecter) getJobEventsByAnalysisRequestId(arid interface{}) *MockjobStore_getJobEventsByAnalysisRequestId_Call {
+	return &MockjobStore_getJobEventsByAnalysisRequestId_Call{Call: _e.mock.On("getJobEventsByAnalysisRequestId", arid)}
+}
+
+func (_c *MockjobStore_getJobEventsByAnalysisRequestId_Call) Run(run func(arid uuid.UUID)) *MockjobStore_getJobEventsByAnalysisRequestId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getJobEventsByAnalysisRequestId_Call) Return(_a0 []JobEvent, _a1 error) *MockjobStore_getJobEventsByAnalysisRequestId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getJobEventsByAnalysisRequestId_Call) RunAndReturn(run func(uuid.UUID) ([]JobEvent, error)) *MockjobStore_getJobEventsByAnalysisRequestId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getNextAttemptAt provides a mock function with no fields
+func (_m *MockjobStore) getNextAttemptAt() (*time.Time, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for getNextAttemptAt")
+	}
+
+	var r0 *time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*time.Time, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *time.Time); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getNextAttemptAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getNextAttemptAt'
+type MockjobStore_getNextAttemptAt_Call struct {
+	*mock.Call
+}
+
+// getNextAttemptAt is a helper method to define mock.On call
+func (_e *MockjobStore_Expecter) getNextAttemptAt() *MockjobStore_getNextAttemptAt_Call {
+	return &MockjobStore_getNextAttemptAt_Call{Call: _e.mock.On("getNextAttemptAt")}
+}
+
+func (_c *MockjobStore_getNextAttemptAt_Call) Run(run func()) *MockjobStore_getNextAttemptAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getNextAttemptAt_Call) Return(_a0 *time.Time, _a1 error) *MockjobStore_getNextAttemptAt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getNextAttemptAt_Call) RunAndReturn(run func() (*time.Time, error)) *MockjobStore_getNextAttemptAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getNotFinishedJobs provides a mock function with no fields
+func (_m *MockjobStore) getNotFinishedJobs() ([]Job, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for getNotFinishedJobs")
+	}
+
+	var r0 []Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]Job, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []Job); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getNotFinishedJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getNotFinishedJobs'
+type MockjobStore_getNotFinishedJobs_Call struct {
+	*mock.Call
+}
+
+// getNotFinishedJobs is a helper method to define mock.On call
+func (_e *MockjobStore_Expecter) getNotFinishedJobs() *MockjobStore_getNotFinishedJobs_Call {
+	return &MockjobStore_getNotFinishedJobs_Call{Call: _e.mock.On("getNotFinishedJobs")}
+}
+
+func (_c *MockjobStore_getNotFinishedJobs_Call) Run(run func()) *MockjobStore_getNotFinishedJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getNotFinishedJobs_Call) Return(_a0 []Job, _a1 error) *MockjobStore_getNotFinishedJobs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getNotFinishedJobs_Call) RunAndReturn(run func() ([]Job, error)) *MockjobStore_getNotFinishedJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getQueuedGroupKeys provides a mock function with no fields
+func (_m *MockjobStore) getQueuedGroupKeys() ([]string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for getQueuedGroupKeys")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getQueuedGroupKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getQueuedGroupKeys'
+type MockjobStore_getQueuedGroupKeys_Call struct {
+	*mock.Call
+}
+
+// getQueuedGroupKeys is a helper method to define mock.On call
+func (_e *MockjobStore_Expecter) getQueuedGroupKeys() *MockjobStore_getQueuedGroupKeys_Call {
+	return &MockjobStore_getQueuedGroupKeys_Call{Call: _e.mock.On("getQueuedGroupKeys")}
+}
+
+func (_c *MockjobStore_getQueuedGroupKeys_Call) Run(run func()) *MockjobStore_getQueuedGroupKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getQueuedGroupKeys_Call) Return(_a0 []string, _a1 error) *MockjobStore_getQueuedGroupKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getQueuedGroupKeys_Call) RunAndReturn(run func() ([]string, error)) *MockjobStore_getQueuedGroupKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getQueuedJobs provides a mock function with given fields: groupKey, perUser
+func (_m *MockjobStore) getQueuedJobs(groupKey string, perUser int) ([]Job, error) {
+	ret := _m.Called(groupKey, perUser)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getQueuedJobs")
+	}
+
+	var r0 []Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) ([]Job, error)); ok {
+		return rf(groupKey, perUser)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) []Job); ok {
+		r0 = rf(groupKey, perUser)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(groupKey, perUser)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_getQueuedJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getQueuedJobs'
+type MockjobStore_getQueuedJobs_Call struct {
+	*mock.Call
+}
+
+// getQueuedJobs is a helper method to define mock.On call
+//   - groupKey string
+//   - perUser int
+func (_e *MockjobStore_Expecter) getQueuedJobs(groupKey interface{}, perUser interface{}) *MockjobStore_getQueuedJobs_Call {
+	return &MockjobStore_getQueuedJobs_Call{Call: _e.mock.On("getQueuedJobs", groupKey, perUser)}
+}
+
+func (_c *MockjobStore_getQueuedJobs_Call) Run(run func(groupKey string, perUser int)) *MockjobStore_getQueuedJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_getQueuedJobs_Call) Return(_a0 []Job, _a1 error) *MockjobStore_getQueuedJobs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_getQueuedJobs_Call) RunAndReturn(run func(string, int) ([]Job, error)) *MockjobStore_getQueuedJobs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// isJobInprogress provides a mock function with given fields: id
+func (_m *MockjobStore) isJobInprogress(id uuid.UUID) (bool, error) {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for isJobInprogress")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) (bool, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_isJobInprogress_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'isJobInprogress'
+type MockjobStore_isJobInprogress_Call struct {
+	*mock.Call
+}
+
+// isJobInprogress is a helper method to define mock.On call
+//   - id uuid.UUID
+func (_e *MockjobStore_Expecter) isJobInprogress(id interface{}) *MockjobStore_isJobInprogress_Call {
+	return &MockjobStore_isJobInprogress_Call{Call: _e.mock.On("isJobInprogress", id)}
+}
+
+func (_c *MockjobStore_isJobInprogress_Call) Run(run func(id uuid.UUID)) *MockjobStore_isJobInprogress_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_isJobInprogress_Call) Return(_a0 bool, _a1 error) *MockjobStore_isJobInprogress_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_isJobInprogress_Call) RunAndReturn(run func(uuid.UUID) (bool, error)) *MockjobStore_isJobInprogress_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// moveToDeadLetter provides a mock function with given fields: ids, reason
+func (_m *MockjobStore) moveToDeadLetter(ids []uuid.UUID, reason string) (int, error) {
+	ret := _m.Called(ids, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for moveToDeadLetter")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) (int, error)); ok {
+		return rf(ids, reason)
+	}
+	if rf, ok := ret.Get(0).(func([]uuid.UUID, string) int); ok {
+		r0 = rf(ids, reason)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func([]uuid.UUID, string) error); ok {
+		r1 = rf(ids, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobStore_moveToDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'moveToDeadLetter'
+type MockjobStore_moveToDeadLetter_Call struct {
+	*mock.Call
+}
+
+// moveToDeadLetter is a helper method to define mock.On call
+//   - ids []uuid.UUID
+//   - reason string
+func (_e *MockjobStore_Expecter) moveToDeadLetter(ids interface{}, reason interface{}) *MockjobStore_moveToDeadLetter_Call {
+	return &MockjobStore_moveToDeadLetter_Call{Call: _e.mock.On("moveToDeadLetter", ids, reason)}
+}
+
+func (_c *MockjobStore_moveToDeadLetter_Call) Run(run func(ids []uuid.UUID, reason string)) *MockjobStore_moveToDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]uuid.UUID), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_moveToDeadLetter_Call) Return(_a0 int, _a1 error) *MockjobStore_moveToDeadLetter_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobStore_moveToDeadLetter_Call) RunAndReturn(run func([]uuid.UUID, string) (int, error)) *MockjobStore_moveToDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// notifyJobChange provides a mock function with given fields: jn
+func (_m *MockjobStore) notifyJobChange(jn JobNotification) error {
+	ret := _m.Called(jn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for notifyJobChange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(JobNotification) error); ok {
+		r0 = rf(jn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockjobStore_notifyJobChange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'notifyJobChange'
+type MockjobStore_notifyJobChange_Call struct {
+	*mock.Call
+}
+
+// notifyJobChange is a helper method to define mock.On call
+//   - jn JobNotification
+func (_e *MockjobStore_Expecter) notifyJobChange(jn interface{}) *MockjobStore_notifyJobChange_Call {
+	return &MockjobStore_notifyJobChange_Call{Call: _e.mock.On("notifyJobChange", jn)}
+}
+
+func (_c *MockjobStore_notifyJobChange_Call) Run(run func(jn JobNotification)) *MockjobStore_notifyJobChange_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(JobNotification))
+	})
+	return _c
+}
+
+func (_c *MockjobStore_notifyJobChange_Call) Return(_a0 error) *MockjobStore_notifyJobChange_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockjobStore_notifyJobChange_Call) RunAndReturn(run func(JobNotification) error) *MockjobStore_notifyJobChange_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -169,17 +1024,17 @@ func (_c *MockjobStore_saveJob_Call) RunAndReturn(run func(*Job) error) *Mockjob
 	return _c
 }
 
-// updateJobStatus provides a mock function with given fields: id, s, sd, rc
-func (_m *MockjobStore) updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int) error {
-	ret := _m.Called(id, s, sd, rc)
+// updateJobStatus provides a mock function with given fields: id, s, sd, rc, naa
+func (_m *MockjobStore) updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int, naa time.Time) error {
+	ret := _m.Called(id, s, sd, rc, naa)
 
 	if len(ret) == 0 {
 		panic("no return value specified for updateJobStatus")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID, JobStatus, string, int) error); ok {
-		r0 = rf(id, s, sd, rc)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, JobStatus, string, int, time.Time) error); ok {
+		r0 = rf(id, s, sd, rc, naa)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -197,13 +1052,14 @@ type MockjobStore_updateJobStatus_Call struct {
 //   - s JobStatus
 //   - sd string
 //   - rc int
-func (_e *MockjobStore_Expecter) updateJobStatus(id interface{}, s interface{}, sd interface{}, rc interface{}) *MockjobStore_updateJobStatus_Call {
-	return &MockjobStore_updateJobStatus_Call{Call: _e.mock.On("updateJobStatus", id, s, sd, rc)}
+//   - naa time.Time
+func (_e *MockjobStore_Expecter) updateJobStatus(id interface{}, s interface{}, sd interface{}, rc interface{}, naa interface{}) *MockjobStore_updateJobStatus_Call {
+	return &MockjobStore_updateJobStatus_Call{Call: _e.mock.On("updateJobStatus", id, s, sd, rc, naa)}
 }
 
-func (_c *MockjobStore_updateJobStatus_Call) Run(run func(id uuid.UUID, s JobStatus, sd string, rc int)) *MockjobStore_updateJobStatus_Call {
+func (_c *MockjobStore_updateJobStatus_Call) Run(run func(id uuid.UUID, s JobStatus, sd string, rc int, naa time.Time)) *MockjobStore_updateJobStatus_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(uuid.UUID), args[1].(JobStatus), args[2].(string), args[3].(int))
+		run(args[0].(uuid.UUID), args[1].(JobStatus), args[2].(string), args[3].(int), args[4].(time.Time))
 	})
 	return _c
 }
@@ -213,7 +1069,7 @@ func (_c *MockjobStore_updateJobStatus_Call) Return(_a0 error) *MockjobStore_upd
 	return _c
 }
 
-func (_c *MockjobStore_updateJobStatus_Call) RunAndReturn(run func(uuid.UUID, JobStatus, string, int) error) *MockjobStore_updateJobStatus_Call {
+func (_c *MockjobStore_updateJobStatus_Call) RunAndReturn(run func(uuid.UUID, JobStatus, string, int, time.Time) error) *MockjobStore_updateJobStatus_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -18,6 +18,7 @@ const (
 	Inprogress JobStatus = "inprogress"
 	Finished   JobStatus = "finished"
 	Error      JobStatus = "error"
+	Cancelled  JobStatus = "cancelled"
 )
 
 type JobType string
@@ -28,10 +29,51 @@ const (
 	Report  JobType = "report"
 )
 
+// JobPriority orders queued jobs of the same group key, higher goes first.
+type JobPriority int
+
+const (
+	PriorityBackfill    JobPriority = 0
+	PriorityNormal      JobPriority = 10
+	PriorityInteractive JobPriority = 20
+)
+
+// JobMeta describes on whose behalf, for which analysis request and how
+// urgently a job runs.
+type JobMeta struct {
+	UserId            uuid.UUID
+	AnalysisRequestId uuid.UUID
+	Priority          JobPriority
+}
+
+// JobsChannel is the Postgres channel job changes are announced on.
+const JobsChannel = "guardlight_jobs"
+
+// JobNotification is the payload of a notification on the JobsChannel. An
+// empty GroupKey means the change can affect any group key.
+type JobNotification struct {
+	JobId    uuid.UUID `json:"jobId"`
+	Status   JobStatus `json:"status"`
+	GroupKey string    `json:"groupKey"`
+}
+
 func (jt JobType) Match(s string) bool {
 	return strings.HasPrefix(s, string(jt))
 }
 
+// stage is the position of the job type in an analysis.
+func (jt JobType) stage() int {
+	switch jt {
+	case Parse:
+		return 0
+	case Analyze:
+		return 1
+	case Report:
+		return 2
+	}
+	return 3
+}
+
 type Job struct {
 	Id                uuid.UUID       `gorm:"column:id;primaryKey;type:uuid"`
 	CreatedAt         time.Time       `gorm:"column:created_at"`
@@ -42,6 +84,83 @@ type Job struct {
 	Type              JobType         `gorm:"column:type"`
 	GroupKey          string          `gorm:"column:group_key"`
 	Data              json.RawMessage `gorm:"column:data;type:jsonb"`
+	StartedAt         time.Time       `gorm:"column:started_at"`
+	NextAttemptAt     time.Time       `gorm:"column:next_attempt_at"`
+	LeaseExpiresAt    time.Time       `gorm:"column:lease_expires_at"`
+	UserId            uuid.UUID       `gorm:"column:user_id;type:uuid"`
+	AnalysisRequestId uuid.UUID       `gorm:"column:analysis_request_id;type:uuid;index"`
+	Priority          JobPriority     `gorm:"column:priority;default:0"`
+}
+
+// DeadLetterJob is a job that failed for good or was cancelled. It is kept
+// out of the jobs table so the orchestrator never picks it up again.
+type DeadLetterJob struct {
+	Id                uuid.UUID       `gorm:"column:id;primaryKey;type:uuid"`
+	CreatedAt         time.Time       `gorm:"column:created_at"`
+	UpdatedAt         time.Time       `gorm:"column:updated_at"`
+	Status            JobStatus       `gorm:"column:status"`
+	StatusDescription string          `gorm:"column:status_description"`
+	RetryCount        int             `gorm:"column:retry_count"`
+	Type              JobType         `gorm:"column:type"`
+	GroupKey          string          `gorm:"column:group_key"`
+	Data              json.RawMessage `gorm:"column:data;type:jsonb"`
+	UserId            uuid.UUID       `gorm:"column:user_id;type:uuid"`
+	AnalysisRequestId uuid.UUID       `gorm:"column:analysis_request_id;type:uuid;index"`
+	Priority          JobPriority     `gorm:"column:priority;default:0"`
+	Reason            string          `gorm:"column:reason"`
+	DeadLetteredAt    time.Time       `gorm:"column:dead_lettered_at"`
+}
+
+// JobEvent is a status transition of a job. Events outlive their job, until
+// the history retention removes them.
+type JobEvent struct {
+	Id                uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	JobId             uuid.UUID `gorm:"column:job_id;type:uuid;index"`
+	AnalysisRequestId uuid.UUID `gorm:"column:analysis_request_id;type:uuid;index"`
+	Type              JobType   `gorm:"column:type"`
+	GroupKey          string    `gorm:"column:group_key"`
+	Status            JobStatus `gorm:"column:status"`
+	StatusDescription string    `gorm:"column:status_description"`
+	RetryCount        int       `gorm:"column:retry_count"`
+	CreatedAt         time.Time `gorm:"column:created_at;index"`
+}
+
+// JobTimeline is the history of a single job. StartedAt is the start of the
+// last attempt, EndedAt is zero while the job did not end.
+type JobTimeline struct {
+	JobId             uuid.UUID
+	Type              JobType
+	GroupKey          string
+	Status            JobStatus
+	StatusDescription string
+	RetryCount        int
+	QueuedAt          time.Time
+	StartedAt         time.Time
+	EndedAt           time.Time
+	Events            []JobEvent
+}
+
+func (jt JobTimeline) Ended() bool {
+	return !jt.EndedAt.IsZero()
+}
+
+// WaitDuration is the time the job waited before its last attempt started.
+func (jt JobTimeline) WaitDuration() time.Duration {
+	if jt.StartedAt.IsZero() {
+		return 0
+	}
+	return jt.StartedAt.Sub(jt.QueuedAt)
+}
+
+// RunDuration is the time the last attempt took, or is taking so far.
+func (jt JobTimeline) RunDuration(now time.Time) time.Duration {
+	if jt.StartedAt.IsZero() {
+		return 0
+	}
+	if jt.Ended() {
+		return jt.EndedAt.Sub(jt.StartedAt)
+	}
+	return now.Sub(jt.StartedAt)
 }
 
 type ParserJobData struct {
@@ -64,3 +183,91 @@ type ReportJobData struct {
 	Image        string                           `json:"image"`
 	ReporterData reportercontract.ReporterRequest `json:"reporterData"`
 }
+
+// startedAt returns when the job went in progress. Jobs created before the
+// started_at column existed fall back to their last update.
+func (j Job) startedAt() time.Time {
+	if j.StartedAt.IsZero() {
+		return j.UpdatedAt
+	}
+	return j.StartedAt
+}
+
+// leaseExpiresAt returns when an inprogress job times out. Until the adapter
+// sends its first heartbeat the timeout counts from the start of the attempt,
+// a lease of an earlier attempt does not count.
+func (j Job) leaseExpiresAt(timeout time.Duration) time.Time {
+	if j.LeaseExpiresAt.After(j.startedAt()) {
+		return j.LeaseExpiresAt
+	}
+	return j.startedAt().Add(timeout)
+}
+
+// Ready reports whether a queued job is past its backoff delay.
+func (j Job) Ready(now time.Time) bool {
+	return !j.NextAttemptAt.After(now)
+}
+
+type JobFilter struct {
+	Status   JobStatus
+	Type     JobType
+	GroupKey string
+}
+
+type Pagination struct {
+	Limit int
+	Page  int
+}
+
+func (p *Pagination) GetOffset() int {
+	return (p.GetPage() - 1) * p.GetLimit()
+}
+
+func (p *Pagination) GetLimit() int {
+	if p.Limit == 0 {
+		p.Limit = 25
+	}
+	return p.Limit
+}
+
+func (p *Pagination) GetPage() int {
+	if p.Page == 0 {
+		p.Page = 1
+	}
+	return p.Page
+}
+
+type JobDto struct {
+	Id                uuid.UUID       `json:"id"`
+	Status            JobStatus       `json:"status"`
+	StatusDescription string          `json:"statusDescription"`
+	RetryCount        int             `json:"retryCount"`
+	Type              JobType         `json:"type"`
+	GroupKey          string          `json:"groupKey"`
+	CreatedAt         time.Time       `json:"createdAt"`
+	UpdatedAt         time.Time       `json:"updatedAt"`
+	StartedAt         time.Time       `json:"startedAt"`
+	NextAttemptAt     time.Time       `json:"nextAttemptAt"`
+	LeaseExpiresAt    time.Time       `json:"leaseExpiresAt"`
+	UserId            uuid.UUID       `json:"userId"`
+	AnalysisRequestId uuid.UUID       `json:"analysisRequestId"`
+	Priority          JobPriority     `json:"priority"`
+	Reason            string          `json:"reason,omitempty"`
+	DeadLetteredAt    *time.Time      `json:"deadLetteredAt,omitempty"`
+	Data              json.RawMessage `json:"data,omitempty"`
+}
+
+type JobsPaginated struct {
+	Limit      int      `json:"limit"`
+	Page       int      `json:"page"`
+	TotalPages int      `json:"totalPages"`
+	Jobs       []JobDto `json:"jobs"`
+}
+
+type JobIds struct {
+	Ids []uuid.UUID `json:"ids"`
+}
+
+type JobActionResult struct {
+	Affected int `json:"affected"`
+}
@@ -0,0 +1,30 @@
+package jobmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobReady(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, Job{}.Ready(now))
+	assert.True(t, Job{NextAttemptAt: now.Add(-time.Second)}.Ready(now))
+	assert.False(t, Job{NextAttemptAt: now.Add(time.Minute)}.Ready(now))
+}
+
+func TestJobLeaseExpiresAt(t *testing.T) {
+	started := time.Now().Add(-time.Hour)
+
+	// Without heartbeat the timeout counts from the start
+	assert.Equal(t, started.Add(time.Minute), Job{StartedAt: started}.leaseExpiresAt(time.Minute))
+
+	// A heartbeat moves the lease
+	lease := time.Now().Add(time.Minute)
+	assert.Equal(t, lease, Job{StartedAt: started, LeaseExpiresAt: lease}.leaseExpiresAt(time.Minute))
+
+	// The lease of an earlier attempt does not count
+	assert.Equal(t, started.Add(time.Minute), Job{StartedAt: started, LeaseExpiresAt: started.Add(-time.Minute)}.leaseExpiresAt(time.Minute))
+}
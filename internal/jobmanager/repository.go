@@ -1,19 +1,26 @@
 package jobmanager
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type JobManagerRepository struct {
 	db *gorm.DB
+	// Told about the jobs that are moved to the dead letter, see deadLetter
+	dlh DeadLetterHandler
 }
 
 func NewJobManagerRepository(db *gorm.DB) *JobManagerRepository {
-	if err := db.AutoMigrate(&Job{}); err != nil {
+	if err := db.AutoMigrate(&Job{}, &DeadLetterJob{}, &JobEvent{}); err != nil {
 		zap.S().DPanicw("Problem automigrating the tables", "error", err)
 	}
 
@@ -22,8 +29,19 @@ func NewJobManagerRepository(db *gorm.DB) *JobManagerRepository {
 	}
 }
 
+// SetDeadLetterHandler lets h fail the work of jobs that are dead lettered.
+func (jmr *JobManagerRepository) SetDeadLetterHandler(h DeadLetterHandler) {
+	jmr.dlh = h
+}
+
 func (jmr JobManagerRepository) saveJob(j *Job) error {
-	if err := jmr.db.Create(j).Error; err != nil {
+	err := jmr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(j).Error; err != nil {
+			return err
+		}
+		return addJobEvents(tx, []uuid.UUID{j.Id})
+	})
+	if err != nil {
 		zap.S().Errorw("Could not save job", "error", err)
 		return err
 	}
@@ -31,33 +49,165 @@ func (jmr JobManagerRepository) saveJob(j *Job) error {
 	return nil
 }
 
+// addJobEvents records the current state of the jobs in the job history.
+func addJobEvents(tx *gorm.DB, ids []uuid.UUID) error {
+	return tx.Exec(`INSERT INTO job_events (job_id, analysis_request_id, type, group_key, status, status_description, retry_count, created_at)
+		SELECT id, analysis_request_id, type, group_key, status, status_description, retry_count, ? FROM jobs WHERE id IN ?`, tx.NowFunc(), ids).Error
+}
+
+func (jmr JobManagerRepository) getInprogressCounts() (map[string]int, error) {
+	var rows []struct {
+		GroupKey string
+		Count    int
+	}
+	if err := jmr.db.Model(&Job{}).Select("group_key, count(*) AS count").Where("status = ?", Inprogress).Group("group_key").Scan(&rows).Error; err != nil {
+		zap.S().Errorw("Could not count inprogress jobs", "error", err)
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, r := range rows {
+		counts[r.GroupKey] = r.Count
+	}
+	return counts, nil
+}
+
+func (jmr JobManagerRepository) getQueuedGroupKeys() ([]string, error) {
+	var gks []string
+	if err := jmr.db.Model(&Job{}).Distinct("group_key").Where("status = ? AND next_attempt_at <= ?", Queued, jmr.db.NowFunc()).Pluck("group_key", &gks).Error; err != nil {
+		zap.S().Errorw("Could not get queued group keys", "error", err)
+		return nil, err
+	}
+	return gks, nil
+}
+
+func (jmr JobManagerRepository) getNextAttemptAt() (*time.Time, error) {
+	var naa *time.Time
+	if err := jmr.db.Model(&Job{}).Select("min(next_attempt_at)").Where("status = ? AND next_attempt_at > ?", Queued, jmr.db.NowFunc()).Scan(&naa).Error; err != nil {
+		zap.S().Errorw("Could not get the next attempt", "error", err)
+		return nil, err
+	}
+	return naa, nil
+}
+
+// getQueuedJobs returns per user the first perUser dispatchable jobs of a group
+// key, by priority and age, so a single user cannot crowd out the others.
+func (jmr JobManagerRepository) getQueuedJobs(groupKey string, perUser int) ([]Job, error) {
+	ranked := jmr.db.Model(&Job{}).
+		Select("jobs.*, row_number() OVER (PARTITION BY user_id ORDER BY priority DESC, created_at) AS user_rank").
+		Where("status = ? AND group_key = ? AND next_attempt_at <= ?", Queued, groupKey, jmr.db.NowFunc())
+
+	var js []Job
+	if err := jmr.db.Table("(?) AS ranked", ranked).Where("user_rank <= ?", perUser).Order("priority DESC, created_at").Find(&js).Error; err != nil {
+		zap.S().Errorw("Could not get queued jobs", "error", err, "group_key", groupKey)
+		return nil, err
+	}
+	return js, nil
+}
+
+func (jmr JobManagerRepository) getInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		UserId uuid.UUID
+		Count  int
+	}
+	if err := jmr.db.Model(&Job{}).Select("user_id, count(*) AS count").Where("status = ? AND group_key = ?", Inprogress, groupKey).Group("user_id").Scan(&rows).Error; err != nil {
+		zap.S().Errorw("Could not count inprogress jobs per user", "error", err, "group_key", groupKey)
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, r := range rows {
+		counts[r.UserId] = r.Count
+	}
+	return counts, nil
+}
+
+func (jmr JobManagerRepository) notifyJobChange(jn JobNotification) error {
+	return jmr.notify(jmr.db, jn)
+}
+
+func (jmr JobManagerRepository) notify(db *gorm.DB, jn JobNotification) error {
+	payload, err := json.Marshal(jn)
+	if err != nil {
+		return err
+	}
+	if err := db.Exec("SELECT pg_notify(?, ?)", JobsChannel, string(payload)).Error; err != nil {
+		zap.S().Errorw("Could not notify job change", "error", err, "job_id", jn.JobId)
+		return err
+	}
+	return nil
+}
+
 func (jmr JobManagerRepository) getNotFinishedJobs() ([]Job, error) {
 	var js []Job
-	if err := jmr.db.Where("status <> ? AND status <> ? AND retry_count <= ?", Finished, Error, 3).Find(&js).Error; err != nil {
+	if err := jmr.db.Where("status <> ? AND status <> ?", Finished, Error).Find(&js).Error; err != nil {
 		zap.S().Errorw("Could not get unfinished jobs", "error", err)
 		return nil, err
 	}
 	return js, nil
 }
 
-func (jmr JobManagerRepository) updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int) error {
-	res := jmr.db.Model(Job{Id: id}).Updates(Job{
+func (jmr JobManagerRepository) getJob(id uuid.UUID) (Job, error) {
+	j := Job{Id: id}
+	if err := jmr.db.First(&j).Error; err != nil {
+		zap.S().Errorw("Could not get job", "error", err, "id", id)
+		return Job{}, err
+	}
+	return j, nil
+}
+
+func (jmr JobManagerRepository) isJobInprogress(id uuid.UUID) (bool, error) {
+	var n int64
+	if err := jmr.db.Model(&Job{}).Where("id = ? AND status = ?", id, Inprogress).Count(&n).Error; err != nil {
+		zap.S().Errorw("Could not check job status", "error", err, "id", id)
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (jmr JobManagerRepository) updateJobStatus(id uuid.UUID, s JobStatus, sd string, rc int, naa time.Time) error {
+	uj := Job{
 		Status:            s,
 		StatusDescription: sd,
 		RetryCount:        rc,
+		NextAttemptAt:     naa,
+	}
+	if s == Inprogress {
+		uj.StartedAt = jmr.db.NowFunc()
+	}
+
+	return jmr.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(Job{Id: id}).Updates(uj)
+
+		if res.Error != nil {
+			zap.S().Errorw("Could not update job", "error", res.Error)
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			zap.S().Errorw("No records updated", "job_type", id)
+			return errors.New("no records affected after update")
+		}
+
+		if err := addJobEvents(tx, []uuid.UUID{id}); err != nil {
+			zap.S().Errorw("Could not add job event", "error", err, "job_id", id)
+			return err
+		}
+		return nil
 	})
+}
 
+// extendLease moves the lease of an inprogress job. It reports false when the
+// job is not in progress anymore.
+func (jmr JobManagerRepository) extendLease(id uuid.UUID, until time.Time) (bool, error) {
+	res := jmr.db.Model(&Job{}).
+		Where("id = ? AND status = ?", id, Inprogress).
+		Update("lease_expires_at", until)
 	if res.Error != nil {
-		zap.S().Errorw("Could not update job", "error", res.Error)
-		return res.Error
-	}
-
-	if res.RowsAffected == 0 {
-		zap.S().Errorw("No records updated", "job_type", id)
-		return errors.New("no records affected after update")
+		zap.S().Errorw("Could not extend job lease", "error", res.Error, "job_id", id)
+		return false, res.Error
 	}
-
-	return nil
+	return res.RowsAffected > 0, nil
 }
 
 func (jmr JobManagerRepository) deleteJob(id uuid.UUID) error {
@@ -67,3 +217,319 @@ func (jmr JobManagerRepository) deleteJob(id uuid.UUID) error {
 	}
 	return nil
 }
+
+func applyJobFilter(db *gorm.DB, f JobFilter) *gorm.DB {
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.Type != "" {
+		db = db.Where("type = ?", f.Type)
+	}
+	if f.GroupKey != "" {
+		db = db.Where("group_key = ?", f.GroupKey)
+	}
+	return db
+}
+
+func (jmr JobManagerRepository) getJobs(f JobFilter, pag Pagination) ([]Job, int, error) {
+	dbQ := applyJobFilter(jmr.db.Model(&Job{}), f).Session(&gorm.Session{})
+
+	var js []Job
+	if err := dbQ.Offset(pag.GetOffset()).Limit(pag.GetLimit()).Order("created_at DESC").Find(&js).Error; err != nil {
+		zap.S().Errorw("Could not get jobs", "error", err)
+		return nil, 0, err
+	}
+
+	var totalRows int64
+	if err := dbQ.Count(&totalRows).Error; err != nil {
+		zap.S().Errorw("Could not count jobs", "error", err)
+		return nil, 0, err
+	}
+
+	return js, int(math.Ceil(float64(totalRows) / float64(pag.GetLimit()))), nil
+}
+
+func (jmr JobManagerRepository) getDeadLetterJobs(f JobFilter, pag Pagination) ([]DeadLetterJob, int, error) {
+	dbQ := applyJobFilter(jmr.db.Model(&DeadLetterJob{}), f).Session(&gorm.Session{})
+
+	var js []DeadLetterJob
+	if err := dbQ.Offset(pag.GetOffset()).Limit(pag.GetLimit()).Order("dead_lettered_at DESC").Find(&js).Error; err != nil {
+		zap.S().Errorw("Could not get dead letter jobs", "error", err)
+		return nil, 0, err
+	}
+
+	var totalRows int64
+	if err := dbQ.Count(&totalRows).Error; err != nil {
+		zap.S().Errorw("Could not count dead letter jobs", "error", err)
+		return nil, 0, err
+	}
+
+	return js, int(math.Ceil(float64(totalRows) / float64(pag.GetLimit()))), nil
+}
+
+func (jmr JobManagerRepository) getDeadLetterJob(id uuid.UUID) (DeadLetterJob, error) {
+	j := DeadLetterJob{Id: id}
+	if err := jmr.db.First(&j).Error; err != nil {
+		zap.S().Errorw("Could not get dead letter job", "error", err, "id", id)
+		return DeadLetterJob{}, err
+	}
+	return j, nil
+}
+
+func (jmr JobManagerRepository) requeueJobs(ids []uuid.UUID, sd string) (int, error) {
+	var requeued int
+	err := jmr.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":             Queued,
+			"status_description": sd,
+			"retry_count":        0,
+			"next_attempt_at":    time.Time{},
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		requeued = int(res.RowsAffected)
+		return addJobEvents(tx, ids)
+	})
+	if err != nil {
+		zap.S().Errorw("Could not requeue jobs", "error", err)
+		return 0, err
+	}
+	return requeued, nil
+}
+
+// moveToDeadLetter moves the jobs from the jobs table into the dead letter table.
+func (jmr JobManagerRepository) moveToDeadLetter(ids []uuid.UUID, reason string) (int, error) {
+	return jmr.deadLetter(jmr.db, ids, "", reason, true)
+}
+
+// cancelJobs moves the jobs into the dead letter table as cancelled.
+func (jmr JobManagerRepository) cancelJobs(ids []uuid.UUID, reason string) (int, error) {
+	return jmr.deadLetter(jmr.db, ids, Cancelled, reason, true)
+}
+
+// cancelJobsByAnalysisRequestId cancels the jobs of an analysis request in the
+// transaction of the caller and returns their ids. The work of the jobs is
+// gone, so nothing is failed.
+func (jmr JobManagerRepository) cancelJobsByAnalysisRequestId(tx *gorm.DB, arid uuid.UUID, reason string) ([]uuid.UUID, error) {
+	return jmr.cancelJobsWhere(tx, reason, "analysis_request_id = ?", arid)
+}
+
+// cancelJobsByAnalysisIds cancels the analyze and report jobs of the analyses
+// in the transaction of the caller and returns their ids.
+func (jmr JobManagerRepository) cancelJobsByAnalysisIds(tx *gorm.DB, aids []uuid.UUID, reason string) ([]uuid.UUID, error) {
+	if len(aids) == 0 {
+		return []uuid.UUID{}, nil
+	}
+	ids := lo.Map(aids, func(id uuid.UUID, _ int) string { return id.String() })
+	return jmr.cancelJobsWhere(tx, reason, "coalesce(data->'analyzerData'->>'analysisId', data->'reporterData'->>'analysisId') IN ?", ids)
+}
+
+func (jmr JobManagerRepository) cancelJobsWhere(tx *gorm.DB, reason string, query string, args ...interface{}) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&Job{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(query, args...).
+		Pluck("id", &ids).Error
+	if err != nil {
+		zap.S().Errorw("Could not get jobs to cancel", "error", err)
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	if _, err := jmr.deadLetter(tx, ids, Cancelled, reason, false); err != nil {
+		return nil, err
+	}
+	// Delivered once the transaction commits
+	if err := jmr.notify(tx, JobNotification{Status: Cancelled}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// deadLetter moves the jobs into the dead letter table, with the given status
+// or their current one when it is empty. With fail the work waiting on the
+// jobs fails along with them.
+func (jmr JobManagerRepository) deadLetter(db *gorm.DB, ids []uuid.UUID, s JobStatus, reason string, fail bool) (int, error) {
+	fail = fail && jmr.dlh != nil
+	var dljs []DeadLetterJob
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var js []Job
+		if err := tx.Where("id IN ?", ids).Find(&js).Error; err != nil {
+			return err
+		}
+		if len(js) == 0 {
+			return nil
+		}
+
+		now := tx.NowFunc()
+		dljs = make([]DeadLetterJob, 0, len(js))
+		for _, j := range js {
+			if s != "" {
+				j.Status = s
+			}
+			dljs = append(dljs, DeadLetterJob{
+				Id:                j.Id,
+				CreatedAt:         j.CreatedAt,
+				UpdatedAt:         j.UpdatedAt,
+				Status:            j.Status,
+				StatusDescription: j.StatusDescription,
+				RetryCount:        j.RetryCount,
+				Type:              j.Type,
+				GroupKey:          j.GroupKey,
+				Data:              j.Data,
+				UserId:            j.UserId,
+				AnalysisRequestId: j.AnalysisRequestId,
+				Priority:          j.Priority,
+				Reason:            reason,
+				DeadLetteredAt:    now,
+			})
+		}
+
+		if err := tx.Create(&dljs).Error; err != nil {
+			return err
+		}
+		// Jobs that keep their status already have it in their history
+		if s != "" {
+			jes := lo.Map(dljs, func(dlj DeadLetterJob, _ int) JobEvent {
+				return JobEvent{
+					JobId:             dlj.Id,
+					AnalysisRequestId: dlj.AnalysisRequestId,
+					Type:              dlj.Type,
+					GroupKey:          dlj.GroupKey,
+					Status:            dlj.Status,
+					StatusDescription: reason,
+					RetryCount:        dlj.RetryCount,
+					CreatedAt:         now,
+				}
+			})
+			if err := tx.Create(&jes).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&Job{}).Error; err != nil {
+			return err
+		}
+		if fail {
+			return jmr.dlh.FailJobs(tx, dljs)
+		}
+		return nil
+	})
+	if err != nil {
+		zap.S().Errorw("Could not move jobs to dead letter", "error", err)
+		return 0, err
+	}
+
+	if fail && len(dljs) > 0 {
+		jmr.dlh.JobsFailed(dljs)
+	}
+	return len(dljs), nil
+}
+
+// restoreFromDeadLetter moves dead lettered jobs back into the jobs table as
+// freshly queued jobs.
+func (jmr JobManagerRepository) restoreFromDeadLetter(ids []uuid.UUID) (int, error) {
+	var restored int
+	err := jmr.db.Transaction(func(tx *gorm.DB) error {
+		var dljs []DeadLetterJob
+		if err := tx.Where("id IN ?", ids).Find(&dljs).Error; err != nil {
+			return err
+		}
+		if len(dljs) == 0 {
+			return nil
+		}
+
+		js := make([]Job, 0, len(dljs))
+		for _, dlj := range dljs {
+			js = append(js, Job{
+				Id:                dlj.Id,
+				CreatedAt:         dlj.CreatedAt,
+				Status:            Queued,
+				StatusDescription: "requeued from dead letter",
+				RetryCount:        0,
+				Type:              dlj.Type,
+				GroupKey:          dlj.GroupKey,
+				Data:              dlj.Data,
+				UserId:            dlj.UserId,
+				AnalysisRequestId: dlj.AnalysisRequestId,
+				Priority:          dlj.Priority,
+			})
+		}
+
+		if err := tx.Create(&js).Error; err != nil {
+			return err
+		}
+		if err := addJobEvents(tx, ids); err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&DeadLetterJob{}).Error; err != nil {
+			return err
+		}
+		restored = len(js)
+		return nil
+	})
+	if err != nil {
+		zap.S().Errorw("Could not restore jobs from dead letter", "error", err)
+		return 0, err
+	}
+	return restored, nil
+}
+
+func (jmr JobManagerRepository) getJobEventsByAnalysisRequestId(arid uuid.UUID) ([]JobEvent, error) {
+	var jes []JobEvent
+	if err := jmr.db.Where("analysis_request_id = ?", arid).Order("created_at, id").Find(&jes).Error; err != nil {
+		zap.S().Errorw("Could not get job events", "error", err, "analysis_request_id", arid)
+		return nil, err
+	}
+	return jes, nil
+}
+
+// getAverageJobDuration averages the last n finished jobs of the group key, from
+// the start of their last attempt until they finished.
+func (jmr JobManagerRepository) getAverageJobDuration(groupKey string, n int) (time.Duration, error) {
+	var secs float64
+	err := jmr.db.Raw(`
+		SELECT coalesce(avg(extract(epoch FROM f.created_at - s.started_at)), 0)
+		FROM (
+			SELECT job_id, created_at FROM job_events
+			WHERE group_key = ? AND status = ?
+			ORDER BY created_at DESC
+			LIMIT ?
+		) f
+		CROSS JOIN LATERAL (
+			SELECT max(created_at) AS started_at FROM job_events
+			WHERE job_id = f.job_id AND status = ? AND created_at <= f.created_at
+		) s
+		WHERE s.started_at IS NOT NULL`,
+		groupKey, Finished, n, Inprogress,
+	).Scan(&secs).Error
+	if err != nil {
+		zap.S().Errorw("Could not get average job duration", "error", err, "group_key", groupKey)
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// deleteJobHistoriesBefore deletes the job events of the analysis requests
+// whose last event is older than t. A request keeps its whole history as long
+// as one of its jobs is still around, so timelines and durations never see
+// part of a job.
+func (jmr JobManagerRepository) deleteJobHistoriesBefore(t time.Time) (int, error) {
+	old := jmr.db.Model(&JobEvent{}).
+		Select("analysis_request_id").
+		Group("analysis_request_id").
+		Having("max(created_at) < ?", t)
+	active := jmr.db.Model(&Job{}).Select("analysis_request_id")
+
+	res := jmr.db.
+		Where("analysis_request_id IN (?)", old).
+		Where("analysis_request_id NOT IN (?)", active).
+		Delete(&JobEvent{})
+	if res.Error != nil {
+		zap.S().Errorw("Could not delete job events", "error", res.Error)
+		return 0, res.Error
+	}
+	return int(res.RowsAffected), nil
+}
@@ -1,14 +1,20 @@
 package natsclient
 
 import (
+	"context"
 	"encoding/json"
+	"sync"
+	"time"
 
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.uber.org/zap"
 )
 
 type Publisher interface {
-	Publish(topic string, data interface{}) error
+	Publish(topic, msgId string, data interface{}) error
 }
 
 var (
@@ -16,22 +22,78 @@ var (
 )
 
 type NatsClient struct {
-	n *nats.Conn
+	n  *nats.Conn
+	js jetstream.JetStream
+	// topics that already have a work queue
+	wqs sync.Map
 }
 
 func NewNatsClient(ncon *nats.Conn) *NatsClient {
+	js, err := jetstream.New(ncon)
+	if err != nil {
+		zap.S().Panicw("Cannot create jetstream context", "error", err)
+	}
 	return &NatsClient{
-		n: ncon,
+		n:  ncon,
+		js: js,
 	}
 }
 
-func (nc *NatsClient) Publish(topic string, payload interface{}) error {
+// Publish puts the payload on the work queue of the topic. A nil error means
+// the message was stored and acknowledged by the stream. Messages with the
+// same id are stored once within the duplicates window of the stream.
+func (nc *NatsClient) Publish(topic, msgId string, payload interface{}) error {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		zap.S().Errorw("error marshalling request", "error", err)
 		return err
 	}
-	nc.n.Publish(topic, data)
-	zap.S().Infow("Published Data", "topic", topic)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := nc.ensureWorkQueue(ctx, topic); err != nil {
+		zap.S().Errorw("Could not ensure work queue", "topic", topic, "error", err)
+		return err
+	}
+
+	pa, err := nc.js.Publish(ctx, topic, data, jetstream.WithMsgID(msgId))
+	if err != nil {
+		zap.S().Errorw("Could not publish data", "topic", topic, "error", err)
+		return err
+	}
+	zap.S().Infow("Published Data", "topic", topic, "msg_id", msgId, "stream", pa.Stream, "sequence", pa.Sequence, "duplicate", pa.Duplicate)
+	return nil
+}
+
+func (nc *NatsClient) ensureWorkQueue(ctx context.Context, topic string) error {
+	if _, ok := nc.wqs.Load(topic); ok {
+		return nil
+	}
+
+	opts := workqueue.Options{
+		AckWait: time.Duration(config.Get().Nats.AckWaitSeconds) * time.Second,
+	}
+	if _, err := workqueue.EnsureConsumer(ctx, nc.js, topic, opts); err != nil {
+		return err
+	}
+
+	nc.wqs.Store(topic, struct{}{})
+	return nil
+}
+
+// Broadcast publishes the payload to every subscriber of the topic, without a
+// work queue.
+func (nc *NatsClient) Broadcast(topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		zap.S().Errorw("error marshalling request", "error", err)
+		return err
+	}
+	if err := nc.n.Publish(topic, data); err != nil {
+		zap.S().Errorw("Could not broadcast data", "topic", topic, "error", err)
+		return err
+	}
+	zap.S().Infow("Broadcasted Data", "topic", topic)
 	return nil
 }
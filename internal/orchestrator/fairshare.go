@@ -0,0 +1,73 @@
+package orchestrator
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/jobmanager"
+)
+
+// fairShare picks up to n jobs to dispatch. Higher priorities always go first,
+// within a priority the users take turns, starting with the user that has the
+// fewest jobs in progress.
+func fairShare(js []jobmanager.Job, inprogress map[uuid.UUID]int, n int) []jobmanager.Job {
+	queues := make(map[jobmanager.JobPriority]map[uuid.UUID][]jobmanager.Job)
+	for _, j := range js {
+		if queues[j.Priority] == nil {
+			queues[j.Priority] = make(map[uuid.UUID][]jobmanager.Job)
+		}
+		queues[j.Priority][j.UserId] = append(queues[j.Priority][j.UserId], j)
+	}
+
+	prios := make([]jobmanager.JobPriority, 0, len(queues))
+	for p := range queues {
+		prios = append(prios, p)
+	}
+	slices.Sort(prios)
+	slices.Reverse(prios)
+
+	running := make(map[uuid.UUID]int, len(inprogress))
+	for u, c := range inprogress {
+		running[u] = c
+	}
+
+	picked := make([]jobmanager.Job, 0, n)
+	for _, p := range prios {
+		uqs := queues[p]
+		for len(picked) < n && len(uqs) > 0 {
+			u := nextUser(uqs, running)
+			picked = append(picked, uqs[u][0])
+			running[u]++
+
+			uqs[u] = uqs[u][1:]
+			if len(uqs[u]) == 0 {
+				delete(uqs, u)
+			}
+		}
+	}
+	return picked
+}
+
+// nextUser returns the user with the fewest running jobs. Ties go to the user
+// whose next job waits the longest.
+func nextUser(uqs map[uuid.UUID][]jobmanager.Job, running map[uuid.UUID]int) uuid.UUID {
+	var best uuid.UUID
+	first := true
+	for u, q := range uqs {
+		if first || less(u, q[0], best, uqs[best][0], running) {
+			best = u
+			first = false
+		}
+	}
+	return best
+}
+
+func less(u uuid.UUID, j jobmanager.Job, bu uuid.UUID, bj jobmanager.Job, running map[uuid.UUID]int) bool {
+	if running[u] != running[bu] {
+		return running[u] < running[bu]
+	}
+	if !j.CreatedAt.Equal(bj.CreatedAt) {
+		return j.CreatedAt.Before(bj.CreatedAt)
+	}
+	return u.String() < bu.String()
+}
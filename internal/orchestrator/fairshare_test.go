@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/jobmanager"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	bulkUser   = uuid.MustParse("0ab4b8bd-9a36-4a4c-a9a4-7b6f7c4d7c11")
+	singleUser = uuid.MustParse("e3f1c5a4-0f0e-4b52-8d6b-2b1f3f7a9e22")
+)
+
+func queuedJob(u uuid.UUID, p jobmanager.JobPriority, age time.Duration) jobmanager.Job {
+	return jobmanager.Job{
+		Id:        uuid.New(),
+		UserId:    u,
+		Priority:  p,
+		CreatedAt: time.Now().Add(-age),
+	}
+}
+
+func TestFairShareRoundRobinsUsers(t *testing.T) {
+	js := []jobmanager.Job{
+		queuedJob(bulkUser, jobmanager.PriorityNormal, 5*time.Hour),
+		queuedJob(bulkUser, jobmanager.PriorityNormal, 4*time.Hour),
+		queuedJob(bulkUser, jobmanager.PriorityNormal, 3*time.Hour),
+		queuedJob(singleUser, jobmanager.PriorityNormal, time.Minute),
+	}
+
+	picked := fairShare(js, map[uuid.UUID]int{}, 3)
+
+	assert.Equal(t, []jobmanager.Job{js[0], js[3], js[1]}, picked)
+}
+
+func TestFairShareRespectsPriority(t *testing.T) {
+	js := []jobmanager.Job{
+		queuedJob(singleUser, jobmanager.PriorityInteractive, time.Minute),
+		queuedJob(bulkUser, jobmanager.PriorityBackfill, 5*time.Hour),
+	}
+
+	picked := fairShare(js, map[uuid.UUID]int{}, 1)
+
+	assert.Equal(t, []jobmanager.Job{js[0]}, picked)
+}
+
+func TestFairShareFavoursUsersWithLessInprogress(t *testing.T) {
+	js := []jobmanager.Job{
+		queuedJob(bulkUser, jobmanager.PriorityNormal, 5*time.Hour),
+		queuedJob(singleUser, jobmanager.PriorityNormal, time.Minute),
+	}
+
+	picked := fairShare(js, map[uuid.UUID]int{bulkUser: 2}, 1)
+
+	assert.Equal(t, []jobmanager.Job{js[1]}, picked)
+}
+
+func TestFairShareLimit(t *testing.T) {
+	js := []jobmanager.Job{
+		queuedJob(bulkUser, jobmanager.PriorityNormal, time.Hour),
+	}
+
+	assert.Len(t, fairShare(js, nil, 4), 1)
+	assert.Empty(t, fairShare(js, nil, 0))
+}
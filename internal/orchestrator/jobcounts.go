@@ -1,19 +1,15 @@
 package orchestrator
 
-import "github.com/guardlight/server/internal/jobmanager"
-
 type jobCounts map[string]int
 
 func jc() map[string]int {
 	return make(map[string]int)
 }
 
-func (j jobCounts) build(js []jobmanager.Job) {
+func (j jobCounts) set(counts map[string]int) {
 	clear(j)
-	for _, job := range js {
-		if job.Status == jobmanager.Inprogress {
-			j[job.GroupKey]++
-		}
+	for gk, c := range counts {
+		j[gk] = c
 	}
 }
 
@@ -0,0 +1,82 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package orchestrator
+
+import (
+	adapterruntime "github.com/guardlight/server/internal/adapterruntime"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockadapterRuntime is an autogenerated mock type for the adapterRuntime type
+type MockadapterRuntime struct {
+	mock.Mock
+}
+
+type MockadapterRuntime_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockadapterRuntime) EXPECT() *MockadapterRuntime_Expecter {
+	return &MockadapterRuntime_Expecter{mock: &_m.Mock}
+}
+
+// Ensure provides a mock function with given fields: s, ready
+func (_m *MockadapterRuntime) Ensure(s adapterruntime.Spec, ready func()) error {
+	ret := _m.Called(s, ready)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ensure")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(adapterruntime.Spec, func()) error); ok {
+		r0 = rf(s, ready)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockadapterRuntime_Ensure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ensure'
+type MockadapterRuntime_Ensure_Call struct {
+	*mock.Call
+}
+
+// Ensure is a helper method to define mock.On call
+//   - s adapterruntime.Spec
+//   - ready func()
+func (_e *MockadapterRuntime_Expecter) Ensure(s interface{}, ready interface{}) *MockadapterRuntime_Ensure_Call {
+	return &MockadapterRuntime_Ensure_Call{Call: _e.mock.On("Ensure", s, ready)}
+}
+
+func (_c *MockadapterRuntime_Ensure_Call) Run(run func(s adapterruntime.Spec, ready func())) *MockadapterRuntime_Ensure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(adapterruntime.Spec), args[1].(func()))
+	})
+	return _c
+}
+
+func (_c *MockadapterRuntime_Ensure_Call) Return(_a0 error) *MockadapterRuntime_Ensure_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockadapterRuntime_Ensure_Call) RunAndReturn(run func(adapterruntime.Spec, func()) error) *MockadapterRuntime_Ensure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockadapterRuntime creates a new instance of MockadapterRuntime. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockadapterRuntime(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockadapterRuntime {
+	mock := &MockadapterRuntime{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
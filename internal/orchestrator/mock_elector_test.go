@@ -0,0 +1,82 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package orchestrator
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Mockelector is an autogenerated mock type for the elector type
+type Mockelector struct {
+	mock.Mock
+}
+
+type Mockelector_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Mockelector) EXPECT() *Mockelector_Expecter {
+	return &Mockelector_Expecter{mock: &_m.Mock}
+}
+
+// IsLeader provides a mock function with given fields: ctx
+func (_m *Mockelector) IsLeader(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsLeader")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Mockelector_IsLeader_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsLeader'
+type Mockelector_IsLeader_Call struct {
+	*mock.Call
+}
+
+// IsLeader is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Mockelector_Expecter) IsLeader(ctx interface{}) *Mockelector_IsLeader_Call {
+	return &Mockelector_IsLeader_Call{Call: _e.mock.On("IsLeader", ctx)}
+}
+
+func (_c *Mockelector_IsLeader_Call) Run(run func(ctx context.Context)) *Mockelector_IsLeader_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Mockelector_IsLeader_Call) Return(_a0 error) *Mockelector_IsLeader_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Mockelector_IsLeader_Call) RunAndReturn(run func(context.Context) error) *Mockelector_IsLeader_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockelector creates a new instance of Mockelector. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockelector(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Mockelector {
+	mock := &Mockelector{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -6,6 +6,8 @@ import (
 	jobmanager "github.com/guardlight/server/internal/jobmanager"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -22,24 +24,139 @@ func (_m *MockjobManager) EXPECT() *MockjobManager_Expecter {
 	return &MockjobManager_Expecter{mock: &_m.Mock}
 }
 
-// GetAllNonFinishedJobs provides a mock function with no fields
-func (_m *MockjobManager) GetAllNonFinishedJobs() ([]jobmanager.Job, error) {
+// GetInprogressCounts provides a mock function with no fields
+func (_m *MockjobManager) GetInprogressCounts() (map[string]int, error) {
 	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetAllNonFinishedJobs")
+		panic("no return value specified for GetInprogressCounts")
 	}
 
-	var r0 []jobmanager.Job
+	var r0 map[string]int
 	var r1 error
-	if rf, ok := ret.Get(0).(func() ([]jobmanager.Job, error)); ok {
+	if rf, ok := ret.Get(0).(func() (map[string]int, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() []jobmanager.Job); ok {
+	if rf, ok := ret.Get(0).(func() map[string]int); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]jobmanager.Job)
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobManager_GetInprogressCounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInprogressCounts'
+type MockjobManager_GetInprogressCounts_Call struct {
+	*mock.Call
+}
+
+// GetInprogressCounts is a helper method to define mock.On call
+func (_e *MockjobManager_Expecter) GetInprogressCounts() *MockjobManager_GetInprogressCounts_Call {
+	return &MockjobManager_GetInprogressCounts_Call{Call: _e.mock.On("GetInprogressCounts")}
+}
+
+func (_c *MockjobManager_GetInprogressCounts_Call) Run(run func()) *MockjobManager_GetInprogressCounts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockjobManager_GetInprogressCounts_Call) Return(_a0 map[string]int, _a1 error) *MockjobManager_GetInprogressCounts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobManager_GetInprogressCounts_Call) RunAndReturn(run func() (map[string]int, error)) *MockjobManager_GetInprogressCounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInprogressUserCounts provides a mock function with given fields: groupKey
+func (_m *MockjobManager) GetInprogressUserCounts(groupKey string) (map[uuid.UUID]int, error) {
+	ret := _m.Called(groupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInprogressUserCounts")
+	}
+
+	var r0 map[uuid.UUID]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (map[uuid.UUID]int, error)); ok {
+		return rf(groupKey)
+	}
+	if rf, ok := ret.Get(0).(func(string) map[uuid.UUID]int); ok {
+		r0 = rf(groupKey)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uuid.UUID]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(groupKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobManager_GetInprogressUserCounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInprogressUserCounts'
+type MockjobManager_GetInprogressUserCounts_Call struct {
+	*mock.Call
+}
+
+// GetInprogressUserCounts is a helper method to define mock.On call
+//   - groupKey string
+func (_e *MockjobManager_Expecter) GetInprogressUserCounts(groupKey interface{}) *MockjobManager_GetInprogressUserCounts_Call {
+	return &MockjobManager_GetInprogressUserCounts_Call{Call: _e.mock.On("GetInprogressUserCounts", groupKey)}
+}
+
+func (_c *MockjobManager_GetInprogressUserCounts_Call) Run(run func(groupKey string)) *MockjobManager_GetInprogressUserCounts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockjobManager_GetInprogressUserCounts_Call) Return(_a0 map[uuid.UUID]int, _a1 error) *MockjobManager_GetInprogressUserCounts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobManager_GetInprogressUserCounts_Call) RunAndReturn(run func(string) (map[uuid.UUID]int, error)) *MockjobManager_GetInprogressUserCounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNextAttemptAt provides a mock function with no fields
+func (_m *MockjobManager) GetNextAttemptAt() (*time.Time, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNextAttemptAt")
+	}
+
+	var r0 *time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*time.Time, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *time.Time); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
 		}
 	}
 
@@ -52,29 +169,145 @@ func (_m *MockjobManager) GetAllNonFinishedJobs() ([]jobmanager.Job, error) {
 	return r0, r1
 }
 
-// MockjobManager_GetAllNonFinishedJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllNonFinishedJobs'
-type MockjobManager_GetAllNonFinishedJobs_Call struct {
+// MockjobManager_GetNextAttemptAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNextAttemptAt'
+type MockjobManager_GetNextAttemptAt_Call struct {
 	*mock.Call
 }
 
-// GetAllNonFinishedJobs is a helper method to define mock.On call
-func (_e *MockjobManager_Expecter) GetAllNonFinishedJobs() *MockjobManager_GetAllNonFinishedJobs_Call {
-	return &MockjobManager_GetAllNonFinishedJobs_Call{Call: _e.mock.On("GetAllNonFinishedJobs")}
+// GetNextAttemptAt is a helper method to define mock.On call
+func (_e *MockjobManager_Expecter) GetNextAttemptAt() *MockjobManager_GetNextAttemptAt_Call {
+	return &MockjobManager_GetNextAttemptAt_Call{Call: _e.mock.On("GetNextAttemptAt")}
 }
 
-func (_c *MockjobManager_GetAllNonFinishedJobs_Call) Run(run func()) *MockjobManager_GetAllNonFinishedJobs_Call {
+func (_c *MockjobManager_GetNextAttemptAt_Call) Run(run func()) *MockjobManager_GetNextAttemptAt_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run()
 	})
 	return _c
 }
 
-func (_c *MockjobManager_GetAllNonFinishedJobs_Call) Return(_a0 []jobmanager.Job, _a1 error) *MockjobManager_GetAllNonFinishedJobs_Call {
+func (_c *MockjobManager_GetNextAttemptAt_Call) Return(_a0 *time.Time, _a1 error) *MockjobManager_GetNextAttemptAt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobManager_GetNextAttemptAt_Call) RunAndReturn(run func() (*time.Time, error)) *MockjobManager_GetNextAttemptAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetQueuedGroupKeys provides a mock function with no fields
+func (_m *MockjobManager) GetQueuedGroupKeys() ([]string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQueuedGroupKeys")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobManager_GetQueuedGroupKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetQueuedGroupKeys'
+type MockjobManager_GetQueuedGroupKeys_Call struct {
+	*mock.Call
+}
+
+// GetQueuedGroupKeys is a helper method to define mock.On call
+func (_e *MockjobManager_Expecter) GetQueuedGroupKeys() *MockjobManager_GetQueuedGroupKeys_Call {
+	return &MockjobManager_GetQueuedGroupKeys_Call{Call: _e.mock.On("GetQueuedGroupKeys")}
+}
+
+func (_c *MockjobManager_GetQueuedGroupKeys_Call) Run(run func()) *MockjobManager_GetQueuedGroupKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockjobManager_GetQueuedGroupKeys_Call) Return(_a0 []string, _a1 error) *MockjobManager_GetQueuedGroupKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockjobManager_GetQueuedGroupKeys_Call) RunAndReturn(run func() ([]string, error)) *MockjobManager_GetQueuedGroupKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetQueuedJobs provides a mock function with given fields: groupKey, perUser
+func (_m *MockjobManager) GetQueuedJobs(groupKey string, perUser int) ([]jobmanager.Job, error) {
+	ret := _m.Called(groupKey, perUser)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQueuedJobs")
+	}
+
+	var r0 []jobmanager.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) ([]jobmanager.Job, error)); ok {
+		return rf(groupKey, perUser)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) []jobmanager.Job); ok {
+		r0 = rf(groupKey, perUser)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]jobmanager.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(groupKey, perUser)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockjobManager_GetQueuedJobs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetQueuedJobs'
+type MockjobManager_GetQueuedJobs_Call struct {
+	*mock.Call
+}
+
+// GetQueuedJobs is a helper method to define mock.On call
+//   - groupKey string
+//   - perUser int
+func (_e *MockjobManager_Expecter) GetQueuedJobs(groupKey interface{}, perUser interface{}) *MockjobManager_GetQueuedJobs_Call {
+	return &MockjobManager_GetQueuedJobs_Call{Call: _e.mock.On("GetQueuedJobs", groupKey, perUser)}
+}
+
+func (_c *MockjobManager_GetQueuedJobs_Call) Run(run func(groupKey string, perUser int)) *MockjobManager_GetQueuedJobs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockjobManager_GetQueuedJobs_Call) Return(_a0 []jobmanager.Job, _a1 error) *MockjobManager_GetQueuedJobs_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockjobManager_GetAllNonFinishedJobs_Call) RunAndReturn(run func() ([]jobmanager.Job, error)) *MockjobManager_GetAllNonFinishedJobs_Call {
+func (_c *MockjobManager_GetQueuedJobs_Call) RunAndReturn(run func(string, int) ([]jobmanager.Job, error)) *MockjobManager_GetQueuedJobs_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -17,17 +17,17 @@ func (_m *MocknatsSender) EXPECT() *MocknatsSender_Expecter {
 	return &MocknatsSender_Expecter{mock: &_m.Mock}
 }
 
-// Publish provides a mock function with given fields: topic, data
-func (_m *MocknatsSender) Publish(topic string, data interface{}) error {
-	ret := _m.Called(topic, data)
+// Publish provides a mock function with given fields: topic, msgId, data
+func (_m *MocknatsSender) Publish(topic string, msgId string, data interface{}) error {
+	ret := _m.Called(topic, msgId, data)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Publish")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, interface{}) error); ok {
-		r0 = rf(topic, data)
+	if rf, ok := ret.Get(0).(func(string, string, interface{}) error); ok {
+		r0 = rf(topic, msgId, data)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -42,14 +42,15 @@ type MocknatsSender_Publish_Call struct {
 
 // Publish is a helper method to define mock.On call
 //   - topic string
+//   - msgId string
 //   - data interface{}
-func (_e *MocknatsSender_Expecter) Publish(topic interface{}, data interface{}) *MocknatsSender_Publish_Call {
-	return &MocknatsSender_Publish_Call{Call: _e.mock.On("Publish", topic, data)}
+func (_e *MocknatsSender_Expecter) Publish(topic interface{}, msgId interface{}, data interface{}) *MocknatsSender_Publish_Call {
+	return &MocknatsSender_Publish_Call{Call: _e.mock.On("Publish", topic, msgId, data)}
 }
 
-func (_c *MocknatsSender_Publish_Call) Run(run func(topic string, data interface{})) *MocknatsSender_Publish_Call {
+func (_c *MocknatsSender_Publish_Call) Run(run func(topic string, msgId string, data interface{})) *MocknatsSender_Publish_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(interface{}))
+		run(args[0].(string), args[1].(string), args[2].(interface{}))
 	})
 	return _c
 }
@@ -59,7 +60,7 @@ func (_c *MocknatsSender_Publish_Call) Return(_a0 error) *MocknatsSender_Publish
 	return _c
 }
 
-func (_c *MocknatsSender_Publish_Call) RunAndReturn(run func(string, interface{}) error) *MocknatsSender_Publish_Call {
+func (_c *MocknatsSender_Publish_Call) RunAndReturn(run func(string, string, interface{}) error) *MocknatsSender_Publish_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1,18 +1,24 @@
 package orchestrator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-co-op/gocron/v2"
-	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/adapterruntime"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/internal/natsclient"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 )
 
 type jobManager interface {
-	jobmanager.JobsGetter
+	jobmanager.QueuedJobsGetter
 	jobmanager.JobUpdater
 }
 
@@ -20,25 +26,46 @@ type natsSender interface {
 	natsclient.Publisher
 }
 
+type adapterRuntime interface {
+	Ensure(s adapterruntime.Spec, ready func()) error
+}
+
+type elector interface {
+	IsLeader(ctx context.Context) error
+}
+
 type taskCreater interface {
 	NewJob(jobDefinition gocron.JobDefinition, task gocron.Task, options ...gocron.JobOption) (gocron.Job, error)
 }
 
 type Orchestrator struct {
+	sync.Mutex
 	jm  jobManager
 	ns  natsSender
+	rt  adapterRuntime
 	jcs jobCounts
+	// Set when listening for jobs, see wakeForBackoff
+	e    elector
+	wake *time.Timer
 }
 
-func NewOrchestrator(jm jobManager, tc taskCreater, ns natsSender) (*Orchestrator, error) {
+func NewOrchestrator(jm jobManager, tc taskCreater, ns natsSender, rt adapterRuntime) (*Orchestrator, error) {
 	o := &Orchestrator{
 		jm:  jm,
 		ns:  ns,
+		rt:  rt,
 		jcs: jc(),
 	}
 
+	// Notifications dispatch jobs right away, so the sweep only has to catch
+	// what was missed.
+	cron := config.Get().Orchestrator.ScheduleRateCron
+	if config.Get().Orchestrator.ListenForJobs {
+		cron = config.Get().Orchestrator.ReconcileRateCron
+	}
+
 	_, err := tc.NewJob(
-		gocron.CronJob(config.Get().Orchestrator.ScheduleRateCron, true),
+		gocron.CronJob(cron, true),
 		gocron.NewTask(o.checkForJobs),
 		gocron.WithSingletonMode(gocron.LimitModeReschedule),
 	)
@@ -49,20 +76,149 @@ func NewOrchestrator(jm jobManager, tc taskCreater, ns natsSender) (*Orchestrato
 	return o, nil
 }
 
+// ListenForJobs dispatches jobs as soon as a job change is announced, instead
+// of waiting for the next reconciliation sweep. Notifications are ignored while
+// another replica is the leader.
+func (o *Orchestrator) ListenForJobs(notifications <-chan string, e elector) {
+	o.Lock()
+	o.e = e
+	o.Unlock()
+
+	go func() {
+		for n := range notifications {
+			if err := e.IsLeader(context.Background()); err != nil {
+				continue
+			}
+
+			gks := []string{groupKeyFromNotification(n)}
+
+			// Coalesce the notifications that arrived in the meantime
+			for drained := false; !drained; {
+				select {
+				case n := <-notifications:
+					gks = append(gks, groupKeyFromNotification(n))
+				default:
+					drained = true
+				}
+			}
+
+			if lo.Contains(gks, "") {
+				o.dispatch(nil)
+			} else {
+				o.dispatch(lo.Uniq(gks))
+			}
+		}
+	}()
+}
+
+func groupKeyFromNotification(n string) string {
+	var jn jobmanager.JobNotification
+	if err := json.Unmarshal([]byte(n), &jn); err != nil {
+		zap.S().Errorw("Could not unmarshal job notification", "error", err)
+		return ""
+	}
+	return jn.GroupKey
+}
+
+// checkForJobs is the reconciliation sweep that dispatches everything that is
+// dispatchable, in case notifications were missed.
 func (o *Orchestrator) checkForJobs() {
 	zap.S().Debugw("Checking For Jobs")
-	nfj, err := o.jm.GetAllNonFinishedJobs()
+	o.dispatch(nil)
+	zap.S().Debugw("Relevant Jobs Processed")
+}
+
+// dispatch processes the queued jobs of the given group keys, or of all group
+// keys when none are given, as far as the group keys have free capacity. The
+// free slots are shared fairly between the users with queued jobs.
+func (o *Orchestrator) dispatch(groupKeys []string) {
+	o.Lock()
+	defer o.Unlock()
+	defer o.wakeForBackoff()
+
+	counts, err := o.jm.GetInprogressCounts()
 	if err != nil {
-		zap.S().Errorw("Could not check for all open jobs", "err", err)
+		zap.S().Errorw("Could not count inprogress jobs", "err", err)
+		return
 	}
-	o.jcs.build(nfj)
+	o.jcs.set(counts)
+
+	qgks, err := o.jm.GetQueuedGroupKeys()
+	if err != nil {
+		zap.S().Errorw("Could not get queued group keys", "err", err)
+		return
+	}
+
+	for _, gk := range qgks {
+		if len(groupKeys) > 0 && !lo.Contains(groupKeys, gk) {
+			continue
+		}
+
+		free := o.capacity(gk) - o.jcs[gk]
+		if free <= 0 {
+			zap.S().Debugw("Group key at capacity", "group_key", gk)
+			continue
+		}
+
+		js, err := o.jm.GetQueuedJobs(gk, free)
+		if err != nil {
+			zap.S().Errorw("Could not get queued jobs", "group_key", gk, "err", err)
+			continue
+		}
+		// A job is never sent before its backoff passed, even when it was
+		// rescheduled after the query
+		js = lo.Filter(js, func(j jobmanager.Job, _ int) bool { return j.Ready(time.Now()) })
+		if len(js) == 0 {
+			continue
+		}
+
+		uc, err := o.jm.GetInprogressUserCounts(gk)
+		if err != nil {
+			zap.S().Errorw("Could not count inprogress jobs per user", "group_key", gk, "err", err)
+			continue
+		}
 
-	for _, job := range nfj {
-		if job.Status == jobmanager.Queued {
+		for _, job := range fairShare(js, uc, free) {
 			o.processJob(job)
 		}
 	}
-	zap.S().Debugw("Relevant Jobs Processed")
+}
+
+// wakeForBackoff dispatches again once the first backed off job is due. No
+// notification announces that, with only the slow sweep the job would wait
+// longer than its backoff.
+func (o *Orchestrator) wakeForBackoff() {
+	if o.e == nil {
+		return
+	}
+
+	naa, err := o.jm.GetNextAttemptAt()
+	if err != nil {
+		return
+	}
+	if o.wake != nil {
+		o.wake.Stop()
+	}
+	if naa == nil {
+		return
+	}
+
+	o.wake = time.AfterFunc(time.Until(*naa), func() {
+		if err := o.e.IsLeader(context.Background()); err != nil {
+			return
+		}
+		o.dispatch(nil)
+	})
+}
+
+// capacity returns the concurrency of the adapter behind a group key. Unknown
+// group keys still get one job through so it can be failed.
+func (o *Orchestrator) capacity(groupKey string) int {
+	c, ok := config.Get().GetConcurrency(groupKey)
+	if !ok {
+		return o.jcs[groupKey] + 1
+	}
+	return c
 }
 
 func (o *Orchestrator) processJob(j jobmanager.Job) {
@@ -83,34 +239,31 @@ func (o *Orchestrator) processParseJob(j jobmanager.Job) {
 	err := json.Unmarshal(j.Data, &f)
 	if err != nil {
 		zap.S().Errorw("Error unmarshaling parser job data", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, err.Error(), j.RetryCount+1)
+		o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 		return
 	}
 
 	p, ok := config.Get().GetParser(f.Type)
 	if !ok {
 		zap.S().Errorw("Parser type not found", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, "Parser type not found", 3)
+		o.failJob(j, "Parser type not found")
 		return
 	}
 
 	if o.jcs.t(j.GroupKey) <= p.Concurrency {
 		if p.External {
 			zap.S().Infow("Using external parser container", "image", f.Image, "type", p.Type)
-		} else {
-			o.updateJobStatus(j.Id, jobmanager.Error, "External=false not supported yet", 0)
-			// REMOVE WHEN docker support is implemented.
-
-			// Start docker container and wait, with max duration.
+		} else if err := o.startLocalAdapter(j, adapterruntime.Spec{GroupKey: j.GroupKey, Command: p.Command, Args: p.Args, Concurrency: p.Concurrency}); err != nil {
+			return
 		}
 		o.jcs.inc(j.GroupKey)
-		err = o.ns.Publish(f.Topic, f.ParserData)
+		err = o.ns.Publish(f.Topic, messageId(j), f.ParserData)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
-			o.updateJobStatus(j.Id, jobmanager.Queued, "", j.RetryCount+1)
+			o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 			return
 		}
-		err = o.updateJobStatus(j.Id, jobmanager.Inprogress, "", j.RetryCount)
+		err = o.updateJobStatus(j, jobmanager.Inprogress, "", j.RetryCount)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
 			return
@@ -125,34 +278,31 @@ func (o *Orchestrator) processAnalyzeJob(j jobmanager.Job) {
 	err := json.Unmarshal(j.Data, &f)
 	if err != nil {
 		zap.S().Errorw("Error unmarshaling analyzer job data", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, err.Error(), j.RetryCount+1)
+		o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 		return
 	}
 
 	a, ok := config.Get().GetAnalyzer(f.Type)
 	if !ok {
 		zap.S().Errorw("Analyzer not found", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, "Analyzer not found", 3)
+		o.failJob(j, "Analyzer not found")
 		return
 	}
 
 	if o.jcs.t(j.GroupKey) <= a.Concurrency {
 		if a.External {
 			zap.S().Infow("Using external analyzer container", "image", f.Image, "key", a.Key)
-		} else {
-			o.updateJobStatus(j.Id, jobmanager.Error, "External=false not supported yet", 0)
-			// REMOVE WHEN docker support is implemented.
-
-			// Start docker container and wait, with max duration.
+		} else if err := o.startLocalAdapter(j, adapterruntime.Spec{GroupKey: j.GroupKey, Command: a.Command, Args: a.Args, Concurrency: a.Concurrency}); err != nil {
+			return
 		}
 		o.jcs.inc(j.GroupKey)
-		err = o.ns.Publish(f.Topic, f.AnalyzerData)
+		err = o.ns.Publish(f.Topic, messageId(j), f.AnalyzerData)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
-			o.updateJobStatus(j.Id, jobmanager.Queued, "", j.RetryCount+1)
+			o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 			return
 		}
-		err = o.updateJobStatus(j.Id, jobmanager.Inprogress, "", j.RetryCount)
+		err = o.updateJobStatus(j, jobmanager.Inprogress, "", j.RetryCount)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
 			return
@@ -168,34 +318,31 @@ func (o *Orchestrator) processReportJob(j jobmanager.Job) {
 	err := json.Unmarshal(j.Data, &f)
 	if err != nil {
 		zap.S().Errorw("Error unmarshaling reporter job data", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, err.Error(), j.RetryCount+1)
+		o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 		return
 	}
 
 	r, ok := config.Get().GetReporter(f.Type)
 	if !ok {
 		zap.S().Errorw("Reporter not found", "error", err)
-		o.updateJobStatus(j.Id, jobmanager.Queued, "Reporter not found", 3)
+		o.failJob(j, "Reporter not found")
 		return
 	}
 
 	if o.jcs.t(j.GroupKey) <= r.Concurrency {
 		if r.External {
 			zap.S().Infow("Using external reporter container", "image", r.Image, "type", j.Type)
-		} else {
-			o.updateJobStatus(j.Id, jobmanager.Error, "External=false not supported yet", 0)
-			// REMOVE WHEN docker support is implemented.
-
-			// Start docker container and wait, with max duration.
+		} else if err := o.startLocalAdapter(j, adapterruntime.Spec{GroupKey: j.GroupKey, Command: r.Command, Args: r.Args, Concurrency: r.Concurrency}); err != nil {
+			return
 		}
 		o.jcs.inc(j.GroupKey)
-		err = o.ns.Publish(f.Topic, f.ReporterData)
+		err = o.ns.Publish(f.Topic, messageId(j), f.ReporterData)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
-			o.updateJobStatus(j.Id, jobmanager.Queued, "", j.RetryCount+1)
+			o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
 			return
 		}
-		err = o.updateJobStatus(j.Id, jobmanager.Inprogress, "", j.RetryCount)
+		err = o.updateJobStatus(j, jobmanager.Inprogress, "", j.RetryCount)
 		if err != nil {
 			o.jcs.dec(j.GroupKey)
 			return
@@ -205,11 +352,40 @@ func (o *Orchestrator) processReportJob(j jobmanager.Job) {
 	}
 }
 
-func (o *Orchestrator) updateJobStatus(id uuid.UUID, js jobmanager.JobStatus, jsd string, rc int) error {
-	if rc >= 3 {
+// messageId is the same for every publish of an attempt of the job, so the
+// work queue stores an attempt once.
+func messageId(j jobmanager.Job) string {
+	return fmt.Sprintf("%s-%d", j.Id, j.RetryCount)
+}
+
+// startLocalAdapter makes sure the workers of a local adapter run. Their start
+// is not waited on while dispatching, the job stays queued and its group key
+// is dispatched again once they are ready.
+func (o *Orchestrator) startLocalAdapter(j jobmanager.Job, s adapterruntime.Spec) error {
+	err := o.rt.Ensure(s, func() { o.dispatch([]string{s.GroupKey}) })
+	if errors.Is(err, adapterruntime.ErrStarting) {
+		zap.S().Infow("Waiting for local adapter to start", "group_key", j.GroupKey)
+	} else if errors.Is(err, adapterruntime.ErrNoCommand) {
+		zap.S().Errorw("Local adapter has no command", "group_key", j.GroupKey)
+		o.failJob(j, err.Error())
+	} else if err != nil {
+		zap.S().Errorw("Could not start local adapter", "group_key", j.GroupKey, "error", err)
+		o.updateJobStatus(j, jobmanager.Queued, err.Error(), j.RetryCount+1)
+	}
+	return err
+}
+
+// failJob marks a job as failed without retrying it.
+func (o *Orchestrator) failJob(j jobmanager.Job, jsd string) error {
+	rp := config.Get().GetRetryPolicy(string(j.Type), j.GroupKey)
+	return o.updateJobStatus(j, jobmanager.Error, jsd, rp.MaxAttempts)
+}
+
+func (o *Orchestrator) updateJobStatus(j jobmanager.Job, js jobmanager.JobStatus, jsd string, rc int) error {
+	if config.Get().GetRetryPolicy(string(j.Type), j.GroupKey).Exhausted(rc) {
 		js = jobmanager.Error
 	}
-	err := o.jm.UpdateJobStatus(id, js, jsd, rc)
+	err := o.jm.UpdateJobStatus(j.Id, js, jsd, rc)
 	if err != nil {
 		// if js == jobmanager.Error {
 		// 	zap.S().Errorw("Can really not update job status", "status", js, "description", jsd)
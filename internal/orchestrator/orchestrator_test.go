@@ -1,14 +1,18 @@
 package orchestrator
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/guardlight/server/internal/essential/config"
 	"github.com/guardlight/server/internal/essential/logging"
 	"github.com/guardlight/server/internal/jobmanager"
 	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,6 +21,7 @@ func TestAnalysisOrchestratorStart(t *testing.T) {
 	mockJm := NewMockjobManager(t)
 	mockTc := NewMocktaskCreater(t)
 	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
 	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
 	logging.SetupLogging("test")
 
@@ -35,7 +40,10 @@ func TestAnalysisOrchestratorStart(t *testing.T) {
 		},
 	}
 
-	mockJm.EXPECT().GetAllNonFinishedJobs().Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.EXPECT().GetQueuedJobs("parser.freetext", 1).Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressUserCounts("parser.freetext").Return(map[uuid.UUID]int{}, nil)
 
 	mockJm.EXPECT().UpdateJobStatus(uuid.MustParse("b268c2e9-3a9d-4e36-a17f-33032fa77c72"), jobmanager.Inprogress, "", 0).Return(nil)
 
@@ -44,9 +52,9 @@ func TestAnalysisOrchestratorStart(t *testing.T) {
 		AnalysisId: uuid.MustParse("165c0cff-9395-4b10-8636-9d65b3d364ef"),
 		Content:    base64.StdEncoding.EncodeToString([]byte("Running and Walking")),
 	}
-	mockNs.EXPECT().Publish("parser.freetext", pr).Return(nil)
+	mockNs.EXPECT().Publish("parser.freetext", "b268c2e9-3a9d-4e36-a17f-33032fa77c72-0", pr).Return(nil)
 
-	o, err := NewOrchestrator(mockJm, mockTc, mockNs)
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
 	assert.NoError(t, err)
 	o.checkForJobs()
 }
@@ -55,6 +63,7 @@ func TestAnalysisOrchestratorDoNothing(t *testing.T) {
 	mockJm := NewMockjobManager(t)
 	mockTc := NewMocktaskCreater(t)
 	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
 
 	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
 
@@ -81,12 +90,14 @@ func TestAnalysisOrchestratorDoNothing(t *testing.T) {
 		},
 	}
 
-	mockJm.EXPECT().GetAllNonFinishedJobs().Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{jobs[1].GroupKey: 1}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.AssertNotCalled(t, "GetQueuedJobs")
 
 	mockJm.AssertNotCalled(t, "UpdateJobStatus")
 	mockNs.AssertNotCalled(t, "Publish")
 
-	o, err := NewOrchestrator(mockJm, mockTc, mockNs)
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
 	assert.NoError(t, err)
 	o.checkForJobs()
 }
@@ -95,6 +106,7 @@ func TestAnalysisOrchestratorStartNew(t *testing.T) {
 	mockJm := NewMockjobManager(t)
 	mockTc := NewMocktaskCreater(t)
 	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
 	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
 	logging.SetupLogging("test")
 
@@ -122,7 +134,10 @@ func TestAnalysisOrchestratorStartNew(t *testing.T) {
 		},
 	}
 
-	mockJm.EXPECT().GetAllNonFinishedJobs().Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.EXPECT().GetQueuedJobs("parser.freetext", 1).Return(jobs[:1], nil)
+	mockJm.EXPECT().GetInprogressUserCounts("parser.freetext").Return(map[uuid.UUID]int{}, nil)
 
 	mockJm.EXPECT().UpdateJobStatus(jobId, jobmanager.Inprogress, "", 0).Return(nil)
 
@@ -131,9 +146,9 @@ func TestAnalysisOrchestratorStartNew(t *testing.T) {
 		AnalysisId: uuid.MustParse("dcfd5683-bccc-42b0-963a-93fc97ecf67d"),
 		Content:    base64.StdEncoding.EncodeToString([]byte("Running and Walking")),
 	}
-	mockNs.EXPECT().Publish("parser.freetext", pr).Return(nil)
+	mockNs.EXPECT().Publish("parser.freetext", "b268c2e9-3a9d-4e36-a17f-33032fa77c72-0", pr).Return(nil)
 
-	o, err := NewOrchestrator(mockJm, mockTc, mockNs)
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
 	assert.NoError(t, err)
 	o.checkForJobs()
 }
@@ -142,6 +157,7 @@ func TestAnalysisOrchestratorFailed(t *testing.T) {
 	mockJm := NewMockjobManager(t)
 	mockTc := NewMocktaskCreater(t)
 	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
 	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
 	logging.SetupLogging("test")
 
@@ -159,12 +175,15 @@ func TestAnalysisOrchestratorFailed(t *testing.T) {
 		},
 	}
 
-	mockJm.EXPECT().GetAllNonFinishedJobs().Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.EXPECT().GetQueuedJobs("parser.freetext", 1).Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressUserCounts("parser.freetext").Return(map[uuid.UUID]int{}, nil)
 
 	mockJm.EXPECT().UpdateJobStatus(uuid.MustParse("b268c2e9-3a9d-4e36-a17f-33032fa77c72"), jobmanager.Error, "Parser type not found", 3).Return(nil)
 	mockNs.AssertNotCalled(t, "Publish")
 
-	o, err := NewOrchestrator(mockJm, mockTc, mockNs)
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
 	assert.NoError(t, err)
 	o.checkForJobs()
 }
@@ -173,6 +192,7 @@ func TestAnalysisOrchestratorCannotUnmarshal(t *testing.T) {
 	mockJm := NewMockjobManager(t)
 	mockTc := NewMocktaskCreater(t)
 	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
 	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
 	logging.SetupLogging("test")
 
@@ -190,12 +210,135 @@ func TestAnalysisOrchestratorCannotUnmarshal(t *testing.T) {
 		},
 	}
 
-	mockJm.EXPECT().GetAllNonFinishedJobs().Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.EXPECT().GetQueuedJobs("parser.freetext", 1).Return(jobs, nil)
+	mockJm.EXPECT().GetInprogressUserCounts("parser.freetext").Return(map[uuid.UUID]int{}, nil)
 
 	mockJm.EXPECT().UpdateJobStatus(uuid.MustParse("b268c2e9-3a9d-4e36-a17f-33032fa77c72"), jobmanager.Queued, "invalid character 'W' looking for beginning of value", 1).Return(nil)
 	mockNs.AssertNotCalled(t, "Publish")
 
-	o, err := NewOrchestrator(mockJm, mockTc, mockNs)
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
 	assert.NoError(t, err)
 	o.checkForJobs()
 }
+
+func TestAnalysisOrchestratorDispatchOnlyNotifiedGroupKeys(t *testing.T) {
+	mockJm := NewMockjobManager(t)
+	mockTc := NewMocktaskCreater(t)
+	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+	logging.SetupLogging("test")
+
+	mockTc.EXPECT().NewJob(mock.AnythingOfType("cronJobDefinition"), mock.AnythingOfType("Task"), mock.AnythingOfType("JobOption")).Return(nil, nil)
+
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.AssertNotCalled(t, "GetQueuedJobs")
+	mockNs.AssertNotCalled(t, "Publish")
+
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
+	assert.NoError(t, err)
+
+	o.dispatch([]string{groupKeyFromNotification(`{"jobId":"b268c2e9-3a9d-4e36-a17f-33032fa77c72","status":"queued","groupKey":"analyzer.word_search"}`)})
+}
+
+func TestAnalysisOrchestratorBackoffNotPassed(t *testing.T) {
+	mockJm := NewMockjobManager(t)
+	mockTc := NewMocktaskCreater(t)
+	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+	logging.SetupLogging("test")
+
+	mockTc.EXPECT().NewJob(mock.AnythingOfType("cronJobDefinition"), mock.AnythingOfType("Task"), mock.AnythingOfType("JobOption")).Return(nil, nil)
+
+	jobs := []jobmanager.Job{
+		{
+			Id:                uuid.MustParse("b268c2e9-3a9d-4e36-a17f-33032fa77c72"),
+			Status:            jobmanager.Queued,
+			StatusDescription: "",
+			RetryCount:        1,
+			Type:              jobmanager.Parse,
+			GroupKey:          "parser.freetext",
+			NextAttemptAt:     time.Now().Add(time.Minute),
+			Data:              []byte("{\"image\":\"builtin\",\"type\":\"freetext\",\"topic\":\"parser.freetext\",\"parserData\":{\"jobId\":\"b268c2e9-3a9d-4e36-a17f-33032fa77c72\",\"analysisId\":\"165c0cff-9395-4b10-8636-9d65b3d364ef\",\"Content\":\"UnVubmluZyBhbmQgV2Fsa2luZw==\"}}"),
+		},
+	}
+
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil)
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{"parser.freetext"}, nil)
+	mockJm.EXPECT().GetQueuedJobs("parser.freetext", 1).Return(jobs, nil)
+	mockJm.AssertNotCalled(t, "UpdateJobStatus")
+	mockNs.AssertNotCalled(t, "Publish")
+
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
+	assert.NoError(t, err)
+
+	o.checkForJobs()
+}
+
+func TestGroupKeyFromNotification(t *testing.T) {
+	assert.Equal(t, "parser.freetext", groupKeyFromNotification(`{"status":"queued","groupKey":"parser.freetext"}`))
+	assert.Equal(t, "", groupKeyFromNotification(`{"status":"finished"}`))
+	assert.Equal(t, "", groupKeyFromNotification("not json"))
+}
+
+func TestAnalysisOrchestratorIgnoresNotificationsWhenNotLeader(t *testing.T) {
+	mockJm := NewMockjobManager(t)
+	mockTc := NewMocktaskCreater(t)
+	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
+	mockE := NewMockelector(t)
+
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	mockTc.EXPECT().NewJob(mock.AnythingOfType("cronJobDefinition"), mock.AnythingOfType("Task"), mock.AnythingOfType("JobOption")).Return(nil, nil)
+
+	notifications := make(chan string)
+	checked := make(chan struct{})
+	mockE.EXPECT().IsLeader(mock.Anything).Run(func(_ context.Context) { checked <- struct{}{} }).Return(errors.New("not the leader"))
+	mockJm.AssertNotCalled(t, "GetInprogressCounts")
+
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
+	assert.NoError(t, err)
+	o.ListenForJobs(notifications, mockE)
+
+	notifications <- `{"groupKey":"parser.freetext"}`
+	<-checked
+	close(notifications)
+}
+
+func TestAnalysisOrchestratorWakesWhenBackoffPasses(t *testing.T) {
+	mockJm := NewMockjobManager(t)
+	mockTc := NewMocktaskCreater(t)
+	mockNs := NewMocknatsSender(t)
+	mockRt := NewMockadapterRuntime(t)
+	mockE := NewMockelector(t)
+
+	config.SetupConfig("../../testdata/envs/orchestrator.yaml")
+
+	mockTc.EXPECT().NewJob(mock.AnythingOfType("cronJobDefinition"), mock.AnythingOfType("Task"), mock.AnythingOfType("JobOption")).Return(nil, nil)
+
+	woke := make(chan struct{})
+	mockJm.EXPECT().GetInprogressCounts().Return(map[string]int{}, nil).Twice()
+	mockJm.EXPECT().GetQueuedGroupKeys().Return([]string{}, nil).Twice()
+	mockJm.EXPECT().GetNextAttemptAt().Return(lo.ToPtr(time.Now().Add(50*time.Millisecond)), nil).Once()
+	mockJm.EXPECT().GetNextAttemptAt().Run(func() { close(woke) }).Return(nil, nil).Once()
+	mockE.EXPECT().IsLeader(mock.Anything).Return(nil)
+
+	o, err := NewOrchestrator(mockJm, mockTc, mockNs, mockRt)
+	assert.NoError(t, err)
+	notifications := make(chan string)
+	defer close(notifications)
+	o.ListenForJobs(notifications, mockE)
+
+	o.dispatch(nil)
+
+	select {
+	case <-woke:
+	case <-time.After(time.Second):
+		t.Fatal("the backed off job was not dispatched")
+	}
+}
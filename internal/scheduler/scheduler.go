@@ -11,10 +11,20 @@ type Scheduler struct {
 }
 
 func NewScheduler(loc *time.Location) (*Scheduler, error) {
-	gos, err := gocron.NewScheduler(
+	return newScheduler(loc)
+}
+
+// NewLeaderScheduler creates a scheduler that only runs its jobs while the
+// elector considers this replica the leader.
+func NewLeaderScheduler(loc *time.Location, e gocron.Elector) (*Scheduler, error) {
+	return newScheduler(loc, gocron.WithDistributedElector(e))
+}
+
+func newScheduler(loc *time.Location, opts ...gocron.SchedulerOption) (*Scheduler, error) {
+	gos, err := gocron.NewScheduler(append([]gocron.SchedulerOption{
 		gocron.WithLocation(loc),
 		gocron.WithLimitConcurrentJobs(15, gocron.LimitModeWait),
-	)
+	}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
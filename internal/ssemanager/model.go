@@ -13,11 +13,13 @@ const (
 	ActionReportDone        ActionType = "report_done"
 	ActionAnalysisDone      ActionType = "analysis_done"
 	ActionAnalysisRequested ActionType = "analysis_requested"
+	ActionAnalysisProgress  ActionType = "analysis_progress"
 	ActionBeat              ActionType = "beat"
 )
 
 type SseEvent struct {
 	Type   EventType  `json:"type"`
 	Action ActionType `json:"action"`
-	Data   string     `json:"data"`
+	// Data is an id for most actions, progress events carry the progress
+	Data interface{} `json:"data"`
 }
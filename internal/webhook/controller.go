@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/glerror"
+	"github.com/guardlight/server/internal/essential/glsecurity"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	s *WebhookService
+}
+
+func NewWebhookController(group *gin.RouterGroup, s *WebhookService) *WebhookController {
+	wc := &WebhookController{
+		s: s,
+	}
+
+	webhookGroup := group.Group("webhook")
+	webhookGroup.Use(glsecurity.UseGuardlightAuth())
+	webhookGroup.GET("", wc.getWebhooks)
+	webhookGroup.POST("", wc.createWebhook)
+	webhookGroup.PUT("/:id", wc.updateWebhook)
+	webhookGroup.DELETE("/:id", wc.deleteWebhook)
+	webhookGroup.POST("/:id/secret", wc.rotateSecret)
+	webhookGroup.GET("/:id/deliveries", wc.getDeliveries)
+
+	return wc
+}
+
+func (wc *WebhookController) getWebhooks(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	ws, err := wc.s.GetWebhooks(uid)
+	if err != nil {
+		zap.S().Errorw("error getting webhooks", "error", err)
+		c.JSON(glerror.InternalServerError())
+		return
+	}
+
+	c.JSON(http.StatusOK, ws)
+}
+
+func (wc *WebhookController) createWebhook(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+
+	req := WebhookRequest{}
+	if err := glsecurity.ReuseBindAndValidate(c, &req); err != nil {
+		zap.S().Errorw("error validating webhook", "error", err)
+		return
+	}
+
+	w, err := wc.s.CreateWebhook(uid, req)
+	if err != nil {
+		zap.S().Errorw("error creating webhook", "error", err)
+		c.JSON(webhookError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, w)
+}
+
+func (wc *WebhookController) updateWebhook(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	req := WebhookRequest{}
+	if err := glsecurity.ReuseBindAndValidate(c, &req); err != nil {
+		zap.S().Errorw("error validating webhook", "error", err)
+		return
+	}
+
+	w, err := wc.s.UpdateWebhook(uid, id, req)
+	if err != nil {
+		zap.S().Errorw("error updating webhook", "webhook_id", id, "error", err)
+		c.JSON(webhookError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+func (wc *WebhookController) deleteWebhook(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	if err := wc.s.DeleteWebhook(uid, id); err != nil {
+		zap.S().Errorw("error deleting webhook", "webhook_id", id, "error", err)
+		c.JSON(webhookError(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (wc *WebhookController) rotateSecret(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	w, err := wc.s.RotateSecret(uid, id)
+	if err != nil {
+		zap.S().Errorw("error rotating webhook secret", "webhook_id", id, "error", err)
+		c.JSON(webhookError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+func (wc *WebhookController) getDeliveries(c *gin.Context) {
+	uid := glsecurity.GetUserIdFromContextParsed(c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(glerror.InvalidIdFormatError())
+		return
+	}
+
+	pgNr, err := strconv.Atoi(c.DefaultQuery("page", "0"))
+	if err != nil {
+		pgNr = 0
+	}
+	pgLim, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		pgLim = 0
+	}
+
+	ds, err := wc.s.GetDeliveries(uid, id, pgLim, pgNr)
+	if err != nil {
+		zap.S().Errorw("error getting webhook deliveries", "webhook_id", id, "error", err)
+		c.JSON(webhookError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, ds)
+}
+
+func webhookError(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return glerror.ResourceNotFoundError()
+	case errors.Is(err, ErrInvalidUrl), errors.Is(err, ErrInvalidEvents):
+		return glerror.BadRequestError()
+	default:
+		return glerror.InternalServerError()
+	}
+}
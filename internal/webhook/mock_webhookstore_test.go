@@ -0,0 +1,619 @@
+// Code generated by mockery v2.51.1. DO NOT EDIT.
+
+package webhook
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockwebhookStore is an autogenerated mock type for the webhookStore type
+type MockwebhookStore struct {
+	mock.Mock
+}
+
+type MockwebhookStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockwebhookStore) EXPECT() *MockwebhookStore_Expecter {
+	return &MockwebhookStore_Expecter{mock: &_m.Mock}
+}
+
+// claimDueDeliveries provides a mock function with given fields: limit, lease
+func (_m *MockwebhookStore) claimDueDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	ret := _m.Called(limit, lease)
+
+	if len(ret) == 0 {
+		panic("no return value specified for claimDueDeliveries")
+	}
+
+	var r0 []WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) ([]WebhookDelivery, error)); ok {
+		return rf(limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []WebhookDelivery); ok {
+		r0 = rf(limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
+		r1 = rf(limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockwebhookStore_claimDueDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'claimDueDeliveries'
+type MockwebhookStore_claimDueDeliveries_Call struct {
+	*mock.Call
+}
+
+// claimDueDeliveries is a helper method to define mock.On call
+//   - limit int
+//   - lease time.Duration
+func (_e *MockwebhookStore_Expecter) claimDueDeliveries(limit interface{}, lease interface{}) *MockwebhookStore_claimDueDeliveries_Call {
+	return &MockwebhookStore_claimDueDeliveries_Call{Call: _e.mock.On("claimDueDeliveries", limit, lease)}
+}
+
+func (_c *MockwebhookStore_claimDueDeliveries_Call) Run(run func(limit int, lease time.Duration)) *MockwebhookStore_claimDueDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_claimDueDeliveries_Call) Return(_a0 []WebhookDelivery, _a1 error) *MockwebhookStore_claimDueDeliveries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockwebhookStore_claimDueDeliveries_Call) RunAndReturn(run func(int, time.Duration) ([]WebhookDelivery, error)) *MockwebhookStore_claimDueDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// createDeliveries provides a mock function with given fields: ds
+func (_m *MockwebhookStore) createDeliveries(ds []WebhookDelivery) error {
+	ret := _m.Called(ds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for createDeliveries")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]WebhookDelivery) error); ok {
+		r0 = rf(ds)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_createDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'createDeliveries'
+type MockwebhookStore_createDeliveries_Call struct {
+	*mock.Call
+}
+
+// createDeliveries is a helper method to define mock.On call
+//   - ds []WebhookDelivery
+func (_e *MockwebhookStore_Expecter) createDeliveries(ds interface{}) *MockwebhookStore_createDeliveries_Call {
+	return &MockwebhookStore_createDeliveries_Call{Call: _e.mock.On("createDeliveries", ds)}
+}
+
+func (_c *MockwebhookStore_createDeliveries_Call) Run(run func(ds []WebhookDelivery)) *MockwebhookStore_createDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]WebhookDelivery))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_createDeliveries_Call) Return(_a0 error) *MockwebhookStore_createDeliveries_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_createDeliveries_Call) RunAndReturn(run func([]WebhookDelivery) error) *MockwebhookStore_createDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// createWebhook provides a mock function with given fields: w
+func (_m *MockwebhookStore) createWebhook(w *Webhook) error {
+	ret := _m.Called(w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for createWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*Webhook) error); ok {
+		r0 = rf(w)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_createWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'createWebhook'
+type MockwebhookStore_createWebhook_Call struct {
+	*mock.Call
+}
+
+// createWebhook is a helper method to define mock.On call
+//   - w *Webhook
+func (_e *MockwebhookStore_Expecter) createWebhook(w interface{}) *MockwebhookStore_createWebhook_Call {
+	return &MockwebhookStore_createWebhook_Call{Call: _e.mock.On("createWebhook", w)}
+}
+
+func (_c *MockwebhookStore_createWebhook_Call) Run(run func(w *Webhook)) *MockwebhookStore_createWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*Webhook))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_createWebhook_Call) Return(_a0 error) *MockwebhookStore_createWebhook_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_createWebhook_Call) RunAndReturn(run func(*Webhook) error) *MockwebhookStore_createWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// deleteWebhook provides a mock function with given fields: uid, id
+func (_m *MockwebhookStore) deleteWebhook(uid uuid.UUID, id uuid.UUID) error {
+	ret := _m.Called(uid, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for deleteWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(uid, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_deleteWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'deleteWebhook'
+type MockwebhookStore_deleteWebhook_Call struct {
+	*mock.Call
+}
+
+// deleteWebhook is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - id uuid.UUID
+func (_e *MockwebhookStore_Expecter) deleteWebhook(uid interface{}, id interface{}) *MockwebhookStore_deleteWebhook_Call {
+	return &MockwebhookStore_deleteWebhook_Call{Call: _e.mock.On("deleteWebhook", uid, id)}
+}
+
+func (_c *MockwebhookStore_deleteWebhook_Call) Run(run func(uid uuid.UUID, id uuid.UUID)) *MockwebhookStore_deleteWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_deleteWebhook_Call) Return(_a0 error) *MockwebhookStore_deleteWebhook_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_deleteWebhook_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) error) *MockwebhookStore_deleteWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getActiveWebhooksByEvent provides a mock function with given fields: uid, et
+func (_m *MockwebhookStore) getActiveWebhooksByEvent(uid uuid.UUID, et EventType) ([]Webhook, error) {
+	ret := _m.Called(uid, et)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getActiveWebhooksByEvent")
+	}
+
+	var r0 []Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, EventType) ([]Webhook, error)); ok {
+		return rf(uid, et)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, EventType) []Webhook); ok {
+		r0 = rf(uid, et)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, EventType) error); ok {
+		r1 = rf(uid, et)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockwebhookStore_getActiveWebhooksByEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getActiveWebhooksByEvent'
+type MockwebhookStore_getActiveWebhooksByEvent_Call struct {
+	*mock.Call
+}
+
+// getActiveWebhooksByEvent is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - et EventType
+func (_e *MockwebhookStore_Expecter) getActiveWebhooksByEvent(uid interface{}, et interface{}) *MockwebhookStore_getActiveWebhooksByEvent_Call {
+	return &MockwebhookStore_getActiveWebhooksByEvent_Call{Call: _e.mock.On("getActiveWebhooksByEvent", uid, et)}
+}
+
+func (_c *MockwebhookStore_getActiveWebhooksByEvent_Call) Run(run func(uid uuid.UUID, et EventType)) *MockwebhookStore_getActiveWebhooksByEvent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(EventType))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_getActiveWebhooksByEvent_Call) Return(_a0 []Webhook, _a1 error) *MockwebhookStore_getActiveWebhooksByEvent_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockwebhookStore_getActiveWebhooksByEvent_Call) RunAndReturn(run func(uuid.UUID, EventType) ([]Webhook, error)) *MockwebhookStore_getActiveWebhooksByEvent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getDeliveriesByWebhookId provides a mock function with given fields: uid, id, limit, page
+func (_m *MockwebhookStore) getDeliveriesByWebhookId(uid uuid.UUID, id uuid.UUID, limit int, page int) ([]WebhookDelivery, int64, error) {
+	ret := _m.Called(uid, id, limit, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getDeliveriesByWebhookId")
+	}
+
+	var r0 []WebhookDelivery
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, int, int) ([]WebhookDelivery, int64, error)); ok {
+		return rf(uid, id, limit, page)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, int, int) []WebhookDelivery); ok {
+		r0 = rf(uid, id, limit, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID, int, int) int64); ok {
+		r1 = rf(uid, id, limit, page)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(uuid.UUID, uuid.UUID, int, int) error); ok {
+		r2 = rf(uid, id, limit, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockwebhookStore_getDeliveriesByWebhookId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getDeliveriesByWebhookId'
+type MockwebhookStore_getDeliveriesByWebhookId_Call struct {
+	*mock.Call
+}
+
+// getDeliveriesByWebhookId is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - id uuid.UUID
+//   - limit int
+//   - page int
+func (_e *MockwebhookStore_Expecter) getDeliveriesByWebhookId(uid interface{}, id interface{}, limit interface{}, page interface{}) *MockwebhookStore_getDeliveriesByWebhookId_Call {
+	return &MockwebhookStore_getDeliveriesByWebhookId_Call{Call: _e.mock.On("getDeliveriesByWebhookId", uid, id, limit, page)}
+}
+
+func (_c *MockwebhookStore_getDeliveriesByWebhookId_Call) Run(run func(uid uuid.UUID, id uuid.UUID, limit int, page int)) *MockwebhookStore_getDeliveriesByWebhookId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(int), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_getDeliveriesByWebhookId_Call) Return(_a0 []WebhookDelivery, _a1 int64, _a2 error) *MockwebhookStore_getDeliveriesByWebhookId_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockwebhookStore_getDeliveriesByWebhookId_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, int, int) ([]WebhookDelivery, int64, error)) *MockwebhookStore_getDeliveriesByWebhookId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getWebhookById provides a mock function with given fields: uid, id
+func (_m *MockwebhookStore) getWebhookById(uid uuid.UUID, id uuid.UUID) (Webhook, error) {
+	ret := _m.Called(uid, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getWebhookById")
+	}
+
+	var r0 Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) (Webhook, error)); ok {
+		return rf(uid, id)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID) Webhook); ok {
+		r0 = rf(uid, id)
+	} else {
+		r0 = ret.Get(0).(Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(uid, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockwebhookStore_getWebhookById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getWebhookById'
+type MockwebhookStore_getWebhookById_Call struct {
+	*mock.Call
+}
+
+// getWebhookById is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - id uuid.UUID
+func (_e *MockwebhookStore_Expecter) getWebhookById(uid interface{}, id interface{}) *MockwebhookStore_getWebhookById_Call {
+	return &MockwebhookStore_getWebhookById_Call{Call: _e.mock.On("getWebhookById", uid, id)}
+}
+
+func (_c *MockwebhookStore_getWebhookById_Call) Run(run func(uid uuid.UUID, id uuid.UUID)) *MockwebhookStore_getWebhookById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_getWebhookById_Call) Return(_a0 Webhook, _a1 error) *MockwebhookStore_getWebhookById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockwebhookStore_getWebhookById_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID) (Webhook, error)) *MockwebhookStore_getWebhookById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// getWebhooksByUserId provides a mock function with given fields: uid
+func (_m *MockwebhookStore) getWebhooksByUserId(uid uuid.UUID) ([]Webhook, error) {
+	ret := _m.Called(uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for getWebhooksByUserId")
+	}
+
+	var r0 []Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]Webhook, error)); ok {
+		return rf(uid)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []Webhook); ok {
+		r0 = rf(uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockwebhookStore_getWebhooksByUserId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'getWebhooksByUserId'
+type MockwebhookStore_getWebhooksByUserId_Call struct {
+	*mock.Call
+}
+
+// getWebhooksByUserId is a helper method to define mock.On call
+//   - uid uuid.UUID
+func (_e *MockwebhookStore_Expecter) getWebhooksByUserId(uid interface{}) *MockwebhookStore_getWebhooksByUserId_Call {
+	return &MockwebhookStore_getWebhooksByUserId_Call{Call: _e.mock.On("getWebhooksByUserId", uid)}
+}
+
+func (_c *MockwebhookStore_getWebhooksByUserId_Call) Run(run func(uid uuid.UUID)) *MockwebhookStore_getWebhooksByUserId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_getWebhooksByUserId_Call) Return(_a0 []Webhook, _a1 error) *MockwebhookStore_getWebhooksByUserId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockwebhookStore_getWebhooksByUserId_Call) RunAndReturn(run func(uuid.UUID) ([]Webhook, error)) *MockwebhookStore_getWebhooksByUserId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// recordAttempt provides a mock function with given fields: d, a
+func (_m *MockwebhookStore) recordAttempt(d WebhookDelivery, a WebhookAttempt) error {
+	ret := _m.Called(d, a)
+
+	if len(ret) == 0 {
+		panic("no return value specified for recordAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(WebhookDelivery, WebhookAttempt) error); ok {
+		r0 = rf(d, a)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_recordAttempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'recordAttempt'
+type MockwebhookStore_recordAttempt_Call struct {
+	*mock.Call
+}
+
+// recordAttempt is a helper method to define mock.On call
+//   - d WebhookDelivery
+//   - a WebhookAttempt
+func (_e *MockwebhookStore_Expecter) recordAttempt(d interface{}, a interface{}) *MockwebhookStore_recordAttempt_Call {
+	return &MockwebhookStore_recordAttempt_Call{Call: _e.mock.On("recordAttempt", d, a)}
+}
+
+func (_c *MockwebhookStore_recordAttempt_Call) Run(run func(d WebhookDelivery, a WebhookAttempt)) *MockwebhookStore_recordAttempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(WebhookDelivery), args[1].(WebhookAttempt))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_recordAttempt_Call) Return(_a0 error) *MockwebhookStore_recordAttempt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_recordAttempt_Call) RunAndReturn(run func(WebhookDelivery, WebhookAttempt) error) *MockwebhookStore_recordAttempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// updateWebhook provides a mock function with given fields: w
+func (_m *MockwebhookStore) updateWebhook(w Webhook) error {
+	ret := _m.Called(w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for updateWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(Webhook) error); ok {
+		r0 = rf(w)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_updateWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateWebhook'
+type MockwebhookStore_updateWebhook_Call struct {
+	*mock.Call
+}
+
+// updateWebhook is a helper method to define mock.On call
+//   - w Webhook
+func (_e *MockwebhookStore_Expecter) updateWebhook(w interface{}) *MockwebhookStore_updateWebhook_Call {
+	return &MockwebhookStore_updateWebhook_Call{Call: _e.mock.On("updateWebhook", w)}
+}
+
+func (_c *MockwebhookStore_updateWebhook_Call) Run(run func(w Webhook)) *MockwebhookStore_updateWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(Webhook))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_updateWebhook_Call) Return(_a0 error) *MockwebhookStore_updateWebhook_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_updateWebhook_Call) RunAndReturn(run func(Webhook) error) *MockwebhookStore_updateWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// updateWebhookSecret provides a mock function with given fields: uid, id, secret
+func (_m *MockwebhookStore) updateWebhookSecret(uid uuid.UUID, id uuid.UUID, secret string) error {
+	ret := _m.Called(uid, id, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for updateWebhookSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, uuid.UUID, string) error); ok {
+		r0 = rf(uid, id, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockwebhookStore_updateWebhookSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'updateWebhookSecret'
+type MockwebhookStore_updateWebhookSecret_Call struct {
+	*mock.Call
+}
+
+// updateWebhookSecret is a helper method to define mock.On call
+//   - uid uuid.UUID
+//   - id uuid.UUID
+//   - secret string
+func (_e *MockwebhookStore_Expecter) updateWebhookSecret(uid interface{}, id interface{}, secret interface{}) *MockwebhookStore_updateWebhookSecret_Call {
+	return &MockwebhookStore_updateWebhookSecret_Call{Call: _e.mock.On("updateWebhookSecret", uid, id, secret)}
+}
+
+func (_c *MockwebhookStore_updateWebhookSecret_Call) Run(run func(uid uuid.UUID, id uuid.UUID, secret string)) *MockwebhookStore_updateWebhookSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(uuid.UUID), args[1].(uuid.UUID), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockwebhookStore_updateWebhookSecret_Call) Return(_a0 error) *MockwebhookStore_updateWebhookSecret_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockwebhookStore_updateWebhookSecret_Call) RunAndReturn(run func(uuid.UUID, uuid.UUID, string) error) *MockwebhookStore_updateWebhookSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockwebhookStore creates a new instance of MockwebhookStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockwebhookStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockwebhookStore {
+	mock := &MockwebhookStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventAnalysisRequested EventType = "analysis_requested"
+	EventAnalysisFinished  EventType = "analysis_finished"
+	EventReportFinished    EventType = "report_finished"
+	EventAnalysisError     EventType = "analysis_error"
+)
+
+var eventTypes = []EventType{EventAnalysisRequested, EventAnalysisFinished, EventReportFinished, EventAnalysisError}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Webhook is a subscription of a user, deliveries are signed with its secret.
+// The secret is encrypted like the content of the analyses.
+type Webhook struct {
+	Id        uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserId    uuid.UUID `gorm:"column:user_id;index"`
+	Url       string    `gorm:"column:url"`
+	Events    Events    `gorm:"column:events;type:jsonb"`
+	Secret    string    `gorm:"column:secret;serializer:encrypted"`
+	Active    bool      `gorm:"column:active"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+type Events []EventType
+
+func (e Events) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+func (e *Events) Scan(src any) error {
+	return json.Unmarshal(src.([]byte), &e)
+}
+
+// WebhookDelivery is an event in the outbox of a webhook. It stays pending
+// until the receiver accepts it or the retries are exhausted.
+type WebhookDelivery struct {
+	Id            uuid.UUID        `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	WebhookId     uuid.UUID        `gorm:"column:webhook_id;index"`
+	Webhook       Webhook          `gorm:"foreignKey:WebhookId"`
+	Event         Event            `gorm:"column:event;type:jsonb"`
+	Status        DeliveryStatus   `gorm:"column:status;index"`
+	Attempts      int              `gorm:"column:attempts"`
+	NextAttemptAt time.Time        `gorm:"column:next_attempt_at;index"`
+	History       []WebhookAttempt `gorm:"foreignKey:DeliveryId"`
+	CreatedAt     time.Time        `gorm:"column:created_at"`
+	UpdatedAt     time.Time        `gorm:"column:updated_at"`
+}
+
+// WebhookAttempt is the delivery log, one row per request sent to a receiver.
+type WebhookAttempt struct {
+	Id         uuid.UUID `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()"`
+	DeliveryId uuid.UUID `gorm:"column:delivery_id;index"`
+	WebhookId  uuid.UUID `gorm:"column:webhook_id;index"`
+	Attempt    int       `gorm:"column:attempt"`
+	StatusCode int       `gorm:"column:status_code"`
+	Error      string    `gorm:"column:error"`
+	DurationMs int64     `gorm:"column:duration_ms"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// Event is what happened to an analysis request, it is the body of a delivery.
+type Event struct {
+	Type              EventType  `json:"event"`
+	AnalysisRequestId uuid.UUID  `json:"analysisRequestId"`
+	AnalysisId        *uuid.UUID `json:"analysisId,omitempty"`
+	Title             string     `json:"title"`
+	Status            string     `json:"status,omitempty"`
+	Verdict           string     `json:"verdict,omitempty"`
+	Score             *float32   `json:"score,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+func (e Event) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+func (e *Event) Scan(src any) error {
+	return json.Unmarshal(src.([]byte), &e)
+}
+
+// payload is the body sent to the receiver. The delivery id stays the same
+// over retries so receivers can drop duplicates.
+type payload struct {
+	DeliveryId uuid.UUID `json:"deliveryId"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Event
+}
+
+type WebhookDto struct {
+	Id        uuid.UUID   `json:"id"`
+	Url       string      `json:"url"`
+	Events    []EventType `json:"events"`
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"createdAt"`
+	// Only returned when the webhook is created or its secret is rotated
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookRequest struct {
+	Url    string      `json:"url"`
+	Events []EventType `json:"events"`
+	Active *bool       `json:"active"`
+}
+
+type DeliveryDto struct {
+	Id            uuid.UUID      `json:"id"`
+	Event         EventType      `json:"event"`
+	Payload       Event          `json:"payload"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      []AttemptDto   `json:"attempts"`
+	NextAttemptAt *time.Time     `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+}
+
+type AttemptDto struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type DeliveriesPaginated struct {
+	Limit      int           `json:"limit"`
+	Page       int           `json:"page"`
+	TotalPages int           `json:"totalPages"`
+	Deliveries []DeliveryDto `json:"deliveries"`
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/guardlight/server/internal/essential/config"
+)
+
+var errPrivateAddress = errors.New("webhook receiver resolves to a private address")
+
+// sharedAddressSpace is the carrier grade NAT range, it is not public either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether the receiver may be reached on the address.
+// Loopback, private, link local and other internal addresses are refused
+// unless private networks are allowed.
+func publicAddress(addr netip.Addr) bool {
+	if config.Get().Webhook.AllowPrivateNetworks {
+		return true
+	}
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// publicHost looks the host up and refuses it when any of its addresses is
+// internal. Hosts that do not resolve are left to the dialer.
+func publicHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return publicAddress(addr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return true
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// newClient only connects to public addresses. The address is checked after
+// it is resolved, right before connecting, so a host can not point somewhere
+// else between the check and the request. Redirects go through it as well.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddress(ap.Addr()) {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the receiver
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: t,
+	}
+}
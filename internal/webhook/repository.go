@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	if err := db.AutoMigrate(
+		&Webhook{},
+		&WebhookDelivery{},
+		&WebhookAttempt{},
+	); err != nil {
+		zap.S().DPanicw("Problem automigrating the tables", "error", err)
+	}
+
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+func (wr *WebhookRepository) getWebhooksByUserId(uid uuid.UUID) ([]Webhook, error) {
+	var ws []Webhook
+	if err := wr.db.Where("user_id = ?", uid).Order("created_at").Find(&ws).Error; err != nil {
+		zap.S().Errorw("Could not get webhooks for user", "error", err)
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (wr *WebhookRepository) getWebhookById(uid, id uuid.UUID) (Webhook, error) {
+	var w Webhook
+	if err := wr.db.Where("id = ? AND user_id = ?", id, uid).First(&w).Error; err != nil {
+		return Webhook{}, err
+	}
+
+	return w, nil
+}
+
+// getActiveWebhooksByEvent returns the webhooks of the user that subscribed
+// to the event.
+func (wr *WebhookRepository) getActiveWebhooksByEvent(uid uuid.UUID, et EventType) ([]Webhook, error) {
+	var ws []Webhook
+	if err := wr.db.Where("user_id = ? AND active AND events @> ?", uid, Events{et}).Find(&ws).Error; err != nil {
+		zap.S().Errorw("Could not get webhooks for event", "event", et, "error", err)
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (wr *WebhookRepository) createWebhook(w *Webhook) error {
+	if err := wr.db.Create(w).Error; err != nil {
+		zap.S().Errorw("Could not create webhook", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) updateWebhook(w Webhook) error {
+	res := wr.db.Model(&Webhook{}).
+		Where("id = ? AND user_id = ?", w.Id, w.UserId).
+		Select("url", "events", "active").
+		Updates(w)
+	if res.Error != nil {
+		zap.S().Errorw("Could not update webhook", "webhook_id", w.Id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) updateWebhookSecret(uid, id uuid.UUID, secret string) error {
+	// Updated from the struct, a plain column update would skip the encryption
+	res := wr.db.Model(&Webhook{}).Where("id = ? AND user_id = ?", id, uid).Select("secret").Updates(&Webhook{Secret: secret})
+	if res.Error != nil {
+		zap.S().Errorw("Could not update webhook secret", "webhook_id", id, "error", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// deleteWebhook removes the webhook with its outbox and delivery log.
+func (wr *WebhookRepository) deleteWebhook(uid, id uuid.UUID) error {
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", id, uid).First(&Webhook{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", id).Delete(&WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", id).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Webhook{Id: id}).Error
+	})
+}
+
+func (wr *WebhookRepository) createDeliveries(ds []WebhookDelivery) error {
+	if err := wr.db.Omit("Webhook").Create(&ds).Error; err != nil {
+		zap.S().Errorw("Could not create webhook deliveries", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// claimDueDeliveries takes the pending deliveries that are due and pushes
+// their next attempt out by the lease, so they are not sent twice while they
+// are in flight.
+func (wr *WebhookRepository) claimDueDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	var ids []uuid.UUID
+	err := wr.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		zap.S().Errorw("Could not claim webhook deliveries", "error", err)
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []WebhookDelivery{}, nil
+	}
+
+	var ds []WebhookDelivery
+	if err := wr.db.Preload("Webhook").Where("id IN ?", ids).Find(&ds).Error; err != nil {
+		zap.S().Errorw("Could not get claimed webhook deliveries", "error", err)
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// recordAttempt logs the attempt and moves the delivery to its next state.
+func (wr *WebhookRepository) recordAttempt(d WebhookDelivery, a WebhookAttempt) error {
+	err := wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id = ?", d.Id).Updates(map[string]interface{}{
+			"status":          d.Status,
+			"attempts":        d.Attempts,
+			"next_attempt_at": d.NextAttemptAt,
+		}).Error
+	})
+	if err != nil {
+		zap.S().Errorw("Could not record webhook attempt", "delivery_id", d.Id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) getDeliveriesByWebhookId(uid, id uuid.UUID, limit, page int) ([]WebhookDelivery, int64, error) {
+	if _, err := wr.getWebhookById(uid, id); err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := wr.db.Model(&WebhookDelivery{}).Where("webhook_id = ?", id).Count(&total).Error; err != nil {
+		zap.S().Errorw("Could not count webhook deliveries", "webhook_id", id, "error", err)
+		return nil, 0, err
+	}
+
+	ds := make([]WebhookDelivery, 0)
+	if err := wr.db.Where("webhook_id = ?", id).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&ds).Error; err != nil {
+		zap.S().Errorw("Could not get webhook deliveries", "webhook_id", id, "error", err)
+		return nil, 0, err
+	}
+
+	return ds, total, nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/encryption"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storedSecret is the secret of a webhook as it is stored, without
+// decrypting it.
+type storedSecret struct {
+	Id     uuid.UUID
+	Secret string
+}
+
+// ReencryptSecrets moves the secrets of the webhooks to the current key, or
+// back to plaintext when encryption is disabled. Secrets from before
+// encryption are encrypted as well.
+func ReencryptSecrets(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&Webhook{}) {
+		return 0, nil
+	}
+
+	var rows []storedSecret
+	if err := db.Table("webhooks").Select("id, secret").Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	ids := lo.FilterMap(rows, func(r storedSecret, _ int) (uuid.UUID, bool) {
+		return r.Id, encryption.StaleText(r.Secret)
+	})
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ws []Webhook
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Find(&ws).Error; err != nil {
+			return err
+		}
+		for _, w := range ws {
+			if err := tx.Model(&w).Select("secret").Updates(&w).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	zap.S().Infow("Re-encrypted webhook secrets", "rows", len(ids))
+	return len(ids), nil
+}
@@ -0,0 +1,365 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderEvent     = "X-Guardlight-Event"
+	HeaderDelivery  = "X-Guardlight-Delivery"
+	HeaderTimestamp = "X-Guardlight-Timestamp"
+	HeaderSignature = "X-Guardlight-Signature"
+)
+
+var (
+	ErrInvalidUrl    = errors.New("webhook url must be an absolute http or https url of a public host")
+	ErrInvalidEvents = errors.New("webhook needs at least one known event")
+
+	// Deliveries of a disabled webhook fail without being sent
+	errWebhookDisabled = errors.New("webhook is disabled")
+)
+
+type webhookStore interface {
+	getWebhooksByUserId(uid uuid.UUID) ([]Webhook, error)
+	getWebhookById(uid, id uuid.UUID) (Webhook, error)
+	getActiveWebhooksByEvent(uid uuid.UUID, et EventType) ([]Webhook, error)
+	createWebhook(w *Webhook) error
+	updateWebhook(w Webhook) error
+	updateWebhookSecret(uid, id uuid.UUID, secret string) error
+	deleteWebhook(uid, id uuid.UUID) error
+	createDeliveries(ds []WebhookDelivery) error
+	claimDueDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error)
+	recordAttempt(d WebhookDelivery, a WebhookAttempt) error
+	getDeliveriesByWebhookId(uid, id uuid.UUID, limit, page int) ([]WebhookDelivery, int64, error)
+}
+
+type taskCreater interface {
+	NewJob(jobDefinition gocron.JobDefinition, task gocron.Task, options ...gocron.JobOption) (gocron.Job, error)
+}
+
+type WebhookService struct {
+	ws     webhookStore
+	client *http.Client
+}
+
+// NewWebhookService sends the outbox on the scheduler, give it the leader
+// scheduler so only one replica delivers.
+func NewWebhookService(ws webhookStore, tc taskCreater) *WebhookService {
+	s := &WebhookService{
+		ws:     ws,
+		client: newClient(time.Duration(config.Get().Webhook.TimeoutSeconds) * time.Second),
+	}
+
+	_, err := tc.NewJob(
+		gocron.DurationJob(
+			time.Duration(config.Get().Webhook.IntervalSeconds)*time.Second,
+		),
+		gocron.NewTask(s.deliverDue),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		zap.S().Errorw("Could not schedule webhook deliveries", "error", err)
+	}
+
+	return s
+}
+
+func (s *WebhookService) GetWebhooks(uid uuid.UUID) ([]WebhookDto, error) {
+	ws, err := s.ws.getWebhooksByUserId(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(ws, func(w Webhook, _ int) WebhookDto { return mapToDto(w) }), nil
+}
+
+// CreateWebhook returns the secret once, it is not shown again.
+func (s *WebhookService) CreateWebhook(uid uuid.UUID, req WebhookRequest) (WebhookDto, error) {
+	if err := validate(req); err != nil {
+		return WebhookDto{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return WebhookDto{}, err
+	}
+
+	w := Webhook{
+		UserId: uid,
+		Url:    req.Url,
+		Events: lo.Uniq(req.Events),
+		Secret: secret,
+		Active: req.Active == nil || *req.Active,
+	}
+	if err := s.ws.createWebhook(&w); err != nil {
+		return WebhookDto{}, err
+	}
+
+	dto := mapToDto(w)
+	dto.Secret = secret
+	return dto, nil
+}
+
+func (s *WebhookService) UpdateWebhook(uid, id uuid.UUID, req WebhookRequest) (WebhookDto, error) {
+	if err := validate(req); err != nil {
+		return WebhookDto{}, err
+	}
+
+	w, err := s.ws.getWebhookById(uid, id)
+	if err != nil {
+		return WebhookDto{}, err
+	}
+
+	w.Url = req.Url
+	w.Events = lo.Uniq(req.Events)
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+	if err := s.ws.updateWebhook(w); err != nil {
+		return WebhookDto{}, err
+	}
+
+	return mapToDto(w), nil
+}
+
+// RotateSecret replaces the signing secret, deliveries that are still pending
+// are signed with the new one.
+func (s *WebhookService) RotateSecret(uid, id uuid.UUID) (WebhookDto, error) {
+	w, err := s.ws.getWebhookById(uid, id)
+	if err != nil {
+		return WebhookDto{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return WebhookDto{}, err
+	}
+	if err := s.ws.updateWebhookSecret(uid, id, secret); err != nil {
+		return WebhookDto{}, err
+	}
+
+	dto := mapToDto(w)
+	dto.Secret = secret
+	return dto, nil
+}
+
+func (s *WebhookService) DeleteWebhook(uid, id uuid.UUID) error {
+	return s.ws.deleteWebhook(uid, id)
+}
+
+func (s *WebhookService) GetDeliveries(uid, id uuid.UUID, limit, page int) (DeliveriesPaginated, error) {
+	limit = lo.Ternary(limit <= 0, 10, limit)
+	page = lo.Ternary(page <= 0, 1, page)
+
+	ds, total, err := s.ws.getDeliveriesByWebhookId(uid, id, limit, page)
+	if err != nil {
+		return DeliveriesPaginated{}, err
+	}
+
+	return DeliveriesPaginated{
+		Limit:      limit,
+		Page:       page,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+		Deliveries: lo.Map(ds, mapToDeliveryDto),
+	}, nil
+}
+
+// Notify puts the event in the outbox of every webhook of the user that
+// subscribed to it. It never fails the caller, the event is dropped when the
+// outbox can not be written.
+func (s *WebhookService) Notify(uid uuid.UUID, e Event) {
+	ws, err := s.ws.getActiveWebhooksByEvent(uid, e.Type)
+	if err != nil || len(ws) == 0 {
+		return
+	}
+
+	now := time.Now()
+	ds := lo.Map(ws, func(w Webhook, _ int) WebhookDelivery {
+		return WebhookDelivery{
+			WebhookId:     w.Id,
+			Event:         e,
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+		}
+	})
+	if err := s.ws.createDeliveries(ds); err != nil {
+		zap.S().Errorw("Could not queue webhook deliveries", "event", e.Type, "analysis_request_id", e.AnalysisRequestId, "error", err)
+	}
+}
+
+// deliverDue sends the deliveries that are due side by side. They are leased
+// a little longer than a request can take.
+func (s *WebhookService) deliverDue() {
+	lease := s.client.Timeout + 30*time.Second
+	ds, err := s.ws.claimDueDeliveries(config.Get().Webhook.BatchSize, lease)
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range ds {
+		wg.Add(1)
+		go func(d WebhookDelivery) {
+			defer wg.Done()
+			s.deliver(d)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// deliver sends the delivery once and schedules the next attempt with backoff
+// when it fails, until the retries are exhausted.
+func (s *WebhookService) deliver(d WebhookDelivery) {
+	start := time.Now()
+	code, err := 0, errWebhookDisabled
+	if d.Webhook.Active {
+		code, err = s.send(d)
+	}
+
+	d.Attempts++
+	a := WebhookAttempt{
+		DeliveryId: d.Id,
+		WebhookId:  d.WebhookId,
+		Attempt:    d.Attempts,
+		StatusCode: code,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	rp := config.Get().Webhook.Retry
+	switch {
+	case err == nil:
+		d.Status = DeliveryDelivered
+	case errors.Is(err, errWebhookDisabled) || rp.Exhausted(d.Attempts):
+		a.Error = err.Error()
+		d.Status = DeliveryFailed
+	default:
+		a.Error = err.Error()
+		d.NextAttemptAt = time.Now().Add(rp.Backoff(d.Attempts))
+	}
+
+	zap.S().Infow("Webhook delivery attempted", "delivery_id", d.Id, "webhook_id", d.WebhookId, "attempt", d.Attempts, "status", d.Status, "status_code", code, "error", a.Error)
+	_ = s.ws.recordAttempt(d, a)
+}
+
+// send posts the event to the receiver, any response other than 2xx fails it.
+func (s *WebhookService) send(d WebhookDelivery) (int, error) {
+	body, err := json.Marshal(payload{
+		DeliveryId: d.Id,
+		OccurredAt: d.CreatedAt,
+		Event:      d.Event,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.Webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(d.Event.Type))
+	req.Header.Set(HeaderDelivery, d.Id.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Webhook.Secret, ts, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded with %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// Sign is the signature receivers check: the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the secret of the webhook.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func validate(req WebhookRequest) error {
+	u, err := url.Parse(req.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidUrl
+	}
+	if !publicHost(u.Hostname()) {
+		return ErrInvalidUrl
+	}
+	if len(req.Events) == 0 {
+		return ErrInvalidEvents
+	}
+	if unknown, _ := lo.Difference(req.Events, eventTypes); len(unknown) > 0 {
+		return ErrInvalidEvents
+	}
+	return nil
+}
+
+func mapToDto(w Webhook) WebhookDto {
+	return WebhookDto{
+		Id:        w.Id,
+		Url:       w.Url,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func mapToDeliveryDto(d WebhookDelivery, _ int) DeliveryDto {
+	dto := DeliveryDto{
+		Id:        d.Id,
+		Event:     d.Event.Type,
+		Payload:   d.Event,
+		Status:    d.Status,
+		CreatedAt: d.CreatedAt,
+		Attempts: lo.Map(d.History, func(a WebhookAttempt, _ int) AttemptDto {
+			return AttemptDto{
+				Attempt:    a.Attempt,
+				StatusCode: a.StatusCode,
+				Error:      a.Error,
+				DurationMs: a.DurationMs,
+				CreatedAt:  a.CreatedAt,
+			}
+		}),
+	}
+	if d.Status == DeliveryPending {
+		dto.NextAttemptAt = lo.ToPtr(d.NextAttemptAt)
+	}
+	return dto
+}
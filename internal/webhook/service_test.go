@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/internal/essential/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestService(t *testing.T) (*WebhookService, *MockwebhookStore) {
+	config.SetupConfig("../../testdata/envs/webhook.yaml")
+
+	mockWs := NewMockwebhookStore(t)
+	mockTc := NewMocktaskCreater(t)
+	mockTc.EXPECT().NewJob(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	return NewWebhookService(mockWs, mockTc), mockWs
+}
+
+func TestCreateWebhook(t *testing.T) {
+	s, mockWs := newTestService(t)
+	uid := uuid.MustParse("c5fdf7a6-ec31-49d5-af69-6a77bb6e43fe")
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := s.CreateWebhook(uid, WebhookRequest{Url: "ftp://example.com", Events: []EventType{EventReportFinished}})
+		assert.ErrorIs(t, err, ErrInvalidUrl)
+
+		_, err = s.CreateWebhook(uid, WebhookRequest{Url: "/hook", Events: []EventType{EventReportFinished}})
+		assert.ErrorIs(t, err, ErrInvalidUrl)
+
+		for _, u := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://10.0.0.7/hook", "http://169.254.169.254/latest", "http://[::1]/hook", "http://[::ffff:192.168.1.1]/hook"} {
+			_, err = s.CreateWebhook(uid, WebhookRequest{Url: u, Events: []EventType{EventReportFinished}})
+			assert.ErrorIs(t, err, ErrInvalidUrl, u)
+		}
+
+		_, err = s.CreateWebhook(uid, WebhookRequest{Url: "https://example.com/hook"})
+		assert.ErrorIs(t, err, ErrInvalidEvents)
+
+		_, err = s.CreateWebhook(uid, WebhookRequest{Url: "https://example.com/hook", Events: []EventType{"analysis_deleted"}})
+		assert.ErrorIs(t, err, ErrInvalidEvents)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockWs.EXPECT().createWebhook(mock.Anything).RunAndReturn(func(w *Webhook) error {
+			assert.Equal(t, uid, w.UserId)
+			assert.Equal(t, Events{EventReportFinished}, w.Events)
+			assert.True(t, w.Active)
+			w.Id = uuid.New()
+			return nil
+		})
+
+		w, err := s.CreateWebhook(uid, WebhookRequest{Url: "https://example.com/hook", Events: []EventType{EventReportFinished, EventReportFinished}})
+
+		assert.NoError(t, err)
+		assert.Regexp(t, "^whsec_[0-9a-f]{64}$", w.Secret)
+		assert.Equal(t, []EventType{EventReportFinished}, w.Events)
+	})
+}
+
+func TestNotifyQueuesDeliveries(t *testing.T) {
+	s, mockWs := newTestService(t)
+	uid := uuid.MustParse("c5fdf7a6-ec31-49d5-af69-6a77bb6e43fe")
+	e := Event{Type: EventAnalysisFinished, AnalysisRequestId: uuid.New()}
+	ws := []Webhook{{Id: uuid.New()}, {Id: uuid.New()}}
+
+	mockWs.EXPECT().getActiveWebhooksByEvent(uid, EventAnalysisFinished).Return(ws, nil)
+	mockWs.EXPECT().createDeliveries(mock.Anything).RunAndReturn(func(ds []WebhookDelivery) error {
+		assert.Len(t, ds, 2)
+		for i, d := range ds {
+			assert.Equal(t, ws[i].Id, d.WebhookId)
+			assert.Equal(t, e, d.Event)
+			assert.Equal(t, DeliveryPending, d.Status)
+			assert.WithinDuration(t, time.Now(), d.NextAttemptAt, time.Second)
+		}
+		return nil
+	})
+
+	s.Notify(uid, e)
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	s, mockWs := newTestService(t)
+	secret := "whsec_test"
+
+	d := WebhookDelivery{
+		Id:        uuid.New(),
+		WebhookId: uuid.New(),
+		Event:     Event{Type: EventReportFinished, AnalysisRequestId: uuid.New(), Title: "Alice"},
+		Status:    DeliveryPending,
+		CreatedAt: time.Now(),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+
+		assert.Equal(t, Sign(secret, ts, body), r.Header.Get(HeaderSignature))
+		assert.Equal(t, string(EventReportFinished), r.Header.Get(HeaderEvent))
+		assert.Equal(t, d.Id.String(), r.Header.Get(HeaderDelivery))
+
+		var p payload
+		assert.NoError(t, json.Unmarshal(body, &p))
+		assert.Equal(t, d.Id, p.DeliveryId)
+		assert.Equal(t, d.Event, p.Event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	// The receiver listens on loopback, which the client of the service refuses
+	s.client = srv.Client()
+	d.Webhook = Webhook{Id: d.WebhookId, Url: srv.URL, Secret: secret, Active: true}
+
+	mockWs.EXPECT().recordAttempt(mock.Anything, mock.Anything).RunAndReturn(func(rd WebhookDelivery, a WebhookAttempt) error {
+		assert.Equal(t, DeliveryDelivered, rd.Status)
+		assert.Equal(t, 1, rd.Attempts)
+		assert.Equal(t, http.StatusNoContent, a.StatusCode)
+		assert.Empty(t, a.Error)
+		return nil
+	})
+
+	s.deliver(d)
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	s, mockWs := newTestService(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	s.client = srv.Client()
+
+	d := WebhookDelivery{
+		Id:      uuid.New(),
+		Webhook: Webhook{Url: srv.URL, Secret: "whsec_test", Active: true},
+		Status:  DeliveryPending,
+	}
+
+	t.Run("retry", func(t *testing.T) {
+		mockWs.EXPECT().recordAttempt(mock.Anything, mock.Anything).RunAndReturn(func(rd WebhookDelivery, a WebhookAttempt) error {
+			assert.Equal(t, DeliveryPending, rd.Status)
+			assert.Equal(t, 2, rd.Attempts)
+			// Second attempt waits initialDelay * multiplier, with jitter
+			assert.WithinDuration(t, time.Now().Add(20*time.Second), rd.NextAttemptAt, 5*time.Second)
+			assert.Equal(t, http.StatusServiceUnavailable, a.StatusCode)
+			assert.Equal(t, "receiver responded with 503", a.Error)
+			return nil
+		}).Once()
+
+		d.Attempts = 1
+		s.deliver(d)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		mockWs.EXPECT().recordAttempt(mock.Anything, mock.Anything).RunAndReturn(func(rd WebhookDelivery, a WebhookAttempt) error {
+			assert.Equal(t, DeliveryFailed, rd.Status)
+			assert.Equal(t, 3, rd.Attempts)
+			return nil
+		}).Once()
+
+		d.Attempts = 2
+		s.deliver(d)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		mockWs.EXPECT().recordAttempt(mock.Anything, mock.Anything).RunAndReturn(func(rd WebhookDelivery, a WebhookAttempt) error {
+			assert.Equal(t, DeliveryFailed, rd.Status)
+			assert.Equal(t, errWebhookDisabled.Error(), a.Error)
+			return nil
+		}).Once()
+
+		d.Attempts = 0
+		d.Webhook.Active = false
+		s.deliver(d)
+	})
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	s, mockWs := newTestService(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the receiver on loopback should not be reached")
+	}))
+	defer srv.Close()
+
+	d := WebhookDelivery{
+		Id:      uuid.New(),
+		Webhook: Webhook{Url: srv.URL, Secret: "whsec_test", Active: true},
+		Status:  DeliveryPending,
+	}
+
+	mockWs.EXPECT().recordAttempt(mock.Anything, mock.Anything).RunAndReturn(func(rd WebhookDelivery, a WebhookAttempt) error {
+		assert.Equal(t, DeliveryPending, rd.Status)
+		assert.Contains(t, a.Error, errPrivateAddress.Error())
+		return nil
+	})
+
+	s.deliver(d)
+}
@@ -1,6 +1,10 @@
 package analysisrequest
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type ContentType string
 
@@ -41,9 +45,25 @@ type AnalyzerInput struct {
 	Value string `json:"value"`
 }
 
+// AnalysisUpload is the metadata part of a multipart upload, the file part
+// that follows it carries the content.
+type AnalysisUpload struct {
+	Title       string      `json:"title"`
+	ContentType ContentType `json:"contentType"`
+	Category    string      `json:"category"`
+	Mimetype    string      `json:"mimetype"`
+	Themes      []Theme     `json:"themes"`
+}
+
 type AnalysisUpdateScore struct {
-	Id    uuid.UUID `json:"id"`
-	Score float32   `json:"score"`
+	Id     uuid.UUID `json:"id"`
+	Score  float32   `json:"score"`
+	Reason string    `json:"reason"`
+}
+
+type AnalysisRevertScore struct {
+	Id     uuid.UUID `json:"id"`
+	Reason string    `json:"reason"`
 }
 
 type AnalysisRequestDataloom struct {
@@ -57,3 +77,68 @@ type AnalysisRequestDataloom struct {
 type AnalysisRequestResponse struct {
 	Id uuid.UUID `json:"id"`
 }
+
+type AnalysisRerun struct {
+	ThemeIds []uuid.UUID `json:"themeIds"`
+}
+
+type AnalysisRerunTheme struct {
+	ThemeId uuid.UUID `json:"themeId"`
+}
+
+type AnalysisRerunResponse struct {
+	Rerun   []uuid.UUID `json:"rerun"`
+	Skipped []uuid.UUID `json:"skipped"`
+}
+
+type AnalysisRequestDataloomBatch struct {
+	Items []AnalysisRequestDataloom `json:"items"`
+}
+
+// BatchManifest describes the files of a batch archive. File is the path of
+// the content within the archive.
+type BatchManifest struct {
+	Items []BatchManifestItem `json:"items"`
+}
+
+type BatchManifestItem struct {
+	File        string      `json:"file"`
+	Title       string      `json:"title"`
+	ContentType ContentType `json:"contentType"`
+	Category    string      `json:"category"`
+	Mimetype    string      `json:"mimetype"`
+	ThemeIds    []uuid.UUID `json:"themeIds"`
+}
+
+type AnalysisBatchResponse struct {
+	Id    uuid.UUID           `json:"id"`
+	Items []AnalysisBatchItem `json:"items"`
+}
+
+// AnalysisBatchItem is the result of an item of a batch, in the order it was
+// submitted. Result is one of created, duplicate or rejected.
+type AnalysisBatchItem struct {
+	Index  int        `json:"index"`
+	Title  string     `json:"title"`
+	Id     *uuid.UUID `json:"id"`
+	Result string     `json:"result"`
+	Reason string     `json:"reason,omitempty"`
+	Status string     `json:"status,omitempty"`
+}
+
+// AnalysisBatchProgress counts the items of a batch by result and the
+// analysis requests of the batch by status.
+type AnalysisBatchProgress struct {
+	Id         uuid.UUID           `json:"id"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	Total      int                 `json:"total"`
+	Created    int                 `json:"created"`
+	Duplicate  int                 `json:"duplicate"`
+	Rejected   int                 `json:"rejected"`
+	Waiting    int                 `json:"waiting"`
+	Inprogress int                 `json:"inprogress"`
+	Finished   int                 `json:"finished"`
+	Error      int                 `json:"error"`
+	Done       bool                `json:"done"`
+	Items      []AnalysisBatchItem `json:"items"`
+}
@@ -13,14 +13,41 @@ type AnalysisPaginated struct {
 	Analyses   []Analysis `json:"analyses"`
 }
 
+type AnalysisSearchPaginated struct {
+	Limit      int            `json:"limit"`
+	Page       int            `json:"page"`
+	TotalPages int            `json:"totalPages"`
+	Results    []SearchResult `json:"results"`
+}
+
+// SearchResult is an analysis whose text matched the search. The matched words
+// in Snippet are wrapped in <mark> tags, the rest of it is the text escaped for
+// HTML.
+type SearchResult struct {
+	Analysis Analysis `json:"analysis"`
+	Snippet  string   `json:"snippet"`
+	Rank     float32  `json:"rank"`
+}
+
 type Analysis struct {
 	Id            uuid.UUID `json:"id"`
 	Title         string    `json:"title"`
 	Category      string    `json:"category"`
 	RequestOrigin string    `json:"requestOrigin"`
 	ContentType   string    `json:"contentType"`
+	Verdict       string    `json:"verdict"`
 	Themes        []Theme   `json:"themes"`
 	CreatedAt     time.Time `json:"createdAt"`
+	Text          Text      `json:"text"`
+}
+
+// Text tells whether the processed text is still stored. State is pending,
+// available, exported or purged. Purged texts were removed by the retention
+// rule PurgedBy.
+type Text struct {
+	State    string     `json:"state"`
+	PurgedAt *time.Time `json:"purgedAt,omitempty"`
+	PurgedBy string     `json:"purgedBy,omitempty"`
 }
 
 type Theme struct {
@@ -36,14 +63,44 @@ type Reporter struct {
 }
 
 type Analyzer struct {
-	Id      uuid.UUID             `json:"id"`
-	Key     string                `json:"key"`
-	Name    string                `json:"name"`
-	Status  string                `json:"status"`
-	Score   float32               `json:"score"`
-	Content []string              `json:"content"`
-	Inputs  []AnalyzerInput       `json:"inputs"`
-	Jobs    []AnalyzerJobProgress `json:"jobs"`
+	Id      uuid.UUID `json:"id"`
+	Key     string    `json:"key"`
+	Name    string    `json:"name"`
+	Status  string    `json:"status"`
+	Score   float32   `json:"score"`
+	Content []string  `json:"content"`
+	// Findings are the structured matches, Content only the flagged sentences
+	Findings []Finding             `json:"findings"`
+	Inputs   []AnalyzerInput       `json:"inputs"`
+	Jobs     []AnalyzerJobProgress `json:"jobs"`
+	// Overridden scores were set by hand, ReporterScore is the computed one
+	ReporterScore float32 `json:"reporterScore"`
+	Overridden    bool    `json:"overridden"`
+}
+
+// Finding is a match of an analyzer. Start and End are character offsets into
+// the processed text, End is exclusive.
+type Finding struct {
+	Term       string  `json:"term"`
+	Sentence   string  `json:"sentence"`
+	ChunkIndex int     `json:"chunkIndex"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Confidence float32 `json:"confidence"`
+}
+
+// ScoreOverride is a manual change of the score of an analysis. Reverted ones
+// put the score of the reporter back.
+type ScoreOverride struct {
+	Id            uuid.UUID `json:"id"`
+	AnalysisId    uuid.UUID `json:"analysisId"`
+	UserId        uuid.UUID `json:"userId"`
+	ReporterScore float32   `json:"reporterScore"`
+	PreviousScore float32   `json:"previousScore"`
+	Score         float32   `json:"score"`
+	Reason        string    `json:"reason"`
+	Reverted      bool      `json:"reverted"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
 
 type AnalyzerInput struct {
@@ -55,3 +112,120 @@ type AnalyzerInput struct {
 type AnalyzerJobProgress struct {
 	Status string `json:"status"`
 }
+
+// AnalysisProgress is how far the analyses of a request are. Completed and
+// Total count analyzer jobs, one per chunk of the text. EtaSeconds is nil as
+// long as no job of the analyzers finished before.
+type AnalysisProgress struct {
+	AnalysisRequestId uuid.UUID          `json:"analysisRequestId"`
+	Stage             string             `json:"stage"`
+	Completed         int                `json:"completed"`
+	Total             int                `json:"total"`
+	Percentage        float32            `json:"percentage"`
+	EtaSeconds        *int               `json:"etaSeconds"`
+	Analyses          []AnalyzerProgress `json:"analyses"`
+}
+
+type AnalyzerProgress struct {
+	AnalysisId  uuid.UUID `json:"analysisId"`
+	ThemeId     uuid.UUID `json:"themeId"`
+	AnalyzerKey string    `json:"analyzerKey"`
+	Stage       string    `json:"stage"`
+	Status      string    `json:"status"`
+	Completed   int       `json:"completed"`
+	Total       int       `json:"total"`
+	Percentage  float32   `json:"percentage"`
+	EtaSeconds  *int      `json:"etaSeconds"`
+}
+
+type Timeline struct {
+	AnalysisRequestId uuid.UUID      `json:"analysisRequestId"`
+	Steps             []TimelineStep `json:"steps"`
+}
+
+// TimelineStep is a single job of the analysis. StartedAt and EndedAt are
+// those of the last attempt, EndedAt is nil while the job did not end.
+type TimelineStep struct {
+	JobId             uuid.UUID       `json:"jobId"`
+	Type              string          `json:"type"`
+	GroupKey          string          `json:"groupKey"`
+	Status            string          `json:"status"`
+	StatusDescription string          `json:"statusDescription"`
+	RetryCount        int             `json:"retryCount"`
+	QueuedAt          time.Time       `json:"queuedAt"`
+	StartedAt         *time.Time      `json:"startedAt"`
+	EndedAt           *time.Time      `json:"endedAt"`
+	WaitMs            int64           `json:"waitMs"`
+	RunMs             int64           `json:"runMs"`
+	Events            []TimelineEvent `json:"events"`
+}
+
+type TimelineEvent struct {
+	Status            string    `json:"status"`
+	StatusDescription string    `json:"statusDescription"`
+	RetryCount        int       `json:"retryCount"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// Stats is an overview of the analyses of a user. With FromRollup the counts,
+// terms and trend lag behind by the refresh interval of the rollup.
+type Stats struct {
+	GeneratedAt  time.Time     `json:"generatedAt"`
+	FromRollup   bool          `json:"fromRollup"`
+	Total        int           `json:"total"`
+	ContentTypes []CountStat   `json:"contentTypes"`
+	Categories   []CountStat   `json:"categories"`
+	Verdicts     []VerdictStat `json:"verdicts"`
+	// Shares of the approved and the flagged requests among the two
+	ApprovedRatio   float32          `json:"approvedRatio"`
+	FlaggedRatio    float32          `json:"flaggedRatio"`
+	Terms           []ThemeTerms     `json:"terms"`
+	ProcessingTimes []ProcessingTime `json:"processingTimes"`
+	Trend           Trend            `json:"trend"`
+}
+
+type CountStat struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// VerdictStat counts a verdict, Ratio is its share of all requests.
+type VerdictStat struct {
+	Verdict string  `json:"verdict"`
+	Count   int     `json:"count"`
+	Ratio   float32 `json:"ratio"`
+}
+
+// ThemeTerms are the terms matched most in the analyses of a theme.
+type ThemeTerms struct {
+	ThemeId uuid.UUID  `json:"themeId"`
+	Title   string     `json:"title"`
+	Terms   []TermStat `json:"terms"`
+}
+
+type TermStat struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// ProcessingTime is how long the finished jobs of a parser or analyzer took on
+// average. Only the jobs still in the job history count.
+type ProcessingTime struct {
+	Type      string `json:"type"`
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	Jobs      int    `json:"jobs"`
+	AverageMs int64  `json:"averageMs"`
+}
+
+// Trend counts the requests made per week or month by verdict, oldest first.
+type Trend struct {
+	Bucket string       `json:"bucket"`
+	Points []TrendPoint `json:"points"`
+}
+
+type TrendPoint struct {
+	Start    time.Time      `json:"start"`
+	Total    int            `json:"total"`
+	Verdicts map[string]int `json:"verdicts"`
+}
@@ -3,9 +3,13 @@ package analyzercontract
 import "github.com/google/uuid"
 
 type AnalyzerRequest struct {
-	JobId      uuid.UUID       `json:"jobId"`
-	AnalysisId uuid.UUID       `json:"analysisId"`
-	Content    string          `json:"content"`
+	JobId      uuid.UUID `json:"jobId"`
+	AnalysisId uuid.UUID `json:"analysisId"`
+	Content    string    `json:"content"`
+	// ChunkIndex is the position of the content among the chunks of the
+	// processed text, Offset the character offset of its first character.
+	ChunkIndex int             `json:"chunkIndex"`
+	Offset     int             `json:"offset"`
 	Inputs     []AnalysisInput `json:"inputs"`
 }
 
@@ -22,8 +26,36 @@ const (
 )
 
 type AnalyzerResponse struct {
-	JobId      uuid.UUID              `json:"jobId"`
-	AnalysisId uuid.UUID              `json:"analysisId"`
-	Results    []string               `json:"results"`
-	Status     AnalyzerResponseStatus `json:"status"`
+	JobId      uuid.UUID `json:"jobId"`
+	AnalysisId uuid.UUID `json:"analysisId"`
+	// Results are the flagged sentences, kept for analyzers and clients that
+	// do not know about findings.
+	Results  []string               `json:"results"`
+	Findings []Finding              `json:"findings"`
+	Status   AnalyzerResponseStatus `json:"status"`
+}
+
+// Finding is a single match of an analyzer. Start and End are character
+// offsets of the match into the processed text, End is exclusive.
+type Finding struct {
+	Term       string  `json:"term"`
+	Sentence   string  `json:"sentence"`
+	ChunkIndex int     `json:"chunkIndex"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Confidence float32 `json:"confidence"`
+}
+
+// HeartbeatSubject receives heartbeats of every analyzer.
+const HeartbeatSubject = "analyzer.heartbeat"
+
+// AnalyzerHeartbeat tells the server the job is still being worked on. Each
+// heartbeat extends the lease of the job, the job only times out when the
+// lease runs out.
+type AnalyzerHeartbeat struct {
+	JobId      uuid.UUID `json:"jobId"`
+	AnalysisId uuid.UUID `json:"analysisId"`
+	// LeaseSeconds is how long the job may go without the next heartbeat. It
+	// is capped by the configured timeout, 0 uses the configured timeout.
+	LeaseSeconds int `json:"leaseSeconds"`
 }
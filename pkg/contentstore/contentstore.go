@@ -0,0 +1,74 @@
+package contentstore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Bucket is the object store holding uploaded content. Parser jobs refer to
+// their content by object name instead of carrying it inline.
+const Bucket = "guardlight_content"
+
+var ErrNoStore = errors.New("content store is not available")
+
+type Store struct {
+	os jetstream.ObjectStore
+}
+
+// NewStore opens the content bucket, creating it when it does not exist yet.
+func NewStore(ctx context.Context, ncon *nats.Conn) (*Store, error) {
+	js, err := jetstream.New(ncon)
+	if err != nil {
+		return nil, err
+	}
+
+	os, err := js.ObjectStore(ctx, Bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		os, err = js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{
+			Bucket:  Bucket,
+			Storage: jetstream.FileStorage,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{os: os}, nil
+}
+
+// Put streams the content into the store under the name.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader) error {
+	_, err := s.os.Put(ctx, jetstream.ObjectMeta{Name: name}, r)
+	return err
+}
+
+func (s *Store) Get(ctx context.Context, name string) ([]byte, error) {
+	return s.os.GetBytes(ctx, name)
+}
+
+// Delete removes the content. Content that is already gone is not an error.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	err := s.os.Delete(ctx, name)
+	if errors.Is(err, jetstream.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ParserContent returns the content of a parser request, from the store when
+// the request refers to it, otherwise decoded from the request itself.
+func (s *Store) ParserContent(ctx context.Context, pr parsercontract.ParserRequest) ([]byte, error) {
+	if pr.ContentRef != "" {
+		if s == nil {
+			return nil, ErrNoStore
+		}
+		return s.Get(ctx, pr.ContentRef)
+	}
+	return base64.StdEncoding.DecodeString(pr.Content)
+}
@@ -0,0 +1,66 @@
+package contentstore
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func runJetStream(t *testing.T) *nats.Conn {
+	ns, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+	go ns.Start()
+	assert.True(t, ns.ReadyForConnections(5*time.Second))
+	t.Cleanup(ns.Shutdown)
+
+	ncon, err := nats.Connect(ns.ClientURL())
+	assert.NoError(t, err)
+	t.Cleanup(ncon.Close)
+	return ncon
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ncon := runJetStream(t)
+	ctx := context.Background()
+
+	s, err := NewStore(ctx, ncon)
+	assert.NoError(t, err)
+
+	// A second store opens the existing bucket
+	s2, err := NewStore(ctx, ncon)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Put(ctx, "ref", strings.NewReader("Running and walking")))
+
+	b, err := s2.ParserContent(ctx, parsercontract.ParserRequest{ContentRef: "ref"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Running and walking", string(b))
+
+	assert.NoError(t, s.Delete(ctx, "ref"))
+	assert.NoError(t, s.Delete(ctx, "ref"))
+	_, err = s.Get(ctx, "ref")
+	assert.Error(t, err)
+}
+
+func TestParserContentInline(t *testing.T) {
+	var s *Store
+	pr := parsercontract.ParserRequest{Content: base64.StdEncoding.EncodeToString([]byte("Running"))}
+
+	b, err := s.ParserContent(context.Background(), pr)
+	assert.NoError(t, err)
+	assert.Equal(t, "Running", string(b))
+
+	_, err = s.ParserContent(context.Background(), parsercontract.ParserRequest{ContentRef: "ref"})
+	assert.ErrorIs(t, err, ErrNoStore)
+}
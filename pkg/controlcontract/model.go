@@ -0,0 +1,19 @@
+package controlcontract
+
+import "github.com/google/uuid"
+
+// CancelSubject is broadcast to all adapters, it is not a work queue.
+const CancelSubject = "control.cancel"
+
+type CancelRequest struct {
+	JobIds []uuid.UUID `json:"jobIds"`
+	Reason string      `json:"reason"`
+}
+
+// RestoreSubject is broadcast when cancelled jobs are queued again, adapters
+// forget they were cancelled.
+const RestoreSubject = "control.restore"
+
+type RestoreRequest struct {
+	JobIds []uuid.UUID `json:"jobIds"`
+}
@@ -0,0 +1,22 @@
+package analyzers
+
+import (
+	"encoding/json"
+
+	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/guardlight/server/pkg/workqueue"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// heartbeats extend the lease of analyzer jobs while they are worked on.
+var heartbeats = workqueue.Heartbeats{
+	Subject: analyzercontract.HeartbeatSubject,
+	Every:   workqueue.HeartbeatInterval,
+	Beat: func(m jetstream.Msg) ([]byte, error) {
+		var r analyzercontract.AnalyzerRequest
+		if err := json.Unmarshal(m.Data(), &r); err != nil {
+			return nil, err
+		}
+		return json.Marshal(analyzercontract.AnalyzerHeartbeat{JobId: r.JobId, AnalysisId: r.AnalysisId})
+	},
+}
@@ -67,7 +67,7 @@ func (s *TestSuiteWordsearchAnalyzerIntegration) TestWordsearchAnalyzer() {
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	s.ncon.Subscribe("analyzer.result", func(m *nats.Msg) {
+	sub, err := s.ncon.Subscribe("analyzer.result", func(m *nats.Msg) {
 		var t analyzercontract.AnalyzerResponse
 		err := json.Unmarshal(m.Data, &t)
 		s.Assert().NoError(err)
@@ -77,6 +77,9 @@ func (s *TestSuiteWordsearchAnalyzerIntegration) TestWordsearchAnalyzer() {
 		// os.WriteFile("./test.txt", []byte(strings.Join(t.Results, "\n")), os.ModePerm)
 		wg.Done()
 	})
+	s.Assert().NoError(err)
+	// The next test publishes a result on the same subject
+	defer sub.Unsubscribe()
 
 	wg.Wait()
 
@@ -108,7 +111,7 @@ func (s *TestSuiteWordsearchAnalyzerIntegration) TestWordsearchAnalyzerSingle()
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	s.ncon.Subscribe("analyzer.result", func(m *nats.Msg) {
+	sub, err := s.ncon.Subscribe("analyzer.result", func(m *nats.Msg) {
 		var t analyzercontract.AnalyzerResponse
 		err := json.Unmarshal(m.Data, &t)
 		s.Assert().NoError(err)
@@ -118,6 +121,9 @@ func (s *TestSuiteWordsearchAnalyzerIntegration) TestWordsearchAnalyzerSingle()
 		// os.WriteFile("./test.txt", []byte(strings.Join(t.Results, "\n")), os.ModePerm)
 		wg.Done()
 	})
+	s.Assert().NoError(err)
+	// The next test publishes a result on the same subject
+	defer sub.Unsubscribe()
 
 	wg.Wait()
 
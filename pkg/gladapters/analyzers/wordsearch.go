@@ -5,9 +5,13 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 )
@@ -18,27 +22,37 @@ const (
 
 type wordsearchAnalyzer struct {
 	ncon *nats.Conn
+	cs   *workqueue.Cancellations
 }
 
 func NewWordsearchAnalyzer(ncon *nats.Conn) *wordsearchAnalyzer {
 	wa := &wordsearchAnalyzer{
 		ncon: ncon,
+		cs:   workqueue.WatchCancellations(ncon),
+	}
+	if _, err := workqueue.ConsumeWithHeartbeats(ncon, "analyzer.word_search", heartbeats, wa.analyze); err != nil {
+		zap.S().Errorw("Could not consume work queue", "subject", "analyzer.word_search", "error", err)
 	}
-	ncon.Subscribe("analyzer.word_search", wa.analyze)
 	return wa
 }
 
-func (wa *wordsearchAnalyzer) analyze(m *nats.Msg) {
+func (wa *wordsearchAnalyzer) analyze(m jetstream.Msg) {
 	var ar analyzercontract.AnalyzerRequest
-	err := json.Unmarshal(m.Data, &ar)
+	err := json.Unmarshal(m.Data(), &ar)
 	if err != nil {
-		wa.makeParserErrorResponse(&ar, err)
+		wa.makeParserErrorResponse(m, &ar, err)
+		return
+	}
+
+	if wa.cs.Cancelled(ar.JobId) {
+		zap.S().Infow("Skipping cancelled job", "job_id", ar.JobId)
+		m.Term()
 		return
 	}
 
-	res, err := analyze(ar.Content, ar.Inputs)
+	res, fs, err := analyze(ar.Content, ar.Inputs, ar.ChunkIndex, ar.Offset)
 	if err != nil {
-		wa.makeParserErrorResponse(&ar, err)
+		wa.makeParserErrorResponse(m, &ar, err)
 		return
 	}
 
@@ -46,18 +60,22 @@ func (wa *wordsearchAnalyzer) analyze(m *nats.Msg) {
 		JobId:      ar.JobId,
 		AnalysisId: ar.AnalysisId,
 		Results:    res,
+		Findings:   fs,
 		Status:     analyzercontract.AnalyzerSuccess,
 	}
 	dat, err := json.Marshal(aresp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal analyzer response", "error", err)
+		m.Term()
 		return
 	}
 
-	wa.ncon.Publish("analyzer.result", dat)
+	if err := workqueue.Respond(wa.ncon, m, "analyzer.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
 
-func (wa *wordsearchAnalyzer) makeParserErrorResponse(ar *analyzercontract.AnalyzerRequest, err error) {
+func (wa *wordsearchAnalyzer) makeParserErrorResponse(m jetstream.Msg, ar *analyzercontract.AnalyzerRequest, err error) {
 	aresp := analyzercontract.AnalyzerResponse{
 		JobId:      ar.JobId,
 		AnalysisId: ar.AnalysisId,
@@ -69,24 +87,34 @@ func (wa *wordsearchAnalyzer) makeParserErrorResponse(ar *analyzercontract.Analy
 	dat, err := json.Marshal(aresp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal analyzer error response", "error", err)
+		m.Term()
 		return
 	}
 
-	wa.ncon.Publish("analyzer.result", dat)
+	if err := workqueue.Respond(wa.ncon, m, "analyzer.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
 
-func analyze(text string, ins []analyzercontract.AnalysisInput) ([]string, error) {
+// analyze flags every sentence of the chunk containing one of the strict
+// words. Each match is a finding, a sentence is flagged once however many
+// words it contains.
+func analyze(text string, ins []analyzercontract.AnalysisInput, chunkIndex, offset int) ([]string, []analyzercontract.Finding, error) {
 	in, ok := lo.Find(ins, func(item analyzercontract.AnalysisInput) bool {
 		return item.Key == INPUT_KEY_STRICT_WORDS
 	})
 	if !ok {
-		return nil, errors.New("strict_words key not found in data")
+		return nil, nil, errors.New("strict_words key not found in data")
 	}
 
 	strWordsMapper := func(str string, _ int) string {
 		return strings.ToLower(strings.TrimSpace(str))
 	}
-	splStrictWords := lo.Map(strings.Split(in.Value, ","), strWordsMapper)
+	splStrictWords := lo.Compact(lo.Map(strings.Split(in.Value, ","), strWordsMapper))
+	// Matching case insensitive keeps the offsets in the original text
+	wordRes := lo.Map(splStrictWords, func(sw string, _ int) *regexp.Regexp {
+		return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(sw) + `\b`)
+	})
 
 	// match sentence-ending punctuation (including optional closing quote)
 	re := regexp.MustCompile(`([.!?][")]?)(\s+|$)`)
@@ -95,27 +123,41 @@ func analyze(text string, ins []analyzercontract.AnalysisInput) ([]string, error
 	matches := re.FindAllStringIndex(text, -1)
 
 	var splText []string
+	var sentStarts []int
 	start := 0
 
 	for _, match := range matches {
 		end := match[1]
-		splText = append(splText, strings.TrimSpace(text[start:end]))
+		raw := text[start:end]
+		splText = append(splText, strings.TrimSpace(raw))
+		sentStarts = append(sentStarts, start+len(raw)-len(strings.TrimLeftFunc(raw, unicode.IsSpace)))
 		start = end
 	}
 
 	sents := make([]string, 0)
+	findings := make([]analyzercontract.Finding, 0)
 
 	for i, sentance := range splText {
-		for _, sw := range splStrictWords {
-			pattern := `\b` + regexp.QuoteMeta(strings.ToLower(sw)) + `\b`
-			re := regexp.MustCompile(pattern)
-			if re.MatchString(strings.ToLower(sentance)) {
-				sents = append(sents, buildSent(splText, i))
+		flagged := false
+		for j, wre := range wordRes {
+			for _, loc := range wre.FindAllStringIndex(sentance, -1) {
+				flagged = true
+				findings = append(findings, analyzercontract.Finding{
+					Term:       splStrictWords[j],
+					Sentence:   sentance,
+					ChunkIndex: chunkIndex,
+					Start:      offset + utf8.RuneCountInString(text[:sentStarts[i]+loc[0]]),
+					End:        offset + utf8.RuneCountInString(text[:sentStarts[i]+loc[1]]),
+					Confidence: 1,
+				})
 			}
 		}
+		if flagged {
+			sents = append(sents, buildSent(splText, i))
+		}
 	}
 
-	return sents, nil
+	return sents, findings, nil
 }
 
 func buildSent(splText []string, ind int) string {
@@ -1 +1,40 @@
 package analyzers
+
+import (
+	"testing"
+
+	"github.com/guardlight/server/pkg/analyzercontract"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeFindings(t *testing.T) {
+	text := "  Ünder the bridge. The gun and the knife lay there! A Gun again."
+	ins := []analyzercontract.AnalysisInput{
+		{Key: INPUT_KEY_STRICT_WORDS, Value: "gun, knife,"},
+	}
+
+	sents, fs, err := analyze(text, ins, 2, 100)
+
+	assert.NoError(t, err)
+	// Every flagged sentence only once
+	assert.Equal(t, []string{"The gun and the knife lay there!", "A Gun again."}, sents)
+	assert.Len(t, fs, 3)
+
+	assert.Equal(t, analyzercontract.Finding{
+		Term:       "gun",
+		Sentence:   "The gun and the knife lay there!",
+		ChunkIndex: 2,
+		Start:      100 + 24,
+		End:        100 + 27,
+		Confidence: 1,
+	}, fs[0])
+	assert.Equal(t, "knife", fs[1].Term)
+	assert.Equal(t, 100+36, fs[1].Start)
+	assert.Equal(t, "gun", fs[2].Term)
+	assert.Equal(t, []rune(text)[fs[2].Start-100:fs[2].End-100], []rune("Gun"))
+}
+
+func TestAnalyzeWithoutStrictWords(t *testing.T) {
+	_, _, err := analyze("A gun.", nil, 0, 0)
+	assert.Error(t, err)
+}
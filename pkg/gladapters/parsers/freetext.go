@@ -1,39 +1,64 @@
 package parsers
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/guardlight/server/pkg/contentstore"
 	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.uber.org/zap"
 )
 
 type freetextParser struct {
 	ncon *nats.Conn
+	cs   *workqueue.Cancellations
+	st   *contentstore.Store
 }
 
 func NewFreetextParser(ncon *nats.Conn) *freetextParser {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	st, err := contentstore.NewStore(ctx, ncon)
+	if err != nil {
+		zap.S().Errorw("Could not open content store", "error", err)
+	}
+
 	fp := &freetextParser{
 		ncon: ncon,
+		cs:   workqueue.WatchCancellations(ncon),
+		st:   st,
+	}
+	if _, err := workqueue.ConsumeWithHeartbeats(ncon, "parser.freetext", heartbeats, fp.parseFreetext); err != nil {
+		zap.S().Errorw("Could not consume work queue", "subject", "parser.freetext", "error", err)
 	}
-	ncon.Subscribe("parser.freetext", fp.parseFreetext)
 	return fp
 }
 
-func (fp *freetextParser) parseFreetext(m *nats.Msg) {
+func (fp *freetextParser) parseFreetext(m jetstream.Msg) {
 	var pr parsercontract.ParserRequest
-	err := json.Unmarshal(m.Data, &pr)
+	err := json.Unmarshal(m.Data(), &pr)
 	if err != nil {
-		fp.makeParserErrorResponse(&pr, err)
+		fp.makeParserErrorResponse(m, &pr, err)
 		return
 	}
 
-	bContent, err := base64.StdEncoding.DecodeString(pr.Content)
+	if fp.cs.Cancelled(pr.JobId) {
+		zap.S().Infow("Skipping cancelled job", "job_id", pr.JobId)
+		m.Term()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	bContent, err := fp.st.ParserContent(ctx, pr)
 	if err != nil {
-		fp.makeParserErrorResponse(&pr, err)
+		fp.makeParserErrorResponse(m, &pr, err)
 		return
 	}
 	sc := parse(bContent)
@@ -47,11 +72,13 @@ func (fp *freetextParser) parseFreetext(m *nats.Msg) {
 
 	dat, err := json.Marshal(presp)
 	if err != nil {
-		fp.makeParserErrorResponse(&pr, err)
+		fp.makeParserErrorResponse(m, &pr, err)
 		return
 	}
 
-	fp.ncon.Publish("parser.result", dat)
+	if err := workqueue.Respond(fp.ncon, m, "parser.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
 
 func parse(data []byte) string {
@@ -65,7 +92,7 @@ func parse(data []byte) string {
 	return cleanText
 }
 
-func (fp *freetextParser) makeParserErrorResponse(pr *parsercontract.ParserRequest, err error) {
+func (fp *freetextParser) makeParserErrorResponse(m jetstream.Msg, pr *parsercontract.ParserRequest, err error) {
 	presp := parsercontract.ParserResponse{
 		JobId:      pr.JobId,
 		AnalysisId: pr.AnalysisId,
@@ -75,8 +102,11 @@ func (fp *freetextParser) makeParserErrorResponse(pr *parsercontract.ParserReque
 	dat, err := json.Marshal(presp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal parser error response", "error", err)
+		m.Term()
 		return
 	}
 
-	fp.ncon.Publish("parser.result", dat)
+	if err := workqueue.Respond(fp.ncon, m, "parser.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
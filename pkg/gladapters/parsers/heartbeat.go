@@ -0,0 +1,22 @@
+package parsers
+
+import (
+	"encoding/json"
+
+	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/workqueue"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// heartbeats extend the lease of parser jobs while they are worked on.
+var heartbeats = workqueue.Heartbeats{
+	Subject: parsercontract.HeartbeatSubject,
+	Every:   workqueue.HeartbeatInterval,
+	Beat: func(m jetstream.Msg) ([]byte, error) {
+		var r parsercontract.ParserRequest
+		if err := json.Unmarshal(m.Data(), &r); err != nil {
+			return nil, err
+		}
+		return json.Marshal(parsercontract.ParserHeartbeat{JobId: r.JobId, AnalysisId: r.AnalysisId})
+	},
+}
@@ -1,34 +1,66 @@
 package parsers
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
+	"time"
 
+	"github.com/guardlight/server/pkg/contentstore"
 	"github.com/guardlight/server/pkg/parsercontract"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.uber.org/zap"
 )
 
 type subripSubtitleParser struct {
 	ncon *nats.Conn
+	cs   *workqueue.Cancellations
+	st   *contentstore.Store
 }
 
 func NewSubripSubtitleParser(ncon *nats.Conn) *subripSubtitleParser {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	st, err := contentstore.NewStore(ctx, ncon)
+	if err != nil {
+		zap.S().Errorw("Could not open content store", "error", err)
+	}
+
 	srtp := &subripSubtitleParser{
 		ncon: ncon,
+		cs:   workqueue.WatchCancellations(ncon),
+		st:   st,
+	}
+	if _, err := workqueue.ConsumeWithHeartbeats(ncon, "parser.srt", heartbeats, srtp.parseSubripSubtitle); err != nil {
+		zap.S().Errorw("Could not consume work queue", "subject", "parser.srt", "error", err)
 	}
-	ncon.Subscribe("parser.srt", srtp.parseSubripSubtitle)
 	return srtp
 }
 
-func (srtp *subripSubtitleParser) parseSubripSubtitle(m *nats.Msg) {
+func (srtp *subripSubtitleParser) parseSubripSubtitle(m jetstream.Msg) {
 	var pr parsercontract.ParserRequest
-	err := json.Unmarshal(m.Data, &pr)
+	err := json.Unmarshal(m.Data(), &pr)
 	if err != nil {
-		srtp.makeParserErrorResponse(&pr, err)
+		srtp.makeParserErrorResponse(m, &pr, err)
 		return
 	}
 
-	var sc = "EMPTY_ALPHA"
+	if srtp.cs.Cancelled(pr.JobId) {
+		zap.S().Infow("Skipping cancelled job", "job_id", pr.JobId)
+		m.Term()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	bContent, err := srtp.st.ParserContent(ctx, pr)
+	if err != nil {
+		srtp.makeParserErrorResponse(m, &pr, err)
+		return
+	}
+	sc := parseSubrip(bContent)
 
 	presp := parsercontract.ParserResponse{
 		JobId:      pr.JobId,
@@ -39,14 +71,38 @@ func (srtp *subripSubtitleParser) parseSubripSubtitle(m *nats.Msg) {
 
 	dat, err := json.Marshal(presp)
 	if err != nil {
-		srtp.makeParserErrorResponse(&pr, err)
+		srtp.makeParserErrorResponse(m, &pr, err)
 		return
 	}
 
-	srtp.ncon.Publish("parser.result", dat)
+	if err := workqueue.Respond(srtp.ncon, m, "parser.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
+}
+
+// parseSubrip keeps the text of the cues. The timing line and the cue number
+// right before it are left out.
+func parseSubrip(data []byte) string {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimPrefix(string(data), "\uFEFF"), "\r\n", "\n"), "\n")
+	text := make([]string, 0, len(lines))
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || isSubripTiming(l) {
+			continue
+		}
+		if i+1 < len(lines) && isSubripTiming(lines[i+1]) {
+			continue
+		}
+		text = append(text, l)
+	}
+	return parse([]byte(strings.Join(text, " ")))
+}
+
+func isSubripTiming(l string) bool {
+	return strings.Contains(l, "-->")
 }
 
-func (fp *subripSubtitleParser) makeParserErrorResponse(pr *parsercontract.ParserRequest, err error) {
+func (fp *subripSubtitleParser) makeParserErrorResponse(m jetstream.Msg, pr *parsercontract.ParserRequest, err error) {
 	presp := parsercontract.ParserResponse{
 		JobId:      pr.JobId,
 		AnalysisId: pr.AnalysisId,
@@ -56,8 +112,11 @@ func (fp *subripSubtitleParser) makeParserErrorResponse(pr *parsercontract.Parse
 	dat, err := json.Marshal(presp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal parser error response", "error", err)
+		m.Term()
 		return
 	}
 
-	fp.ncon.Publish("parser.result", dat)
+	if err := workqueue.Respond(fp.ncon, m, "parser.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
@@ -0,0 +1,52 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSubrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "cues",
+			input:  "1\n00:00:01,000 --> 00:00:02,000\nHello there\n\n2\n00:00:03,000 --> 00:00:04,000\nGeneral Kenobi\nYou are a bold one\n",
+			expect: "Hello there General Kenobi You are a bold one",
+		},
+		{
+			name:   "windows_line_endings",
+			input:  "1\r\n00:00:01,000 --> 00:00:02,000\r\nHello there\r\n\r\n",
+			expect: "Hello there",
+		},
+		{
+			name:   "byte_order_mark",
+			input:  "\uFEFF1\n00:00:01,000 --> 00:00:02,000\nHello there\n",
+			expect: "Hello there",
+		},
+		{
+			name:   "numeric_text",
+			input:  "1\n00:00:01,000 --> 00:00:02,000\n1984\n\n2\n00:00:03,000 --> 00:00:04,000\nThe year is\n2001\n",
+			expect: "1984 The year is 2001",
+		},
+		{
+			name:   "without_cue_numbers",
+			input:  "00:00:01,000 --> 00:00:02,000\nHello there\n\n00:00:03,000 --> 00:00:04,000\nGeneral Kenobi\n",
+			expect: "Hello there General Kenobi",
+		},
+		{
+			name:   "empty",
+			input:  "",
+			expect: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, parseSubrip([]byte(tt.input)))
+		})
+	}
+}
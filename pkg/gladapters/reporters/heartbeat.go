@@ -0,0 +1,22 @@
+package reporters
+
+import (
+	"encoding/json"
+
+	"github.com/guardlight/server/pkg/reportercontract"
+	"github.com/guardlight/server/pkg/workqueue"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// heartbeats extend the lease of reporter jobs while they are worked on.
+var heartbeats = workqueue.Heartbeats{
+	Subject: reportercontract.HeartbeatSubject,
+	Every:   workqueue.HeartbeatInterval,
+	Beat: func(m jetstream.Msg) ([]byte, error) {
+		var r reportercontract.ReporterRequest
+		if err := json.Unmarshal(m.Data(), &r); err != nil {
+			return nil, err
+		}
+		return json.Marshal(reportercontract.ReporterHeartbeat{JobId: r.JobId, AnalysisId: r.AnalysisId})
+	},
+}
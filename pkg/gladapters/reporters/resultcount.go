@@ -4,27 +4,39 @@ import (
 	"encoding/json"
 
 	"github.com/guardlight/server/pkg/reportercontract"
+	"github.com/guardlight/server/pkg/workqueue"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.uber.org/zap"
 )
 
 type wordcountReporter struct {
 	ncon *nats.Conn
+	cs   *workqueue.Cancellations
 }
 
 func NewWordcountReporter(ncon *nats.Conn) *wordcountReporter {
 	wr := &wordcountReporter{
 		ncon: ncon,
+		cs:   workqueue.WatchCancellations(ncon),
+	}
+	if _, err := workqueue.ConsumeWithHeartbeats(ncon, "reporter.word_count", heartbeats, wr.report); err != nil {
+		zap.S().Errorw("Could not consume work queue", "subject", "reporter.word_count", "error", err)
 	}
-	ncon.Subscribe("reporter.word_count", wr.report)
 	return wr
 }
 
-func (wr *wordcountReporter) report(m *nats.Msg) {
+func (wr *wordcountReporter) report(m jetstream.Msg) {
 	var rr reportercontract.ReporterRequest
-	err := json.Unmarshal(m.Data, &rr)
+	err := json.Unmarshal(m.Data(), &rr)
 	if err != nil {
-		wr.makeReporterErrorResponse(&rr, err)
+		wr.makeReporterErrorResponse(m, &rr, err)
+		return
+	}
+
+	if wr.cs.Cancelled(rr.JobId) {
+		zap.S().Infow("Skipping cancelled job", "job_id", rr.JobId)
+		m.Term()
 		return
 	}
 
@@ -47,13 +59,16 @@ func (wr *wordcountReporter) report(m *nats.Msg) {
 	dat, err := json.Marshal(aresp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal analyzer response", "error", err)
+		m.Term()
 		return
 	}
 
-	wr.ncon.Publish("reporter.result", dat)
+	if err := workqueue.Respond(wr.ncon, m, "reporter.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
 
-func (wr *wordcountReporter) makeReporterErrorResponse(rr *reportercontract.ReporterRequest, err error) {
+func (wr *wordcountReporter) makeReporterErrorResponse(m jetstream.Msg, rr *reportercontract.ReporterRequest, err error) {
 	aresp := reportercontract.ReporterResponse{
 		JobId:      rr.JobId,
 		AnalysisId: rr.AnalysisId,
@@ -64,8 +79,11 @@ func (wr *wordcountReporter) makeReporterErrorResponse(rr *reportercontract.Repo
 	dat, err := json.Marshal(aresp)
 	if err != nil {
 		zap.S().Errorw("Could not marshal reporter error response", "error", err)
+		m.Term()
 		return
 	}
 
-	wr.ncon.Publish("reporter.result", dat)
+	if err := workqueue.Respond(wr.ncon, m, "reporter.result", dat); err != nil {
+		zap.S().Errorw("Could not publish result", "error", err)
+	}
 }
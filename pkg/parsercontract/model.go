@@ -5,7 +5,10 @@ import "github.com/google/uuid"
 type ParserRequest struct {
 	JobId      uuid.UUID `json:"jobId"`
 	AnalysisId uuid.UUID `json:"analysisId"`
-	Content    string    `json:"content"`
+	// Content is the base64 encoded content, unless it is uploaded to the
+	// content store, then ContentRef names the object holding it.
+	Content    string `json:"content"`
+	ContentRef string `json:"contentRef,omitempty"`
 }
 
 type ParserResponseStatus string
@@ -21,3 +24,17 @@ type ParserResponse struct {
 	Text       string               `json:"text"`
 	Status     ParserResponseStatus `json:"status"`
 }
+
+// HeartbeatSubject receives heartbeats of every parser.
+const HeartbeatSubject = "parser.heartbeat"
+
+// ParserHeartbeat tells the server the job is still being worked on. Each
+// heartbeat extends the lease of the job, the job only times out when the
+// lease runs out.
+type ParserHeartbeat struct {
+	JobId      uuid.UUID `json:"jobId"`
+	AnalysisId uuid.UUID `json:"analysisId"`
+	// LeaseSeconds is how long the job may go without the next heartbeat. It
+	// is capped by the configured timeout, 0 uses the configured timeout.
+	LeaseSeconds int `json:"leaseSeconds"`
+}
@@ -22,3 +22,17 @@ type ReporterResponse struct {
 	Comments   string                 `json:"comments"`
 	Status     ReporterResponseStatus `json:"status"`
 }
+
+// HeartbeatSubject receives heartbeats of every reporter.
+const HeartbeatSubject = "reporter.heartbeat"
+
+// ReporterHeartbeat tells the server the job is still being worked on. Each
+// heartbeat extends the lease of the job, the job only times out when the
+// lease runs out.
+type ReporterHeartbeat struct {
+	JobId      uuid.UUID `json:"jobId"`
+	AnalysisId uuid.UUID `json:"analysisId"`
+	// LeaseSeconds is how long the job may go without the next heartbeat. It
+	// is capped by the configured timeout, 0 uses the configured timeout.
+	LeaseSeconds int `json:"leaseSeconds"`
+}
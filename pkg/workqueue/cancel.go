@@ -0,0 +1,79 @@
+package workqueue
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guardlight/server/pkg/controlcontract"
+	"github.com/nats-io/nats.go"
+	"go.uber.org/zap"
+)
+
+// Cancelled job ids are forgotten after a while, by then their messages are
+// either handled or dropped from the work queue.
+const cancelRetention = 24 * time.Hour
+
+// Cancellations remembers the jobs that were cancelled on the control.cancel
+// subject, so an adapter can skip them.
+type Cancellations struct {
+	sync.Mutex
+	ids map[uuid.UUID]time.Time
+}
+
+func WatchCancellations(ncon *nats.Conn) *Cancellations {
+	cs := &Cancellations{
+		ids: make(map[uuid.UUID]time.Time),
+	}
+	if _, err := ncon.Subscribe(controlcontract.CancelSubject, cs.add); err != nil {
+		zap.S().Errorw("Could not subscribe to cancellations", "error", err)
+	}
+	if _, err := ncon.Subscribe(controlcontract.RestoreSubject, cs.remove); err != nil {
+		zap.S().Errorw("Could not subscribe to restored jobs", "error", err)
+	}
+	return cs
+}
+
+func (cs *Cancellations) add(m *nats.Msg) {
+	var cr controlcontract.CancelRequest
+	if err := json.Unmarshal(m.Data, &cr); err != nil {
+		zap.S().Errorw("Could not unmarshal cancel request", "error", err)
+		return
+	}
+
+	cs.Lock()
+	defer cs.Unlock()
+
+	now := time.Now()
+	for id, at := range cs.ids {
+		if now.Sub(at) > cancelRetention {
+			delete(cs.ids, id)
+		}
+	}
+	for _, id := range cr.JobIds {
+		cs.ids[id] = now
+	}
+}
+
+func (cs *Cancellations) remove(m *nats.Msg) {
+	var rr controlcontract.RestoreRequest
+	if err := json.Unmarshal(m.Data, &rr); err != nil {
+		zap.S().Errorw("Could not unmarshal restore request", "error", err)
+		return
+	}
+
+	cs.Lock()
+	defer cs.Unlock()
+
+	for _, id := range rr.JobIds {
+		delete(cs.ids, id)
+	}
+}
+
+func (cs *Cancellations) Cancelled(id uuid.UUID) bool {
+	cs.Lock()
+	defer cs.Unlock()
+	_, ok := cs.ids[id]
+	return ok
+}
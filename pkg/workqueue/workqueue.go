@@ -0,0 +1,199 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	// ConsumerName is the durable consumer every adapter instance of a subject
+	// shares, so each job message is handled by exactly one of them.
+	ConsumerName = "worker"
+
+	// Unacknowledged job messages are dropped after a day, the job itself is
+	// timed out and retried by the server long before that.
+	streamMaxAge = 24 * time.Hour
+
+	// A job is published again under the same message id when it could not
+	// be marked in progress, the orchestrator tries that again right away.
+	duplicatesWindow = 10 * time.Minute
+
+	// The server owns the retries of jobs. A message is only delivered again
+	// to be terminated, see Consume.
+	maxDeliver = 2
+)
+
+// Options bound for how long a job message is delivered before it is
+// redelivered.
+type Options struct {
+	AckWait time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		AckWait: 60 * time.Second,
+	}
+}
+
+// StreamName returns the work queue stream of a subject,
+// e.g. "parser.freetext" -> "JOBS_PARSER_FREETEXT".
+func StreamName(subject string) string {
+	r := strings.NewReplacer(".", "_", "-", "_", "*", "_", ">", "_")
+	return "JOBS_" + strings.ToUpper(r.Replace(subject))
+}
+
+// EnsureStream creates or updates the work queue stream of a subject.
+func EnsureStream(ctx context.Context, js jetstream.JetStream, subject string) (jetstream.Stream, error) {
+	return js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       StreamName(subject),
+		Subjects:   []string{subject},
+		Retention:  jetstream.WorkQueuePolicy,
+		Storage:    jetstream.FileStorage,
+		MaxAge:     streamMaxAge,
+		Duplicates: duplicatesWindow,
+	})
+}
+
+// EnsureConsumer creates or updates the work queue stream and its durable
+// consumer with the given ack wait.
+func EnsureConsumer(ctx context.Context, js jetstream.JetStream, subject string, opts Options) (jetstream.Consumer, error) {
+	if _, err := EnsureStream(ctx, js, subject); err != nil {
+		return nil, err
+	}
+	return js.CreateOrUpdateConsumer(ctx, StreamName(subject), jetstream.ConsumerConfig{
+		Durable:       ConsumerName,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxDeliver:    maxDeliver,
+		AckWait:       opts.AckWait,
+	})
+}
+
+// HeartbeatInterval is how often the builtin adapters send a heartbeat, well
+// within the default inprogress timeout of a minute.
+const HeartbeatInterval = 15 * time.Second
+
+// Heartbeats describe the heartbeats that are sent for the job of a message
+// while its handler works on it. Beat returns the heartbeat of a message.
+type Heartbeats struct {
+	Subject string
+	Every   time.Duration
+	Beat    func(m jetstream.Msg) ([]byte, error)
+}
+
+// Consume handles the job messages of a subject. It binds to the consumer the
+// server configured, or creates one with the default options when the adapter
+// starts first. The handler must Ack or Term every message.
+//
+// The server owns the retries of jobs, it times a job out and publishes it
+// again. A message that is delivered again is terminated instead of handled a
+// second time, and the message is kept in progress while the handler runs so
+// it is not redelivered to another adapter in the meantime.
+func Consume(ncon *nats.Conn, subject string, handler jetstream.MessageHandler) (jetstream.ConsumeContext, error) {
+	return ConsumeWithHeartbeats(ncon, subject, Heartbeats{}, handler)
+}
+
+// ConsumeWithHeartbeats is Consume that also sends the heartbeats of the jobs
+// while they are handled, so the server extends their lease.
+func ConsumeWithHeartbeats(ncon *nats.Conn, subject string, hbs Heartbeats, handler jetstream.MessageHandler) (jetstream.ConsumeContext, error) {
+	js, err := jetstream.New(ncon)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := js.Consumer(ctx, StreamName(subject), ConsumerName)
+	if errors.Is(err, jetstream.ErrStreamNotFound) || errors.Is(err, jetstream.ErrConsumerNotFound) {
+		c, err = EnsureConsumer(ctx, js, subject, DefaultOptions())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ackWait := DefaultOptions().AckWait
+	if ci := c.CachedInfo(); ci != nil && ci.Config.AckWait > 0 {
+		ackWait = ci.Config.AckWait
+	}
+	every := ackWait / 2
+	if hbs.Subject != "" && hbs.Every > 0 && hbs.Every < every {
+		every = hbs.Every
+	}
+
+	return c.Consume(func(m jetstream.Msg) {
+		handle(ncon, m, every, hbs, handler)
+	})
+}
+
+func handle(ncon *nats.Conn, m jetstream.Msg, every time.Duration, hbs Heartbeats, handler jetstream.MessageHandler) {
+	if md, err := m.Metadata(); err == nil && md.NumDelivered > 1 {
+		zap.S().Infow("Terminating redelivered job message", "subject", m.Subject(), "deliveries", md.NumDelivered)
+		m.Term()
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go keepInProgress(ncon, m, every, hbs, done)
+
+	handler(m)
+}
+
+// keepInProgress resets the ack wait of the message until done is closed, by
+// sending the heartbeat of its job when there is one.
+func keepInProgress(ncon *nats.Conn, m jetstream.Msg, every time.Duration, hbs Heartbeats, done <-chan struct{}) {
+	var hb []byte
+	if hbs.Subject != "" {
+		var err error
+		if hb, err = hbs.Beat(m); err != nil {
+			zap.S().Errorw("Could not make heartbeat of job message", "subject", m.Subject(), "error", err)
+		}
+	}
+
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			var err error
+			if hb != nil {
+				err = Heartbeat(ncon, m, hbs.Subject, hb)
+			} else {
+				err = m.InProgress()
+			}
+			if err != nil {
+				zap.S().Debugw("Could not mark job message in progress", "error", err)
+			}
+		}
+	}
+}
+
+// Respond publishes the result of a job message and acknowledges the message.
+// The message is terminated when the result cannot be published, the server
+// retries the job once it times out.
+func Respond(ncon *nats.Conn, m jetstream.Msg, subject string, data []byte) error {
+	if err := ncon.Publish(subject, data); err != nil {
+		m.Term()
+		return err
+	}
+	return m.Ack()
+}
+
+// Heartbeat tells the server the job of the message is still being worked on.
+// It also resets the ack wait of the message, so the work queue does not
+// redeliver it in the meantime.
+func Heartbeat(ncon *nats.Conn, m jetstream.Msg, subject string, data []byte) error {
+	if err := m.InProgress(); err != nil {
+		return err
+	}
+	return ncon.Publish(subject, data)
+}
@@ -0,0 +1,169 @@
+package workqueue
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func runJetStream(t *testing.T) *nats.Conn {
+	ns, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+	go ns.Start()
+	assert.True(t, ns.ReadyForConnections(5*time.Second))
+	t.Cleanup(ns.Shutdown)
+
+	ncon, err := nats.Connect(ns.ClientURL())
+	assert.NoError(t, err)
+	t.Cleanup(ncon.Close)
+	return ncon
+}
+
+func TestStreamName(t *testing.T) {
+	assert.Equal(t, "JOBS_PARSER_FREETEXT", StreamName("parser.freetext"))
+	assert.Equal(t, "JOBS_ANALYZER_WORD_SEARCH", StreamName("analyzer.word-search"))
+}
+
+func TestPublishWithoutConsumerIsKept(t *testing.T) {
+	ncon := runJetStream(t)
+	js, err := jetstream.New(ncon)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = EnsureConsumer(ctx, js, "parser.freetext", DefaultOptions())
+	assert.NoError(t, err)
+
+	_, err = js.Publish(ctx, "parser.freetext", []byte("job"))
+	assert.NoError(t, err)
+
+	received := make(chan []byte, 1)
+	cc, err := Consume(ncon, "parser.freetext", func(m jetstream.Msg) {
+		m.Ack()
+		received <- m.Data()
+	})
+	assert.NoError(t, err)
+	defer cc.Stop()
+
+	select {
+	case d := <-received:
+		assert.Equal(t, []byte("job"), d)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job message was not delivered")
+	}
+}
+
+func TestRedeliveryIsTerminated(t *testing.T) {
+	ncon := runJetStream(t)
+	js, err := jetstream.New(ncon)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = EnsureConsumer(ctx, js, "analyzer.word_search", Options{AckWait: time.Second})
+	assert.NoError(t, err)
+
+	var deliveries atomic.Int32
+	cc, err := Consume(ncon, "analyzer.word_search", func(m jetstream.Msg) {
+		deliveries.Add(1)
+		m.Nak()
+	})
+	assert.NoError(t, err)
+	defer cc.Stop()
+
+	_, err = js.Publish(ctx, "analyzer.word_search", []byte("job"))
+	assert.NoError(t, err)
+
+	s, err := js.Stream(ctx, StreamName("analyzer.word_search"))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		si, err := s.Info(ctx)
+		return err == nil && si.State.Msgs == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), deliveries.Load())
+}
+
+func TestLongJobIsKeptInProgress(t *testing.T) {
+	ncon := runJetStream(t)
+	js, err := jetstream.New(ncon)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	c, err := EnsureConsumer(ctx, js, "parser.epub", Options{AckWait: time.Second})
+	assert.NoError(t, err)
+
+	var deliveries atomic.Int32
+	cc, err := Consume(ncon, "parser.epub", func(m jetstream.Msg) {
+		deliveries.Add(1)
+		time.Sleep(2500 * time.Millisecond)
+		m.Ack()
+	})
+	assert.NoError(t, err)
+	defer cc.Stop()
+
+	_, err = js.Publish(ctx, "parser.epub", []byte("job"))
+	assert.NoError(t, err)
+
+	time.Sleep(3500 * time.Millisecond)
+	assert.Equal(t, int32(1), deliveries.Load())
+
+	ci, err := c.Info(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), ci.Delivered.Consumer)
+}
+
+func TestHeartbeatsWhileJobRuns(t *testing.T) {
+	ncon := runJetStream(t)
+	js, err := jetstream.New(ncon)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = EnsureConsumer(ctx, js, "analyzer.llm", Options{AckWait: 10 * time.Second})
+	assert.NoError(t, err)
+
+	var beats atomic.Int32
+	sub, err := ncon.Subscribe("analyzer.heartbeat", func(m *nats.Msg) {
+		assert.Equal(t, []byte("job-1"), m.Data)
+		beats.Add(1)
+	})
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	hbs := Heartbeats{
+		Subject: "analyzer.heartbeat",
+		Every:   200 * time.Millisecond,
+		Beat:    func(m jetstream.Msg) ([]byte, error) { return m.Data(), nil },
+	}
+	finished := make(chan struct{})
+	cc, err := ConsumeWithHeartbeats(ncon, "analyzer.llm", hbs, func(m jetstream.Msg) {
+		time.Sleep(time.Second)
+		m.Ack()
+		close(finished)
+	})
+	assert.NoError(t, err)
+	defer cc.Stop()
+
+	_, err = js.Publish(ctx, "analyzer.llm", []byte("job-1"))
+	assert.NoError(t, err)
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job message was not handled")
+	}
+	time.Sleep(300 * time.Millisecond)
+	n := beats.Load()
+	assert.GreaterOrEqual(t, n, int32(3))
+
+	// No heartbeats once the job is done
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, n, beats.Load())
+}